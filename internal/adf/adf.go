@@ -0,0 +1,503 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package adf
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Node represents a node of an Atlassian Document Format document.
+type Node struct {
+	// Type represents the type of the node; e.g. doc, paragraph, or text
+	Type string `json:"type"`
+	// Version represents the version of the document; only set on the root
+	Version int `json:"version,omitempty"`
+	// Attrs represents the attributes of the node
+	Attrs map[string]any `json:"attrs,omitempty"`
+	// Content represents the child nodes of the node
+	Content []Node `json:"content,omitempty"`
+	// Text represents the text of a text node
+	Text string `json:"text,omitempty"`
+	// Marks represents the formatting of a text node
+	Marks []Mark `json:"marks,omitempty"`
+}
+
+// Mark represents the formatting of a text node.
+type Mark struct {
+	// Type represents the type of the mark; e.g. strong or link
+	Type string `json:"type"`
+	// Attrs represents the attributes of the mark
+	Attrs map[string]any `json:"attrs,omitempty"`
+}
+
+var (
+	// htmlCommentPattern represents an HTML comment
+	htmlCommentPattern = regexp.MustCompile(`(?s)<!--.*?-->`)
+	// headingPattern represents an ATX heading
+	headingPattern = regexp.MustCompile(`^\s{0,3}(#{1,6})(?:\s+(.*?))?(?:\s+#+)?\s*$`)
+	// fencePattern represents the opening of a fenced code block
+	fencePattern = regexp.MustCompile("^(\\s{0,3})(`{3,}|~{3,})\\s*([^`\\s]*)")
+	// rulePattern represents a thematic break
+	rulePattern = regexp.MustCompile(`^\s{0,3}(?:(?:-\s*){3,}|(?:\*\s*){3,}|(?:_\s*){3,})$`)
+	// setextPattern represents the underline of a setext heading
+	setextPattern = regexp.MustCompile(`^\s{0,3}(=+|-+)\s*$`)
+	// blockquotePattern represents a blockquote line
+	blockquotePattern = regexp.MustCompile(`^\s{0,3}>\s?`)
+	// listItemPattern represents the first line of a list item
+	listItemPattern = regexp.MustCompile(`^(\s*)([-*+]|\d{1,9}[.)])(\s+|$)(.*)$`)
+	// taskPattern represents the checkbox of a task list item
+	taskPattern = regexp.MustCompile(`^\[([ xX])\](?:\s+|$)`)
+	// tableDelimiterPattern represents the delimiter row of a table
+	tableDelimiterPattern = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+)
+
+// converter represents the state of the conversion of a markdown document.
+type converter struct {
+	// localIDs represents the number of local IDs generated for task lists
+	// and items
+	localIDs int
+}
+
+// Convert will convert GitHub flavored markdown into an Atlassian Document
+// Format document. Headings, paragraphs, lists, task lists, code blocks,
+// blockquotes, tables, rules, and inline formatting and links are supported;
+// HTML comments are removed.
+func Convert(markdown string) Node {
+	markdown = strings.ReplaceAll(markdown, "\r\n", "\n")
+	markdown = strings.ReplaceAll(markdown, "\t", "    ")
+	markdown = htmlCommentPattern.ReplaceAllString(markdown, "")
+	c := &converter{}
+	return Node{
+		Type:    "doc",
+		Version: 1,
+		Content: c.blocks(strings.Split(markdown, "\n")),
+	}
+}
+
+// MarshalJSON will encode the node as JSON; the content of a document is
+// always encoded as it is required even when empty.
+func (n Node) MarshalJSON() ([]byte, error) {
+	type node Node
+	if n.Type != "doc" || len(n.Content) > 0 {
+		return json.Marshal(node(n))
+	}
+	return json.Marshal(struct {
+		node
+		Content []Node `json:"content"`
+	}{node: node(n), Content: []Node{}})
+}
+
+// localID will generate a local ID unique within the document.
+func (c *converter) localID(prefix string) string {
+	c.localIDs++
+	return fmt.Sprintf("%s-%d", prefix, c.localIDs)
+}
+
+// blocks will convert lines of markdown into block nodes.
+func (c *converter) blocks(lines []string) []Node {
+	var nodes []Node
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case len(strings.TrimSpace(line)) == 0:
+			i++
+		case fencePattern.MatchString(line):
+			var node Node
+			node, i = codeBlock(lines, i)
+			nodes = append(nodes, node)
+		case headingPattern.MatchString(line):
+			matches := headingPattern.FindStringSubmatch(line)
+			nodes = append(nodes, Node{
+				Type:    "heading",
+				Attrs:   map[string]any{"level": len(matches[1])},
+				Content: inline(matches[2], nil),
+			})
+			i++
+		case rulePattern.MatchString(line):
+			nodes = append(nodes, Node{Type: "rule"})
+			i++
+		case blockquotePattern.MatchString(line):
+			var quoted []string
+			for ; i < len(lines) && blockquotePattern.MatchString(lines[i]); i++ {
+				quoted = append(quoted, blockquotePattern.ReplaceAllString(lines[i], ""))
+			}
+			nodes = append(nodes, Node{
+				Type:    "blockquote",
+				Content: sanitize(c.blocks(quoted), quoteContent),
+			})
+		case listItemPattern.MatchString(line):
+			var node Node
+			node, i = c.list(lines, i)
+			nodes = append(nodes, node)
+		case isTableStart(lines, i):
+			var node Node
+			node, i = table(lines, i)
+			nodes = append(nodes, node)
+		default:
+			var node Node
+			node, i = paragraph(lines, i)
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// interruptsParagraph will determine if a line starts a block ending the
+// paragraph before it.
+func interruptsParagraph(lines []string, i int) bool {
+	line := lines[i]
+	return len(strings.TrimSpace(line)) == 0 || fencePattern.MatchString(line) || headingPattern.MatchString(line) ||
+		rulePattern.MatchString(line) || blockquotePattern.MatchString(line) || listItemPattern.MatchString(line) ||
+		isTableStart(lines, i)
+}
+
+// paragraph will convert the lines of a paragraph or a setext heading; lines
+// are separated by hard breaks as GitHub renders them.
+func paragraph(lines []string, i int) (Node, int) {
+	text := []string{strings.TrimSpace(lines[i])}
+	for i++; i < len(lines); i++ {
+		if matches := setextPattern.FindStringSubmatch(lines[i]); matches != nil {
+			level := 1
+			if strings.HasPrefix(matches[1], "-") {
+				level = 2
+			}
+			return Node{
+				Type:    "heading",
+				Attrs:   map[string]any{"level": level},
+				Content: inline(strings.Join(text, " "), nil),
+			}, i + 1
+		}
+		if interruptsParagraph(lines, i) {
+			break
+		}
+		text = append(text, strings.TrimSpace(lines[i]))
+	}
+	return Node{
+		Type:    "paragraph",
+		Content: inlineLines(text),
+	}, i
+}
+
+// inlineLines will convert lines of inline markdown separated by hard breaks.
+func inlineLines(lines []string) []Node {
+	var nodes []Node
+	for i, line := range lines {
+		if i > 0 {
+			nodes = append(nodes, Node{Type: "hardBreak"})
+		}
+		nodes = append(nodes, inline(line, nil)...)
+	}
+	return nodes
+}
+
+// codeBlock will convert a fenced code block; an unclosed block extends to the
+// end of the document.
+func codeBlock(lines []string, i int) (Node, int) {
+	matches := fencePattern.FindStringSubmatch(lines[i])
+	indent := len(matches[1])
+	fence := matches[2]
+	var code []string
+	for i++; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(trimmed, fence) && len(strings.Trim(trimmed, fence[:1])) == 0 {
+			i++
+			break
+		}
+		line := lines[i]
+		// Remove the indentation of the opening fence from the content
+		for j := 0; j < indent && strings.HasPrefix(line, " "); j++ {
+			line = line[1:]
+		}
+		code = append(code, line)
+	}
+	node := Node{Type: "codeBlock"}
+	if len(matches[3]) > 0 {
+		node.Attrs = map[string]any{"language": matches[3]}
+	}
+	if text := strings.Join(code, "\n"); len(text) > 0 {
+		node.Content = []Node{{Type: "text", Text: text}}
+	}
+	return node, i
+}
+
+// indentation will get the number of leading spaces of a line.
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// listItem represents a list item being parsed.
+type listItem struct {
+	// lines represents the content lines of the item without indentation
+	lines []string
+	// task represents whether the item is a task
+	task bool
+	// done represents whether the task is done
+	done bool
+}
+
+// list will convert a bullet, ordered, or task list; nested lists are
+// converted from the indented content of the items.
+func (c *converter) list(lines []string, i int) (Node, int) {
+	first := listItemPattern.FindStringSubmatch(lines[i])
+	baseIndent := len(first[1])
+	ordered := !strings.ContainsAny(first[2][:1], "-*+")
+	delimiter := first[2][len(first[2])-1:]
+
+	var items []listItem
+	var contentIndent int
+	blank := false
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		matches := listItemPattern.FindStringSubmatch(line)
+		if matches != nil && len(matches[1]) >= baseIndent && len(matches[1]) < baseIndent+2 {
+			// A new item of the list or the start of a different list
+			isOrdered := !strings.ContainsAny(matches[2][:1], "-*+")
+			if isOrdered != ordered || matches[2][len(matches[2])-1:] != delimiter {
+				break
+			}
+			contentIndent = len(matches[1]) + len(matches[2]) + len(matches[3])
+			text := matches[4]
+			item := listItem{}
+			if task := taskPattern.FindStringSubmatch(text); task != nil && !ordered {
+				item.task = true
+				item.done = task[1] != " "
+				text = text[len(task[0]):]
+			}
+			item.lines = []string{text}
+			items = append(items, item)
+			blank = false
+			continue
+		}
+		current := &items[len(items)-1]
+		switch {
+		case len(strings.TrimSpace(line)) == 0:
+			blank = true
+			current.lines = append(current.lines, "")
+		case indentation(line) >= contentIndent:
+			blank = false
+			current.lines = append(current.lines, line[contentIndent:])
+		case !blank && !interruptsParagraph(lines, i):
+			// Lazy continuation of the paragraph of the item
+			current.lines = append(current.lines, strings.TrimSpace(line))
+		default:
+			return c.listNode(items, ordered, first[2]), i
+		}
+	}
+	return c.listNode(items, ordered, first[2]), i
+}
+
+// listNode will create the node of a parsed list; bullet lists whose items are
+// all tasks become task lists.
+func (c *converter) listNode(items []listItem, ordered bool, marker string) Node {
+	allTasks := !ordered
+	for _, item := range items {
+		allTasks = allTasks && item.task
+	}
+	if allTasks {
+		return c.taskList(items)
+	}
+
+	node := Node{Type: "bulletList"}
+	if ordered {
+		node.Type = "orderedList"
+		if order, err := strconv.Atoi(marker[:len(marker)-1]); err == nil && order != 1 {
+			node.Attrs = map[string]any{"order": order}
+		}
+	}
+	for _, item := range items {
+		content := sanitize(c.blocks(item.lines), listItemContent)
+		if len(content) == 0 || content[0].Type != "paragraph" {
+			content = append([]Node{{Type: "paragraph"}}, content...)
+		}
+		if item.task {
+			// Tasks mixed with other items are kept as checkbox characters
+			checkbox := Node{Type: "text", Text: "☐ "}
+			if item.done {
+				checkbox.Text = "☑ "
+			}
+			content[0].Content = append([]Node{checkbox}, content[0].Content...)
+		}
+		node.Content = append(node.Content, Node{Type: "listItem", Content: content})
+	}
+	return node
+}
+
+// taskList will create a task list; nested task lists are kept while other
+// nested blocks are flattened into the text of their task.
+func (c *converter) taskList(items []listItem) Node {
+	node := Node{
+		Type:  "taskList",
+		Attrs: map[string]any{"localId": c.localID("task-list")},
+	}
+	for _, item := range items {
+		state := "TODO"
+		if item.done {
+			state = "DONE"
+		}
+		task := Node{
+			Type:  "taskItem",
+			Attrs: map[string]any{"localId": c.localID("task"), "state": state},
+		}
+		var nested []Node
+		for _, block := range c.blocks(item.lines) {
+			if block.Type == "taskList" {
+				nested = append(nested, block)
+				continue
+			}
+			if len(task.Content) > 0 {
+				task.Content = append(task.Content, Node{Type: "hardBreak"})
+			}
+			task.Content = append(task.Content, flatten(block)...)
+		}
+		node.Content = append(node.Content, task)
+		node.Content = append(node.Content, nested...)
+	}
+	return node
+}
+
+// flatten will get the inline nodes of a block and its children separated by
+// hard breaks.
+func flatten(block Node) []Node {
+	switch block.Type {
+	case "paragraph", "heading":
+		return block.Content
+	case "codeBlock":
+		var nodes []Node
+		for _, text := range block.Content {
+			nodes = append(nodes, Node{Type: "text", Text: text.Text, Marks: []Mark{{Type: "code"}}})
+		}
+		return nodes
+	}
+	var nodes []Node
+	for _, child := range block.Content {
+		inlines := flatten(child)
+		if len(nodes) > 0 && len(inlines) > 0 {
+			nodes = append(nodes, Node{Type: "hardBreak"})
+		}
+		nodes = append(nodes, inlines...)
+	}
+	return nodes
+}
+
+// listItemContent represents the block types allowed in list items.
+var listItemContent = map[string]bool{
+	"paragraph":   true,
+	"bulletList":  true,
+	"orderedList": true,
+	"codeBlock":   true,
+}
+
+// quoteContent represents the block types allowed in blockquotes.
+var quoteContent = map[string]bool{
+	"paragraph":   true,
+	"bulletList":  true,
+	"orderedList": true,
+	"codeBlock":   true,
+}
+
+// sanitize will convert the blocks that are not allowed in a container into
+// allowed blocks; headings become paragraphs and other blocks are flattened.
+func sanitize(blocks []Node, allowed map[string]bool) []Node {
+	var sanitized []Node
+	for _, block := range blocks {
+		switch {
+		case allowed[block.Type]:
+			sanitized = append(sanitized, block)
+		case block.Type == "rule":
+		case block.Type == "blockquote":
+			sanitized = append(sanitized, sanitize(block.Content, allowed)...)
+		default:
+			if content := flatten(block); len(content) > 0 {
+				sanitized = append(sanitized, Node{Type: "paragraph", Content: content})
+			}
+		}
+	}
+	return sanitized
+}
+
+// isTableStart will determine if a table starts at a line; the header row must
+// be followed by a delimiter row.
+func isTableStart(lines []string, i int) bool {
+	return strings.Contains(lines[i], "|") && i+1 < len(lines) && strings.Contains(lines[i+1], "-") &&
+		tableDelimiterPattern.MatchString(lines[i+1])
+}
+
+// table will convert a table; rows are padded or truncated to the number of
+// header cells.
+func table(lines []string, i int) (Node, int) {
+	header := tableCells(lines[i])
+	node := Node{
+		Type:  "table",
+		Attrs: map[string]any{"isNumberColumnEnabled": false, "layout": "default"},
+	}
+	node.Content = append(node.Content, tableRow(header, len(header), "tableHeader"))
+	for i += 2; i < len(lines); i++ {
+		if len(strings.TrimSpace(lines[i])) == 0 || !strings.Contains(lines[i], "|") {
+			break
+		}
+		node.Content = append(node.Content, tableRow(tableCells(lines[i]), len(header), "tableCell"))
+	}
+	return node, i
+}
+
+// tableRow will create a table row of the given number of cells.
+func tableRow(cells []string, columns int, cellType string) Node {
+	row := Node{Type: "tableRow"}
+	for i := 0; i < columns; i++ {
+		var text string
+		if i < len(cells) {
+			text = cells[i]
+		}
+		row.Content = append(row.Content, Node{
+			Type:    cellType,
+			Content: []Node{{Type: "paragraph", Content: inline(text, nil)}},
+		})
+	}
+	return row
+}
+
+// tableCells will split a table row into the text of its cells; pipes that
+// are escaped or within code spans do not split cells.
+func tableCells(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+	var cells []string
+	var cell strings.Builder
+	inCode := false
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case line[i] == '`':
+			inCode = !inCode
+			cell.WriteByte('`')
+		case line[i] == '|' && !inCode:
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
@@ -0,0 +1,79 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package adf
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// update represents whether the golden files are regenerated from the
+// converted documents.
+var update = flag.Bool("update", false, "update the golden files")
+
+var _ = Describe("adf", Label("adf"), func() {
+	markdownFiles, err := filepath.Glob(filepath.Join("testdata", "*.md"))
+	if err != nil {
+		panic(err)
+	}
+
+	It("empty markdown will be converted to an empty document", func() {
+		document, err := json.Marshal(Convert("  \n<!-- comment -->\n"))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(document).Should(MatchJSON(`{"type":"doc","version":1,"content":[]}`))
+	})
+
+	It("unbalanced brackets will be converted as text", func() {
+		brackets := strings.Repeat("[", 100000)
+		document := Convert(brackets)
+		Expect(document.Content).To(HaveLen(1))
+		Expect(document.Content[0].Content).Should(Equal([]Node{{Type: "text", Text: brackets}}))
+	})
+
+	It("links nested too deeply will be kept as text", func() {
+		markdown := strings.Repeat("[", maxInlineDepth+2) + "a" + strings.Repeat("](b)", maxInlineDepth+2)
+		document := Convert(markdown)
+		Expect(document.Content).To(HaveLen(1))
+		nodes := document.Content[0].Content
+		Expect(nodes).To(HaveLen(1))
+		Expect(nodes[0].Marks).To(HaveLen(maxInlineDepth + 1))
+		Expect(nodes[0].Text).Should(Equal("[a](b)"))
+	})
+
+	for _, markdownFile := range markdownFiles {
+		markdownFile := markdownFile
+		goldenFile := strings.TrimSuffix(markdownFile, ".md") + ".json"
+		It("markdown will be converted to match "+goldenFile, func() {
+			markdown, err := os.ReadFile(markdownFile)
+			Expect(err).ShouldNot(HaveOccurred())
+			document, err := json.MarshalIndent(Convert(string(markdown)), "", "  ")
+			Expect(err).ShouldNot(HaveOccurred())
+			document = append(document, '\n')
+			if *update {
+				Expect(os.WriteFile(goldenFile, document, 0o600)).To(Succeed())
+			}
+			golden, err := os.ReadFile(goldenFile)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(document).Should(MatchJSON(golden))
+		})
+	}
+})
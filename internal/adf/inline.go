@@ -0,0 +1,360 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package adf
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	// autolinkPattern represents a URL enclosed in angle brackets
+	autolinkPattern = regexp.MustCompile(`^<(https?://[^>\s]+)>`)
+	// lineBreakPattern represents an HTML line break
+	lineBreakPattern = regexp.MustCompile(`^<br\s*/?>`)
+	// urlPattern represents a bare URL
+	urlPattern = regexp.MustCompile(`^https?://[^\s<]+`)
+)
+
+// maxInlineDepth represents the maximum nesting of links and emphasis that is
+// converted; deeper text is kept as is to bound the conversion time.
+const maxInlineDepth = 8
+
+// inlineParser represents the state of the conversion of inline markdown.
+type inlineParser struct {
+	// nodes represents the converted inline nodes
+	nodes []Node
+	// text represents the text not yet added as a node
+	text strings.Builder
+	// marks represents the marks applied to the text
+	marks []Mark
+	// depth represents the nesting of the text within links and emphasis
+	depth int
+	// brackets represents the indexes of the closing brackets and parentheses
+	// keyed by the indexes of their opening ones
+	brackets map[int]int
+}
+
+// inline will convert inline markdown into text nodes formatted with marks;
+// adjacent text nodes with the same marks are merged.
+func inline(text string, marks []Mark) []Node {
+	return nestedInline(text, marks, 0)
+}
+
+// nestedInline will convert inline markdown nested within links and emphasis;
+// text nested too deeply is not converted.
+func nestedInline(text string, marks []Mark, depth int) []Node {
+	p := &inlineParser{marks: marks, depth: depth}
+	if depth > maxInlineDepth {
+		p.text.WriteString(text)
+	} else {
+		p.parse(text)
+	}
+	p.flush()
+	return p.nodes
+}
+
+// flush will add the pending text as a node.
+func (p *inlineParser) flush() {
+	if p.text.Len() == 0 {
+		return
+	}
+	p.add(Node{Type: "text", Text: p.text.String(), Marks: p.marks})
+	p.text.Reset()
+}
+
+// add will add nodes merging text nodes with the same marks.
+func (p *inlineParser) add(nodes ...Node) {
+	for _, node := range nodes {
+		last := len(p.nodes) - 1
+		if node.Type == "text" && last >= 0 && p.nodes[last].Type == "text" &&
+			reflect.DeepEqual(p.nodes[last].Marks, node.Marks) {
+			p.nodes[last].Text += node.Text
+			continue
+		}
+		p.nodes = append(p.nodes, node)
+	}
+}
+
+// withMark will get the marks of the parser with an additional mark.
+func (p *inlineParser) withMark(mark Mark) []Mark {
+	marks := make([]Mark, 0, len(p.marks)+1)
+	marks = append(marks, p.marks...)
+	return append(marks, mark)
+}
+
+// linkMark will create a link mark.
+func linkMark(href string) Mark {
+	return Mark{Type: "link", Attrs: map[string]any{"href": href}}
+}
+
+// parse will convert the inline markdown.
+func (p *inlineParser) parse(text string) {
+	p.brackets = matchBrackets(text)
+	for i := 0; i < len(text); {
+		consumed := 0
+		switch text[i] {
+		case '\\':
+			if i+1 < len(text) && isASCIIPunctuation(text[i+1]) {
+				p.text.WriteByte(text[i+1])
+				consumed = 2
+			}
+		case '`':
+			consumed = p.codeSpan(text[i:])
+		case '!':
+			if i+1 < len(text) && text[i+1] == '[' {
+				if n := p.link(text, i+1); n > 0 {
+					consumed = n + 1
+				}
+			}
+		case '[':
+			consumed = p.link(text, i)
+		case '<':
+			consumed = p.angleBracket(text[i:])
+		case 'h':
+			if i == 0 || !isAlphanumeric(text[:i], true) {
+				consumed = p.url(text[i:])
+			}
+		case '*', '_', '~':
+			consumed = p.emphasis(text, i)
+		}
+		if consumed == 0 {
+			p.text.WriteByte(text[i])
+			consumed = 1
+		}
+		i += consumed
+	}
+}
+
+// codeSpan will convert a code span at the start of the text; code marks may
+// only be combined with links.
+func (p *inlineParser) codeSpan(text string) int {
+	run := len(text) - len(strings.TrimLeft(text, "`"))
+	delimiter := text[:run]
+	for offset := run; offset < len(text); {
+		index := strings.Index(text[offset:], delimiter)
+		if index < 0 {
+			break
+		}
+		start := offset + index
+		end := start + run
+		if end < len(text) && text[end] == '`' {
+			// The closing run must be the same length as the opening run
+			offset = end + len(text[end:]) - len(strings.TrimLeft(text[end:], "`"))
+			continue
+		}
+		code := text[run:start]
+		if len(code) > 2 && strings.HasPrefix(code, " ") && strings.HasSuffix(code, " ") {
+			code = code[1 : len(code)-1]
+		}
+		if len(code) == 0 {
+			return 0
+		}
+		marks := []Mark{}
+		for _, mark := range p.marks {
+			if mark.Type == "link" {
+				marks = append(marks, mark)
+			}
+		}
+		p.flush()
+		p.add(Node{Type: "text", Text: code, Marks: append(marks, Mark{Type: "code"})})
+		return end
+	}
+	return 0
+}
+
+// link will convert a link or the alternative text of an image starting at
+// the index of the text; links without text show their URL.
+func (p *inlineParser) link(text string, i int) int {
+	closing, ok := p.brackets[i]
+	if !ok || closing+1 >= len(text) || text[closing+1] != '(' {
+		return 0
+	}
+	end, ok := p.brackets[closing+1]
+	if !ok {
+		return 0
+	}
+	destination := strings.Fields(text[closing+2 : end])
+	if len(destination) == 0 {
+		return 0
+	}
+	href := strings.Trim(destination[0], "<>")
+	label := text[i+1 : closing]
+	if len(strings.TrimSpace(label)) == 0 {
+		label = href
+	}
+	p.flush()
+	p.add(nestedInline(label, p.withMark(linkMark(href)), p.depth+1)...)
+	return end + 1 - i
+}
+
+// matchBrackets will find the closing brackets and parentheses of the text
+// keyed by the indexes of their opening ones in a single pass; escaped
+// brackets and parentheses are ignored.
+func matchBrackets(text string) map[int]int {
+	brackets := make(map[int]int)
+	var squares, parentheses []int
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '[':
+			squares = append(squares, i)
+		case ']':
+			if last := len(squares) - 1; last >= 0 {
+				brackets[squares[last]] = i
+				squares = squares[:last]
+			}
+		case '(':
+			parentheses = append(parentheses, i)
+		case ')':
+			if last := len(parentheses) - 1; last >= 0 {
+				brackets[parentheses[last]] = i
+				parentheses = parentheses[:last]
+			}
+		}
+	}
+	return brackets
+}
+
+// angleBracket will convert an autolink or an HTML line break at the start of
+// the text.
+func (p *inlineParser) angleBracket(text string) int {
+	if matches := autolinkPattern.FindStringSubmatch(text); matches != nil {
+		p.flush()
+		p.add(Node{Type: "text", Text: matches[1], Marks: p.withMark(linkMark(matches[1]))})
+		return len(matches[0])
+	}
+	if match := lineBreakPattern.FindString(text); len(match) > 0 {
+		p.flush()
+		p.add(Node{Type: "hardBreak"})
+		return len(match)
+	}
+	return 0
+}
+
+// url will convert a bare URL at the start of the text; trailing punctuation
+// and unbalanced closing parentheses are not part of the URL.
+func (p *inlineParser) url(text string) int {
+	href := urlPattern.FindString(text)
+	if len(href) == 0 || p.linked() {
+		return 0
+	}
+	for len(href) > 0 {
+		last := href[len(href)-1]
+		if strings.IndexByte(".,:;!?'\"*_~", last) >= 0 ||
+			(last == ')' && strings.Count(href, ")") > strings.Count(href, "(")) {
+			href = href[:len(href)-1]
+			continue
+		}
+		break
+	}
+	p.flush()
+	p.add(Node{Type: "text", Text: href, Marks: p.withMark(linkMark(href))})
+	return len(href)
+}
+
+// linked will determine if the text is already within a link.
+func (p *inlineParser) linked() bool {
+	for _, mark := range p.marks {
+		if mark.Type == "link" {
+			return true
+		}
+	}
+	return false
+}
+
+// emphasisMarks represents the marks of the emphasis delimiter runs.
+var emphasisMarks = map[string]string{
+	"*":  "em",
+	"_":  "em",
+	"**": "strong",
+	"__": "strong",
+	"~~": "strike",
+}
+
+// emphasis will convert emphasis, strong emphasis, or strikethrough starting at
+// the index of the text; underscores do not format within words.
+func (p *inlineParser) emphasis(text string, i int) int {
+	delimiter := text[i : i+runLength(text, i)]
+	markType, ok := emphasisMarks[delimiter]
+	if !ok || i+len(delimiter) >= len(text) || text[i+len(delimiter)] == ' ' {
+		return 0
+	}
+	underscore := delimiter[0] == '_'
+	if underscore && isAlphanumeric(text[:i], true) {
+		return 0
+	}
+	for j := i + len(delimiter); j < len(text); j++ {
+		switch text[j] {
+		case '\\':
+			j++
+			continue
+		case '`':
+			// Delimiters within code spans do not close the emphasis
+			if end := strings.IndexByte(text[j+1:], '`'); end >= 0 {
+				j += end + 1
+			}
+			continue
+		}
+		if text[j] != delimiter[0] {
+			continue
+		}
+		run := runLength(text, j)
+		if run != len(delimiter) || text[j-1] == ' ' ||
+			(underscore && isAlphanumeric(text[j+run:], false)) {
+			j += run - 1
+			continue
+		}
+		p.flush()
+		p.add(nestedInline(text[i+len(delimiter):j], p.withMark(Mark{Type: markType}), p.depth+1)...)
+		return j + run - i
+	}
+	return 0
+}
+
+// runLength will get the length of the run of the character at the index of
+// the text.
+func runLength(text string, i int) int {
+	n := 1
+	for i+n < len(text) && text[i+n] == text[i] {
+		n++
+	}
+	return n
+}
+
+// isAlphanumeric will determine if the last, or first, character of the text
+// is a letter or a digit.
+func isAlphanumeric(text string, last bool) bool {
+	if len(text) == 0 {
+		return false
+	}
+	var r rune
+	if last {
+		r, _ = utf8.DecodeLastRuneInString(text)
+	} else {
+		r, _ = utf8.DecodeRuneInString(text)
+	}
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// isASCIIPunctuation will determine if a character may be escaped.
+func isASCIIPunctuation(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
@@ -0,0 +1,199 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package compat
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kong/koko-slack-bot/internal/lua"
+)
+
+const (
+	// RemovedFieldsPath represents the path of the gateway file listing the
+	// fields removed from the configuration sent to older data planes
+	RemovedFieldsPath = "kong/clustering/compat/removed_fields.lua"
+	// CheckersPath represents the path of the gateway file containing the
+	// compatibility checkers applied to the configuration sent to older data
+	// planes
+	CheckersPath = "kong/clustering/compat/checkers.lua"
+)
+
+// RemovedFields represents the fields removed from the configuration sent to
+// data planes older than a gateway version; keyed by version number and
+// plugin.
+type RemovedFields map[int64]map[string][]string
+
+// RemovedFieldsEntry represents the removed fields of a plugin for a gateway version that
+// differ between two revisions of removed_fields.lua.
+type RemovedFieldsEntry struct {
+	// Version represents the gateway version number; e.g. 3001000000
+	Version int64
+	// Plugin represents the name of the plugin
+	Plugin string
+	// Added represents the fields that are now removed for older data planes
+	Added []string
+	// Removed represents the fields that are no longer removed for older data
+	// planes
+	Removed []string
+}
+
+// IsCompatFile will determine if a path is one of the gateway compatibility
+// files Koko mirrors.
+func IsCompatFile(path string) bool {
+	return path == RemovedFieldsPath || path == CheckersPath
+}
+
+// VersionString will convert a gateway version number into a semantic
+// version; e.g. 3001000000 is 3.1.0.
+func VersionString(version int64) string {
+	return fmt.Sprintf("%d.%d.%d", version/1000000000, version/1000000%1000, version/1000%1000)
+}
+
+// ParseRemovedFields will parse the content of removed_fields.lua; an empty
+// content results in no removed fields.
+func ParseRemovedFields(source string) (RemovedFields, error) {
+	removedFields := make(RemovedFields)
+	if len(strings.TrimSpace(source)) == 0 {
+		return removedFields, nil
+	}
+	value, err := lua.ParseReturn(source)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse removed fields: %w", err)
+	}
+	if value.Kind != lua.KindTable {
+		return nil, fmt.Errorf("unable to parse removed fields: expected a table but found %s", value.Source())
+	}
+	for _, versionField := range value.Table.Fields {
+		if !versionField.Key.IsInteger() || versionField.Value.Kind != lua.KindTable {
+			return nil, fmt.Errorf("unable to parse removed fields: invalid version entry %s",
+				versionField.Key.Source())
+		}
+		version := int64(versionField.Key.Number)
+		plugins := make(map[string][]string)
+		for _, pluginField := range versionField.Value.Table.Fields {
+			if pluginField.Key.Kind != lua.KindString || pluginField.Value.Kind != lua.KindTable {
+				return nil, fmt.Errorf("unable to parse removed fields: invalid plugin entry %s for %s",
+					pluginField.Key.Source(), VersionString(version))
+			}
+			plugins[pluginField.Key.String] = append(plugins[pluginField.Key.String],
+				pluginField.Value.Table.Strings()...)
+		}
+		removedFields[version] = plugins
+	}
+	return removedFields, nil
+}
+
+// ParseCheckers will parse the content of checkers.lua and get the gateway
+// version numbers that have a compatibility checker; an empty content results
+// in no checkers.
+func ParseCheckers(source string) ([]int64, error) {
+	if len(strings.TrimSpace(source)) == 0 {
+		return nil, nil
+	}
+	value, err := lua.ParseReturn(source)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse checkers: %w", err)
+	}
+	if value.Kind != lua.KindTable {
+		return nil, fmt.Errorf("unable to parse checkers: expected a table but found %s", value.Source())
+	}
+	var versions []int64
+	for _, checker := range value.Table.Array {
+		if checker.Kind != lua.KindTable || len(checker.Table.Array) == 0 || !checker.Table.Array[0].IsInteger() {
+			return nil, fmt.Errorf("unable to parse checkers: invalid checker %s", checker.Source())
+		}
+		versions = append(versions, int64(checker.Table.Array[0].Number))
+	}
+	return versions, nil
+}
+
+// DiffRemovedFields will get the entries that differ between the base and
+// head revisions of removed_fields.lua ordered by version and plugin.
+func DiffRemovedFields(base RemovedFields, head RemovedFields) []RemovedFieldsEntry {
+	versions := make(map[int64]struct{})
+	for version := range base {
+		versions[version] = struct{}{}
+	}
+	for version := range head {
+		versions[version] = struct{}{}
+	}
+
+	var entries []RemovedFieldsEntry
+	for version := range versions {
+		plugins := make(map[string]struct{})
+		for plugin := range base[version] {
+			plugins[plugin] = struct{}{}
+		}
+		for plugin := range head[version] {
+			plugins[plugin] = struct{}{}
+		}
+		for plugin := range plugins {
+			entry := RemovedFieldsEntry{
+				Version: version,
+				Plugin:  plugin,
+				Added:   difference(head[version][plugin], base[version][plugin]),
+				Removed: difference(base[version][plugin], head[version][plugin]),
+			}
+			if len(entry.Added) > 0 || len(entry.Removed) > 0 {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Version != entries[j].Version {
+			return entries[i].Version < entries[j].Version
+		}
+		return entries[i].Plugin < entries[j].Plugin
+	})
+	return entries
+}
+
+// DiffCheckers will get the gateway version numbers of the checkers added in
+// the head revision of checkers.lua in ascending order.
+func DiffCheckers(base []int64, head []int64) []int64 {
+	existing := make(map[int64]struct{}, len(base))
+	for _, version := range base {
+		existing[version] = struct{}{}
+	}
+	var added []int64
+	for _, version := range head {
+		if _, ok := existing[version]; !ok {
+			added = append(added, version)
+			existing[version] = struct{}{}
+		}
+	}
+	sort.Slice(added, func(i, j int) bool { return added[i] < added[j] })
+	return added
+}
+
+// difference will get the values of a that are not in b preserving their
+// order.
+func difference(a []string, b []string) []string {
+	excluded := make(map[string]struct{}, len(b))
+	for _, value := range b {
+		excluded[value] = struct{}{}
+	}
+	var values []string
+	for _, value := range a {
+		if _, ok := excluded[value]; !ok {
+			values = append(values, value)
+			excluded[value] = struct{}{}
+		}
+	}
+	return values
+}
@@ -0,0 +1,139 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package compat
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("compat", Label("compat"), func() {
+	const base = `
+return {
+  -- Any dataplane older than 3.1.0
+  [3001000000] = {
+    acme = {
+      "enable_ipv4_common_name",
+    },
+    rate_limiting = {
+      "error_code",
+      "error_message",
+    },
+  },
+}
+`
+	const head = `
+return {
+  -- Any dataplane older than 3.1.0
+  [3001000000] = {
+    acme = {
+      "enable_ipv4_common_name",
+      "storage_config.redis.ssl",
+    },
+    rate_limiting = {
+      "error_code",
+    },
+  },
+  -- Any dataplane older than 3.5.0
+  [3005000000] = {
+    rate_limiting = {
+      "sync_rate",
+    },
+  },
+}
+`
+
+	It("the gateway version will be converted from its number", func() {
+		Expect(VersionString(3005000000)).Should(Equal("3.5.0"))
+		Expect(VersionString(2008001000)).Should(Equal("2.8.1"))
+	})
+
+	It("the removed fields will be parsed", func() {
+		removedFields, err := ParseRemovedFields(base)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(removedFields).Should(Equal(RemovedFields{
+			3001000000: {
+				"acme":          {"enable_ipv4_common_name"},
+				"rate_limiting": {"error_code", "error_message"},
+			},
+		}))
+	})
+
+	It("the removed fields differences will be ordered by version and plugin", func() {
+		baseFields, err := ParseRemovedFields(base)
+		Expect(err).NotTo(HaveOccurred())
+		headFields, err := ParseRemovedFields(head)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(DiffRemovedFields(baseFields, headFields)).Should(Equal([]RemovedFieldsEntry{
+			{Version: 3001000000, Plugin: "acme", Added: []string{"storage_config.redis.ssl"}},
+			{Version: 3001000000, Plugin: "rate_limiting", Removed: []string{"error_message"}},
+			{Version: 3005000000, Plugin: "rate_limiting", Added: []string{"sync_rate"}},
+		}))
+	})
+
+	It("a missing base file will result in every entry being added", func() {
+		baseFields, err := ParseRemovedFields("")
+		Expect(err).NotTo(HaveOccurred())
+		headFields, err := ParseRemovedFields(base)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(DiffRemovedFields(baseFields, headFields)).To(HaveLen(2))
+	})
+
+	It("an invalid removed fields file will error", func() {
+		_, err := ParseRemovedFields(`return { acme = { "field" } }`)
+		Expect(err).Should(MatchError(ContainSubstring("invalid version entry")))
+	})
+
+	It("the added checkers will be found", func() {
+		baseVersions, err := ParseCheckers(`
+local compatible_checkers = {
+  { 3003000000, --[[ 3.3.0.0 ]]
+    function(config_table, dp_version, log_suffix)
+      return false
+    end,
+  },
+}
+
+return compatible_checkers
+`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(baseVersions).Should(Equal([]int64{3003000000}))
+		headVersions, err := ParseCheckers(`
+local compatible_checkers = {
+  { 3005000000, --[[ 3.5.0.0 ]]
+    function(config_table, dp_version, log_suffix)
+      local has_update
+      for _, plugin in ipairs(config_table.plugins or {}) do
+        if plugin.name == "rate-limiting" then
+          has_update = true
+        end
+      end
+      return has_update
+    end,
+  },
+  { 3003000000, --[[ 3.3.0.0 ]]
+    function(config_table, dp_version, log_suffix)
+      return false
+    end,
+  },
+}
+
+return compatible_checkers
+`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(DiffCheckers(baseVersions, headVersions)).Should(Equal([]int64{3005000000}))
+	})
+})
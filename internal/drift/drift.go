@@ -0,0 +1,228 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package drift
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Kind represents the kind of drift between a gateway plugin schema and its
+// Koko schema.
+type Kind string
+
+const (
+	// KindMissingPlugin represents a gateway plugin without a Koko schema
+	KindMissingPlugin Kind = "missing-plugin"
+	// KindUnknownPlugin represents a Koko schema of a plugin the gateway does
+	// not have
+	KindUnknownPlugin Kind = "unknown-plugin"
+	// KindMissingField represents a gateway field missing from the Koko schema
+	KindMissingField Kind = "missing-field"
+	// KindUnknownField represents a Koko field the gateway schema does not
+	// have
+	KindUnknownField Kind = "unknown-field"
+	// KindType represents a field whose type differs
+	KindType Kind = "type"
+	// KindDefault represents a field whose default value differs
+	KindDefault Kind = "default"
+)
+
+// Field represents a normalized field of a plugin configuration.
+type Field struct {
+	// Path represents the dotted path of the field within the plugin
+	// configuration; array elements use []
+	Path string
+	// Type represents the JSON type of the field; empty for any value
+	Type string
+	// Default represents the compact JSON encoding of the default value of the
+	// field; empty when the field has no default
+	Default string
+}
+
+// Drift represents a difference between a gateway plugin schema and its Koko
+// schema.
+type Drift struct {
+	// Plugin represents the name of the plugin
+	Plugin string
+	// Kind represents the kind of drift
+	Kind Kind
+	// Path represents the dotted path of the drifting field; empty for plugin
+	// drift
+	Path string
+	// Gateway represents the type or default of the field in the gateway
+	Gateway string
+	// Koko represents the type or default of the field in Koko
+	Koko string
+}
+
+// Normalize will flatten the plugin configuration of a JSON schema document
+// into fields ordered by path. The config property is used when the document
+// describes a whole plugin, otherwise the document is the configuration.
+func Normalize(document []byte) ([]Field, error) {
+	var root map[string]any
+	if err := json.Unmarshal(document, &root); err != nil {
+		return nil, fmt.Errorf("unable to parse JSON schema: %w", err)
+	}
+	if properties, ok := root["properties"].(map[string]any); ok {
+		if config, ok := properties["config"].(map[string]any); ok {
+			root = config
+		}
+	}
+	var fields []Field
+	flatten(root, "", &fields)
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Path < fields[j].Path
+	})
+	return fields, nil
+}
+
+// flatten will add the properties of an object schema and the elements of an
+// array schema as fields.
+func flatten(node map[string]any, path string, fields *[]Field) {
+	if properties, ok := node["properties"].(map[string]any); ok {
+		for name, property := range properties {
+			property, ok := property.(map[string]any)
+			if !ok {
+				continue
+			}
+			fieldPath := name
+			if len(path) > 0 {
+				fieldPath = path + "." + name
+			}
+			*fields = append(*fields, field(property, fieldPath))
+			flatten(property, fieldPath, fields)
+		}
+	}
+	if items, ok := node["items"].(map[string]any); ok {
+		*fields = append(*fields, field(items, path+"[]"))
+		flatten(items, path+"[]", fields)
+	}
+}
+
+// field will normalize the type and default of a property schema; nullable
+// types are reduced to their non null type.
+func field(node map[string]any, path string) Field {
+	f := Field{Path: path}
+	switch kind := node["type"].(type) {
+	case string:
+		f.Type = kind
+	case []any:
+		var types []string
+		for _, t := range kind {
+			if t, ok := t.(string); ok && t != "null" {
+				types = append(types, t)
+			}
+		}
+		sort.Strings(types)
+		f.Type = strings.Join(types, "|")
+	}
+	if value, ok := node["default"]; ok {
+		// Maps are encoded with sorted keys, so equal defaults are encoded the
+		// same way
+		if encoded, err := json.Marshal(value); err == nil {
+			f.Default = string(encoded)
+		}
+	}
+	return f
+}
+
+// Compare will find the drift between the fields of a gateway plugin schema
+// and the fields of its Koko schema ordered by path. Types are only compared
+// when both schemas declare one.
+func Compare(plugin string, gateway []Field, koko []Field) []Drift {
+	kokoFields := make(map[string]Field, len(koko))
+	for _, f := range koko {
+		kokoFields[f.Path] = f
+	}
+	gatewayFields := make(map[string]Field, len(gateway))
+	for _, f := range gateway {
+		gatewayFields[f.Path] = f
+	}
+
+	var drifts []Drift
+	for _, g := range gateway {
+		k, ok := kokoFields[g.Path]
+		switch {
+		case !ok:
+			if !hasAncestor(kokoFields, gatewayFields, g.Path) {
+				drifts = append(drifts, Drift{Plugin: plugin, Kind: KindMissingField, Path: g.Path, Gateway: g.Type})
+			}
+		case len(g.Type) > 0 && len(k.Type) > 0 && g.Type != k.Type:
+			drifts = append(drifts, Drift{Plugin: plugin, Kind: KindType, Path: g.Path, Gateway: g.Type, Koko: k.Type})
+		case g.Default != k.Default:
+			drifts = append(drifts, Drift{Plugin: plugin, Kind: KindDefault, Path: g.Path, Gateway: g.Default,
+				Koko: k.Default})
+		}
+	}
+	for _, k := range koko {
+		if _, ok := gatewayFields[k.Path]; !ok && !hasAncestor(gatewayFields, kokoFields, k.Path) {
+			drifts = append(drifts, Drift{Plugin: plugin, Kind: KindUnknownField, Path: k.Path, Koko: k.Type})
+		}
+	}
+	sort.SliceStable(drifts, func(i, j int) bool {
+		return drifts[i].Path < drifts[j].Path
+	})
+	return drifts
+}
+
+// hasAncestor will determine if an ancestor of a field is missing from the
+// other schema while present in its own; only the topmost missing field is
+// reported as drift.
+func hasAncestor(other map[string]Field, own map[string]Field, path string) bool {
+	for i := len(path) - 1; i > 0; i-- {
+		if path[i] != '.' && path[i] != '[' {
+			continue
+		}
+		ancestor := path[:i]
+		_, inOwn := own[ancestor]
+		_, inOther := other[ancestor]
+		if inOwn && !inOther {
+			return true
+		}
+	}
+	return false
+}
+
+// String will get a short description of the drift; e.g. sync_rate: default
+// -1 in the gateway but none in Koko.
+func (d Drift) String() string {
+	switch d.Kind {
+	case KindMissingPlugin:
+		return "no Koko schema"
+	case KindUnknownPlugin:
+		return "Koko schema of a plugin the gateway does not have"
+	case KindMissingField:
+		return fmt.Sprintf("%s: missing from Koko", d.Path)
+	case KindUnknownField:
+		return fmt.Sprintf("%s: not in the gateway", d.Path)
+	case KindType:
+		return fmt.Sprintf("%s: type %s in the gateway but %s in Koko", d.Path, d.Gateway, d.Koko)
+	default:
+		return fmt.Sprintf("%s: default %s in the gateway but %s in Koko", d.Path, describe(d.Gateway),
+			describe(d.Koko))
+	}
+}
+
+// describe will describe a default value; an empty value is none.
+func describe(value string) string {
+	if len(value) == 0 {
+		return "none"
+	}
+	return value
+}
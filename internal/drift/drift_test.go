@@ -0,0 +1,102 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package drift
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("drift", Label("drift"), func() {
+	It("the plugin configuration of a JSON schema will be normalized", func() {
+		fields, err := Normalize([]byte(`{
+  "type": "object",
+  "properties": {
+    "protocols": {"type": "array"},
+    "config": {
+      "type": "object",
+      "properties": {
+        "policy": {"type": "string", "default": "local"},
+        "redis": {"type": "object", "default": {"port": 6379, "host": null}, "properties": {
+          "port": {"type": ["integer", "null"]}
+        }},
+        "limits": {"type": "array", "items": {"type": "object", "properties": {"window": {"type": "integer"}}}}
+      }
+    }
+  }
+}`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fields).Should(Equal([]Field{
+			{Path: "limits", Type: "array"},
+			{Path: "limits[]", Type: "object"},
+			{Path: "limits[].window", Type: "integer"},
+			{Path: "policy", Type: "string", Default: `"local"`},
+			{Path: "redis", Type: "object", Default: `{"host":null,"port":6379}`},
+			{Path: "redis.port", Type: "integer"},
+		}))
+	})
+
+	It("a JSON schema of the configuration only will be normalized", func() {
+		fields, err := Normalize([]byte(`{"type": "object", "properties": {"policy": {"type": "string"}}}`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fields).Should(Equal([]Field{{Path: "policy", Type: "string"}}))
+	})
+
+	It("an invalid JSON schema will not be normalized", func() {
+		_, err := Normalize([]byte(`{`))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("missing fields, mismatched types, and mismatched defaults will be found", func() {
+		gateway := []Field{
+			{Path: "policy", Type: "string", Default: `"local"`},
+			{Path: "redis", Type: "object"},
+			{Path: "redis.host", Type: "string"},
+			{Path: "redis.port", Type: "integer", Default: "6379"},
+			{Path: "second", Type: "number"},
+			{Path: "sync_rate", Type: "number", Default: "-1"},
+			{Path: "sync_rate_options", Type: "object"},
+			{Path: "sync_rate_options.jitter", Type: "number"},
+			{Path: "header_name"},
+		}
+		koko := []Field{
+			{Path: "header_name", Type: "string"},
+			{Path: "hide_client_headers", Type: "boolean", Default: "false"},
+			{Path: "policy", Type: "string", Default: `"cluster"`},
+			{Path: "redis", Type: "object"},
+			{Path: "redis.host", Type: "string"},
+			{Path: "redis.port", Type: "integer", Default: "6379"},
+			{Path: "second", Type: "integer"},
+		}
+		var descriptions []string
+		for _, d := range Compare("rate-limiting", gateway, koko) {
+			Expect(d.Plugin).Should(Equal("rate-limiting"))
+			descriptions = append(descriptions, d.String())
+		}
+		Expect(descriptions).Should(Equal([]string{
+			"hide_client_headers: not in the gateway",
+			`policy: default "local" in the gateway but "cluster" in Koko`,
+			"second: type number in the gateway but integer in Koko",
+			"sync_rate: missing from Koko",
+			"sync_rate_options: missing from Koko",
+		}))
+	})
+
+	It("identical schemas will not drift", func() {
+		fields := []Field{{Path: "policy", Type: "string", Default: `"local"`}}
+		Expect(Compare("rate-limiting", fields, fields)).To(BeEmpty())
+	})
+})
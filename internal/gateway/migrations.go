@@ -0,0 +1,203 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package gateway
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/kong/koko-slack-bot/internal/lua"
+)
+
+// MigrationsPath represents the path of the gateway database migrations.
+const MigrationsPath = "kong/db/migrations/"
+
+const (
+	// ColumnAdded represents a column added to a table
+	ColumnAdded ColumnOperation = "added"
+	// ColumnDropped represents a column dropped from a table
+	ColumnDropped ColumnOperation = "dropped"
+	// ColumnAltered represents a column whose type, default, or constraints
+	// are altered
+	ColumnAltered ColumnOperation = "altered"
+	// ColumnRenamed represents a column that is renamed
+	ColumnRenamed ColumnOperation = "renamed"
+)
+
+// ColumnOperation represents the operation applied to a column by a
+// migration.
+type ColumnOperation string
+
+// ColumnChange represents a change to a column of a table.
+type ColumnChange struct {
+	// Table represents the name of the table
+	Table string
+	// Column represents the name of the column
+	Column string
+	// Operation represents the operation applied to the column
+	Operation ColumnOperation
+	// Definition represents the remainder of the operation; e.g. the type of
+	// an added column or the new name of a renamed column
+	Definition string
+}
+
+// Migration represents the Postgres changes of a database migration.
+type Migration struct {
+	// Path represents the path of the migration in the repository
+	Path string
+	// CreatedTables represents the tables created by the migration
+	CreatedTables []string
+	// Columns represents the column changes of the migration
+	Columns []ColumnChange
+}
+
+var (
+	// sqlCommentPattern represents a SQL line comment
+	sqlCommentPattern = regexp.MustCompile(`--[^\n]*`)
+	// whitespacePattern represents consecutive whitespace
+	whitespacePattern = regexp.MustCompile(`\s+`)
+	// createTablePattern represents a CREATE TABLE statement
+	createTablePattern = regexp.MustCompile(`(?i)\bCREATE\s+TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?("?[\w.]+"?)`)
+	// alterTablePattern represents an ALTER TABLE statement and its actions
+	alterTablePattern = regexp.MustCompile(
+		`(?is)\bALTER\s+TABLE\s+(?:IF\s+EXISTS\s+)?(?:ONLY\s+)?("?[\w.]+"?)\s+(.*)$`)
+	// columnActionPattern represents an ADD, DROP, or ALTER column action
+	columnActionPattern = regexp.MustCompile(
+		`(?is)^(ADD|DROP|ALTER)\s+(?:COLUMN\s+)?(?:IF\s+(?:NOT\s+)?EXISTS\s+)?("[^"]+"|\w+)\s*(.*)$`)
+	// renameColumnPattern represents a RENAME column action
+	renameColumnPattern = regexp.MustCompile(`(?is)^RENAME\s+(?:COLUMN\s+)?("[^"]+"|\w+)\s+TO\s+("[^"]+"|\w+)$`)
+)
+
+// constraintKeywords represents the keywords following ADD or DROP in table
+// constraint actions that do not change columns.
+var constraintKeywords = map[string]bool{
+	"CONSTRAINT": true,
+	"PRIMARY":    true,
+	"UNIQUE":     true,
+	"FOREIGN":    true,
+	"CHECK":      true,
+	"EXCLUDE":    true,
+}
+
+// columnOperations represents the column operation of each action keyword.
+var columnOperations = map[string]ColumnOperation{
+	"ADD":   ColumnAdded,
+	"DROP":  ColumnDropped,
+	"ALTER": ColumnAltered,
+}
+
+// IsNewMigration will determine if a file added by a pull request is a gateway
+// database migration.
+func IsNewMigration(filename string, status string) bool {
+	return status == "added" && strings.HasPrefix(filename, MigrationsPath) &&
+		path.Ext(filename) == ".lua" && path.Base(filename) != "init.lua"
+}
+
+// ParseMigration will parse the content of a database migration and extract
+// the changes of its Postgres up SQL.
+func ParseMigration(filename string, source string) (Migration, error) {
+	migration := Migration{Path: filename}
+	value, err := lua.ParseReturn(source)
+	if err != nil {
+		return Migration{}, fmt.Errorf("unable to parse migration %s: %w", filename, err)
+	}
+	postgres, ok := value.Table.Get("postgres")
+	if value.Kind != lua.KindTable || !ok {
+		return migration, nil
+	}
+	up, ok := postgres.Table.Get("up")
+	if !ok || up.Kind != lua.KindString {
+		return migration, nil
+	}
+	migration.CreatedTables, migration.Columns = ParsePostgresChanges(up.String)
+	return migration, nil
+}
+
+// ParsePostgresChanges will extract the created tables and the column changes
+// of Postgres SQL statements.
+func ParsePostgresChanges(sql string) ([]string, []ColumnChange) {
+	var createdTables []string
+	var columns []ColumnChange
+	sql = sqlCommentPattern.ReplaceAllString(sql, "")
+	for _, statement := range strings.Split(sql, ";") {
+		for _, matches := range createTablePattern.FindAllStringSubmatch(statement, -1) {
+			createdTables = append(createdTables, unquoteIdentifier(matches[1]))
+		}
+		matches := alterTablePattern.FindStringSubmatch(statement)
+		if matches == nil {
+			continue
+		}
+		table := unquoteIdentifier(matches[1])
+		for _, action := range splitActions(matches[2]) {
+			if change, ok := parseColumnAction(table, action); ok {
+				columns = append(columns, change)
+			}
+		}
+	}
+	return createdTables, columns
+}
+
+// parseColumnAction will parse an ALTER TABLE action changing a column.
+func parseColumnAction(table string, action string) (ColumnChange, bool) {
+	action = whitespacePattern.ReplaceAllString(strings.TrimSpace(action), " ")
+	if matches := renameColumnPattern.FindStringSubmatch(action); matches != nil {
+		return ColumnChange{
+			Table:      table,
+			Column:     unquoteIdentifier(matches[1]),
+			Operation:  ColumnRenamed,
+			Definition: "to " + unquoteIdentifier(matches[2]),
+		}, true
+	}
+	matches := columnActionPattern.FindStringSubmatch(action)
+	if matches == nil || constraintKeywords[strings.ToUpper(matches[2])] {
+		return ColumnChange{}, false
+	}
+	return ColumnChange{
+		Table:      table,
+		Column:     unquoteIdentifier(matches[2]),
+		Operation:  columnOperations[strings.ToUpper(matches[1])],
+		Definition: matches[3],
+	}, true
+}
+
+// splitActions will split the actions of an ALTER TABLE statement on the
+// commas that are not within parentheses.
+func splitActions(actions string) []string {
+	var split []string
+	depth := 0
+	start := 0
+	for i, c := range actions {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				split = append(split, actions[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(split, actions[start:])
+}
+
+// unquoteIdentifier will remove the quotes of a SQL identifier.
+func unquoteIdentifier(identifier string) string {
+	return strings.ReplaceAll(identifier, `"`, "")
+}
@@ -0,0 +1,87 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package gateway
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("migrations", Label("migrations"), func() {
+	It("new migrations will be detected", func() {
+		Expect(IsNewMigration("kong/db/migrations/core/020_330_to_340.lua", "added")).To(BeTrue())
+		Expect(IsNewMigration("kong/db/migrations/core/020_330_to_340.lua", "modified")).To(BeFalse())
+		Expect(IsNewMigration("kong/db/migrations/core/init.lua", "added")).To(BeFalse())
+		Expect(IsNewMigration("kong/plugins/acme/schema.lua", "added")).To(BeFalse())
+	})
+
+	It("the Postgres column changes will be parsed", func() {
+		migration, err := ParseMigration("kong/db/migrations/core/020_330_to_340.lua", `
+return {
+  postgres = {
+    up = [[
+      DO $$
+      BEGIN
+        ALTER TABLE IF EXISTS ONLY "upstreams" ADD "use_srv_name"  BOOLEAN DEFAULT false;
+      EXCEPTION WHEN DUPLICATE_COLUMN THEN
+        -- Do nothing, accept existing state
+      END;
+      $$;
+
+      CREATE TABLE IF NOT EXISTS "keys" (
+        "id"   UUID PRIMARY KEY,
+        "name" TEXT UNIQUE
+      );
+
+      ALTER TABLE "routes"
+        ADD COLUMN IF NOT EXISTS "expression" TEXT,
+        ADD CONSTRAINT "routes_priority_check" CHECK (priority >= 0),
+        DROP COLUMN IF EXISTS "regex_priority",
+        ALTER COLUMN "paths" TYPE TEXT[] USING (ARRAY['/']),
+        RENAME "hosts" TO "domains";
+    ]],
+  },
+  cassandra = {
+    up = [[]],
+  },
+}
+`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(migration).Should(Equal(Migration{
+			Path:          "kong/db/migrations/core/020_330_to_340.lua",
+			CreatedTables: []string{"keys"},
+			Columns: []ColumnChange{
+				{Table: "upstreams", Column: "use_srv_name", Operation: ColumnAdded, Definition: "BOOLEAN DEFAULT false"},
+				{Table: "routes", Column: "expression", Operation: ColumnAdded, Definition: "TEXT"},
+				{Table: "routes", Column: "regex_priority", Operation: ColumnDropped},
+				{Table: "routes", Column: "paths", Operation: ColumnAltered, Definition: "TYPE TEXT[] USING (ARRAY['/'])"},
+				{Table: "routes", Column: "hosts", Operation: ColumnRenamed, Definition: "to domains"},
+			},
+		}))
+	})
+
+	It("a migration without Postgres SQL will have no changes", func() {
+		migration, err := ParseMigration("kong/db/migrations/core/021_340_to_350.lua", `
+return {
+  postgres = {
+    up_f = function(connector) end,
+  },
+}
+`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(migration.Columns).To(BeEmpty())
+	})
+})
@@ -0,0 +1,73 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kong/koko-slack-bot/internal/lua"
+)
+
+const (
+	// ConstantsPath represents the path of the gateway file declaring the
+	// bundled plugins
+	ConstantsPath = "kong/constants.lua"
+	// bundledPluginsLocal represents the local variable of constants.lua
+	// listing the bundled plugins
+	bundledPluginsLocal = "plugins"
+)
+
+// ParseBundledPlugins will parse the content of constants.lua and get the
+// names of the bundled plugins; an empty content results in no plugins.
+func ParseBundledPlugins(source string) ([]string, error) {
+	if len(strings.TrimSpace(source)) == 0 {
+		return nil, nil
+	}
+	chunk, err := lua.Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse bundled plugins: %w", err)
+	}
+	plugins, ok := chunk.Locals[bundledPluginsLocal]
+	if !ok || plugins.Kind != lua.KindTable {
+		return nil, errors.New("unable to parse bundled plugins: plugins table not found")
+	}
+	return plugins.Table.Strings(), nil
+}
+
+// DiffBundledPlugins will get the plugins added to and removed from the
+// bundled plugins between the base and head revisions of constants.lua.
+func DiffBundledPlugins(base []string, head []string) ([]string, []string) {
+	return difference(head, base), difference(base, head)
+}
+
+// difference will get the values of a that are not in b preserving their
+// order.
+func difference(a []string, b []string) []string {
+	excluded := make(map[string]struct{}, len(b))
+	for _, value := range b {
+		excluded[value] = struct{}{}
+	}
+	var values []string
+	for _, value := range a {
+		if _, ok := excluded[value]; !ok {
+			values = append(values, value)
+			excluded[value] = struct{}{}
+		}
+	}
+	return values
+}
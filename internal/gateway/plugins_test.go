@@ -0,0 +1,74 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package gateway
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("bundled plugins", Label("plugins"), func() {
+	const constants = `
+local plugins = {
+  "jwt",
+  "acl",
+  "correlation-id",
+}
+
+local plugin_map = {}
+for i = 1, #plugins do
+  plugin_map[plugins[i]] = true
+end
+
+local deprecated_plugins = {} -- no currently deprecated plugin
+
+local deprecated_plugin_map = {}
+for _, plugin in ipairs(deprecated_plugins) do
+  deprecated_plugin_map[plugin] = true
+end
+
+local constants = {
+  BUNDLED_PLUGINS = plugin_map,
+  DEPRECATED_PLUGINS = deprecated_plugin_map,
+  RATELIMIT = {
+    PERIODS = {
+      "second",
+      "minute",
+    },
+  },
+}
+
+return constants
+`
+
+	It("the bundled plugins will be parsed", func() {
+		plugins, err := ParseBundledPlugins(constants)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plugins).Should(Equal([]string{"jwt", "acl", "correlation-id"}))
+	})
+
+	It("the added and removed plugins will be found", func() {
+		added, removed := DiffBundledPlugins([]string{"jwt", "acl", "correlation-id"},
+			[]string{"jwt", "correlation-id", "ai-proxy", "opentelemetry"})
+		Expect(added).Should(Equal([]string{"ai-proxy", "opentelemetry"}))
+		Expect(removed).Should(Equal([]string{"acl"}))
+	})
+
+	It("a constants file without the plugins table will error", func() {
+		_, err := ParseBundledPlugins("return {}")
+		Expect(err).Should(MatchError(ContainSubstring("plugins table not found")))
+	})
+})
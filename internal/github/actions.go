@@ -0,0 +1,124 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+)
+
+// WorkflowRunCompleted represents the status of a finished workflow run.
+const WorkflowRunCompleted = "completed"
+
+// WorkflowRun represents a run of a GitHub Actions workflow.
+type WorkflowRun struct {
+	// ID represents the identifier of the run
+	ID int64
+	// Name represents the display title of the run; i.e. the run-name of the
+	// workflow when set
+	Name string
+	// Status represents the status of the run; e.g. queued, in_progress, or
+	// completed
+	Status string
+	// Conclusion represents the result of a completed run; e.g. success or
+	// failure
+	Conclusion string
+	// URL represents the browsable URL of the run
+	URL string
+	// CreatedAt represents the time the run was created
+	CreatedAt time.Time
+}
+
+// DispatchWorkflow will trigger a workflow_dispatch event of a workflow on a
+// ref with the given inputs; e.g. gateway-compat.yaml on main.
+func (c *Client) DispatchWorkflow(organization string, repository string, workflow string, ref string,
+	inputs map[string]any,
+) error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	_, err := c.client.Actions.CreateWorkflowDispatchEventByFileName(ctx, organization, repository, workflow,
+		github.CreateWorkflowDispatchEventRequest{Ref: ref, Inputs: inputs})
+	if err != nil {
+		return fmt.Errorf("unable to dispatch workflow %s: %w", workflow, err)
+	}
+	return nil
+}
+
+// DispatchedWorkflowRuns will get the runs of a workflow triggered by
+// workflow_dispatch events since the given time ordered by creation.
+func (c *Client) DispatchedWorkflowRuns(organization string, repository string, workflow string,
+	since time.Time,
+) ([]WorkflowRun, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	// The display title of the runs is not exposed by go-github
+	query := url.Values{}
+	query.Set("event", "workflow_dispatch")
+	query.Set("created", ">="+since.UTC().Format(time.RFC3339))
+	query.Set("per_page", "100")
+	request, err := c.client.NewRequest("GET", fmt.Sprintf("repos/%s/%s/actions/workflows/%s/runs?%s",
+		organization, repository, url.PathEscape(workflow), query.Encode()), nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list runs of workflow %s: %w", workflow, err)
+	}
+	var runs struct {
+		WorkflowRuns []struct {
+			*github.WorkflowRun
+			DisplayTitle string `json:"display_title"`
+		} `json:"workflow_runs"`
+	}
+	if _, err := c.client.Do(ctx, request, &runs); err != nil {
+		return nil, fmt.Errorf("unable to list runs of workflow %s: %w", workflow, err)
+	}
+	workflowRuns := make([]WorkflowRun, 0, len(runs.WorkflowRuns))
+	for _, run := range runs.WorkflowRuns {
+		workflowRun := newWorkflowRun(run.WorkflowRun)
+		workflowRun.Name = run.DisplayTitle
+		workflowRuns = append(workflowRuns, workflowRun)
+	}
+	sort.SliceStable(workflowRuns, func(i, j int) bool {
+		return workflowRuns[i].CreatedAt.Before(workflowRuns[j].CreatedAt)
+	})
+	return workflowRuns, nil
+}
+
+// WorkflowRun will get the current state of a workflow run.
+func (c *Client) WorkflowRun(organization string, repository string, id int64) (WorkflowRun, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	run, _, err := c.client.Actions.GetWorkflowRunByID(ctx, organization, repository, id)
+	if err != nil {
+		return WorkflowRun{}, fmt.Errorf("unable to get workflow run %d: %w", id, err)
+	}
+	return newWorkflowRun(run), nil
+}
+
+// newWorkflowRun will convert a workflow run of GitHub's API.
+func newWorkflowRun(run *github.WorkflowRun) WorkflowRun {
+	return WorkflowRun{
+		ID:         run.GetID(),
+		Status:     run.GetStatus(),
+		Conclusion: run.GetConclusion(),
+		URL:        run.GetHTMLURL(),
+		CreatedAt:  run.GetCreatedAt().Time,
+	}
+}
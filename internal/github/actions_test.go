@@ -0,0 +1,101 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package github
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("actions", Label("github-actions"), func() {
+	var client *Client
+	var server *httptest.Server
+	var dispatched map[string]any
+
+	BeforeEach(func() {
+		logger, err := zap.NewDevelopment()
+		Expect(err).NotTo(HaveOccurred())
+		dispatched = nil
+		mux := http.NewServeMux()
+		mux.HandleFunc("/repos/kong/koko/actions/workflows/gateway-compat.yaml/dispatches",
+			func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.Method).Should(Equal(http.MethodPost))
+				Expect(json.NewDecoder(r.Body).Decode(&dispatched)).To(Succeed())
+				w.WriteHeader(http.StatusNoContent)
+			})
+		mux.HandleFunc("/repos/kong/koko/actions/workflows/gateway-compat.yaml/runs",
+			func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.URL.Query().Get("event")).Should(Equal("workflow_dispatch"))
+				Expect(r.URL.Query().Get("created")).Should(Equal(">=2023-09-01T10:00:00Z"))
+				_, _ = w.Write([]byte(`{"total_count": 2, "workflow_runs": [
+					{"id": 2, "status": "queued", "display_title": "kong/kong#11235@def", "created_at": "2023-09-01T10:00:05Z"},
+					{"id": 1, "status": "in_progress", "display_title": "kong/kong#11234@abc", "created_at": "2023-09-01T10:00:01Z"}
+				]}`))
+			})
+		mux.HandleFunc("/repos/kong/koko/actions/runs/1", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{
+				"id": 1,
+				"status": "completed",
+				"conclusion": "failure",
+				"html_url": "https://github.com/kong/koko/actions/runs/1",
+				"created_at": "2023-09-01T10:00:01Z"
+			}`))
+		})
+		client, server = newTestClient(logger, mux)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("a workflow will be dispatched with its inputs", func() {
+		Expect(client.DispatchWorkflow("kong", "koko", "gateway-compat.yaml", "main",
+			map[string]any{"pull_request": "11234"})).To(Succeed())
+		Expect(dispatched).Should(Equal(map[string]any{
+			"ref":    "main",
+			"inputs": map[string]any{"pull_request": "11234"},
+		}))
+	})
+
+	It("the dispatched runs of a workflow will be ordered by creation", func() {
+		runs, err := client.DispatchedWorkflowRuns("kong", "koko", "gateway-compat.yaml",
+			time.Date(2023, 9, 1, 12, 0, 0, 0, time.FixedZone("CEST", 2*60*60)))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(runs).To(HaveLen(2))
+		Expect(runs[0].ID).Should(Equal(int64(1)))
+		Expect(runs[0].Name).Should(Equal("kong/kong#11234@abc"))
+		Expect(runs[0].Status).Should(Equal("in_progress"))
+		Expect(runs[1].ID).Should(Equal(int64(2)))
+	})
+
+	It("the state of a workflow run will be retrieved", func() {
+		run, err := client.WorkflowRun("kong", "koko", 1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(run).Should(Equal(WorkflowRun{
+			ID:         1,
+			Status:     WorkflowRunCompleted,
+			Conclusion: "failure",
+			URL:        "https://github.com/kong/koko/actions/runs/1",
+			CreatedAt:  time.Date(2023, 9, 1, 10, 0, 1, 0, time.UTC),
+		}))
+	})
+})
@@ -0,0 +1,229 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package github
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// changelogPath represents the path of the unreleased changelog entries
+	changelogPath = "changelog/unreleased/"
+	// descriptionSource represents the source of changelog entries extracted
+	// from the description of a pull request
+	descriptionSource = "description"
+)
+
+var (
+	// changelogHeadingPattern represents a markdown heading of a changelog
+	// section
+	changelogHeadingPattern = regexp.MustCompile(`(?i)^#{1,6}\s+.*changelog`)
+	// headingPattern represents a markdown heading
+	headingPattern = regexp.MustCompile(`^#{1,6}\s+`)
+	// bulletPattern represents a markdown list item that is not a checkbox
+	bulletPattern = regexp.MustCompile(`^\s*[-*+]\s+(.+)$`)
+	// checkboxPattern represents a markdown checkbox
+	checkboxPattern = regexp.MustCompile(`^\[[ xX]\]`)
+	// conventionalPattern represents a conventional commit style message; e.g.
+	// feat(rate-limiting): add sync rate
+	conventionalPattern = regexp.MustCompile(`^(\w+)(?:\(([^)]+)\))?!?:\s*(.+)$`)
+	// jiraPattern represents a Jira issue key
+	jiraPattern = regexp.MustCompile(`\b([A-Z][A-Z0-9]+)-\d+\b`)
+)
+
+// nonJiraPrefixes represents the prefixes of well-known tokens shaped like Jira
+// issue keys; e.g. UTF-8, SHA-256, HTTP-2, or CVE-2023.
+var nonJiraPrefixes = map[string]struct{}{
+	"AES":   {},
+	"BASE":  {},
+	"CRC":   {},
+	"CVE":   {},
+	"CWE":   {},
+	"ECDSA": {},
+	"ES":    {},
+	"HMAC":  {},
+	"HS":    {},
+	"HTTP":  {},
+	"HTTPS": {},
+	"IPV":   {},
+	"ISO":   {},
+	"MD":    {},
+	"PS":    {},
+	"RFC":   {},
+	"RS":    {},
+	"RSA":   {},
+	"SHA":   {},
+	"SSL":   {},
+	"TLS":   {},
+	"TLSV":  {},
+	"UCS":   {},
+	"UTF":   {},
+}
+
+// findJiraKeys will find the Jira issue keys in a text; well-known tokens
+// shaped like issue keys are ignored.
+func findJiraKeys(text string) []string {
+	var keys []string
+	for _, matches := range jiraPattern.FindAllStringSubmatch(text, -1) {
+		if _, ok := nonJiraPrefixes[matches[1]]; !ok {
+			keys = append(keys, matches[0])
+		}
+	}
+	return keys
+}
+
+// conventionalTypes represents the changelog types of conventional commit
+// types.
+var conventionalTypes = map[string]string{
+	"feat":     "feature",
+	"fix":      "bugfix",
+	"perf":     "performance",
+	"chore":    "dependency",
+	"refactor": "refactor",
+}
+
+// ChangelogEntry represents a changelog entry of a pull request.
+type ChangelogEntry struct {
+	// Type represents the type of the change; e.g. feature or bugfix
+	Type string
+	// Scope represents the area of the change; e.g. Plugin or Core
+	Scope string
+	// Message represents the human readable summary of the change
+	Message string
+	// Jiras represents the Jira issue keys associated with the change
+	Jiras []string
+	// Source represents the path of the changelog file or "description" when
+	// the entry comes from the description of the pull request
+	Source string
+}
+
+// changelogFile represents the content of an unreleased changelog file.
+type changelogFile struct {
+	// Message represents the human readable summary of the change
+	Message string `yaml:"message"`
+	// Type represents the type of the change
+	Type string `yaml:"type"`
+	// Scope represents the area of the change
+	Scope string `yaml:"scope"`
+	// Jiras represents the Jira issue keys associated with the change
+	Jiras stringList `yaml:"jiras"`
+}
+
+// stringList represents a YAML value that is either a single string or a list
+// of strings.
+type stringList []string
+
+// UnmarshalYAML will unmarshal a single string or a list of strings.
+func (l *stringList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*l = []string{value.Value}
+		return nil
+	}
+	var values []string
+	if err := value.Decode(&values); err != nil {
+		return err
+	}
+	*l = values
+	return nil
+}
+
+// IsChangelogFile will determine if a path is an unreleased changelog entry.
+func IsChangelogFile(filename string) bool {
+	extension := path.Ext(filename)
+	return strings.HasPrefix(filename, changelogPath) && (extension == ".yml" || extension == ".yaml")
+}
+
+// ParseChangelogFile will parse the content of an unreleased changelog file.
+func ParseChangelogFile(filename string, content string) (ChangelogEntry, error) {
+	var file changelogFile
+	if err := yaml.Unmarshal([]byte(content), &file); err != nil {
+		return ChangelogEntry{}, fmt.Errorf("unable to parse changelog %s: %w", filename, err)
+	}
+	return ChangelogEntry{
+		Type:    file.Type,
+		Scope:   file.Scope,
+		Message: strings.TrimSpace(file.Message),
+		Jiras:   file.Jiras,
+		Source:  filename,
+	}, nil
+}
+
+// ParseChangelogDescription will parse the list items of the changelog
+// sections of a pull request description.
+func ParseChangelogDescription(description string) []ChangelogEntry {
+	var entries []ChangelogEntry
+	inChangelog := false
+	for _, line := range strings.Split(strings.ReplaceAll(description, "\r\n", "\n"), "\n") {
+		if headingPattern.MatchString(line) {
+			inChangelog = changelogHeadingPattern.MatchString(line)
+			continue
+		}
+		if !inChangelog {
+			continue
+		}
+		matches := bulletPattern.FindStringSubmatch(line)
+		if matches == nil || checkboxPattern.MatchString(matches[1]) {
+			continue
+		}
+		entry := ChangelogEntry{
+			Message: strings.TrimSpace(matches[1]),
+			Jiras:   findJiraKeys(matches[1]),
+			Source:  descriptionSource,
+		}
+		if conventional := conventionalPattern.FindStringSubmatch(entry.Message); conventional != nil {
+			if changelogType, ok := conventionalTypes[strings.ToLower(conventional[1])]; ok {
+				entry.Type = changelogType
+				entry.Scope = conventional[2]
+				entry.Message = conventional[3]
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// ChangelogEntries will get the changelog entries of a pull request from the
+// unreleased changelog files it adds or modifies and from the changelog
+// section of its description; changelog files that cannot be retrieved or
+// parsed are skipped.
+func (c *Client) ChangelogEntries(organization string, repository string, pullRequest PullRequest,
+	files []File,
+) []ChangelogEntry {
+	var entries []ChangelogEntry
+	for _, file := range files {
+		if !IsChangelogFile(file.Filename) || file.Status == "removed" {
+			continue
+		}
+		content, err := c.FileContent(organization, repository, file.Filename, pullRequest.HeadSHA)
+		if err != nil {
+			c.logger.Warn("changelog file skipped", zap.String("path", file.Filename), zap.Error(err))
+			continue
+		}
+		entry, err := ParseChangelogFile(file.Filename, content)
+		if err != nil {
+			c.logger.Warn("changelog file skipped", zap.String("path", file.Filename), zap.Error(err))
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return append(entries, ParseChangelogDescription(pullRequest.Description)...)
+}
@@ -0,0 +1,144 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package github
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("changelog", Label("github-changelog"), func() {
+	It("changelog files will be detected", func() {
+		Expect(IsChangelogFile("changelog/unreleased/kong/rate-limiting-sync-rate.yml")).To(BeTrue())
+		Expect(IsChangelogFile("changelog/unreleased/kong-manager/fix.yaml")).To(BeTrue())
+		Expect(IsChangelogFile("changelog/unreleased/kong/.gitkeep")).To(BeFalse())
+		Expect(IsChangelogFile("changelog/3.4.0/kong/fix.yml")).To(BeFalse())
+	})
+
+	It("a changelog file will be parsed", func() {
+		entry, err := ParseChangelogFile("changelog/unreleased/kong/sync-rate.yml", `
+message: |
+  **Rate Limiting**: Added the sync_rate option.
+type: feature
+scope: Plugin
+jiras:
+  - "KAG-1234"
+  - "FTI-5678"
+`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entry).Should(Equal(ChangelogEntry{
+			Type:    "feature",
+			Scope:   "Plugin",
+			Message: "**Rate Limiting**: Added the sync_rate option.",
+			Jiras:   []string{"KAG-1234", "FTI-5678"},
+			Source:  "changelog/unreleased/kong/sync-rate.yml",
+		}))
+	})
+
+	It("a changelog file with a single Jira will be parsed", func() {
+		entry, err := ParseChangelogFile("changelog/unreleased/kong/fix.yml", `
+message: Fixed a crash.
+type: bugfix
+scope: Core
+jiras: KAG-1
+`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entry.Jiras).Should(Equal([]string{"KAG-1"}))
+	})
+
+	It("an invalid changelog file will error", func() {
+		_, err := ParseChangelogFile("changelog/unreleased/kong/fix.yml", "message: [")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("the changelog section of a description will be parsed", func() {
+		entries := ParseChangelogDescription(`### Summary
+
+Adds the sync rate.
+
+### Checklist
+
+- [x] A changelog file has been created under changelog/unreleased/kong
+
+### Full changelog
+
+* feat(rate-limiting): add sync_rate option [KAG-1234]
+* Improve the error message of the admin API
+
+### Issue reference
+
+- Fix #11233
+`)
+		Expect(entries).Should(Equal([]ChangelogEntry{
+			{
+				Type:    "feature",
+				Scope:   "rate-limiting",
+				Message: "add sync_rate option [KAG-1234]",
+				Jiras:   []string{"KAG-1234"},
+				Source:  "description",
+			},
+			{
+				Message: "Improve the error message of the admin API",
+				Source:  "description",
+			},
+		}))
+	})
+
+	It("the changelog files that cannot be parsed will be skipped", func() {
+		logger, err := zap.NewDevelopment()
+		Expect(err).NotTo(HaveOccurred())
+		mux := http.NewServeMux()
+		files := map[string]string{
+			"changelog/unreleased/kong/sync-rate.yml": "message: Add sync rate\ntype: feature\nscope: Plugin\n",
+			"changelog/unreleased/kong/broken.yml":    "message: [",
+		}
+		for path, source := range files {
+			source := source
+			mux.HandleFunc("/repos/kong/kong/contents/"+path, func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(fmt.Sprintf(`{"type": "file", "encoding": "base64", "content": %q}`,
+					base64.StdEncoding.EncodeToString([]byte(source)))))
+			})
+		}
+		client, server := newTestClient(logger, mux)
+		defer server.Close()
+
+		entries := client.ChangelogEntries("kong", "kong", PullRequest{
+			HeadSHA:     "abcdef0",
+			Description: "### Changelog\n\n- Improve the error message\n",
+		}, []File{
+			{Filename: "changelog/unreleased/kong/broken.yml", Status: "added"},
+			{Filename: "changelog/unreleased/kong/missing.yml", Status: "added"},
+			{Filename: "changelog/unreleased/kong/sync-rate.yml", Status: "added"},
+		})
+		Expect(entries).Should(Equal([]ChangelogEntry{
+			{
+				Type:    "feature",
+				Scope:   "Plugin",
+				Message: "Add sync rate",
+				Source:  "changelog/unreleased/kong/sync-rate.yml",
+			},
+			{
+				Message: "Improve the error message",
+				Source:  "description",
+			},
+		}))
+	})
+})
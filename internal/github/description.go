@@ -0,0 +1,239 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package github
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// htmlCommentPattern represents an HTML comment; the pull request
+	// template uses them for instructions
+	htmlCommentPattern = regexp.MustCompile(`(?s)<!--.*?-->`)
+	// sectionHeadingPattern represents a markdown heading and its title
+	sectionHeadingPattern = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	// fencePattern represents the start or end of a fenced code block
+	fencePattern = regexp.MustCompile("^\\s*(```|~~~)")
+	// checklistPattern represents a markdown checkbox list item
+	checklistPattern = regexp.MustCompile(`^\s*[-*+]\s+\[([ xX])\]\s+(.*)$`)
+	// issueURLPattern represents the URL of a GitHub issue
+	issueURLPattern = regexp.MustCompile(`https://github\.com/([\w.-]+)/([\w.-]+)/issues/(\d+)`)
+	// pullRequestURLPattern represents the URL of a GitHub pull request
+	pullRequestURLPattern = regexp.MustCompile(`https://github\.com/([\w.-]+)/([\w.-]+)/pull/(\d+)`)
+	// issueShortPattern represents a short issue reference; e.g. #123 or
+	// kong/kong#123
+	issueShortPattern = regexp.MustCompile(`(?:^|[^\w/#])(?:([\w.-]+)/([\w.-]+))?#(\d+)\b`)
+)
+
+// Description represents the structured content of a pull request
+// description following Kong's pull request template.
+type Description struct {
+	// Sections represents the sections of the description in order; content
+	// before the first heading is a section without a title
+	Sections []Section
+	// Checklist represents the checkbox items of the description
+	Checklist []ChecklistItem
+	// Issues represents the issues referenced by the description
+	Issues []Reference
+	// JiraKeys represents the Jira issue keys referenced by the description
+	JiraKeys []string
+	// PullRequests represents the pull requests linked by the description
+	PullRequests []Reference
+	// Changelog represents the entries of the changelog section
+	Changelog []ChangelogEntry
+}
+
+// Section represents a section of a markdown document.
+type Section struct {
+	// Title represents the text of the section heading
+	Title string
+	// Level represents the level of the section heading
+	Level int
+	// Content represents the markdown content of the section without its
+	// heading
+	Content string
+}
+
+// ChecklistItem represents a checkbox list item.
+type ChecklistItem struct {
+	// Text represents the text of the item
+	Text string
+	// Checked represents whether the item is checked
+	Checked bool
+}
+
+// Reference represents a reference to a GitHub issue or pull request.
+type Reference struct {
+	// Organization represents the GitHub organization/owner
+	Organization string
+	// Repository represents the GitHub repository
+	Repository string
+	// Number represents the issue or pull request number
+	Number int
+}
+
+// String will get the short form of the reference; e.g. kong/kong#123.
+func (r Reference) String() string {
+	return r.Organization + "/" + r.Repository + "#" + strconv.Itoa(r.Number)
+}
+
+// Section will get the first section whose title matches case insensitively.
+func (d Description) Section(title string) (Section, bool) {
+	for _, section := range d.Sections {
+		if strings.EqualFold(section.Title, title) {
+			return section, true
+		}
+	}
+	return Section{}, false
+}
+
+// Summary will get the content of the summary section or the content of the
+// whole description when there is no summary section.
+func (d Description) Summary() string {
+	if section, ok := d.Section("Summary"); ok {
+		return section.Content
+	}
+	contents := make([]string, 0, len(d.Sections))
+	for _, section := range d.Sections {
+		if len(section.Content) > 0 {
+			contents = append(contents, section.Content)
+		}
+	}
+	return strings.Join(contents, "\n\n")
+}
+
+// ChecklistDone will get the number of checked checklist items.
+func (d Description) ChecklistDone() int {
+	done := 0
+	for _, item := range d.Checklist {
+		if item.Checked {
+			done++
+		}
+	}
+	return done
+}
+
+// FindJiraKeys will find the unique Jira issue keys in a text; well-known
+// tokens shaped like issue keys, e.g. UTF-8 or SHA-256, are ignored.
+func FindJiraKeys(text string) []string {
+	return uniqueStrings(findJiraKeys(text))
+}
+
+// ParseDescription will parse the markdown description of a pull request;
+// short issue references without a repository refer to the given organization
+// and repository.
+func ParseDescription(body string, organization string, repository string) Description {
+	body = htmlCommentPattern.ReplaceAllString(strings.ReplaceAll(body, "\r\n", "\n"), "")
+
+	var description Description
+	current := Section{}
+	var content []string
+	inFence := false
+	flush := func() {
+		current.Content = strings.TrimSpace(strings.Join(content, "\n"))
+		if len(current.Title) > 0 || len(current.Content) > 0 {
+			description.Sections = append(description.Sections, current)
+		}
+		content = nil
+	}
+	for _, line := range strings.Split(body, "\n") {
+		if fencePattern.MatchString(line) {
+			inFence = !inFence
+		}
+		if !inFence {
+			if matches := sectionHeadingPattern.FindStringSubmatch(line); matches != nil {
+				flush()
+				current = Section{Title: matches[2], Level: len(matches[1])}
+				continue
+			}
+			if matches := checklistPattern.FindStringSubmatch(line); matches != nil {
+				description.Checklist = append(description.Checklist, ChecklistItem{
+					Text:    strings.TrimSpace(matches[2]),
+					Checked: matches[1] != " ",
+				})
+			}
+		}
+		content = append(content, line)
+	}
+	flush()
+
+	description.JiraKeys = FindJiraKeys(body)
+	description.PullRequests = findReferences(pullRequestURLPattern, body, "", "")
+	description.Issues = findReferences(issueURLPattern, body, "", "")
+	// Remove the URLs to avoid matching their fragments as short references
+	withoutURLs := issueURLPattern.ReplaceAllString(pullRequestURLPattern.ReplaceAllString(body, ""), "")
+	description.Issues = appendUniqueReferences(description.Issues,
+		findReferences(issueShortPattern, withoutURLs, organization, repository)...)
+	description.Changelog = ParseChangelogDescription(body)
+	return description
+}
+
+// findReferences will find the references matching a pattern whose groups are
+// the organization, the repository, and the number; missing organizations and
+// repositories are defaulted.
+func findReferences(pattern *regexp.Regexp, text string, organization string, repository string) []Reference {
+	var references []Reference
+	for _, matches := range pattern.FindAllStringSubmatch(text, -1) {
+		number, err := strconv.Atoi(matches[3])
+		if err != nil {
+			continue
+		}
+		reference := Reference{
+			Organization: matches[1],
+			Repository:   matches[2],
+			Number:       number,
+		}
+		if len(reference.Organization) == 0 {
+			reference.Organization = organization
+			reference.Repository = repository
+		}
+		references = appendUniqueReferences(references, reference)
+	}
+	return references
+}
+
+// appendUniqueReferences will append the references that are not already in
+// the list; references are compared case insensitively.
+func appendUniqueReferences(references []Reference, others ...Reference) []Reference {
+	for _, other := range others {
+		found := false
+		for _, reference := range references {
+			if strings.EqualFold(reference.String(), other.String()) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			references = append(references, other)
+		}
+	}
+	return references
+}
+
+// uniqueStrings will remove the duplicate values preserving their order.
+func uniqueStrings(values []string) []string {
+	var unique []string
+	seen := make(map[string]struct{}, len(values))
+	for _, value := range values {
+		if _, ok := seen[value]; !ok {
+			seen[value] = struct{}{}
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
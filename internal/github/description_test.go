@@ -0,0 +1,96 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package github
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("description", Label("github-description"), func() {
+	const body = "<!--\nNOTE: Please read the CONTRIBUTING.md guidelines before submitting your patch,\n-->\r\n" + `
+### Summary
+
+Adds the sync_rate option to rate-limiting [KAG-1234].
+Follow up of https://github.com/kong/kong/pull/11200 and kong/kong-ee#42.
+
+` + "```lua\n# not a heading\n```" + `
+
+### Checklist
+
+- [x] The Pull Request has tests
+- [ ] A changelog file has been created under changelog/unreleased/kong
+- [X] There is a user-facing docs PR against https://github.com/Kong/docs.konghq.com/pull/5000
+
+### Full changelog
+
+* feat(rate-limiting): add sync_rate option
+
+### Issue reference
+
+Fix #11233, https://github.com/kong/kong/issues/11230 and FTI-123
+Duplicate of #11233
+`
+
+	It("the description will be split into sections", func() {
+		description := ParseDescription(body, "kong", "kong")
+		titles := make([]string, 0, len(description.Sections))
+		for _, section := range description.Sections {
+			titles = append(titles, section.Title)
+			Expect(section.Level).Should(Equal(3))
+		}
+		Expect(titles).Should(Equal([]string{"Summary", "Checklist", "Full changelog", "Issue reference"}))
+		Expect(description.Summary()).Should(HavePrefix("Adds the sync_rate option"))
+		Expect(description.Summary()).Should(HaveSuffix("```lua\n# not a heading\n```"))
+	})
+
+	It("the checklist state will be extracted", func() {
+		description := ParseDescription(body, "kong", "kong")
+		Expect(description.Checklist).To(HaveLen(3))
+		Expect(description.Checklist[1]).Should(Equal(ChecklistItem{
+			Text:    "A changelog file has been created under changelog/unreleased/kong",
+			Checked: false,
+		}))
+		Expect(description.ChecklistDone()).Should(Equal(2))
+	})
+
+	It("the references will be extracted", func() {
+		description := ParseDescription(body, "kong", "kong")
+		Expect(description.JiraKeys).Should(Equal([]string{"KAG-1234", "FTI-123"}))
+		Expect(description.PullRequests).Should(Equal([]Reference{
+			{Organization: "kong", Repository: "kong", Number: 11200},
+			{Organization: "Kong", Repository: "docs.konghq.com", Number: 5000},
+		}))
+		Expect(description.Issues).Should(Equal([]Reference{
+			{Organization: "kong", Repository: "kong", Number: 11230},
+			{Organization: "kong", Repository: "kong-ee", Number: 42},
+			{Organization: "kong", Repository: "kong", Number: 11233},
+		}))
+		Expect(description.Changelog).To(HaveLen(1))
+	})
+
+	It("well-known tokens shaped like Jira issue keys will be ignored", func() {
+		Expect(FindJiraKeys("Use UTF-8 and SHA-256 over HTTP-2 to fix CVE-2023 (KAG-1234, utf-8)")).
+			Should(Equal([]string{"KAG-1234"}))
+	})
+
+	It("a description without sections will be summarized as a whole", func() {
+		description := ParseDescription("Fixes the thing.", "kong", "kong")
+		Expect(description.Summary()).Should(Equal("Fixes the thing."))
+		_, ok := description.Section("Summary")
+		Expect(ok).To(BeFalse())
+	})
+})
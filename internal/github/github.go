@@ -19,7 +19,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gofri/go-github-ratelimit/github_ratelimit"
@@ -28,20 +31,131 @@ import (
 	"golang.org/x/oauth2"
 )
 
+// defaultTimeout represents the maximum amount of time a single GitHub API
+// request is allowed to take.
+const defaultTimeout = 30 * time.Second
+
+// ErrNotFound represents a file or ref that does not exist in a repository.
+var ErrNotFound = errors.New("not found")
+
 // Options contain the parameters to create a new GitHub client instance.
 type Options struct {
 	// Token represents the GITHUB_TOKEN for the client
 	Token string
+	// BaseURL represents the URL of GitHub's API; api.github.com is used when
+	// empty
+	BaseURL string
+	// Mirror represents the local mirror answering file, diff, and code
+	// search queries; GitHub's API is used when not set or when the mirror is
+	// unavailable
+	Mirror Mirror
 	// Logger represents the base logger to use for the GitHub package
 	Logger *zap.Logger
 }
 
+// Mirror represents a local mirror of repositories answering file, diff, and
+// code search queries without GitHub's API. ErrNotFound is returned for a
+// file that does not exist at a ref; any other error makes the client fall
+// back to the API.
+type Mirror interface {
+	// FileContent will get the content of a file at the given ref
+	FileContent(organization string, repository string, path string, ref string) (string, error)
+	// CompareFiles will get the files modified between two refs
+	CompareFiles(organization string, repository string, base string, head string) ([]File, error)
+	// Tree will get the blob SHAs of the files at the given ref keyed by path
+	Tree(organization string, repository string, ref string) (map[string]string, error)
+	// SearchCode will get the lines of the default branch containing any of
+	// the terms
+	SearchCode(organization string, repository string, terms []string) ([]CodeMatch, error)
+}
+
 // Client represents a GitHub client instance.
 type Client struct {
 	// client represents the connection for GitHub's API
 	client *github.Client
+	// mirror represents the local mirror answering file and diff queries
+	mirror Mirror
 	// logger represents the logger to use for the GitHub package
 	logger *zap.Logger
+	// commitDates represents the known committer dates of commits keyed by
+	// SHA; commits are immutable so the dates never expire
+	commitDates map[string]time.Time
+	// commitDatesMutex represents the lock guarding the commit dates
+	commitDatesMutex sync.Mutex
+	// login represents the login of the authenticated user once retrieved
+	login string
+	// loginMutex represents the lock guarding the login
+	loginMutex sync.Mutex
+}
+
+// PullRequest represents the details of a pull request.
+type PullRequest struct {
+	// Author represents the GitHub login of the pull request author
+	Author string
+	// BaseRef represents the branch the pull request is merged into
+	BaseRef string
+	// BaseSHA represents the SHA of the base branch commit the pull request
+	// is compared against
+	BaseSHA string
+	// ClosedAt represents the time the pull request was closed, including
+	// when merged; zero when open
+	ClosedAt time.Time
+	// Description represents the body of the pull request
+	Description string
+	// HeadRef represents the branch the pull request originates from
+	HeadRef string
+	// HeadSHA represents the SHA of the latest commit of the pull request
+	HeadSHA string
+	// MergeCommitSHA represents the SHA of the merge commit once merged
+	MergeCommitSHA string
+	// Merged represents whether the pull request has been merged
+	Merged bool
+	// MergedAt represents the time the pull request was merged; zero when not
+	// merged
+	MergedAt time.Time
+	// Number represents the pull request number
+	Number int
+	// State represents the state of the pull request; open or closed
+	State string
+	// Title represents the title of the pull request
+	Title string
+	// URL represents the HTML URL of the pull request
+	URL string
+}
+
+// Commit represents a single commit of a repository.
+type Commit struct {
+	// Author represents the name of the commit author
+	Author string
+	// Date represents the date the commit was authored
+	Date time.Time
+	// Message represents the first line of the commit message
+	Message string
+	// SHA represents the full SHA of the commit
+	SHA string
+	// URL represents the HTML URL of the commit
+	URL string
+}
+
+// DirectoryEntry represents an entry of a directory of a repository.
+type DirectoryEntry struct {
+	// Name represents the name of the entry within the directory
+	Name string
+	// Type represents the type of the entry; e.g. file or dir
+	Type string
+}
+
+// File represents a file modified by a pull request.
+type File struct {
+	// Filename represents the path of the file in the repository
+	Filename string
+	// PreviousFilename represents the previous path of a renamed file
+	PreviousFilename string
+	// Status represents how the file was modified; e.g. added, modified,
+	// removed, or renamed
+	Status string
+	// Patch represents the unified diff of the file when available
+	Patch string
 }
 
 // NewClient will validate options and instantiate a new GitHub client instance.
@@ -64,8 +178,18 @@ func NewClient(opts Options) (*Client, error) {
 		return nil, fmt.Errorf("unable to create GitHub rate limiter: %w", err)
 	}
 
+	client := github.NewClient(rateLimiter)
+	if len(opts.BaseURL) > 0 {
+		baseURL, err := url.Parse(strings.TrimSuffix(opts.BaseURL, "/") + "/")
+		if err != nil {
+			return nil, fmt.Errorf("invalid github URL: %w", err)
+		}
+		client.BaseURL = baseURL
+	}
+
 	return &Client{
-		client: github.NewClient(rateLimiter),
+		client: client,
+		mirror: opts.Mirror,
 		logger: opts.Logger.With(zap.String("component", "github")),
 	}, nil
 }
@@ -74,7 +198,7 @@ func NewClient(opts Options) (*Client, error) {
 // organization and repository.
 func (c *Client) PRDescription(organization string, repository string, pullRequest int) (string, error) {
 	// Get the pull request for the given parameters
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
 	defer cancel()
 	pr, _, err := c.client.PullRequests.Get(ctx, organization, repository, pullRequest)
 	if err != nil {
@@ -83,3 +207,217 @@ func (c *Client) PRDescription(organization string, repository string, pullReque
 
 	return *pr.Body, nil
 }
+
+// PullRequest will get the details of a pull request for a given
+// organization and repository.
+func (c *Client) PullRequest(organization string, repository string, pullRequest int) (PullRequest, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	pr, _, err := c.client.PullRequests.Get(ctx, organization, repository, pullRequest)
+	if err != nil {
+		return PullRequest{}, fmt.Errorf("unable to retrieve pull request: %w", err)
+	}
+
+	return PullRequest{
+		Author:         pr.GetUser().GetLogin(),
+		BaseRef:        pr.GetBase().GetRef(),
+		BaseSHA:        pr.GetBase().GetSHA(),
+		ClosedAt:       pr.GetClosedAt().Time,
+		Description:    pr.GetBody(),
+		HeadRef:        pr.GetHead().GetRef(),
+		HeadSHA:        pr.GetHead().GetSHA(),
+		MergeCommitSHA: pr.GetMergeCommitSHA(),
+		Merged:         pr.GetMerged(),
+		MergedAt:       pr.GetMergedAt().Time,
+		Number:         pr.GetNumber(),
+		State:          pr.GetState(),
+		Title:          pr.GetTitle(),
+		URL:            pr.GetHTMLURL(),
+	}, nil
+}
+
+// FileCommitsSince will get the commits on the default branch that modified
+// the given path and are missing from the commit the given ref points to. The
+// ref can be a tag, a branch, or a SHA.
+func (c *Client) FileCommitsSince(organization string, repository string, path string, ref string) ([]Commit, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	repo, _, err := c.client.Repositories.Get(ctx, organization, repository)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve repository: %w", err)
+	}
+	defaultBranch := repo.GetDefaultBranch()
+
+	// Commit dates cannot tell whether a commit is part of the ref; e.g. a
+	// commit of a branch merged after the ref was created predates it
+	missing := make(map[string]struct{})
+	var oldest time.Time
+	compareOpts := &github.ListOptions{PerPage: 100}
+	for {
+		comparison, res, err := c.client.Repositories.CompareCommits(ctx, organization, repository, ref,
+			defaultBranch, compareOpts)
+		if err != nil {
+			return nil, fmt.Errorf("unable to compare %s with %s: %w", defaultBranch, ref, err)
+		}
+		for _, repositoryCommit := range comparison.Commits {
+			missing[repositoryCommit.GetSHA()] = struct{}{}
+			date := repositoryCommit.GetCommit().GetCommitter().GetDate().Time
+			if oldest.IsZero() || date.Before(oldest) {
+				oldest = date
+			}
+		}
+		if res.NextPage == 0 {
+			break
+		}
+		compareOpts.Page = res.NextPage
+	}
+	if len(missing) == 0 {
+		return nil, nil
+	}
+
+	// The oldest missing commit bounds the history of the path to list
+	var commits []Commit
+	opts := &github.CommitsListOptions{
+		SHA:         defaultBranch,
+		Path:        path,
+		Since:       oldest,
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		repositoryCommits, res, err := c.client.Repositories.ListCommits(ctx, organization, repository, opts)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list commits for %s: %w", path, err)
+		}
+		for _, repositoryCommit := range repositoryCommits {
+			if _, ok := missing[repositoryCommit.GetSHA()]; ok {
+				commits = append(commits, newCommit(repositoryCommit))
+			}
+		}
+		if res.NextPage == 0 {
+			break
+		}
+		opts.Page = res.NextPage
+	}
+	return commits, nil
+}
+
+// newCommit will convert a GitHub repository commit into a commit.
+func newCommit(repositoryCommit *github.RepositoryCommit) Commit {
+	message, _, _ := strings.Cut(repositoryCommit.GetCommit().GetMessage(), "\n")
+	return Commit{
+		Author:  repositoryCommit.GetCommit().GetAuthor().GetName(),
+		Date:    repositoryCommit.GetCommit().GetAuthor().GetDate().Time,
+		Message: message,
+		SHA:     repositoryCommit.GetSHA(),
+		URL:     repositoryCommit.GetHTMLURL(),
+	}
+}
+
+// PullRequestCommitMessages will get the full messages of the commits of a
+// pull request for a given organization and repository.
+func (c *Client) PullRequestCommitMessages(organization string, repository string, pullRequest int) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	var messages []string
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		repositoryCommits, res, err := c.client.PullRequests.ListCommits(ctx, organization, repository, pullRequest, opts)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list pull request commits: %w", err)
+		}
+		for _, repositoryCommit := range repositoryCommits {
+			messages = append(messages, repositoryCommit.GetCommit().GetMessage())
+		}
+		if res.NextPage == 0 {
+			break
+		}
+		opts.Page = res.NextPage
+	}
+	return messages, nil
+}
+
+// PullRequestFiles will get the files modified by a pull request for a given
+// organization and repository.
+func (c *Client) PullRequestFiles(organization string, repository string, pullRequest int) ([]File, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	var files []File
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		commitFiles, res, err := c.client.PullRequests.ListFiles(ctx, organization, repository, pullRequest, opts)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list pull request files: %w", err)
+		}
+		for _, commitFile := range commitFiles {
+			files = append(files, File{
+				Filename:         commitFile.GetFilename(),
+				PreviousFilename: commitFile.GetPreviousFilename(),
+				Status:           commitFile.GetStatus(),
+				Patch:            commitFile.GetPatch(),
+			})
+		}
+		if res.NextPage == 0 {
+			break
+		}
+		opts.Page = res.NextPage
+	}
+	return files, nil
+}
+
+// Directory will get the entries of a directory of a repository at a ref;
+// the default branch is used when the ref is empty.
+func (c *Client) Directory(organization string, repository string, path string, ref string) ([]DirectoryEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	_, directoryContent, res, err := c.client.Repositories.GetContents(ctx, organization, repository, path,
+		&github.RepositoryContentGetOptions{Ref: ref})
+	if err != nil {
+		if res != nil && res.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("unable to list %s at %s: %w", path, ref, ErrNotFound)
+		}
+		return nil, fmt.Errorf("unable to list %s at %s: %w", path, ref, err)
+	}
+	if directoryContent == nil {
+		return nil, fmt.Errorf("unable to list %s at %s: path is a file", path, ref)
+	}
+	entries := make([]DirectoryEntry, 0, len(directoryContent))
+	for _, content := range directoryContent {
+		entries = append(entries, DirectoryEntry{Name: content.GetName(), Type: content.GetType()})
+	}
+	return entries, nil
+}
+
+// FileContent will get the content of a file at the given ref; ErrNotFound is
+// returned when the file does not exist at the ref.
+func (c *Client) FileContent(organization string, repository string, path string, ref string) (string, error) {
+	if c.mirror != nil {
+		content, err := c.mirror.FileContent(organization, repository, path, ref)
+		if err == nil || errors.Is(err, ErrNotFound) {
+			return content, err
+		}
+		c.logger.Debug("falling back to the API for file content", zap.String("path", path),
+			zap.String("ref", ref), zap.Error(err))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	fileContent, _, res, err := c.client.Repositories.GetContents(ctx, organization, repository, path,
+		&github.RepositoryContentGetOptions{Ref: ref})
+	if err != nil {
+		if res != nil && res.StatusCode == http.StatusNotFound {
+			return "", fmt.Errorf("unable to retrieve %s at %s: %w", path, ref, ErrNotFound)
+		}
+		return "", fmt.Errorf("unable to retrieve %s at %s: %w", path, ref, err)
+	}
+	if fileContent == nil {
+		return "", fmt.Errorf("unable to retrieve %s at %s: path is a directory", path, ref)
+	}
+	content, err := fileContent.GetContent()
+	if err != nil {
+		return "", fmt.Errorf("unable to decode %s at %s: %w", path, ref, err)
+	}
+	return content, nil
+}
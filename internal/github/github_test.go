@@ -16,11 +16,79 @@ limitations under the License.
 package github
 
 import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v50/github"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"go.uber.org/zap"
 )
 
+// newTestClient will create a GitHub client instance that sends its requests
+// to a test server using the given handler.
+func newTestClient(logger *zap.Logger, handler http.Handler) (*Client, *httptest.Server) {
+	server := httptest.NewServer(handler)
+	client := github.NewClient(nil)
+	baseURL, err := url.Parse(server.URL + "/")
+	Expect(err).NotTo(HaveOccurred())
+	client.BaseURL = baseURL
+	return &Client{
+		client: client,
+		logger: logger,
+	}, server
+}
+
+// fakeMirror represents a mirror answering from fixed files.
+type fakeMirror struct {
+	// files represents the content of the files keyed by ref and path
+	files map[string]string
+}
+
+// FileContent will get the content of a fixed file; refs other than main are
+// unavailable.
+func (m fakeMirror) FileContent(_ string, _ string, path string, ref string) (string, error) {
+	if ref != "main" {
+		return "", errors.New("mirror unavailable")
+	}
+	content, ok := m.files[path]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return content, nil
+}
+
+// CompareFiles will always be unavailable.
+func (m fakeMirror) CompareFiles(_ string, _ string, _ string, _ string) ([]File, error) {
+	return nil, errors.New("mirror unavailable")
+}
+
+// Tree will always be unavailable.
+func (m fakeMirror) Tree(_ string, _ string, _ string) (map[string]string, error) {
+	return nil, errors.New("mirror unavailable")
+}
+
+// SearchCode will find the terms in the fixed files of the main ref; the kong
+// repository is unavailable.
+func (m fakeMirror) SearchCode(_ string, repository string, terms []string) ([]CodeMatch, error) {
+	if repository == "kong" {
+		return nil, errors.New("mirror unavailable")
+	}
+	var matches []CodeMatch
+	for path, content := range m.files {
+		for _, term := range terms {
+			if strings.Contains(content, term) {
+				matches = append(matches, CodeMatch{Path: path, Line: 1, Text: content, Term: term})
+			}
+		}
+	}
+	return matches, nil
+}
+
 var _ = Describe("GitHub", func() {
 	var logger *zap.Logger
 
@@ -64,4 +132,219 @@ var _ = Describe("GitHub", func() {
 			})
 		})
 	})
+
+	Describe("retrieving pull request information", Label("github-pull-requests"), func() {
+		var client *Client
+		var server *httptest.Server
+
+		BeforeEach(func() {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/repos/kong/kong/pulls/11234", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{
+					"number": 11234,
+					"state": "closed",
+					"title": "feat(rate-limiting): add sync rate",
+					"body": "### Summary",
+					"html_url": "https://github.com/kong/kong/pull/11234",
+					"merged": true,
+					"merge_commit_sha": "abcdef0",
+					"user": {"login": "gateway-engineer"},
+					"head": {"ref": "feat/sync-rate", "sha": "1234567"},
+					"base": {"ref": "master", "sha": "7654321"}
+				}`))
+			})
+			mux.HandleFunc("/repos/kong/kong", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"name": "kong", "default_branch": "master"}`))
+			})
+			mux.HandleFunc("/repos/kong/kong/compare/3.4.0...master", func(w http.ResponseWriter, r *http.Request) {
+				// The commit of a branch merged after the tag was created
+				// predates the tag
+				_, _ = w.Write([]byte(`{"commits": [
+					{"sha": "3333333333333333333333333333333333333333",
+						"commit": {"committer": {"date": "2023-07-15T00:00:00Z"}}},
+					{"sha": "1111111111111111111111111111111111111111",
+						"commit": {"committer": {"date": "2023-09-01T00:00:00Z"}}}
+				]}`))
+			})
+			mux.HandleFunc("/repos/kong/kong/commits", func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.URL.Query().Get("sha")).Should(Equal("master"))
+				Expect(r.URL.Query().Get("path")).Should(Equal("kong/plugins/rate-limiting/schema.lua"))
+				Expect(r.URL.Query().Get("since")).Should(Equal("2023-07-15T00:00:00Z"))
+				_, _ = w.Write([]byte(`[
+					{
+						"sha": "1111111111111111111111111111111111111111",
+						"html_url": "https://github.com/kong/kong/commit/1111111",
+						"commit": {
+							"message": "feat(rate-limiting): add sync rate (#11234)\n\nDetails",
+							"author": {"name": "Gateway Engineer", "date": "2023-09-01T00:00:00Z"}
+						}
+					},
+					{
+						"sha": "0000000000000000000000000000000000000000",
+						"commit": {"message": "chore(release): 3.4.0"}
+					},
+					{
+						"sha": "3333333333333333333333333333333333333333",
+						"html_url": "https://github.com/kong/kong/commit/3333333",
+						"commit": {
+							"message": "fix(rate-limiting): validate sync rate",
+							"author": {"name": "Gateway Engineer", "date": "2023-07-15T00:00:00Z"}
+						}
+					}
+				]`))
+			})
+			mux.HandleFunc("/repos/kong/kong/pulls/11234/files", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`[
+					{"filename": "kong/clustering/compat/removed_fields.lua", "status": "modified", "patch": "@@ -1 +1 @@"},
+					{"filename": "kong/plugins/acme/schema.lua", "previous_filename": "kong/plugins/acme/old.lua", "status": "renamed"}
+				]`))
+			})
+			mux.HandleFunc("/repos/kong/kong/pulls/11234/commits", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`[
+					{"sha": "2222222", "commit": {"message": "Revert \"feat: add sync rate\"\n\nThis reverts commit abcdef0."}}
+				]`))
+			})
+			mux.HandleFunc("/repos/kong/kong/contents/kong/clustering/compat/removed_fields.lua",
+				func(w http.ResponseWriter, r *http.Request) {
+					if r.URL.Query().Get("ref") != "7654321" {
+						w.WriteHeader(http.StatusNotFound)
+						_, _ = w.Write([]byte(`{"message": "Not Found"}`))
+						return
+					}
+					_, _ = w.Write([]byte(`{
+						"type": "file",
+						"encoding": "base64",
+						"content": "cmV0dXJuIHt9Cg=="
+					}`))
+				})
+			mux.HandleFunc("/repos/kong/kong/contents/kong/plugins", func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.URL.Query().Get("ref")).Should(Equal("master"))
+				_, _ = w.Write([]byte(`[
+					{"type": "dir", "name": "acme", "path": "kong/plugins/acme"},
+					{"type": "file", "name": "base_plugin.lua", "path": "kong/plugins/base_plugin.lua"}
+				]`))
+			})
+			client, server = newTestClient(logger, mux)
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("the files of a pull request will be retrieved", func() {
+			files, err := client.PullRequestFiles("kong", "kong", 11234)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files).Should(Equal([]File{
+				{
+					Filename: "kong/clustering/compat/removed_fields.lua",
+					Status:   "modified",
+					Patch:    "@@ -1 +1 @@",
+				},
+				{
+					Filename:         "kong/plugins/acme/schema.lua",
+					PreviousFilename: "kong/plugins/acme/old.lua",
+					Status:           "renamed",
+				},
+			}))
+		})
+
+		It("the full commit messages of a pull request will be retrieved", func() {
+			messages, err := client.PullRequestCommitMessages("kong", "kong", 11234)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(messages).Should(Equal([]string{"Revert \"feat: add sync rate\"\n\nThis reverts commit abcdef0."}))
+		})
+
+		It("the content of a file at a ref will be retrieved", func() {
+			content, err := client.FileContent("kong", "kong", "kong/clustering/compat/removed_fields.lua", "7654321")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(content).Should(Equal("return {}\n"))
+		})
+
+		It("a not found error will occur when the file does not exist at a ref", func() {
+			_, err := client.FileContent("kong", "kong", "kong/clustering/compat/removed_fields.lua", "0000000")
+			Expect(err).Should(MatchError(ErrNotFound))
+		})
+
+		It("the entries of a directory at a ref will be retrieved", func() {
+			entries, err := client.Directory("kong", "kong", "kong/plugins", "master")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).Should(Equal([]DirectoryEntry{
+				{Name: "acme", Type: "dir"},
+				{Name: "base_plugin.lua", Type: "file"},
+			}))
+		})
+
+		It("an error will occur when listing a file as a directory", func() {
+			_, err := client.Directory("kong", "kong", "kong/clustering/compat/removed_fields.lua", "7654321")
+			Expect(err).To(HaveOccurred())
+		})
+
+		When("a mirror is used", func() {
+			BeforeEach(func() {
+				client.mirror = fakeMirror{files: map[string]string{"kong/constants.lua": "return {}"}}
+			})
+
+			It("the content of a file will be retrieved from the mirror", func() {
+				content, err := client.FileContent("kong", "kong", "kong/constants.lua", "main")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(content).Should(Equal("return {}"))
+			})
+
+			It("a not found error from the mirror will not fall back to the API", func() {
+				_, err := client.FileContent("kong", "kong", "kong/clustering/compat/removed_fields.lua", "main")
+				Expect(err).Should(MatchError(ErrNotFound))
+			})
+
+			It("the API will be used when the mirror is unavailable", func() {
+				content, err := client.FileContent("kong", "kong", "kong/clustering/compat/removed_fields.lua", "7654321")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(content).Should(Equal("return {}\n"))
+			})
+		})
+
+		It("the pull request details will be retrieved", func() {
+			pr, err := client.PullRequest("kong", "kong", 11234)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pr).Should(Equal(PullRequest{
+				Author:         "gateway-engineer",
+				BaseRef:        "master",
+				BaseSHA:        "7654321",
+				Description:    "### Summary",
+				HeadRef:        "feat/sync-rate",
+				HeadSHA:        "1234567",
+				MergeCommitSHA: "abcdef0",
+				Merged:         true,
+				Number:         11234,
+				State:          "closed",
+				Title:          "feat(rate-limiting): add sync rate",
+				URL:            "https://github.com/kong/kong/pull/11234",
+			}))
+		})
+
+		It("the commits of a file missing from a ref will be retrieved regardless of their dates", func() {
+			commits, err := client.FileCommitsSince("kong", "kong", "kong/plugins/rate-limiting/schema.lua", "3.4.0")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(commits).Should(Equal([]Commit{
+				{
+					Author:  "Gateway Engineer",
+					Date:    time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC),
+					Message: "feat(rate-limiting): add sync rate (#11234)",
+					SHA:     "1111111111111111111111111111111111111111",
+					URL:     "https://github.com/kong/kong/commit/1111111",
+				},
+				{
+					Author:  "Gateway Engineer",
+					Date:    time.Date(2023, 7, 15, 0, 0, 0, 0, time.UTC),
+					Message: "fix(rate-limiting): validate sync rate",
+					SHA:     "3333333333333333333333333333333333333333",
+					URL:     "https://github.com/kong/kong/commit/3333333",
+				},
+			}))
+		})
+
+		It("an error will occur when the pull request does not exist", func() {
+			_, err := client.PullRequest("kong", "kong", 1)
+			Expect(err).To(HaveOccurred())
+		})
+	})
 })
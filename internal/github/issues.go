@@ -0,0 +1,294 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package github
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/v50/github"
+)
+
+// maxSearchResults represents the maximum number of results retrieved by a
+// search.
+const maxSearchResults = 50
+
+// repositoryURLPattern represents the API URL of a repository.
+var repositoryURLPattern = regexp.MustCompile(`/repos/([^/]+)/([^/]+)$`)
+
+// Issue represents a GitHub issue.
+type Issue struct {
+	// Labels represents the names of the labels of the issue
+	Labels []string
+	// Number represents the issue number
+	Number int
+	// Organization represents the organization of the repository of the issue
+	Organization string
+	// Repository represents the repository of the issue
+	Repository string
+	// State represents the state of the issue; open or closed
+	State string
+	// StateReason represents why the issue was closed; completed or
+	// not_planned
+	StateReason string
+	// Title represents the title of the issue
+	Title string
+	// URL represents the HTML URL of the issue
+	URL string
+}
+
+// NewIssue represents the content of an issue to create.
+type NewIssue struct {
+	// Title represents the title of the issue
+	Title string
+	// Body represents the markdown body of the issue
+	Body string
+	// Labels represents the labels to add to the issue
+	Labels []string
+}
+
+// IssueUpdate represents the changes to make to an issue; empty values are
+// left unchanged.
+type IssueUpdate struct {
+	// Title represents the new title of the issue
+	Title string
+	// Body represents the new markdown body of the issue
+	Body string
+	// State represents the new state of the issue; open or closed
+	State string
+	// StateReason represents why the issue is closed or opened; completed,
+	// not_planned, or reopened
+	StateReason string
+}
+
+// CreateIssue will create an issue in a repository.
+func (c *Client) CreateIssue(organization string, repository string, issue NewIssue) (Issue, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	labels := issue.Labels
+	if labels == nil {
+		labels = []string{}
+	}
+	created, _, err := c.client.Issues.Create(ctx, organization, repository, &github.IssueRequest{
+		Title:  github.String(issue.Title),
+		Body:   github.String(issue.Body),
+		Labels: &labels,
+	})
+	if err != nil {
+		return Issue{}, fmt.Errorf("unable to create issue: %w", err)
+	}
+	return newIssue(organization, repository, created), nil
+}
+
+// UpdateIssue will update the title, body, or state of an issue.
+func (c *Client) UpdateIssue(organization string, repository string, number int, update IssueUpdate) error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	request := &github.IssueRequest{}
+	if len(update.Title) > 0 {
+		request.Title = github.String(update.Title)
+	}
+	if len(update.Body) > 0 {
+		request.Body = github.String(update.Body)
+	}
+	if len(update.State) > 0 {
+		request.State = github.String(update.State)
+	}
+	if len(update.StateReason) > 0 {
+		request.StateReason = github.String(update.StateReason)
+	}
+	if _, _, err := c.client.Issues.Edit(ctx, organization, repository, number, request); err != nil {
+		return fmt.Errorf("unable to update issue %d: %w", number, err)
+	}
+	return nil
+}
+
+// AddIssueLabels will add labels to an issue; existing labels are kept.
+func (c *Client) AddIssueLabels(organization string, repository string, number int, labels ...string) error {
+	if len(labels) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	if _, _, err := c.client.Issues.AddLabelsToIssue(ctx, organization, repository, number, labels); err != nil {
+		return fmt.Errorf("unable to add labels to issue %d: %w", number, err)
+	}
+	return nil
+}
+
+// AssignIssue will add the users with the given logins as assignees of an
+// issue.
+func (c *Client) AssignIssue(organization string, repository string, number int, logins ...string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	if _, _, err := c.client.Issues.AddAssignees(ctx, organization, repository, number, logins); err != nil {
+		return fmt.Errorf("unable to assign issue %d: %w", number, err)
+	}
+	return nil
+}
+
+// CommentOnIssue will add a markdown comment to an issue or pull request.
+func (c *Client) CommentOnIssue(organization string, repository string, number int, comment string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	_, _, err := c.client.Issues.CreateComment(ctx, organization, repository, number, &github.IssueComment{
+		Body: github.String(comment),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to comment on issue %d: %w", number, err)
+	}
+	return nil
+}
+
+// UpsertIssueComment will maintain a single markdown comment on an issue or
+// pull request identified by a marker; e.g. a hidden HTML comment. The marker
+// is prepended to the comment, an existing comment of the authenticated user
+// carrying the marker is edited when its body differs, and a new comment is
+// created otherwise; comments of other users quoting the marker are ignored.
+func (c *Client) UpsertIssueComment(organization string, repository string, number int, marker string,
+	comment string,
+) error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	body := marker + "\n" + comment
+	login, err := c.authenticatedLogin(ctx)
+	if err != nil {
+		return err
+	}
+
+	opts := &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		comments, res, err := c.client.Issues.ListComments(ctx, organization, repository, number, opts)
+		if err != nil {
+			return fmt.Errorf("unable to list comments of issue %d: %w", number, err)
+		}
+		for _, existing := range comments {
+			if !strings.Contains(existing.GetBody(), marker) ||
+				!strings.EqualFold(existing.GetUser().GetLogin(), login) {
+				continue
+			}
+			if existing.GetBody() == body {
+				return nil
+			}
+			_, _, err := c.client.Issues.EditComment(ctx, organization, repository, existing.GetID(),
+				&github.IssueComment{Body: github.String(body)})
+			if err != nil {
+				return fmt.Errorf("unable to edit comment of issue %d: %w", number, err)
+			}
+			return nil
+		}
+		if res.NextPage == 0 {
+			break
+		}
+		opts.Page = res.NextPage
+	}
+
+	_, _, err = c.client.Issues.CreateComment(ctx, organization, repository, number, &github.IssueComment{
+		Body: github.String(body),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to comment on issue %d: %w", number, err)
+	}
+	return nil
+}
+
+// authenticatedLogin will get the login of the authenticated user; the login
+// is retrieved once as it never changes for a token.
+func (c *Client) authenticatedLogin(ctx context.Context) (string, error) {
+	c.loginMutex.Lock()
+	defer c.loginMutex.Unlock()
+	if len(c.login) > 0 {
+		return c.login, nil
+	}
+	user, _, err := c.client.Users.Get(ctx, "")
+	if err != nil {
+		return "", fmt.Errorf("unable to retrieve authenticated user: %w", err)
+	}
+	c.login = user.GetLogin()
+	return c.login, nil
+}
+
+// Issue will get an issue of a repository.
+func (c *Client) Issue(organization string, repository string, number int) (Issue, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	issue, _, err := c.client.Issues.Get(ctx, organization, repository, number)
+	if err != nil {
+		return Issue{}, fmt.Errorf("unable to retrieve issue %d: %w", number, err)
+	}
+	return newIssue(organization, repository, issue), nil
+}
+
+// SearchIssues will search the issues and pull requests matching a GitHub
+// search query; e.g. repo:kong/koko is:issue is:open label:gateway.
+func (c *Client) SearchIssues(query string) ([]Issue, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	result, _, err := c.client.Search.Issues(ctx, query, &github.SearchOptions{
+		Sort:        "created",
+		Order:       "asc",
+		ListOptions: github.ListOptions{PerPage: maxSearchResults},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to search issues: %w", err)
+	}
+	issues := make([]Issue, 0, len(result.Issues))
+	for _, issue := range result.Issues {
+		// The repository is only available from its API URL in search results;
+		// e.g. https://api.github.com/repos/kong/koko
+		var organization, repository string
+		if matches := repositoryURLPattern.FindStringSubmatch(issue.GetRepositoryURL()); matches != nil {
+			organization, repository = matches[1], matches[2]
+		}
+		issues = append(issues, newIssue(organization, repository, issue))
+	}
+	return issues, nil
+}
+
+// UserByEmail will find the login of the GitHub user with a public email
+// address.
+func (c *Client) UserByEmail(email string) (string, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	result, _, err := c.client.Search.Users(ctx, fmt.Sprintf("%s in:email", email), nil)
+	if err != nil {
+		return "", false, fmt.Errorf("unable to search users: %w", err)
+	}
+	if len(result.Users) == 0 {
+		return "", false, nil
+	}
+	return result.Users[0].GetLogin(), true, nil
+}
+
+// newIssue will create an issue from a GitHub issue.
+func newIssue(organization string, repository string, issue *github.Issue) Issue {
+	labels := make([]string, 0, len(issue.Labels))
+	for _, label := range issue.Labels {
+		labels = append(labels, label.GetName())
+	}
+	return Issue{
+		Labels:       labels,
+		Number:       issue.GetNumber(),
+		Organization: organization,
+		Repository:   repository,
+		State:        issue.GetState(),
+		StateReason:  issue.GetStateReason(),
+		Title:        issue.GetTitle(),
+		URL:          issue.GetHTMLURL(),
+	}
+}
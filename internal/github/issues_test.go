@@ -0,0 +1,194 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package github
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("issues", Label("github-issues"), func() {
+	var client *Client
+	var mux *http.ServeMux
+	var server *httptest.Server
+
+	BeforeEach(func() {
+		logger, err := zap.NewDevelopment()
+		Expect(err).NotTo(HaveOccurred())
+		mux = http.NewServeMux()
+		client, server = newTestClient(logger, mux)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("an issue will be created in a repository", func() {
+		mux.HandleFunc("/repos/kong/koko/issues", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Method).Should(Equal(http.MethodPost))
+			var request map[string]any
+			Expect(json.NewDecoder(r.Body).Decode(&request)).To(Succeed())
+			Expect(request).Should(HaveKeyWithValue("title", "Gateway schema change"))
+			Expect(request).Should(HaveKeyWithValue("labels", []any{"gateway-schema-change"}))
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{
+				"number": 42,
+				"state": "open",
+				"title": "Gateway schema change",
+				"html_url": "https://github.com/kong/koko/issues/42",
+				"labels": [{"name": "gateway-schema-change"}]
+			}`))
+		})
+
+		issue, err := client.CreateIssue("kong", "koko", NewIssue{
+			Title:  "Gateway schema change",
+			Body:   "### Summary",
+			Labels: []string{"gateway-schema-change"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(issue).Should(Equal(Issue{
+			Labels:       []string{"gateway-schema-change"},
+			Number:       42,
+			Organization: "kong",
+			Repository:   "koko",
+			State:        "open",
+			Title:        "Gateway schema change",
+			URL:          "https://github.com/kong/koko/issues/42",
+		}))
+	})
+
+	It("only the given fields of an issue will be updated", func() {
+		mux.HandleFunc("/repos/kong/koko/issues/42", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Method).Should(Equal(http.MethodPatch))
+			var request map[string]any
+			Expect(json.NewDecoder(r.Body).Decode(&request)).To(Succeed())
+			Expect(request).Should(Equal(map[string]any{"state": "closed", "state_reason": "not_planned"}))
+			_, _ = w.Write([]byte(`{"number": 42}`))
+		})
+
+		Expect(client.UpdateIssue("kong", "koko", 42, IssueUpdate{
+			State:       "closed",
+			StateReason: "not_planned",
+		})).To(Succeed())
+	})
+
+	It("the issues matching a search will be retrieved with their repository", func() {
+		mux.HandleFunc("/search/issues", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Query().Get("q")).Should(Equal(`repo:kong/koko is:issue is:open label:"gateway-pr"`))
+			_, _ = w.Write([]byte(`{"total_count": 1, "items": [{
+				"number": 42,
+				"state": "open",
+				"repository_url": "https://api.github.com/repos/kong/koko"
+			}]}`))
+		})
+
+		issues, err := client.SearchIssues(`repo:kong/koko is:issue is:open label:"gateway-pr"`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(issues).To(HaveLen(1))
+		Expect(issues[0].Organization).Should(Equal("kong"))
+		Expect(issues[0].Repository).Should(Equal("koko"))
+		Expect(issues[0].Number).Should(Equal(42))
+	})
+
+	It("the login of a user will be found by email", func() {
+		mux.HandleFunc("/search/users", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Query().Get("q")).Should(Equal("engineer@konghq.com in:email"))
+			_, _ = w.Write([]byte(`{"total_count": 1, "items": [{"login": "koko-engineer"}]}`))
+		})
+
+		login, found, err := client.UserByEmail("engineer@konghq.com")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(login).Should(Equal("koko-engineer"))
+	})
+
+	When("maintaining a marked comment", func() {
+		var created, edited []string
+		var users int
+
+		BeforeEach(func() {
+			created, edited, users = nil, nil, 0
+			mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+				users++
+				_, _ = w.Write([]byte(`{"login": "koko-bot"}`))
+			})
+			mux.HandleFunc("/repos/kong/kong/issues/11234/comments", func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodPost {
+					var request map[string]any
+					Expect(json.NewDecoder(r.Body).Decode(&request)).To(Succeed())
+					created = append(created, request["body"].(string))
+					w.WriteHeader(http.StatusCreated)
+					_, _ = w.Write([]byte(`{"id": 3}`))
+					return
+				}
+				_, _ = w.Write([]byte(`[
+					{"id": 1, "body": "LGTM", "user": {"login": "octocat"}},
+					{"id": 2, "body": "<!-- koko -->\nPicked up by Koko", "user": {"login": "koko-bot"}}
+				]`))
+			})
+			mux.HandleFunc("/repos/kong/kong/issues/comments/2", func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.Method).Should(Equal(http.MethodPatch))
+				var request map[string]any
+				Expect(json.NewDecoder(r.Body).Decode(&request)).To(Succeed())
+				edited = append(edited, request["body"].(string))
+				_, _ = w.Write([]byte(`{"id": 2}`))
+			})
+			mux.HandleFunc("/repos/kong/kong/issues/11235/comments", func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodPost {
+					var request map[string]any
+					Expect(json.NewDecoder(r.Body).Decode(&request)).To(Succeed())
+					created = append(created, request["body"].(string))
+					w.WriteHeader(http.StatusCreated)
+					_, _ = w.Write([]byte(`{"id": 4}`))
+					return
+				}
+				_, _ = w.Write([]byte(`[
+					{"id": 1, "body": "LGTM", "user": {"login": "octocat"}},
+					{"id": 5, "body": "> <!-- koko -->\n> Picked up by Koko", "user": {"login": "octocat"}}
+				]`))
+			})
+		})
+
+		It("the comment will be created when none of the authenticated user carries the marker", func() {
+			Expect(client.UpsertIssueComment("kong", "kong", 11235, "<!-- koko -->", "Picked up by Koko")).To(Succeed())
+			Expect(created).Should(Equal([]string{"<!-- koko -->\nPicked up by Koko"}))
+			Expect(edited).To(BeEmpty())
+		})
+
+		It("the marked comment will be edited when its body differs", func() {
+			Expect(client.UpsertIssueComment("kong", "kong", 11234, "<!-- koko -->", "Tracked by KOKO-1")).To(Succeed())
+			Expect(edited).Should(Equal([]string{"<!-- koko -->\nTracked by KOKO-1"}))
+			Expect(created).To(BeEmpty())
+		})
+
+		It("the marked comment will be left untouched when unchanged", func() {
+			Expect(client.UpsertIssueComment("kong", "kong", 11234, "<!-- koko -->", "Picked up by Koko")).To(Succeed())
+			Expect(edited).To(BeEmpty())
+			Expect(created).To(BeEmpty())
+		})
+
+		It("the authenticated user will only be retrieved once", func() {
+			Expect(client.UpsertIssueComment("kong", "kong", 11234, "<!-- koko -->", "Picked up by Koko")).To(Succeed())
+			Expect(client.UpsertIssueComment("kong", "kong", 11234, "<!-- koko -->", "Tracked by KOKO-1")).To(Succeed())
+			Expect(users).Should(Equal(1))
+		})
+	})
+})
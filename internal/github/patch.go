@@ -0,0 +1,54 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package github
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+)
+
+// PatchID will compute an identifier of the changes of files similar to git
+// patch-id; file order, whitespace, context lines, and line numbers are
+// ignored so the same change applied to another branch or repository has the
+// same identifier. Empty is returned when no file has a patch.
+func PatchID(files []File) string {
+	sorted := make([]File, 0, len(files))
+	for _, file := range files {
+		if len(file.Patch) > 0 {
+			sorted = append(sorted, file)
+		}
+	}
+	if len(sorted) == 0 {
+		return ""
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Filename < sorted[j].Filename
+	})
+
+	hash := sha256.New()
+	for _, file := range sorted {
+		hash.Write([]byte(file.Filename + "\n"))
+		for _, line := range strings.Split(file.Patch, "\n") {
+			if !strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "-") {
+				continue
+			}
+			hash.Write([]byte(line[:1] + strings.Join(strings.Fields(line[1:]), "") + "\n"))
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
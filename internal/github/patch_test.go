@@ -0,0 +1,44 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package github
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("patch ID", Label("github-patch-id"), func() {
+	files := []File{
+		{Filename: "kong/plugins/acme/schema.lua", Patch: "@@ -1,3 +1,3 @@\n context\n-  old = 1\n+  new = 2"},
+		{Filename: "CHANGELOG.md", Patch: "@@ -10 +10,2 @@\n+- feat: acme"},
+	}
+
+	It("the same change on another branch will have the same identifier", func() {
+		backport := []File{
+			{Filename: "CHANGELOG.md", Patch: "@@ -42 +42,2 @@\n+-  feat: acme"},
+			{Filename: "kong/plugins/acme/schema.lua", Patch: "@@ -7,3 +7,3 @@\n other context\n-\told = 1\n+\tnew = 2"},
+		}
+		Expect(PatchID(backport)).Should(Equal(PatchID(files)))
+	})
+
+	It("a different change will have a different identifier", func() {
+		Expect(PatchID(files[:1])).ShouldNot(Equal(PatchID(files)))
+	})
+
+	It("files without patches will have no identifier", func() {
+		Expect(PatchID([]File{{Filename: "logo.png"}})).Should(BeEmpty())
+	})
+})
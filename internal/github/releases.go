@@ -0,0 +1,447 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package github
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+	"go.uber.org/zap"
+)
+
+const (
+	// releaseBranchPrefix represents the prefix of the release branches of the
+	// gateway; e.g. release/3.4.x
+	releaseBranchPrefix = "release/"
+	// compareStatusAhead represents the status of a comparison whose head
+	// contains the base
+	compareStatusAhead = "ahead"
+	// compareStatusIdentical represents the status of a comparison whose head
+	// and base are the same commit
+	compareStatusIdentical = "identical"
+	// commitDateTolerance represents the tolerance applied to the merge time
+	// when excluding the refs whose commit predates a merge; committer clocks
+	// are not reliable
+	commitDateTolerance = time.Hour
+)
+
+// releaseTagPattern represents the pattern of the release tags of the gateway;
+// e.g. 3.4.0 or 2.8.4.1, pre-release tags are not matched.
+var releaseTagPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)(?:\.(\d+))?$`)
+
+// Tag represents a tag of a repository.
+type Tag struct {
+	// Name represents the name of the tag
+	Name string
+	// SHA represents the SHA of the commit the tag points to
+	SHA string
+}
+
+// Branch represents a branch of a repository.
+type Branch struct {
+	// Name represents the name of the branch
+	Name string
+	// SHA represents the SHA of the commit at the tip of the branch
+	SHA string
+}
+
+// Inclusion represents the releases containing a commit.
+type Inclusion struct {
+	// Tags represents the release tags containing the commit from the oldest
+	// to the newest release
+	Tags []string
+	// Branches represents the release branches containing the commit
+	Branches []string
+}
+
+// ReleaseVersion represents the version of a release tag.
+type ReleaseVersion struct {
+	// Tag represents the name of the release tag
+	Tag string
+	// segments represents the numeric segments of the version
+	segments [4]int
+}
+
+// Release will get the first release containing the commit; empty when the
+// commit is not released yet.
+func (i Inclusion) Release() string {
+	if len(i.Tags) == 0 {
+		return ""
+	}
+	return i.Tags[0]
+}
+
+// ParseReleaseVersion will parse the version of a release tag; false is
+// returned when the tag is not a release tag.
+func ParseReleaseVersion(tag string) (ReleaseVersion, bool) {
+	matches := releaseTagPattern.FindStringSubmatch(tag)
+	if matches == nil {
+		return ReleaseVersion{}, false
+	}
+	version := ReleaseVersion{Tag: tag}
+	for i, segment := range matches[1:] {
+		if len(segment) == 0 {
+			continue
+		}
+		number, err := strconv.Atoi(segment)
+		if err != nil {
+			return ReleaseVersion{}, false
+		}
+		version.segments[i] = number
+	}
+	return version, true
+}
+
+// Less will determine if the version is older than another version.
+func (v ReleaseVersion) Less(other ReleaseVersion) bool {
+	for i := range v.segments {
+		if v.segments[i] != other.segments[i] {
+			return v.segments[i] < other.segments[i]
+		}
+	}
+	return false
+}
+
+// Line will get the release line of the version; e.g. 3.4 for 3.4.1.
+func (v ReleaseVersion) Line() string {
+	return fmt.Sprintf("%d.%d", v.segments[0], v.segments[1])
+}
+
+// Tags will get the tags of a repository.
+func (c *Client) Tags(organization string, repository string) ([]Tag, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	var tags []Tag
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		repositoryTags, res, err := c.client.Repositories.ListTags(ctx, organization, repository, opts)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list tags: %w", err)
+		}
+		for _, repositoryTag := range repositoryTags {
+			tags = append(tags, Tag{
+				Name: repositoryTag.GetName(),
+				SHA:  repositoryTag.GetCommit().GetSHA(),
+			})
+		}
+		if res.NextPage == 0 {
+			break
+		}
+		opts.Page = res.NextPage
+	}
+	return tags, nil
+}
+
+// ReleaseBranches will get the release branches of a repository.
+func (c *Client) ReleaseBranches(organization string, repository string) ([]Branch, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	var branches []Branch
+	opts := &github.BranchListOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		repositoryBranches, res, err := c.client.Repositories.ListBranches(ctx, organization, repository, opts)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list branches: %w", err)
+		}
+		for _, repositoryBranch := range repositoryBranches {
+			if strings.HasPrefix(repositoryBranch.GetName(), releaseBranchPrefix) {
+				branches = append(branches, Branch{
+					Name: repositoryBranch.GetName(),
+					SHA:  repositoryBranch.GetCommit().GetSHA(),
+				})
+			}
+		}
+		if res.NextPage == 0 {
+			break
+		}
+		opts.Page = res.NextPage
+	}
+	return branches, nil
+}
+
+// Contains will determine if a ref contains a commit by comparing them; the
+// ref can be a tag, a branch, or a SHA.
+func (c *Client) Contains(organization string, repository string, ref string, sha string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	comparison, _, err := c.client.Repositories.CompareCommits(ctx, organization, repository, sha, ref,
+		&github.ListOptions{PerPage: 1})
+	if err != nil {
+		return false, fmt.Errorf("unable to compare %s with %s: %w", ref, sha, err)
+	}
+	status := comparison.GetStatus()
+	return status == compareStatusAhead || status == compareStatusIdentical, nil
+}
+
+// MergeBase will get the SHA of the best common ancestor of two refs; i.e. the
+// commit the changes of the head ref are based on.
+func (c *Client) MergeBase(organization string, repository string, base string, head string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	comparison, _, err := c.client.Repositories.CompareCommits(ctx, organization, repository, base, head,
+		&github.ListOptions{PerPage: 1})
+	if err != nil {
+		return "", fmt.Errorf("unable to compare %s with %s: %w", head, base, err)
+	}
+	sha := comparison.GetMergeBaseCommit().GetSHA()
+	if len(sha) == 0 {
+		return "", fmt.Errorf("unable to find merge base of %s and %s", base, head)
+	}
+	return sha, nil
+}
+
+// CompareFiles will get the files modified between two refs; GitHub limits
+// the comparison to the first 300 files when the mirror is not used.
+func (c *Client) CompareFiles(organization string, repository string, base string, head string) ([]File, error) {
+	if c.mirror != nil {
+		files, err := c.mirror.CompareFiles(organization, repository, base, head)
+		if err == nil {
+			return files, nil
+		}
+		c.logger.Debug("falling back to the API for comparison", zap.String("base", base),
+			zap.String("head", head), zap.Error(err))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	comparison, _, err := c.client.Repositories.CompareCommits(ctx, organization, repository, base, head,
+		&github.ListOptions{PerPage: 1})
+	if err != nil {
+		return nil, fmt.Errorf("unable to compare %s with %s: %w", head, base, err)
+	}
+	files := make([]File, 0, len(comparison.Files))
+	for _, commitFile := range comparison.Files {
+		files = append(files, File{
+			Filename:         commitFile.GetFilename(),
+			PreviousFilename: commitFile.GetPreviousFilename(),
+			Status:           commitFile.GetStatus(),
+			Patch:            commitFile.GetPatch(),
+		})
+	}
+	return files, nil
+}
+
+// Tree will get the blob SHAs of the files of a repository at a ref keyed by
+// path; an error is returned when GitHub truncates the tree.
+func (c *Client) Tree(organization string, repository string, ref string) (map[string]string, error) {
+	if c.mirror != nil {
+		tree, err := c.mirror.Tree(organization, repository, ref)
+		if err == nil {
+			return tree, nil
+		}
+		c.logger.Debug("falling back to the API for tree", zap.String("ref", ref), zap.Error(err))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	tree, _, err := c.client.Git.GetTree(ctx, organization, repository, ref, true)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve tree at %s: %w", ref, err)
+	}
+	if tree.GetTruncated() {
+		return nil, fmt.Errorf("unable to retrieve tree at %s: tree is truncated", ref)
+	}
+	blobs := make(map[string]string, len(tree.Entries))
+	for _, entry := range tree.Entries {
+		if entry.GetType() == "blob" {
+			blobs[entry.GetPath()] = entry.GetSHA()
+		}
+	}
+	return blobs, nil
+}
+
+// CompareTrees will get the files that differ between the trees of two refs
+// by comparing their blob SHAs; unlike CompareFiles the comparison is not
+// limited in files, but renames are reported as a removal and an addition and
+// no patches are available.
+func (c *Client) CompareTrees(organization string, repository string, base string, head string) ([]File, error) {
+	baseTree, err := c.Tree(organization, repository, base)
+	if err != nil {
+		return nil, err
+	}
+	headTree, err := c.Tree(organization, repository, head)
+	if err != nil {
+		return nil, err
+	}
+	var files []File
+	for path, sha := range headTree {
+		baseSHA, ok := baseTree[path]
+		switch {
+		case !ok:
+			files = append(files, File{Filename: path, Status: "added"})
+		case baseSHA != sha:
+			files = append(files, File{Filename: path, Status: "modified"})
+		}
+	}
+	for path := range baseTree {
+		if _, ok := headTree[path]; !ok {
+			files = append(files, File{Filename: path, Status: "removed"})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Filename < files[j].Filename
+	})
+	return files, nil
+}
+
+// ReleaseInclusion will find the release tags and the release branches that
+// contain a commit merged at the given time. Refs whose commit predates the
+// merge cannot contain it and are not compared; the whole merge history is
+// compared when the merge time is zero. The patch releases of a release line
+// are cumulative, so only the newest release of each line is compared before
+// searching for the first release of the line containing the commit.
+func (c *Client) ReleaseInclusion(organization string, repository string, sha string, mergedAt time.Time) (Inclusion,
+	error,
+) {
+	tags, err := c.Tags(organization, repository)
+	if err != nil {
+		return Inclusion{}, err
+	}
+	tagSHAs := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		tagSHAs[tag.Name] = tag.SHA
+	}
+	var inclusion Inclusion
+	for _, line := range releaseLines(tags) {
+		newest := line[len(line)-1].Tag
+		predates, err := c.predates(organization, repository, tagSHAs[newest], mergedAt)
+		if err != nil {
+			return Inclusion{}, err
+		}
+		if predates {
+			continue
+		}
+		contains, err := c.Contains(organization, repository, newest, sha)
+		if err != nil {
+			return Inclusion{}, err
+		}
+		if !contains {
+			continue
+		}
+		// Search for the first release of the line containing the commit
+		low, high := 0, len(line)-1
+		for low < high {
+			middle := (low + high) / 2
+			contains, err := c.Contains(organization, repository, line[middle].Tag, sha)
+			if err != nil {
+				return Inclusion{}, err
+			}
+			if contains {
+				high = middle
+			} else {
+				low = middle + 1
+			}
+		}
+		for _, version := range line[low:] {
+			inclusion.Tags = append(inclusion.Tags, version.Tag)
+		}
+	}
+
+	branches, err := c.ReleaseBranches(organization, repository)
+	if err != nil {
+		return Inclusion{}, err
+	}
+	for _, branch := range branches {
+		predates, err := c.predates(organization, repository, branch.SHA, mergedAt)
+		if err != nil {
+			return Inclusion{}, err
+		}
+		if predates {
+			continue
+		}
+		contains, err := c.Contains(organization, repository, branch.Name, sha)
+		if err != nil {
+			return Inclusion{}, err
+		}
+		if contains {
+			inclusion.Branches = append(inclusion.Branches, branch.Name)
+		}
+	}
+	return inclusion, nil
+}
+
+// predates will determine if a commit was committed before a merge and thus
+// cannot contain it; nothing predates a zero merge time.
+func (c *Client) predates(organization string, repository string, sha string, mergedAt time.Time) (bool, error) {
+	if mergedAt.IsZero() || len(sha) == 0 {
+		return false, nil
+	}
+	date, err := c.commitDate(organization, repository, sha)
+	if err != nil {
+		return false, err
+	}
+	return date.Before(mergedAt.Add(-commitDateTolerance)), nil
+}
+
+// commitDate will get the committer date of a commit; dates are cached as
+// commits are immutable.
+func (c *Client) commitDate(organization string, repository string, sha string) (time.Time, error) {
+	c.commitDatesMutex.Lock()
+	date, ok := c.commitDates[sha]
+	c.commitDatesMutex.Unlock()
+	if ok {
+		return date, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	commit, _, err := c.client.Git.GetCommit(ctx, organization, repository, sha)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unable to get commit %s: %w", sha, err)
+	}
+	date = commit.GetCommitter().GetDate().Time
+
+	c.commitDatesMutex.Lock()
+	defer c.commitDatesMutex.Unlock()
+	if c.commitDates == nil {
+		c.commitDates = make(map[string]time.Time)
+	}
+	c.commitDates[sha] = date
+	return date, nil
+}
+
+// releaseLines will group the release tags by release line; the lines and
+// their releases are sorted from the oldest to the newest.
+func releaseLines(tags []Tag) [][]ReleaseVersion {
+	var versions []ReleaseVersion
+	for _, tag := range tags {
+		if version, ok := ParseReleaseVersion(tag.Name); ok {
+			versions = append(versions, version)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Less(versions[j])
+	})
+
+	var lines [][]ReleaseVersion
+	for _, version := range versions {
+		last := len(lines) - 1
+		if last >= 0 && lines[last][0].Line() == version.Line() {
+			lines[last] = append(lines[last], version)
+			continue
+		}
+		lines = append(lines, []ReleaseVersion{version})
+	}
+	return lines
+}
@@ -0,0 +1,194 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("releases", Label("github-releases"), func() {
+	var client *Client
+	var server *httptest.Server
+	var compared []string
+	var fetched []string
+
+	BeforeEach(func() {
+		logger, err := zap.NewDevelopment()
+		Expect(err).NotTo(HaveOccurred())
+		compared, fetched = nil, nil
+		committed := map[string]string{
+			"1":   "2023-04-01T00:00:00Z",
+			"4":   "2023-07-01T00:00:00Z",
+			"b33": "2023-04-15T00:00:00Z",
+			"b34": "2023-07-15T00:00:00Z",
+		}
+		containing := map[string]bool{"3.4.1": true, "3.4.2": true, "release/3.4.x": true}
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/repos/kong/kong/tags", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`[
+				{"name": "3.5.0-rc.1", "commit": {"sha": "5"}},
+				{"name": "3.4.2", "commit": {"sha": "4"}},
+				{"name": "3.4.1", "commit": {"sha": "3"}},
+				{"name": "3.4.0", "commit": {"sha": "2"}},
+				{"name": "3.3.1", "commit": {"sha": "1"}},
+				{"name": "3.3.0", "commit": {"sha": "0"}}
+			]`))
+		})
+		mux.HandleFunc("/repos/kong/kong/branches", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`[
+				{"name": "master", "commit": {"sha": "m"}},
+				{"name": "release/3.3.x", "commit": {"sha": "b33"}},
+				{"name": "release/3.4.x", "commit": {"sha": "b34"}}
+			]`))
+		})
+		mux.HandleFunc("/repos/kong/kong/git/commits/", func(w http.ResponseWriter, r *http.Request) {
+			sha := strings.TrimPrefix(r.URL.Path, "/repos/kong/kong/git/commits/")
+			fetched = append(fetched, sha)
+			_, _ = w.Write([]byte(fmt.Sprintf(`{"sha": %q, "committer": {"date": %q}}`, sha, committed[sha])))
+		})
+		mux.HandleFunc("/repos/kong/kong/git/trees/", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Query().Get("recursive")).Should(Equal("1"))
+			switch strings.TrimPrefix(r.URL.Path, "/repos/kong/kong/git/trees/") {
+			case "3.4.1":
+				_, _ = w.Write([]byte(`{"truncated": false, "tree": [
+					{"path": "kong/plugins/acme", "type": "tree", "sha": "t1"},
+					{"path": "kong/plugins/acme/schema.lua", "type": "blob", "sha": "a1"},
+					{"path": "kong/plugins/acme/handler.lua", "type": "blob", "sha": "a2"},
+					{"path": "kong/plugins/session/schema.lua", "type": "blob", "sha": "a3"}
+				]}`))
+			case "3.4.2":
+				_, _ = w.Write([]byte(`{"truncated": false, "tree": [
+					{"path": "kong/plugins/acme", "type": "tree", "sha": "t2"},
+					{"path": "kong/plugins/acme/schema.lua", "type": "blob", "sha": "b1"},
+					{"path": "kong/plugins/acme/handler.lua", "type": "blob", "sha": "a2"},
+					{"path": "kong/db/schema/entities/vaults.lua", "type": "blob", "sha": "b4"}
+				]}`))
+			default:
+				_, _ = w.Write([]byte(`{"truncated": true, "tree": []}`))
+			}
+		})
+		mux.HandleFunc("/repos/kong/kong/compare/", func(w http.ResponseWriter, r *http.Request) {
+			base, head, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/repos/kong/kong/compare/"), "...")
+			compared = append(compared, head)
+			if head == "3.4.2" && base == "3.4.1" {
+				_, _ = w.Write([]byte(`{"status": "ahead", "files": [
+					{"filename": "kong/plugins/acme/schema.lua", "status": "modified"}
+				]}`))
+				return
+			}
+			if base == "master" {
+				_, _ = w.Write([]byte(`{"status": "diverged", "merge_base_commit": {"sha": "1234567"}}`))
+				return
+			}
+			Expect(base).Should(Equal("abcdef0"))
+			status := "diverged"
+			if containing[head] {
+				status = "ahead"
+			}
+			_, _ = w.Write([]byte(fmt.Sprintf(`{"status": %q}`, status)))
+		})
+		server = httptest.NewServer(mux)
+		client, err = NewClient(Options{
+			Token:   "token",
+			BaseURL: server.URL,
+			Logger:  logger,
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("the release tags and branches containing a commit will be found", func() {
+		inclusion, err := client.ReleaseInclusion("kong", "kong", "abcdef0", time.Time{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(inclusion).Should(Equal(Inclusion{
+			Tags:     []string{"3.4.1", "3.4.2"},
+			Branches: []string{"release/3.4.x"},
+		}))
+		Expect(inclusion.Release()).Should(Equal("3.4.1"))
+	})
+
+	It("only the newest release of a line will be compared when it does not contain the commit", func() {
+		_, err := client.ReleaseInclusion("kong", "kong", "abcdef0", time.Time{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(compared).NotTo(ContainElement("3.3.0"))
+	})
+
+	It("the release lines and branches predating the merge will not be compared", func() {
+		mergedAt := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+		inclusion, err := client.ReleaseInclusion("kong", "kong", "abcdef0", mergedAt)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(inclusion).Should(Equal(Inclusion{
+			Tags:     []string{"3.4.1", "3.4.2"},
+			Branches: []string{"release/3.4.x"},
+		}))
+		Expect(compared).NotTo(ContainElement("3.3.1"))
+		Expect(compared).NotTo(ContainElement("release/3.3.x"))
+
+		// Commit dates are only retrieved once
+		Expect(fetched).To(HaveLen(4))
+		_, err = client.ReleaseInclusion("kong", "kong", "abcdef0", mergedAt)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fetched).To(HaveLen(4))
+	})
+
+	It("the files modified between two releases will be retrieved", func() {
+		files, err := client.CompareFiles("kong", "kong", "3.4.1", "3.4.2")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files).Should(Equal([]File{{Filename: "kong/plugins/acme/schema.lua", Status: "modified"}}))
+	})
+
+	It("the files differing between the trees of two releases will be retrieved", func() {
+		files, err := client.CompareTrees("kong", "kong", "3.4.1", "3.4.2")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files).Should(Equal([]File{
+			{Filename: "kong/db/schema/entities/vaults.lua", Status: "added"},
+			{Filename: "kong/plugins/acme/schema.lua", Status: "modified"},
+			{Filename: "kong/plugins/session/schema.lua", Status: "removed"},
+		}))
+	})
+
+	It("an error will occur when the tree of a release is truncated", func() {
+		_, err := client.CompareTrees("kong", "kong", "3.4.1", "3.5.0-rc.1")
+		Expect(err).Should(MatchError("unable to retrieve tree at 3.5.0-rc.1: tree is truncated"))
+	})
+
+	It("the merge base of two refs will be found", func() {
+		Expect(client.MergeBase("kong", "kong", "master", "feat/sync-rate")).Should(Equal("1234567"))
+	})
+
+	It("release versions will be parsed and ordered", func() {
+		older, ok := ParseReleaseVersion("2.8.4.1")
+		Expect(ok).To(BeTrue())
+		newer, ok := ParseReleaseVersion("v2.8.10")
+		Expect(ok).To(BeTrue())
+		Expect(older.Less(newer)).To(BeTrue())
+		Expect(newer.Line()).Should(Equal("2.8"))
+		_, ok = ParseReleaseVersion("3.5.0-rc.1")
+		Expect(ok).To(BeFalse())
+	})
+})
@@ -0,0 +1,129 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package github
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/go-github/v50/github"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxCodeSearchResults represents the maximum number of files retrieved
+	// for a search term to keep within a single page of the search API
+	maxCodeSearchResults = 100
+	// MaxCodeMatchesPerTerm represents the maximum number of lines found for
+	// a search term; common names would otherwise match most of a codebase
+	MaxCodeMatchesPerTerm = 20
+)
+
+// CodeMatch represents a line of a repository matching a code search.
+type CodeMatch struct {
+	// Path represents the path of the file containing the line
+	Path string
+	// Line represents the line number; zero when the search API does not
+	// provide it
+	Line int
+	// Text represents the trimmed content of the line
+	Text string
+	// Term represents the search term found in the line
+	Term string
+}
+
+// SearchCode will search the default branch of a repository for the lines
+// containing any of the terms as whole words; the mirror is used when
+// available, otherwise the code search API only provides the lines of the
+// matched fragments. At most MaxCodeMatchesPerTerm lines are found for each
+// term.
+func (c *Client) SearchCode(organization string, repository string, terms []string) ([]CodeMatch, error) {
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	if c.mirror != nil {
+		matches, err := c.mirror.SearchCode(organization, repository, terms)
+		if err == nil {
+			return matches, nil
+		}
+		c.logger.Debug("falling back to the API for code search", zap.String("organization", organization),
+			zap.String("repository", repository), zap.Error(err))
+	}
+
+	var matches []CodeMatch
+	for _, term := range terms {
+		ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+		query := fmt.Sprintf("%q repo:%s/%s", term, organization, repository)
+		result, _, err := c.client.Search.Code(ctx, query, &github.SearchOptions{
+			TextMatch:   true,
+			ListOptions: github.ListOptions{PerPage: maxCodeSearchResults},
+		})
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("unable to search %s/%s for %s: %w", organization, repository, term, err)
+		}
+		found := 0
+		for _, code := range result.CodeResults {
+			seen := make(map[string]struct{})
+			for _, textMatch := range code.TextMatches {
+				for _, line := range strings.Split(textMatch.GetFragment(), "\n") {
+					line = strings.TrimSpace(line)
+					if _, ok := seen[line]; ok || !ContainsWord(line, term) || found == MaxCodeMatchesPerTerm {
+						continue
+					}
+					seen[line] = struct{}{}
+					found++
+					matches = append(matches, CodeMatch{Path: code.GetPath(), Text: line, Term: term})
+				}
+			}
+		}
+	}
+	return matches, nil
+}
+
+// ContainsWord will determine if a line contains a term as a whole word; i.e.
+// an occurrence of the term neither preceded nor followed by a letter, a
+// digit, an underscore, or a hyphen.
+func ContainsWord(line string, term string) bool {
+	if len(term) == 0 {
+		return false
+	}
+	for offset := 0; offset < len(line); {
+		index := strings.Index(line[offset:], term)
+		if index < 0 {
+			return false
+		}
+		start := offset + index
+		end := start + len(term)
+		before, _ := utf8.DecodeLastRuneInString(line[:start])
+		after, _ := utf8.DecodeRuneInString(line[end:])
+		if (start == 0 || !isWordRune(before)) && (end == len(line) || !isWordRune(after)) {
+			return true
+		}
+		offset = start + 1
+	}
+	return false
+}
+
+// isWordRune will determine if a rune is part of a word; hyphens join the
+// words of plugin names, so rate-limiting is not found in
+// rate-limiting-advanced.
+func isWordRune(r rune) bool {
+	return r == '_' || r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
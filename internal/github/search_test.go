@@ -0,0 +1,102 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package github
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("code search", Label("github-search"), func() {
+	var client *Client
+	var server *httptest.Server
+	var queries []string
+
+	BeforeEach(func() {
+		logger, err := zap.NewDevelopment()
+		Expect(err).NotTo(HaveOccurred())
+		queries = nil
+		mux := http.NewServeMux()
+		mux.HandleFunc("/search/code", func(w http.ResponseWriter, r *http.Request) {
+			queries = append(queries, r.URL.Query().Get("q"))
+			Expect(r.Header.Get("Accept")).Should(ContainSubstring("text-match"))
+			_, _ = w.Write([]byte(`{"total_count": 1, "items": [{
+				"path": "internal/plugin/validators/rate_limiting.go",
+				"text_matches": [
+					{"fragment": "\t// sync_rate is validated by the schema\n\tif cfg.SyncRate < 0 {\n\tsync_rate := 1"},
+					{"fragment": "\tsync_rate := 1"}
+				]
+			}]}`))
+		})
+		client, server = newTestClient(logger, mux)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("the matching lines of the fragments will be found for each term", func() {
+		matches, err := client.SearchCode("kong", "koko", []string{"sync_rate"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(queries).Should(Equal([]string{`"sync_rate" repo:kong/koko`}))
+		Expect(matches).Should(Equal([]CodeMatch{
+			{
+				Path: "internal/plugin/validators/rate_limiting.go",
+				Text: "// sync_rate is validated by the schema",
+				Term: "sync_rate",
+			},
+			{
+				Path: "internal/plugin/validators/rate_limiting.go",
+				Text: "sync_rate := 1",
+				Term: "sync_rate",
+			},
+		}))
+	})
+
+	It("terms will only be found as whole words", func() {
+		matches, err := client.SearchCode("kong", "koko", []string{"sync"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(matches).To(BeEmpty())
+
+		Expect(ContainsWord("if cfg.sync_rate < 0 {", "sync_rate")).To(BeTrue())
+		Expect(ContainsWord("rate-limiting-advanced", "rate-limiting")).To(BeFalse())
+		Expect(ContainsWord(`"rate-limiting-advanced", "rate-limiting"`, "rate-limiting")).To(BeTrue())
+		Expect(ContainsWord("sync_rate_limit := sync_rate", "sync_rate")).To(BeTrue())
+		Expect(ContainsWord("sync_rate_limit", "sync_rate")).To(BeFalse())
+		Expect(ContainsWord("résumé", "sum")).To(BeFalse())
+	})
+
+	It("the mirror will be searched when it is available", func() {
+		client.mirror = fakeMirror{files: map[string]string{"internal/plugin/plugin.go": "rate-limiting"}}
+		matches, err := client.SearchCode("kong", "koko", []string{"rate-limiting"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(queries).To(BeEmpty())
+		Expect(matches).Should(Equal([]CodeMatch{
+			{Path: "internal/plugin/plugin.go", Line: 1, Text: "rate-limiting", Term: "rate-limiting"},
+		}))
+	})
+
+	It("the API will be searched when the mirror is unavailable", func() {
+		client.mirror = fakeMirror{}
+		_, err := client.SearchCode("kong", "kong", []string{"sync_rate"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(queries).To(HaveLen(1))
+	})
+})
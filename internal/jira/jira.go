@@ -0,0 +1,488 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package jira
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/kong/koko-slack-bot/internal/adf"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultTimeout represents the maximum amount of time a single Jira API
+	// request is allowed to take.
+	defaultTimeout = 30 * time.Second
+	// defaultIssueType represents the issue type used when creating issues
+	defaultIssueType = "Task"
+	// maxSearchResults represents the maximum number of issues retrieved by a
+	// search
+	maxSearchResults = 50
+	// relatesLinkType represents the name of the "relates to" issue link type
+	relatesLinkType = "Relates"
+)
+
+// Options contain the parameters to create a new Jira client instance.
+type Options struct {
+	// URL represents the base URL of the Jira instance
+	URL string
+	// Username represents the email address of the Jira API user
+	Username string
+	// Token represents the API token of the Jira API user
+	Token string
+	// Project represents the key of the project issues are created in
+	Project string
+	// IssueType represents the type of the issues created; defaults to Task
+	IssueType string
+	// Logger represents the base logger to use for the Jira package
+	Logger *zap.Logger
+}
+
+// Client represents a Jira client instance.
+type Client struct {
+	// baseURL represents the base URL of the Jira instance
+	baseURL *url.URL
+	// httpClient represents the connection for Jira's API
+	httpClient *http.Client
+	// issueType represents the type of the issues created
+	issueType string
+	// logger represents the logger to use for the Jira package
+	logger *zap.Logger
+	// project represents the key of the project issues are created in
+	project string
+	// token represents the API token of the Jira API user
+	token string
+	// username represents the email address of the Jira API user
+	username string
+}
+
+// NewIssue represents the content of an issue to create.
+type NewIssue struct {
+	// Summary represents the title of the issue
+	Summary string
+	// Description represents the markdown body of the issue; it is converted
+	// into an Atlassian Document Format document
+	Description string
+	// Labels represents the labels to add to the issue
+	Labels []string
+	// Priority represents the name of the priority of the issue; the
+	// project's default priority is used when empty
+	Priority string
+	// Components represents the names of the components of the issue
+	Components []string
+	// Fields represents additional fields of the issue keyed by field ID;
+	// e.g. customfield_10010
+	Fields map[string]any
+}
+
+// Issue represents a Jira issue.
+type Issue struct {
+	// Key represents the key of the issue; e.g. KOKO-1234
+	Key string
+	// URL represents the browsable URL of the issue
+	URL string
+	// Summary represents the title of the issue when retrieved by a search
+	Summary string
+	// Status represents the name of the status of the issue when retrieved by
+	// a search
+	Status string
+}
+
+// NewClient will validate options and instantiate a new Jira client instance.
+func NewClient(opts Options) (*Client, error) {
+	// Validate required options
+	if len(strings.TrimSpace(opts.URL)) == 0 {
+		return nil, errors.New("jira URL is not set")
+	}
+	baseURL, err := url.Parse(strings.TrimSuffix(opts.URL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid jira URL: %w", err)
+	}
+	if len(strings.TrimSpace(opts.Username)) == 0 {
+		return nil, errors.New("jira username is not set")
+	}
+	if len(strings.TrimSpace(opts.Token)) == 0 {
+		return nil, errors.New("jira token is not set")
+	}
+	if len(strings.TrimSpace(opts.Project)) == 0 {
+		return nil, errors.New("jira project is not set")
+	}
+	if opts.Logger == nil {
+		return nil, errors.New("logger is not set")
+	}
+	issueType := opts.IssueType
+	if len(strings.TrimSpace(issueType)) == 0 {
+		issueType = defaultIssueType
+	}
+
+	return &Client{
+		baseURL:    baseURL,
+		httpClient: &http.Client{},
+		issueType:  issueType,
+		logger:     opts.Logger.With(zap.String("component", "jira")),
+		project:    opts.Project,
+		token:      opts.Token,
+		username:   opts.Username,
+	}, nil
+}
+
+// CreateIssue will create an issue in the configured project.
+func (c *Client) CreateIssue(issue NewIssue) (Issue, error) {
+	labels := issue.Labels
+	if labels == nil {
+		labels = []string{}
+	}
+	fields := map[string]any{
+		"project":     map[string]string{"key": c.project},
+		"issuetype":   map[string]string{"name": c.issueType},
+		"summary":     issue.Summary,
+		"description": adf.Convert(issue.Description),
+		"labels":      labels,
+	}
+	// Additional fields cannot override the fields set from the issue
+	for field, value := range issue.Fields {
+		if _, ok := fields[field]; !ok {
+			fields[field] = value
+		}
+	}
+	if len(issue.Priority) > 0 {
+		fields["priority"] = map[string]string{"name": issue.Priority}
+	}
+	if len(issue.Components) > 0 {
+		components := make([]map[string]string, 0, len(issue.Components))
+		for _, component := range issue.Components {
+			components = append(components, map[string]string{"name": component})
+		}
+		fields["components"] = components
+	}
+	request := map[string]any{
+		"fields": fields,
+	}
+	var response struct {
+		Key string `json:"key"`
+	}
+	if err := c.do(http.MethodPost, "/rest/api/3/issue", request, &response); err != nil {
+		return Issue{}, fmt.Errorf("unable to create issue: %w", err)
+	}
+	c.logger.Debug("issue created", zap.String("key", response.Key))
+	return Issue{
+		Key: response.Key,
+		URL: c.issueURL(response.Key),
+	}, nil
+}
+
+// AddComment will add a markdown comment to an issue.
+func (c *Client) AddComment(key string, comment string) error {
+	request := map[string]any{
+		"body": adf.Convert(comment),
+	}
+	if err := c.do(http.MethodPost, fmt.Sprintf("/rest/api/3/issue/%s/comment", key), request, nil); err != nil {
+		return fmt.Errorf("unable to add comment to issue %s: %w", key, err)
+	}
+	return nil
+}
+
+// UpdateIssue will update the summary and the markdown description of an
+// issue; empty values are left unchanged.
+func (c *Client) UpdateIssue(key string, summary string, description string) error {
+	fields := map[string]any{}
+	if len(summary) > 0 {
+		fields["summary"] = summary
+	}
+	if len(description) > 0 {
+		fields["description"] = adf.Convert(description)
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	request := map[string]any{
+		"fields": fields,
+	}
+	if err := c.do(http.MethodPut, fmt.Sprintf("/rest/api/3/issue/%s", key), request, nil); err != nil {
+		return fmt.Errorf("unable to update issue %s: %w", key, err)
+	}
+	return nil
+}
+
+// transition represents a transition of an issue to another status.
+type transition struct {
+	// ID represents the identifier of the transition
+	ID string `json:"id"`
+	// Name represents the name of the transition
+	Name string `json:"name"`
+	// To represents the status the transition leads to
+	To struct {
+		Name           string `json:"name"`
+		StatusCategory struct {
+			Key string `json:"key"`
+		} `json:"statusCategory"`
+	} `json:"to"`
+}
+
+// CloseIssue will transition an issue to a done status; the transition named
+// after, or leading to, one of the preferred statuses is used when available.
+func (c *Client) CloseIssue(key string, preferred ...string) error {
+	transitions, err := c.transitions(key)
+	if err != nil {
+		return err
+	}
+	var transitionID string
+	for _, transition := range transitions {
+		if transition.To.StatusCategory.Key == StatusCategoryDone {
+			transitionID = transition.ID
+			break
+		}
+	}
+	// Earlier preferred statuses take precedence over later ones
+	for i := len(preferred) - 1; i >= 0; i-- {
+		for _, transition := range transitions {
+			if transition.To.StatusCategory.Key == StatusCategoryDone &&
+				(strings.EqualFold(transition.Name, preferred[i]) || strings.EqualFold(transition.To.Name, preferred[i])) {
+				transitionID = transition.ID
+				break
+			}
+		}
+	}
+	if len(transitionID) == 0 {
+		return fmt.Errorf("no transition to a done status available for issue %s", key)
+	}
+	return c.transition(key, transitionID)
+}
+
+// ReopenIssue will transition a resolved issue back to a to do status; a
+// transition to an in progress status is used when no to do status is
+// available.
+func (c *Client) ReopenIssue(key string) error {
+	transitions, err := c.transitions(key)
+	if err != nil {
+		return err
+	}
+	var transitionID string
+	for _, transition := range transitions {
+		category := transition.To.StatusCategory.Key
+		if category == StatusCategoryToDo {
+			transitionID = transition.ID
+			break
+		}
+		if category == StatusCategoryInProgress && len(transitionID) == 0 {
+			transitionID = transition.ID
+		}
+	}
+	if len(transitionID) == 0 {
+		return fmt.Errorf("no transition to an open status available for issue %s", key)
+	}
+	return c.transition(key, transitionID)
+}
+
+// transitions will get the transitions available for an issue.
+func (c *Client) transitions(key string) ([]transition, error) {
+	var response struct {
+		Transitions []transition `json:"transitions"`
+	}
+	path := fmt.Sprintf("/rest/api/3/issue/%s/transitions", key)
+	if err := c.do(http.MethodGet, path, nil, &response); err != nil {
+		return nil, fmt.Errorf("unable to get transitions of issue %s: %w", key, err)
+	}
+	return response.Transitions, nil
+}
+
+// transition will apply a transition to an issue.
+func (c *Client) transition(key string, transitionID string) error {
+	path := fmt.Sprintf("/rest/api/3/issue/%s/transitions", key)
+	request := map[string]any{
+		"transition": map[string]string{"id": transitionID},
+	}
+	if err := c.do(http.MethodPost, path, request, nil); err != nil {
+		return fmt.Errorf("unable to transition issue %s: %w", key, err)
+	}
+	return nil
+}
+
+// AddLabels will add labels to an issue.
+func (c *Client) AddLabels(key string, labels ...string) error {
+	operations := make([]map[string]string, 0, len(labels))
+	for _, label := range labels {
+		operations = append(operations, map[string]string{"add": label})
+	}
+	request := map[string]any{
+		"update": map[string]any{
+			"labels": operations,
+		},
+	}
+	if err := c.do(http.MethodPut, fmt.Sprintf("/rest/api/3/issue/%s", key), request, nil); err != nil {
+		return fmt.Errorf("unable to add labels to issue %s: %w", key, err)
+	}
+	return nil
+}
+
+// AssignIssue will assign an issue to the Jira user with the given email
+// address.
+func (c *Client) AssignIssue(key string, email string) error {
+	var users []struct {
+		AccountID string `json:"accountId"`
+	}
+	query := url.Values{"query": []string{email}}
+	if err := c.do(http.MethodGet, "/rest/api/3/user/search?"+query.Encode(), nil, &users); err != nil {
+		return fmt.Errorf("unable to search for user %s: %w", email, err)
+	}
+	if len(users) == 0 {
+		return fmt.Errorf("no jira user found for %s", email)
+	}
+
+	request := map[string]string{
+		"accountId": users[0].AccountID,
+	}
+	if err := c.do(http.MethodPut, fmt.Sprintf("/rest/api/3/issue/%s/assignee", key), request, nil); err != nil {
+		return fmt.Errorf("unable to assign issue %s: %w", key, err)
+	}
+	return nil
+}
+
+// SearchIssues will search for the issues matching a JQL query; references to
+// issues that do not exist are ignored instead of failing the query.
+func (c *Client) SearchIssues(jql string) ([]Issue, error) {
+	request := map[string]any{
+		"jql":           jql,
+		"fields":        []string{"summary", "status"},
+		"maxResults":    maxSearchResults,
+		"validateQuery": "warn",
+	}
+	var response struct {
+		Issues []struct {
+			Key    string `json:"key"`
+			Fields struct {
+				Summary string `json:"summary"`
+				Status  struct {
+					Name string `json:"name"`
+				} `json:"status"`
+			} `json:"fields"`
+		} `json:"issues"`
+	}
+	if err := c.do(http.MethodPost, "/rest/api/3/search", request, &response); err != nil {
+		return nil, fmt.Errorf("unable to search issues: %w", err)
+	}
+	issues := make([]Issue, 0, len(response.Issues))
+	for _, issue := range response.Issues {
+		issues = append(issues, Issue{
+			Key:     issue.Key,
+			URL:     c.issueURL(issue.Key),
+			Summary: issue.Fields.Summary,
+			Status:  issue.Fields.Status.Name,
+		})
+	}
+	return issues, nil
+}
+
+// FindIssues will get the issues with the given keys; keys of issues that do
+// not exist are ignored.
+func (c *Client) FindIssues(keys ...string) ([]Issue, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	quoted := make([]string, 0, len(keys))
+	for _, key := range keys {
+		quoted = append(quoted, quote(key))
+	}
+	return c.SearchIssues(fmt.Sprintf("key in (%s)", strings.Join(quoted, ", ")))
+}
+
+// FindOpenIssue will find an unresolved issue of the configured project that
+// has the given label or whose text contains the given phrase.
+func (c *Client) FindOpenIssue(label string, phrase string) (Issue, bool, error) {
+	issues, err := c.SearchIssues(fmt.Sprintf("project = %s AND statusCategory != Done AND (labels = %s OR text ~ %s) "+
+		"ORDER BY created ASC", quote(c.project), quote(label), quote(quote(phrase))))
+	if err != nil {
+		return Issue{}, false, err
+	}
+	if len(issues) == 0 {
+		return Issue{}, false, nil
+	}
+	return issues[0], true, nil
+}
+
+// LinkIssues will link an issue to a related issue with a "relates to" link.
+func (c *Client) LinkIssues(key string, relatedKey string) error {
+	request := map[string]any{
+		"type":         map[string]string{"name": relatesLinkType},
+		"inwardIssue":  map[string]string{"key": key},
+		"outwardIssue": map[string]string{"key": relatedKey},
+	}
+	if err := c.do(http.MethodPost, "/rest/api/3/issueLink", request, nil); err != nil {
+		return fmt.Errorf("unable to link issue %s to %s: %w", key, relatedKey, err)
+	}
+	return nil
+}
+
+// quote will quote a JQL string value.
+func quote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+// issueURL will get the browsable URL of an issue.
+func (c *Client) issueURL(key string) string {
+	return fmt.Sprintf("%s/browse/%s", c.baseURL.String(), key)
+}
+
+// do will perform a request against Jira's API encoding the request body and
+// decoding the response body as JSON when they are not nil.
+func (c *Client) do(method string, path string, requestBody any, responseBody any) error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	var body io.Reader
+	if requestBody != nil {
+		data, err := json.Marshal(requestBody)
+		if err != nil {
+			return fmt.Errorf("unable to encode request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL.String()+path, body)
+	if err != nil {
+		return fmt.Errorf("unable to create request: %w", err)
+	}
+	req.SetBasicAuth(c.username, c.token)
+	req.Header.Set("Accept", "application/json")
+	if requestBody != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to perform request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		message, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("unexpected status code %d: %s", res.StatusCode, strings.TrimSpace(string(message)))
+	}
+	if responseBody == nil {
+		return nil
+	}
+	if err := json.NewDecoder(res.Body).Decode(responseBody); err != nil {
+		return fmt.Errorf("unable to decode response: %w", err)
+	}
+	return nil
+}
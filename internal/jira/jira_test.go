@@ -0,0 +1,360 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package jira
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/kong/koko-slack-bot/internal/adf"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("Jira", func() {
+	var logger *zap.Logger
+
+	BeforeEach(func() {
+		var err error
+		logger, err = zap.NewDevelopment()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Describe("creating a new Jira instance", Label("jira-options"), func() {
+		When("the options are valid", func() {
+			It("a new Jira client instance will be instantiated", func() {
+				c, err := NewClient(Options{
+					URL:      "https://konghq.atlassian.net",
+					Username: "koko@konghq.com",
+					Token:    "token",
+					Project:  "KOKO",
+					Logger:   logger,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(c).NotTo(BeNil())
+				Expect(c.issueType).Should(Equal("Task"))
+			})
+		})
+
+		When("the options are invalid", func() {
+			DescribeTable("a new Jira client instance will not be instantiated",
+				func(opts Options, expectedError string) {
+					c, err := NewClient(opts)
+					Expect(err).To(HaveOccurred())
+					Expect(err).Should(MatchError(expectedError))
+					Expect(c).To(BeNil())
+				},
+				Entry("and the URL is missing", Options{}, "jira URL is not set"),
+				Entry("and the username is missing", Options{
+					URL: "https://konghq.atlassian.net",
+				}, "jira username is not set"),
+				Entry("and the token is missing", Options{
+					URL:      "https://konghq.atlassian.net",
+					Username: "koko@konghq.com",
+				}, "jira token is not set"),
+				Entry("and the project is missing", Options{
+					URL:      "https://konghq.atlassian.net",
+					Username: "koko@konghq.com",
+					Token:    "token",
+				}, "jira project is not set"),
+				Entry("and the logger is missing", Options{
+					URL:      "https://konghq.atlassian.net",
+					Username: "koko@konghq.com",
+					Token:    "token",
+					Project:  "KOKO",
+				}, "logger is not set"),
+			)
+		})
+	})
+
+	Describe("managing issues", Label("jira-issues"), func() {
+		var client *Client
+		var server *httptest.Server
+		var mux *http.ServeMux
+
+		BeforeEach(func() {
+			mux = http.NewServeMux()
+			server = httptest.NewServer(mux)
+			var err error
+			client, err = NewClient(Options{
+				URL:      server.URL,
+				Username: "koko@konghq.com",
+				Token:    "token",
+				Project:  "KOKO",
+				Logger:   logger,
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("the markdown description of an issue will be converted", func() {
+			mux.HandleFunc("/rest/api/3/issue", func(w http.ResponseWriter, r *http.Request) {
+				var request struct {
+					Fields struct {
+						Description adf.Node `json:"description"`
+					} `json:"fields"`
+				}
+				Expect(json.NewDecoder(r.Body).Decode(&request)).To(Succeed())
+				Expect(request.Fields.Description.Content).To(HaveLen(2))
+				Expect(request.Fields.Description.Content[0].Type).Should(Equal("heading"))
+				Expect(request.Fields.Description.Content[1].Type).Should(Equal("taskList"))
+
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte(`{"id": "10000", "key": "KOKO-1"}`))
+			})
+
+			_, err := client.CreateIssue(NewIssue{
+				Summary:     "summary",
+				Description: "### Checklist\n\n- [x] The Pull Request has tests\n- [ ] A changelog file has been created",
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("an issue will be created in the configured project", func() {
+			mux.HandleFunc("/rest/api/3/issue", func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.Method).Should(Equal(http.MethodPost))
+				username, password, ok := r.BasicAuth()
+				Expect(ok).To(BeTrue())
+				Expect(username).Should(Equal("koko@konghq.com"))
+				Expect(password).Should(Equal("token"))
+
+				var request struct {
+					Fields struct {
+						Project     map[string]string `json:"project"`
+						Summary     string            `json:"summary"`
+						Labels      []string          `json:"labels"`
+						Priority    map[string]string `json:"priority"`
+						Description map[string]any    `json:"description"`
+					} `json:"fields"`
+				}
+				Expect(json.NewDecoder(r.Body).Decode(&request)).To(Succeed())
+				Expect(request.Fields.Project["key"]).Should(Equal("KOKO"))
+				Expect(request.Fields.Summary).Should(Equal("summary"))
+				Expect(request.Fields.Labels).Should(Equal([]string{"gateway-schema-change"}))
+				Expect(request.Fields.Priority["name"]).Should(Equal("High"))
+				Expect(request.Fields.Description["type"]).Should(Equal("doc"))
+				Expect(request.Fields.Description["content"]).To(HaveLen(2))
+
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte(`{"id": "10000", "key": "KOKO-1"}`))
+			})
+
+			issue, err := client.CreateIssue(NewIssue{
+				Summary:     "summary",
+				Description: "first paragraph\nsecond line\n\nsecond paragraph",
+				Labels:      []string{"gateway-schema-change"},
+				Priority:    "High",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(issue).Should(Equal(Issue{
+				Key: "KOKO-1",
+				URL: server.URL + "/browse/KOKO-1",
+			}))
+		})
+
+		It("the components and additional fields of an issue will be set", func() {
+			mux.HandleFunc("/rest/api/3/issue", func(w http.ResponseWriter, r *http.Request) {
+				var request struct {
+					Fields map[string]any `json:"fields"`
+				}
+				Expect(json.NewDecoder(r.Body).Decode(&request)).To(Succeed())
+				Expect(request.Fields).Should(HaveKeyWithValue("summary", "summary"))
+				Expect(request.Fields).Should(HaveKeyWithValue("customfield_10010", "kong/kong#11234"))
+				Expect(request.Fields).Should(HaveKeyWithValue("components", []any{map[string]any{"name": "dp-compat"}}))
+
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte(`{"id": "10000", "key": "KOKO-1"}`))
+			})
+
+			_, err := client.CreateIssue(NewIssue{
+				Summary:    "summary",
+				Components: []string{"dp-compat"},
+				Fields: map[string]any{
+					"customfield_10010": "kong/kong#11234",
+					"summary":           "ignored",
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("an issue will be assigned to the user with the given email", func() {
+			mux.HandleFunc("/rest/api/3/user/search", func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.URL.Query().Get("query")).Should(Equal("engineer@konghq.com"))
+				_, _ = w.Write([]byte(`[{"accountId": "account-id"}]`))
+			})
+			mux.HandleFunc("/rest/api/3/issue/KOKO-1/assignee", func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.Method).Should(Equal(http.MethodPut))
+				var request map[string]string
+				Expect(json.NewDecoder(r.Body).Decode(&request)).To(Succeed())
+				Expect(request["accountId"]).Should(Equal("account-id"))
+				w.WriteHeader(http.StatusNoContent)
+			})
+
+			Expect(client.AssignIssue("KOKO-1", "engineer@konghq.com")).To(Succeed())
+		})
+
+		It("an open issue of the project will be found by label or phrase", func() {
+			mux.HandleFunc("/rest/api/3/search", func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.Method).Should(Equal(http.MethodPost))
+				var request map[string]any
+				Expect(json.NewDecoder(r.Body).Decode(&request)).To(Succeed())
+				Expect(request["jql"]).Should(Equal(`project = "KOKO" AND statusCategory != Done AND ` +
+					`(labels = "gateway-pr-kong-kong-11234" OR text ~ "\"https://github.com/kong/kong/pull/11234\"") ` +
+					`ORDER BY created ASC`))
+				Expect(request["validateQuery"]).Should(Equal("warn"))
+				_, _ = w.Write([]byte(`{"issues": [
+					{"key": "KOKO-1", "fields": {"summary": "Gateway schema change", "status": {"name": "In Progress"}}}
+				]}`))
+			})
+
+			issue, ok, err := client.FindOpenIssue("gateway-pr-kong-kong-11234", "https://github.com/kong/kong/pull/11234")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(issue).Should(Equal(Issue{
+				Key:     "KOKO-1",
+				URL:     server.URL + "/browse/KOKO-1",
+				Summary: "Gateway schema change",
+				Status:  "In Progress",
+			}))
+		})
+
+		It("issues will be found by key", func() {
+			mux.HandleFunc("/rest/api/3/search", func(w http.ResponseWriter, r *http.Request) {
+				var request map[string]any
+				Expect(json.NewDecoder(r.Body).Decode(&request)).To(Succeed())
+				Expect(request["jql"]).Should(Equal(`key in ("KAG-1234", "FTI-123")`))
+				_, _ = w.Write([]byte(`{"issues": [{"key": "KAG-1234", "fields": {"summary": "Sync rate"}}]}`))
+			})
+
+			issues, err := client.FindIssues("KAG-1234", "FTI-123")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(issues).To(HaveLen(1))
+			Expect(issues[0].Key).Should(Equal("KAG-1234"))
+		})
+
+		It("an issue will be linked to a related issue", func() {
+			mux.HandleFunc("/rest/api/3/issueLink", func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.Method).Should(Equal(http.MethodPost))
+				var request struct {
+					Type         map[string]string `json:"type"`
+					InwardIssue  map[string]string `json:"inwardIssue"`
+					OutwardIssue map[string]string `json:"outwardIssue"`
+				}
+				Expect(json.NewDecoder(r.Body).Decode(&request)).To(Succeed())
+				Expect(request.Type["name"]).Should(Equal("Relates"))
+				Expect(request.InwardIssue["key"]).Should(Equal("KOKO-1"))
+				Expect(request.OutwardIssue["key"]).Should(Equal("KAG-1234"))
+				w.WriteHeader(http.StatusCreated)
+			})
+
+			Expect(client.LinkIssues("KOKO-1", "KAG-1234")).To(Succeed())
+		})
+
+		It("the summary and description of an issue will be updated", func() {
+			mux.HandleFunc("/rest/api/3/issue/KOKO-1", func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.Method).Should(Equal(http.MethodPut))
+				var request struct {
+					Fields map[string]any `json:"fields"`
+				}
+				Expect(json.NewDecoder(r.Body).Decode(&request)).To(Succeed())
+				Expect(request.Fields).Should(HaveKeyWithValue("summary", "new summary"))
+				Expect(request.Fields).ShouldNot(HaveKey("description"))
+				w.WriteHeader(http.StatusNoContent)
+			})
+
+			Expect(client.UpdateIssue("KOKO-1", "new summary", "")).To(Succeed())
+		})
+
+		It("an issue will be closed with the preferred done transition", func() {
+			mux.HandleFunc("/rest/api/3/issue/KOKO-1/transitions", func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet {
+					_, _ = w.Write([]byte(`{"transitions": [
+						{"id": "11", "name": "Start", "to": {"name": "In Progress", "statusCategory": {"key": "indeterminate"}}},
+						{"id": "21", "name": "Done", "to": {"name": "Done", "statusCategory": {"key": "done"}}},
+						{"id": "31", "name": "Won't Do", "to": {"name": "Won't Do", "statusCategory": {"key": "done"}}}
+					]}`))
+					return
+				}
+				var request struct {
+					Transition map[string]string `json:"transition"`
+				}
+				Expect(json.NewDecoder(r.Body).Decode(&request)).To(Succeed())
+				Expect(request.Transition["id"]).Should(Equal("31"))
+				w.WriteHeader(http.StatusNoContent)
+			})
+
+			Expect(client.CloseIssue("KOKO-1", "Won't Fix", "Won't Do")).To(Succeed())
+		})
+
+		It("an error will occur when an issue cannot be closed", func() {
+			mux.HandleFunc("/rest/api/3/issue/KOKO-1/transitions", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"transitions": [
+					{"id": "11", "name": "Start", "to": {"name": "In Progress", "statusCategory": {"key": "indeterminate"}}}
+				]}`))
+			})
+
+			err := client.CloseIssue("KOKO-1")
+			Expect(err).Should(MatchError("no transition to a done status available for issue KOKO-1"))
+		})
+
+		It("an issue will be reopened with a to do transition", func() {
+			mux.HandleFunc("/rest/api/3/issue/KOKO-1/transitions", func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet {
+					_, _ = w.Write([]byte(`{"transitions": [
+						{"id": "11", "name": "Start", "to": {"name": "In Progress", "statusCategory": {"key": "indeterminate"}}},
+						{"id": "41", "name": "Reopen", "to": {"name": "To Do", "statusCategory": {"key": "new"}}}
+					]}`))
+					return
+				}
+				var request struct {
+					Transition map[string]string `json:"transition"`
+				}
+				Expect(json.NewDecoder(r.Body).Decode(&request)).To(Succeed())
+				Expect(request.Transition["id"]).Should(Equal("41"))
+				w.WriteHeader(http.StatusNoContent)
+			})
+
+			Expect(client.ReopenIssue("KOKO-1")).To(Succeed())
+		})
+
+		It("an error will occur when an issue cannot be reopened", func() {
+			mux.HandleFunc("/rest/api/3/issue/KOKO-1/transitions", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"transitions": [
+					{"id": "21", "name": "Done", "to": {"name": "Done", "statusCategory": {"key": "done"}}}
+				]}`))
+			})
+
+			err := client.ReopenIssue("KOKO-1")
+			Expect(err).Should(MatchError("no transition to an open status available for issue KOKO-1"))
+		})
+
+		It("an error will occur when the request fails", func() {
+			mux.HandleFunc("/rest/api/3/issue/KOKO-1/comment", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"errorMessages": ["Issue does not exist"]}`))
+			})
+
+			err := client.AddComment("KOKO-1", "comment")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).Should(ContainSubstring("unexpected status code 404"))
+		})
+	})
+})
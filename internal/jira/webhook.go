@@ -0,0 +1,150 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package jira
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// StatusCategoryDone represents the key of the status category of
+	// resolved issues
+	StatusCategoryDone = "done"
+	// StatusCategoryToDo represents the key of the status category of issues
+	// whose work has not started
+	StatusCategoryToDo = "new"
+	// StatusCategoryInProgress represents the key of the status category of
+	// issues whose work is ongoing
+	StatusCategoryInProgress = "indeterminate"
+	// signaturePrefix represents the algorithm prefix of webhook signatures
+	signaturePrefix = "sha256="
+)
+
+// IssueEvent represents the state of an issue received from a webhook.
+type IssueEvent struct {
+	// Event represents the webhook event; e.g. jira:issue_updated
+	Event string
+	// Key represents the key of the issue
+	Key string
+	// Status represents the name of the status of the issue
+	Status string
+	// StatusCategory represents the key of the status category of the issue;
+	// new, indeterminate, or done
+	StatusCategory string
+	// Assignee represents the display name of the assignee of the issue
+	Assignee string
+	// Resolution represents the name of the resolution of the issue; empty
+	// when unresolved
+	Resolution string
+	// Changes represents the fields changed by the event
+	Changes []FieldChange
+}
+
+// FieldChange represents the change of a field of an issue.
+type FieldChange struct {
+	// Field represents the name of the field
+	Field string
+	// From represents the previous value of the field
+	From string
+	// To represents the new value of the field
+	To string
+}
+
+// webhookPayload represents the payload of an issue webhook.
+type webhookPayload struct {
+	// WebhookEvent represents the webhook event
+	WebhookEvent string `json:"webhookEvent"`
+	// Issue represents the issue in its state after the event
+	Issue *struct {
+		Key    string `json:"key"`
+		Fields struct {
+			Status *struct {
+				Name           string `json:"name"`
+				StatusCategory struct {
+					Key string `json:"key"`
+				} `json:"statusCategory"`
+			} `json:"status"`
+			Assignee *struct {
+				DisplayName string `json:"displayName"`
+			} `json:"assignee"`
+			Resolution *struct {
+				Name string `json:"name"`
+			} `json:"resolution"`
+		} `json:"fields"`
+	} `json:"issue"`
+	// Changelog represents the fields changed by the event
+	Changelog *struct {
+		Items []struct {
+			Field      string `json:"field"`
+			FromString string `json:"fromString"`
+			ToString   string `json:"toString"`
+		} `json:"items"`
+	} `json:"changelog"`
+}
+
+// ParseWebhook will parse the payload of an issue webhook.
+func ParseWebhook(body []byte) (IssueEvent, error) {
+	var payload webhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return IssueEvent{}, fmt.Errorf("unable to decode webhook: %w", err)
+	}
+	if payload.Issue == nil || len(payload.Issue.Key) == 0 {
+		return IssueEvent{}, errors.New("webhook does not contain an issue")
+	}
+
+	event := IssueEvent{
+		Event: payload.WebhookEvent,
+		Key:   payload.Issue.Key,
+	}
+	if status := payload.Issue.Fields.Status; status != nil {
+		event.Status = status.Name
+		event.StatusCategory = status.StatusCategory.Key
+	}
+	if assignee := payload.Issue.Fields.Assignee; assignee != nil {
+		event.Assignee = assignee.DisplayName
+	}
+	if resolution := payload.Issue.Fields.Resolution; resolution != nil {
+		event.Resolution = resolution.Name
+	}
+	if payload.Changelog != nil {
+		for _, item := range payload.Changelog.Items {
+			event.Changes = append(event.Changes, FieldChange{
+				Field: item.Field,
+				From:  item.FromString,
+				To:    item.ToString,
+			})
+		}
+	}
+	return event, nil
+}
+
+// VerifySignature will verify the HMAC SHA-256 signature of a webhook payload
+// sent in the X-Hub-Signature header; e.g. sha256=<hex digest>.
+func VerifySignature(body []byte, signature string, secret string) bool {
+	digest, err := hex.DecodeString(strings.TrimPrefix(signature, signaturePrefix))
+	if err != nil || !strings.HasPrefix(signature, signaturePrefix) {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(digest, mac.Sum(nil))
+}
@@ -0,0 +1,88 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package jira
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("webhook", Label("jira-webhook"), func() {
+	const payload = `{
+		"timestamp": 1684108800000,
+		"webhookEvent": "jira:issue_updated",
+		"issue_event_type_name": "issue_generic",
+		"issue": {
+			"key": "KOKO-1",
+			"fields": {
+				"status": {"name": "Done", "statusCategory": {"key": "done", "name": "Done"}},
+				"assignee": {"displayName": "Koko Engineer"},
+				"resolution": {"name": "Fixed"}
+			}
+		},
+		"changelog": {
+			"items": [
+				{"field": "resolution", "fromString": null, "toString": "Fixed"},
+				{"field": "status", "fromString": "In Progress", "toString": "Done"}
+			]
+		}
+	}`
+
+	It("an issue event will be parsed", func() {
+		event, err := ParseWebhook([]byte(payload))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(event).Should(Equal(IssueEvent{
+			Event:          "jira:issue_updated",
+			Key:            "KOKO-1",
+			Status:         "Done",
+			StatusCategory: StatusCategoryDone,
+			Assignee:       "Koko Engineer",
+			Resolution:     "Fixed",
+			Changes: []FieldChange{
+				{Field: "resolution", To: "Fixed"},
+				{Field: "status", From: "In Progress", To: "Done"},
+			},
+		}))
+	})
+
+	It("an unassigned and unresolved issue will be parsed", func() {
+		event, err := ParseWebhook([]byte(`{
+			"webhookEvent": "jira:issue_updated",
+			"issue": {"key": "KOKO-1", "fields": {"assignee": null, "resolution": null}}
+		}`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(event.Assignee).Should(BeEmpty())
+		Expect(event.Resolution).Should(BeEmpty())
+	})
+
+	It("a payload without an issue will error", func() {
+		_, err := ParseWebhook([]byte(`{"webhookEvent": "comment_created"}`))
+		Expect(err).Should(MatchError("webhook does not contain an issue"))
+	})
+
+	It("the signature of a payload will be verified", func() {
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte(payload))
+		signature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+		Expect(VerifySignature([]byte(payload), signature, "secret")).To(BeTrue())
+		Expect(VerifySignature([]byte(payload), signature, "other")).To(BeFalse())
+		Expect(VerifySignature([]byte(payload), "sha1=abc", "secret")).To(BeFalse())
+	})
+})
@@ -0,0 +1,690 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/kong/koko-slack-bot/internal/lua"
+	"github.com/kong/koko-slack-bot/internal/schema"
+)
+
+// Draft represents the JSON Schema dialect of the converted schemas.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema represents a JSON Schema draft 2020-12 document or subschema. Only the
+// keywords needed to express gateway schemas are represented.
+type Schema struct {
+	// Schema represents the dialect of the document; only set on the root
+	Schema string `json:"$schema,omitempty"`
+	// Comment represents the notes about the parts of the gateway schema that
+	// could not be expressed
+	Comment string `json:"$comment,omitempty"`
+	// Title represents the name of the gateway schema; only set on the root
+	Title string `json:"title,omitempty"`
+	// Description represents the description of the field
+	Description string `json:"description,omitempty"`
+	// Type represents the JSON type of the value; empty for any value
+	Type string `json:"type,omitempty"`
+	// Nullable represents whether the value may also be null; encoded as a
+	// type array
+	Nullable bool `json:"-"`
+	// Format represents the semantic format of a string; e.g. uuid
+	Format string `json:"format,omitempty"`
+	// Default represents the default value
+	Default any `json:"default,omitempty"`
+	// Const represents the only allowed value
+	Const any `json:"const,omitempty"`
+	// Enum represents the allowed values
+	Enum []any `json:"enum,omitempty"`
+	// Minimum represents the inclusive lower bound of a number
+	Minimum *float64 `json:"minimum,omitempty"`
+	// Maximum represents the inclusive upper bound of a number
+	Maximum *float64 `json:"maximum,omitempty"`
+	// ExclusiveMinimum represents the exclusive lower bound of a number
+	ExclusiveMinimum *float64 `json:"exclusiveMinimum,omitempty"`
+	// MinLength represents the minimum length of a string
+	MinLength *int `json:"minLength,omitempty"`
+	// MaxLength represents the maximum length of a string
+	MaxLength *int `json:"maxLength,omitempty"`
+	// Pattern represents the regular expression a string must match
+	Pattern string `json:"pattern,omitempty"`
+	// Items represents the schema of the elements of an array
+	Items *Schema `json:"items,omitempty"`
+	// MinItems represents the minimum number of elements of an array
+	MinItems *int `json:"minItems,omitempty"`
+	// MaxItems represents the maximum number of elements of an array
+	MaxItems *int `json:"maxItems,omitempty"`
+	// UniqueItems represents whether the elements of an array must be unique
+	UniqueItems bool `json:"uniqueItems,omitempty"`
+	// Contains represents the schema at least one element must match
+	Contains *Schema `json:"contains,omitempty"`
+	// Properties represents the schemas of the properties of an object
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	// PropertyNames represents the schema of the keys of an object
+	PropertyNames *Schema `json:"propertyNames,omitempty"`
+	// AdditionalProperties represents the schema of the properties not listed
+	// in properties; either a schema or false
+	AdditionalProperties any `json:"additionalProperties,omitempty"`
+	// MinProperties represents the minimum number of properties of an object
+	MinProperties *int `json:"minProperties,omitempty"`
+	// MaxProperties represents the maximum number of properties of an object
+	MaxProperties *int `json:"maxProperties,omitempty"`
+	// Required represents the properties that must be present
+	Required []string `json:"required,omitempty"`
+	// AllOf represents the schemas that must all match
+	AllOf []*Schema `json:"allOf,omitempty"`
+	// AnyOf represents the schemas of which at least one must match
+	AnyOf []*Schema `json:"anyOf,omitempty"`
+	// OneOf represents the schemas of which exactly one must match
+	OneOf []*Schema `json:"oneOf,omitempty"`
+	// Not represents the schema that must not match
+	Not *Schema `json:"not,omitempty"`
+	// If represents the condition of a conditional constraint
+	If *Schema `json:"if,omitempty"`
+	// Then represents the schema that must match when the condition matches
+	Then *Schema `json:"then,omitempty"`
+}
+
+// MarshalJSON will encode a schema; the type of a nullable schema is encoded
+// along with null.
+func (s Schema) MarshalJSON() ([]byte, error) {
+	// plain has no methods, so encoding it does not recurse
+	type plain Schema
+	if !s.Nullable || len(s.Type) == 0 || s.Type == "null" {
+		return json.Marshal(plain(s))
+	}
+	return json.Marshal(struct {
+		plain
+		Type []string `json:"type"`
+	}{
+		plain: plain(s),
+		Type:  []string{s.Type, "null"},
+	})
+}
+
+// typedefs represents the JSON schemas of the common gateway typedefs; other
+// typedefs are converted to any value.
+var typedefs = map[string]func() *Schema{
+	"typedefs.port": func() *Schema {
+		return &Schema{Type: "integer", Minimum: float(0), Maximum: float(65535)}
+	},
+	"typedefs.timeout": func() *Schema {
+		return &Schema{Type: "integer", Minimum: float(0), Maximum: float(math.Pow(2, 31) - 2)}
+	},
+	"typedefs.uuid": func() *Schema {
+		return &Schema{Type: "string", Format: "uuid"}
+	},
+	"typedefs.url": func() *Schema {
+		return &Schema{Type: "string", Format: "uri"}
+	},
+	"typedefs.path": func() *Schema {
+		return &Schema{Type: "string", Pattern: "^/"}
+	},
+	"typedefs.host":        stringTypedef,
+	"typedefs.ip":          stringTypedef,
+	"typedefs.cidr":        stringTypedef,
+	"typedefs.ip_or_cidr":  stringTypedef,
+	"typedefs.name":        stringTypedef,
+	"typedefs.sni":         stringTypedef,
+	"typedefs.tag":         stringTypedef,
+	"typedefs.header_name": stringTypedef,
+	"typedefs.tags": func() *Schema {
+		return &Schema{Type: "array", Items: &Schema{Type: "string"}, UniqueItems: true}
+	},
+	"typedefs.protocols": func() *Schema {
+		return &Schema{Type: "array", Items: &Schema{Type: "string"}, UniqueItems: true}
+	},
+	"typedefs.protocols_http": func() *Schema {
+		return &Schema{
+			Type:        "array",
+			Items:       &Schema{Type: "string", Enum: []any{"http", "https"}},
+			UniqueItems: true,
+		}
+	},
+	"typedefs.consumer": foreign,
+	"typedefs.service":  foreign,
+	"typedefs.route":    foreign,
+	"typedefs.no_consumer": func() *Schema {
+		return &Schema{Type: "null"}
+	},
+	"typedefs.no_service": func() *Schema {
+		return &Schema{Type: "null"}
+	},
+	"typedefs.no_route": func() *Schema {
+		return &Schema{Type: "null"}
+	},
+}
+
+// stringTypedef will create the schema of a typedef validating a string with
+// Lua code.
+func stringTypedef() *Schema {
+	return &Schema{Type: "string"}
+}
+
+// Convert will convert the source of a gateway plugin or entity schema into a
+// JSON Schema draft 2020-12 document. The parts of the schema that cannot be
+// expressed are noted in the comments of the nearest subschema.
+func Convert(source string) (*Schema, error) {
+	value, err := lua.ParseReturn(source)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse schema: %w", err)
+	}
+	if value.Kind != lua.KindTable {
+		return nil, fmt.Errorf("unable to parse schema: expected a table but found %s", value.Source())
+	}
+	root := record(value.Table)
+	root.Schema = Draft
+	// The fields of plugin schemas are merged into the plugins entity, so the
+	// root accepts the properties it does not declare
+	root.AdditionalProperties = nil
+	if name, ok := value.Table.Get("name"); ok && name.Kind == lua.KindString {
+		root.Title = name.String
+	}
+	return root, nil
+}
+
+// record will convert the fields and entity checks of a record or of the root
+// of a schema into an object.
+func record(attributes *lua.Table) *Schema {
+	object := &Schema{
+		Type:                 "object",
+		Properties:           make(map[string]*Schema),
+		AdditionalProperties: false,
+	}
+	fields, _ := attributes.Get("fields")
+	if fields.Kind == lua.KindTable {
+		for _, entry := range fields.Table.Array {
+			if entry.Kind != lua.KindTable || len(entry.Table.Fields) != 1 ||
+				entry.Table.Fields[0].Key.Kind != lua.KindString {
+				continue
+			}
+			name := entry.Table.Fields[0].Key.String
+			property, required := field(entry.Table.Fields[0].Value)
+			object.Properties[name] = property
+			if required {
+				object.Required = append(object.Required, name)
+			} else if property.Default == nil {
+				// The gateway serializes the optional fields without defaults
+				// as null when they are not set
+				nullable(property)
+			}
+		}
+	}
+	if checks, ok := attributes.Get("entity_checks"); ok && checks.Kind == lua.KindTable {
+		for _, check := range checks.Table.Array {
+			entityCheck(object, check)
+		}
+	}
+	return object
+}
+
+// field will convert the definition of a field and determine if it must be
+// present. Typedefs are resolved when known, and the attributes given to a
+// called typedef are applied to it.
+func field(definition lua.Value) (*Schema, bool) {
+	var attributes *lua.Table
+	var s *Schema
+	switch definition.Kind {
+	case lua.KindTable:
+		attributes = definition.Table
+	case lua.KindCall:
+		s = reference(definition.String)
+		if len(definition.Arguments) > 0 && definition.Arguments[0].Kind == lua.KindTable {
+			attributes = definition.Arguments[0].Table
+		}
+	default:
+		s = reference(definition.Source())
+	}
+	if s == nil {
+		s = &Schema{}
+	}
+	if attributes == nil {
+		return s, false
+	}
+
+	if value, ok := attributes.Get("type"); ok && value.Kind == lua.KindString {
+		s = typed(value.String, attributes)
+	}
+	if value, ok := attributes.Get("description"); ok && value.Kind == lua.KindString {
+		s.Description = value.String
+	}
+	constrain(s, attributes)
+
+	var hasDefault bool
+	if value, ok := attributes.Get("default"); ok {
+		if d, ok := constant(value, s.Type); ok {
+			s.Default = d
+			hasDefault = true
+		} else {
+			note(s, "default "+value.Source())
+		}
+	}
+	// The gateway fills in the defaults of required fields, so only the
+	// required fields without defaults must be present
+	required, _ := attributes.Get("required")
+	return s, required.Kind == lua.KindBoolean && required.Boolean && !hasDefault
+}
+
+// nullable will allow a property schema to also accept null.
+func nullable(s *Schema) {
+	s.Nullable = true
+	if s.Const != nil {
+		s.Enum = []any{s.Const}
+		s.Const = nil
+	}
+	if s.Enum != nil {
+		s.Enum = append(s.Enum, nil)
+	}
+}
+
+// nonNull will require a property schema to reject null; constraints on the
+// presence of optional fields must not be satisfied by null values.
+func nonNull(s *Schema) *Schema {
+	null := &Schema{Type: "null"}
+	if s.Not == nil {
+		s.Not = null
+	} else {
+		s.AllOf = append(s.AllOf, &Schema{Not: null})
+	}
+	return s
+}
+
+// reference will create the schema of a field defined by a reference; local
+// variables are resolved when parsing, so only typedefs are expected and other
+// references accept any value and are noted.
+func reference(source string) *Schema {
+	if name := schema.TypedefName(source); strings.HasPrefix(name, "typedefs.") {
+		return typedef(name)
+	}
+	s := &Schema{}
+	note(s, "unresolved reference "+source)
+	return s
+}
+
+// typedef will create the schema of a known typedef; unknown typedefs accept
+// any value and are noted.
+func typedef(name string) *Schema {
+	if create, ok := typedefs[name]; ok {
+		return create()
+	}
+	s := &Schema{}
+	note(s, "typedef "+name)
+	return s
+}
+
+// typed will create the schema of a gateway type along with its nested
+// elements, keys, values, or fields.
+func typed(kind string, attributes *lua.Table) *Schema {
+	switch kind {
+	case "string", "number", "integer", "boolean":
+		return &Schema{Type: kind}
+	case "record":
+		return record(attributes)
+	case "array", "set":
+		s := &Schema{Type: "array", UniqueItems: kind == "set"}
+		if elements, ok := attributes.Get("elements"); ok {
+			s.Items, _ = field(elements)
+		}
+		return s
+	case "map":
+		s := &Schema{Type: "object"}
+		if keys, ok := attributes.Get("keys"); ok {
+			s.PropertyNames, _ = field(keys)
+			// Property names are strings regardless of the key type
+			s.PropertyNames.Type = ""
+		}
+		if values, ok := attributes.Get("values"); ok {
+			s.AdditionalProperties, _ = field(values)
+		}
+		return s
+	case "foreign":
+		return foreign()
+	default:
+		// json and function fields accept any value
+		s := &Schema{}
+		note(s, "type "+kind)
+		return s
+	}
+}
+
+// foreign will create the schema of a reference to another entity.
+func foreign() *Schema {
+	return &Schema{
+		Type:       "object",
+		Properties: map[string]*Schema{"id": {Type: "string", Format: "uuid"}},
+		Required:   []string{"id"},
+	}
+}
+
+// constrain will apply the validators of a field to its schema; validators
+// without equivalent are noted.
+func constrain(s *Schema, attributes *lua.Table) {
+	for _, attribute := range attributes.Fields {
+		if attribute.Key.Kind != lua.KindString {
+			continue
+		}
+		value := attribute.Value
+		switch attribute.Key.String {
+		case "one_of":
+			// Keyed tables have no enum equivalent
+			c, ok := constant(value, "array")
+			if values, isArray := c.([]any); ok && isArray {
+				s.Enum = values
+			} else {
+				note(s, "one_of "+value.Source())
+			}
+		case "eq":
+			if c, ok := constant(value, s.Type); ok {
+				s.Const = c
+			}
+		case "between":
+			if value.Kind == lua.KindTable && len(value.Table.Array) == 2 &&
+				value.Table.Array[0].Kind == lua.KindNumber && value.Table.Array[1].Kind == lua.KindNumber {
+				s.Minimum = float(value.Table.Array[0].Number)
+				s.Maximum = float(value.Table.Array[1].Number)
+			}
+		case "gt":
+			if value.Kind == lua.KindNumber {
+				s.ExclusiveMinimum = float(value.Number)
+			}
+		case "len_min", "len_max", "len_eq":
+			if value.IsInteger() {
+				length(s, attribute.Key.String, int(value.Number))
+			}
+		case "match":
+			if value.Kind == lua.KindString {
+				pattern(s, value.String)
+			}
+		case "not_match":
+			if value.Kind == lua.KindString {
+				not := &Schema{}
+				pattern(not, value.String)
+				if len(not.Pattern) > 0 {
+					s.Not = not
+				}
+			}
+		case "starts_with":
+			if value.Kind == lua.KindString {
+				s.Pattern = "^" + regexp.QuoteMeta(value.String)
+			}
+		case "contains":
+			if c, ok := constant(value, ""); ok {
+				s.Contains = &Schema{Const: c}
+			}
+		case "uuid":
+			if value.Kind == lua.KindBoolean && value.Boolean {
+				s.Format = "uuid"
+			}
+		case "match_all", "match_any", "match_none", "custom_validator", "mutually_exclusive_subsets":
+			note(s, attribute.Key.String+" "+value.Source())
+		}
+	}
+}
+
+// length will apply a length validator to the keyword matching the type of
+// the schema.
+func length(s *Schema, validator string, n int) {
+	var minimum, maximum **int
+	switch s.Type {
+	case "array":
+		minimum, maximum = &s.MinItems, &s.MaxItems
+	case "object":
+		minimum, maximum = &s.MinProperties, &s.MaxProperties
+	default:
+		minimum, maximum = &s.MinLength, &s.MaxLength
+	}
+	if validator != "len_max" {
+		*minimum = integer(n)
+	}
+	if validator != "len_min" {
+		*maximum = integer(n)
+	}
+}
+
+// pattern will apply a Lua pattern to a schema; patterns without equivalent
+// are noted.
+func pattern(s *Schema, luaPattern string) {
+	regex, err := translatePattern(luaPattern)
+	if err != nil {
+		note(s, err.Error())
+		return
+	}
+	s.Pattern = regex
+}
+
+// entityCheck will apply an entity check to the object it is declared on.
+// Checks are expressed on the object containing the fields they refer to;
+// checks referring to fields of different objects are noted.
+func entityCheck(object *Schema, check lua.Value) {
+	if check.Kind != lua.KindTable || len(check.Table.Fields) != 1 || check.Table.Fields[0].Key.Kind != lua.KindString {
+		return
+	}
+	name := check.Table.Fields[0].Key.String
+	arguments := check.Table.Fields[0].Value
+	switch name {
+	case "conditional":
+		conditional(object, arguments)
+		return
+	case "at_least_one_of", "only_one_of", "mutually_exclusive", "mutually_required":
+	default:
+		note(object, "entity check "+name)
+		return
+	}
+	if arguments.Kind != lua.KindTable {
+		note(object, "entity check "+name)
+		return
+	}
+	parent, names, ok := siblings(arguments.Table.Strings())
+	if !ok || len(names) < 2 {
+		note(object, "entity check "+name+" "+arguments.Source())
+		return
+	}
+	target := locate(object, parent)
+	if target == nil {
+		note(object, "entity check "+name+" "+arguments.Source())
+		return
+	}
+	switch name {
+	case "at_least_one_of":
+		target.AllOf = append(target.AllOf, &Schema{AnyOf: requiredEach(names)})
+	case "only_one_of":
+		target.AllOf = append(target.AllOf, &Schema{OneOf: requiredEach(names)})
+	case "mutually_exclusive":
+		for i := range names {
+			for j := i + 1; j < len(names); j++ {
+				target.AllOf = append(target.AllOf, &Schema{Not: present(names[i], names[j])})
+			}
+		}
+	case "mutually_required":
+		// dependentRequired would be satisfied by null values
+		for _, n := range names {
+			var others []string
+			for _, other := range names {
+				if other != n {
+					others = append(others, other)
+				}
+			}
+			target.AllOf = append(target.AllOf, &Schema{If: present(n), Then: present(others...)})
+		}
+	}
+}
+
+// conditional will apply a conditional entity check as an if-then constraint
+// on the object containing both of its fields.
+func conditional(object *Schema, arguments lua.Value) {
+	if arguments.Kind != lua.KindTable {
+		note(object, "entity check conditional")
+		return
+	}
+	ifField, _ := arguments.Table.Get("if_field")
+	thenField, _ := arguments.Table.Get("then_field")
+	ifMatch, _ := arguments.Table.Get("if_match")
+	thenMatch, _ := arguments.Table.Get("then_match")
+	if ifField.Kind != lua.KindString || thenField.Kind != lua.KindString ||
+		ifMatch.Kind != lua.KindTable || thenMatch.Kind != lua.KindTable {
+		note(object, "entity check conditional "+arguments.Source())
+		return
+	}
+	parent, _, ok := siblings([]string{ifField.String, thenField.String})
+	target := locate(object, parent)
+	if !ok || target == nil {
+		note(object, "entity check conditional "+arguments.Source())
+		return
+	}
+	prefix := len(parent)
+	if prefix > 0 {
+		prefix++
+	}
+	ifName := ifField.String[prefix:]
+	thenName := thenField.String[prefix:]
+
+	// The gateway skips the check when the value of the if field is null
+	condition := &Schema{}
+	constrain(condition, ifMatch.Table)
+	consequence := &Schema{}
+	constrain(consequence, thenMatch.Table)
+	then := &Schema{Properties: map[string]*Schema{thenName: consequence}}
+	if required, _ := thenMatch.Table.Get("required"); required.Kind == lua.KindBoolean && required.Boolean {
+		nonNull(consequence)
+		then.Required = []string{thenName}
+	}
+	target.AllOf = append(target.AllOf, &Schema{
+		If: &Schema{
+			Properties: map[string]*Schema{ifName: nonNull(condition)},
+			Required:   []string{ifName},
+		},
+		Then: then,
+	})
+}
+
+// siblings will get the dotted path of the record containing all the given
+// fields along with their names; fields of different records are not
+// siblings.
+func siblings(paths []string) (string, []string, bool) {
+	var parent string
+	names := make([]string, 0, len(paths))
+	for i, path := range paths {
+		p, name := "", path
+		if index := strings.LastIndex(path, "."); index >= 0 {
+			p, name = path[:index], path[index+1:]
+		}
+		if i > 0 && p != parent {
+			return "", nil, false
+		}
+		parent = p
+		names = append(names, name)
+	}
+	return parent, names, len(paths) > 0
+}
+
+// locate will find the object schema of a record from its dotted path.
+func locate(object *Schema, path string) *Schema {
+	if len(path) == 0 {
+		return object
+	}
+	for _, name := range strings.Split(path, ".") {
+		object = object.Properties[name]
+		if object == nil {
+			return nil
+		}
+	}
+	return object
+}
+
+// requiredEach will create a schema requiring each of the given properties to
+// be set.
+func requiredEach(names []string) []*Schema {
+	schemas := make([]*Schema, 0, len(names))
+	for _, name := range names {
+		schemas = append(schemas, present(name))
+	}
+	return schemas
+}
+
+// present will create a schema requiring all the given properties to be set;
+// null values are not set.
+func present(names ...string) *Schema {
+	s := &Schema{
+		Properties: make(map[string]*Schema, len(names)),
+		Required:   names,
+	}
+	for _, name := range names {
+		s.Properties[name] = nonNull(&Schema{})
+	}
+	return s
+}
+
+// constant will convert a constant Lua value into its JSON equivalent; an
+// empty table is an array or an object depending on the given type.
+func constant(value lua.Value, kind string) (any, bool) {
+	switch value.Kind {
+	case lua.KindBoolean:
+		return value.Boolean, true
+	case lua.KindNumber:
+		return value.Number, true
+	case lua.KindString:
+		return value.String, true
+	case lua.KindTable:
+		if len(value.Table.Fields) == 0 && (len(value.Table.Array) > 0 || kind != "object") {
+			values := make([]any, 0, len(value.Table.Array))
+			for _, element := range value.Table.Array {
+				c, ok := constant(element, "")
+				if !ok {
+					return nil, false
+				}
+				values = append(values, c)
+			}
+			return values, true
+		}
+		if len(value.Table.Array) > 0 {
+			return nil, false
+		}
+		object := make(map[string]any, len(value.Table.Fields))
+		for _, entry := range value.Table.Fields {
+			c, ok := constant(entry.Value, "")
+			if entry.Key.Kind != lua.KindString || !ok {
+				return nil, false
+			}
+			object[entry.Key.String] = c
+		}
+		return object, true
+	}
+	return nil, false
+}
+
+// note will add a note about a part of the gateway schema that could not be
+// expressed.
+func note(s *Schema, text string) {
+	if len(s.Comment) > 0 {
+		s.Comment += "; "
+	}
+	s.Comment += "not expressed: " + text
+}
+
+// float will get a pointer to a number.
+func float(n float64) *float64 {
+	return &n
+}
+
+// integer will get a pointer to an integer.
+func integer(n int) *int {
+	return &n
+}
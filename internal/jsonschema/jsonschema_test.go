@@ -0,0 +1,472 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package jsonschema
+
+import (
+	"encoding/json"
+	"math"
+	"regexp"
+	"unicode/utf8"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("jsonschema", Label("jsonschema"), func() {
+	const source = `
+local typedefs = require "kong.db.schema.typedefs"
+
+return {
+  name = "rate-limiting",
+  fields = {
+    { consumer = typedefs.no_consumer },
+    { protocols = typedefs.protocols_http },
+    { config = {
+        type = "record",
+        fields = {
+          { second = { type = "number", gt = 0 } },
+          { policy = { type = "string", default = "local", required = true, one_of = { "local", "redis" } } },
+          { redis_host = typedefs.host },
+          { redis_port = typedefs.port({ default = 6379 }) },
+          { redis_database = { type = "integer", between = { 0, 15 } } },
+          { header_name = { type = "string", len_min = 1, len_max = 64, match = "^[%w%-]+$" } },
+          { hide_headers = { type = "boolean", default = false } },
+          { scopes = { type = "set", elements = { type = "string" }, len_min = 1, required = true } },
+          { limits = { type = "array", default = {}, elements = {
+              type = "record",
+              fields = { { window = { type = "integer", required = true } } },
+            },
+          } },
+          { headers = { type = "map", keys = { type = "string" }, values = { type = "string", len_max = 8 } } },
+        },
+        entity_checks = {
+          { mutually_required = { "redis_host", "redis_port" } },
+        },
+      },
+    },
+  },
+  entity_checks = {
+    { at_least_one_of = { "config.second", "config.limits" } },
+    { conditional = {
+        if_field = "config.policy", if_match = { eq = "redis" },
+        then_field = "config.redis_host", then_match = { required = true },
+    } },
+    { custom_entity_check = { field_sources = { "config" }, fn = function() end } },
+  },
+}
+`
+
+	It("a plugin schema will be converted to JSON Schema", func() {
+		converted, err := Convert(source)
+		Expect(err).NotTo(HaveOccurred())
+		document, err := json.Marshal(converted)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(document).Should(MatchJSON(`{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "$comment": "not expressed: entity check custom_entity_check",
+  "title": "rate-limiting",
+  "type": "object",
+  "properties": {
+    "consumer": {"type": "null"},
+    "protocols": {"type": ["array", "null"], "items": {"type": "string", "enum": ["http", "https"]}, "uniqueItems": true},
+    "config": {
+      "type": ["object", "null"],
+      "additionalProperties": false,
+      "properties": {
+        "second": {"type": ["number", "null"], "exclusiveMinimum": 0},
+        "policy": {"type": "string", "default": "local", "enum": ["local", "redis"]},
+        "redis_host": {"type": ["string", "null"]},
+        "redis_port": {"type": "integer", "minimum": 0, "maximum": 65535, "default": 6379},
+        "redis_database": {"type": ["integer", "null"], "minimum": 0, "maximum": 15},
+        "header_name": {"type": ["string", "null"], "minLength": 1, "maxLength": 64, "pattern": "^[0-9A-Za-z\\-]+$"},
+        "hide_headers": {"type": "boolean", "default": false},
+        "scopes": {"type": "array", "items": {"type": "string"}, "uniqueItems": true, "minItems": 1},
+        "limits": {
+          "type": "array",
+          "default": [],
+          "items": {
+            "type": "object",
+            "additionalProperties": false,
+            "properties": {"window": {"type": "integer"}},
+            "required": ["window"]
+          }
+        },
+        "headers": {
+          "type": ["object", "null"],
+          "propertyNames": {},
+          "additionalProperties": {"type": "string", "maxLength": 8}
+        }
+      },
+      "required": ["scopes"],
+      "allOf": [
+        {
+          "if": {"properties": {"redis_host": {"not": {"type": "null"}}}, "required": ["redis_host"]},
+          "then": {"properties": {"redis_port": {"not": {"type": "null"}}}, "required": ["redis_port"]}
+        },
+        {
+          "if": {"properties": {"redis_port": {"not": {"type": "null"}}}, "required": ["redis_port"]},
+          "then": {"properties": {"redis_host": {"not": {"type": "null"}}}, "required": ["redis_host"]}
+        },
+        {"anyOf": [
+          {"properties": {"second": {"not": {"type": "null"}}}, "required": ["second"]},
+          {"properties": {"limits": {"not": {"type": "null"}}}, "required": ["limits"]}
+        ]},
+        {
+          "if": {"properties": {"policy": {"const": "redis", "not": {"type": "null"}}}, "required": ["policy"]},
+          "then": {"properties": {"redis_host": {"not": {"type": "null"}}}, "required": ["redis_host"]}
+        }
+      ]
+    }
+  }
+}`))
+	})
+
+	It("a configuration serialized by the gateway will be valid", func() {
+		converted, err := Convert(`
+local typedefs = require "kong.db.schema.typedefs"
+
+local ORDERED_PERIODS = { "second", "minute", "hour", "day", "month", "year"}
+
+local function validate_periods_order(config)
+  return true
+end
+
+local policy = { type = "string", default = "local", len_min = 0, one_of = { "local", "cluster", "redis" } }
+
+return {
+  name = "rate-limiting",
+  fields = {
+    { protocols = typedefs.protocols_http },
+    { config = {
+        type = "record",
+        fields = {
+          { second = { type = "number", gt = 0 } },
+          { minute = { type = "number", gt = 0 } },
+          { hour = { type = "number", gt = 0 } },
+          { day = { type = "number", gt = 0 } },
+          { month = { type = "number", gt = 0 } },
+          { year = { type = "number", gt = 0 } },
+          { limit_by = { type = "string", default = "consumer",
+              one_of = { "consumer", "credential", "ip", "service", "header", "path" } } },
+          { header_name = typedefs.header_name },
+          { path = typedefs.path },
+          { policy = policy },
+          { fault_tolerant = { type = "boolean", required = true, default = true } },
+          { redis_host = typedefs.host },
+          { redis_port = typedefs.port({ default = 6379 }) },
+          { redis_password = { type = "string", len_min = 0, referenceable = true } },
+          { redis_ssl = { type = "boolean", required = true, default = false } },
+          { redis_server_name = typedefs.sni },
+          { redis_timeout = { type = "number", default = 2000 } },
+          { redis_database = { type = "integer", default = 0 } },
+          { hide_client_headers = { type = "boolean", required = true, default = false } },
+          { error_code = { type = "number", default = 429, gt = 0 } },
+          { error_message = { type = "string", default = "API rate limit exceeded" } },
+        },
+        custom_validator = validate_periods_order,
+      },
+    },
+  },
+  entity_checks = {
+    { at_least_one_of = { "config.second", "config.minute", "config.hour", "config.day", "config.month", "config.year" } },
+    { conditional = {
+      if_field = "config.policy", if_match = { eq = "redis" },
+      then_field = "config.redis_host", then_match = { required = true },
+    } },
+    { conditional = {
+      if_field = "config.limit_by", if_match = { eq = "header" },
+      then_field = "config.header_name", then_match = { required = true },
+    } },
+  },
+}
+`)
+		Expect(err).NotTo(HaveOccurred())
+		encoded, err := json.Marshal(converted)
+		Expect(err).NotTo(HaveOccurred())
+		var document map[string]any
+		Expect(json.Unmarshal(encoded, &document)).To(Succeed())
+
+		plugin := func(config string) any {
+			var value any
+			Expect(json.Unmarshal([]byte(`{
+  "id": "8ba3f4c9-6d7b-4c52-b45e-9b4cbd2a2e4d",
+  "name": "rate-limiting",
+  "enabled": true,
+  "consumer": null,
+  "route": null,
+  "service": null,
+  "tags": null,
+  "protocols": ["http", "https"],
+  "config": `+config+`
+}`), &value)).To(Succeed())
+			return value
+		}
+		const config = `{
+  "second": null, "minute": 5, "hour": null, "day": null, "month": null, "year": null,
+  "limit_by": "consumer", "header_name": null, "path": null, "policy": "local",
+  "fault_tolerant": true, "redis_host": null, "redis_port": 6379, "redis_password": null,
+  "redis_ssl": false, "redis_server_name": null, "redis_timeout": 2000, "redis_database": 0,
+  "hide_client_headers": false, "error_code": 429, "error_message": "API rate limit exceeded"
+}`
+		Expect(valid(document, plugin(config))).To(BeTrue())
+
+		// null values do not satisfy the entity checks
+		var unset map[string]any
+		Expect(json.Unmarshal([]byte(config), &unset)).To(Succeed())
+		unset["minute"] = nil
+		Expect(valid(document, plugin(encode(unset)))).To(BeFalse())
+		unset["minute"] = 5.0
+		unset["policy"] = "redis"
+		Expect(valid(document, plugin(encode(unset)))).To(BeFalse())
+		unset["redis_host"] = "redis.example.com"
+		Expect(valid(document, plugin(encode(unset)))).To(BeTrue())
+		unset["error_code"] = nil
+		Expect(valid(document, plugin(encode(unset)))).To(BeFalse())
+	})
+
+	It("unknown typedefs and inexpressible validators will be noted", func() {
+		converted, err := Convert(`return {
+  name = "example",
+  fields = {
+    { key = typedefs.key },
+    { id = { type = "string", match = "%bxy" } },
+  },
+}`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(converted.Properties["key"].Comment).Should(Equal("not expressed: typedef typedefs.key"))
+		Expect(converted.Properties["id"].Comment).Should(ContainSubstring("unsupported pattern"))
+		Expect(converted.Properties["id"].Pattern).To(BeEmpty())
+	})
+
+	It("local variables will be resolved and other references will be noted", func() {
+		converted, err := Convert(`
+local policy = { type = "string", default = "local", one_of = { "local", "redis" } }
+
+return {
+  name = "example",
+  fields = {
+    { policy = policy },
+    { strategy = strategy },
+    { window = helpers.window({ required = true }) },
+  },
+}`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(converted.Properties["policy"].Type).Should(Equal("string"))
+		Expect(converted.Properties["policy"].Enum).Should(Equal([]any{"local", "redis"}))
+		Expect(converted.Properties["policy"].Comment).To(BeEmpty())
+		Expect(converted.Properties["strategy"].Comment).Should(Equal("not expressed: unresolved reference strategy"))
+		Expect(converted.Properties["window"].Comment).Should(Equal("not expressed: unresolved reference helpers.window"))
+		Expect(converted.Required).Should(Equal([]string{"window"}))
+	})
+
+	It("a keyed one_of table will be noted rather than enumerated", func() {
+		converted, err := Convert(`return {
+  name = "example",
+  fields = {
+    { mode = { type = "string", one_of = { a = "b" } } },
+  },
+}`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(converted.Properties["mode"].Enum).To(BeEmpty())
+		Expect(converted.Properties["mode"].Comment).Should(HavePrefix("not expressed: one_of"))
+	})
+
+	It("a schema that is not a table will not be converted", func() {
+		_, err := Convert(`return "schema"`)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Lua patterns will be translated to regular expressions", func() {
+		for pattern, regex := range map[string]string{
+			"^%d+%.%d+$": `^[0-9]+\.[0-9]+$`,
+			"^/[^%s]-$":  `^/[^\t-\r ]*?$`,
+			"%S+@%a+":    `[^\t-\r ]+@[A-Za-z]+`,
+			"a{2}|b":     `a\{2\}\|b`,
+			"^[]%-]$":    `^[\]\-]$`,
+			"([%x%-]+)":  `([0-9A-Fa-f\-]+)`,
+			"^-a":        `^\-a`,
+			"50%%":       `50\%`,
+		} {
+			translated, err := translatePattern(pattern)
+			Expect(err).NotTo(HaveOccurred(), "%s", pattern)
+			Expect(translated).Should(Equal(regex), "%s", pattern)
+		}
+		for _, pattern := range []string{"%f[%w]", "(a)%1", "()a", "[a", "a%"} {
+			_, err := translatePattern(pattern)
+			Expect(err).To(HaveOccurred(), "%s", pattern)
+		}
+	})
+})
+
+// encode will encode a value as JSON.
+func encode(value any) string {
+	encoded, err := json.Marshal(value)
+	Expect(err).NotTo(HaveOccurred())
+	return string(encoded)
+}
+
+// valid will validate a decoded JSON value against a decoded JSON schema
+// using the keywords produced by Convert.
+func valid(schema map[string]any, value any) bool {
+	if kind, ok := schema["type"]; ok {
+		kinds, isArray := kind.([]any)
+		if !isArray {
+			kinds = []any{kind}
+		}
+		var matched bool
+		for _, k := range kinds {
+			matched = matched || hasType(k.(string), value)
+		}
+		if !matched {
+			return false
+		}
+	}
+	if c, ok := schema["const"]; ok && !equal(c, value) {
+		return false
+	}
+	if values, ok := schema["enum"].([]any); ok {
+		var matched bool
+		for _, v := range values {
+			matched = matched || equal(v, value)
+		}
+		if !matched {
+			return false
+		}
+	}
+	switch v := value.(type) {
+	case float64:
+		if m, ok := schema["minimum"].(float64); ok && v < m {
+			return false
+		}
+		if m, ok := schema["maximum"].(float64); ok && v > m {
+			return false
+		}
+		if m, ok := schema["exclusiveMinimum"].(float64); ok && v <= m {
+			return false
+		}
+	case string:
+		if m, ok := schema["minLength"].(float64); ok && float64(utf8.RuneCountInString(v)) < m {
+			return false
+		}
+		if m, ok := schema["maxLength"].(float64); ok && float64(utf8.RuneCountInString(v)) > m {
+			return false
+		}
+		if p, ok := schema["pattern"].(string); ok && !regexp.MustCompile(p).MatchString(v) {
+			return false
+		}
+	case []any:
+		if m, ok := schema["minItems"].(float64); ok && float64(len(v)) < m {
+			return false
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for _, item := range v {
+				if !valid(items, item) {
+					return false
+				}
+			}
+		}
+	case map[string]any:
+		for _, name := range asSlice(schema["required"]) {
+			if _, ok := v[name.(string)]; !ok {
+				return false
+			}
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		for name, property := range v {
+			if s, ok := properties[name].(map[string]any); ok {
+				if !valid(s, property) {
+					return false
+				}
+				continue
+			}
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					return false
+				}
+			case map[string]any:
+				if !valid(additional, property) {
+					return false
+				}
+			}
+		}
+	}
+	for _, s := range asSlice(schema["allOf"]) {
+		if !valid(s.(map[string]any), value) {
+			return false
+		}
+	}
+	if anyOf, ok := schema["anyOf"]; ok {
+		var matched bool
+		for _, s := range asSlice(anyOf) {
+			matched = matched || valid(s.(map[string]any), value)
+		}
+		if !matched {
+			return false
+		}
+	}
+	if oneOf, ok := schema["oneOf"]; ok {
+		var matched int
+		for _, s := range asSlice(oneOf) {
+			if valid(s.(map[string]any), value) {
+				matched++
+			}
+		}
+		if matched != 1 {
+			return false
+		}
+	}
+	if not, ok := schema["not"].(map[string]any); ok && valid(not, value) {
+		return false
+	}
+	if condition, ok := schema["if"].(map[string]any); ok && valid(condition, value) {
+		if then, ok := schema["then"].(map[string]any); ok && !valid(then, value) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasType will determine if a decoded JSON value has a JSON Schema type.
+func hasType(kind string, value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return kind == "null"
+	case bool:
+		return kind == "boolean"
+	case float64:
+		return kind == "number" || (kind == "integer" && v == math.Trunc(v))
+	case string:
+		return kind == "string"
+	case []any:
+		return kind == "array"
+	case map[string]any:
+		return kind == "object"
+	}
+	return false
+}
+
+// equal will determine if two decoded JSON values are equal.
+func equal(a any, b any) bool {
+	return encode(a) == encode(b)
+}
+
+// asSlice will get the elements of a decoded JSON array; other values have no
+// elements.
+func asSlice(value any) []any {
+	elements, _ := value.([]any)
+	return elements
+}
@@ -0,0 +1,168 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package jsonschema
+
+import (
+	"fmt"
+	"strings"
+)
+
+// luaClasses represents the ECMA 262 set contents of the Lua character
+// classes; the uppercase classes are their complements.
+var luaClasses = map[byte]string{
+	'a': `A-Za-z`,
+	'c': `\x00-\x1f\x7f`,
+	'd': `0-9`,
+	'l': `a-z`,
+	'p': `!-/:-@\[-` + "`" + `{-~`,
+	's': `\t-\r `,
+	'u': `A-Z`,
+	'w': `0-9A-Za-z`,
+	'x': `0-9A-Fa-f`,
+}
+
+// regexSpecials represents the characters that are literal in Lua patterns but
+// must be escaped in ECMA 262 regular expressions.
+const regexSpecials = `\{}|`
+
+// translatePattern will translate a Lua pattern into an equivalent ECMA 262
+// regular expression; balances, frontiers, back references, and position
+// captures have no equivalent and result in an error.
+func translatePattern(pattern string) (string, error) {
+	var regex strings.Builder
+	// item represents whether the previous element can be quantified
+	item := false
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '^' && i == 0:
+			regex.WriteByte(c)
+			item = false
+		case c == '$' && i == len(pattern)-1:
+			regex.WriteByte(c)
+			item = false
+		case c == '%':
+			if i+1 == len(pattern) {
+				return "", fmt.Errorf("malformed pattern %q: ends with %%", pattern)
+			}
+			i++
+			class, err := translateEscape(pattern, pattern[i], false)
+			if err != nil {
+				return "", err
+			}
+			regex.WriteString(class)
+			item = true
+		case c == '[':
+			end, set, err := translateSet(pattern, i)
+			if err != nil {
+				return "", err
+			}
+			regex.WriteString(set)
+			i = end
+			item = true
+		case c == '-' && item:
+			regex.WriteString("*?")
+			item = false
+		case c == '*' && item, c == '+' && item, c == '?' && item:
+			regex.WriteByte(c)
+			item = false
+		case c == '(':
+			if i+1 < len(pattern) && pattern[i+1] == ')' {
+				return "", fmt.Errorf("unsupported pattern %q: position captures", pattern)
+			}
+			regex.WriteByte(c)
+			item = false
+		case c == ')':
+			regex.WriteByte(c)
+			item = false
+		case c == '.':
+			regex.WriteByte(c)
+			item = true
+		default:
+			if strings.IndexByte(regexSpecials+`^$*+?-[]`, c) >= 0 {
+				regex.WriteByte('\\')
+			}
+			regex.WriteByte(c)
+			item = true
+		}
+	}
+	return regex.String(), nil
+}
+
+// translateEscape will translate an escaped Lua character; classes are written
+// without their brackets when they belong to a set.
+func translateEscape(pattern string, c byte, inSet bool) (string, error) {
+	switch {
+	case c == 'b' || c == 'f':
+		return "", fmt.Errorf("unsupported pattern %q: %%%c", pattern, c)
+	case c >= '0' && c <= '9':
+		return "", fmt.Errorf("unsupported pattern %q: back references", pattern)
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		lower := c | 0x20
+		class, ok := luaClasses[lower]
+		if !ok {
+			return string(c), nil
+		}
+		if c != lower {
+			if inSet {
+				return "", fmt.Errorf("unsupported pattern %q: %%%c in a set", pattern, c)
+			}
+			return "[^" + class + "]", nil
+		}
+		if inSet {
+			return class, nil
+		}
+		return "[" + class + "]", nil
+	default:
+		return `\` + string(c), nil
+	}
+}
+
+// translateSet will translate the Lua set starting at the given index and get
+// the index of its closing bracket.
+func translateSet(pattern string, start int) (int, string, error) {
+	var set strings.Builder
+	set.WriteByte('[')
+	i := start + 1
+	if i < len(pattern) && pattern[i] == '^' {
+		set.WriteByte('^')
+		i++
+	}
+	// A closing bracket right after the opening one is literal
+	first := true
+	for ; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == ']' && !first:
+			set.WriteByte(']')
+			return i, set.String(), nil
+		case c == '%' && i+1 < len(pattern):
+			i++
+			class, err := translateEscape(pattern, pattern[i], true)
+			if err != nil {
+				return 0, "", err
+			}
+			set.WriteString(class)
+		case c == '\\' || c == '[' || c == ']':
+			set.WriteByte('\\')
+			set.WriteByte(c)
+		default:
+			set.WriteByte(c)
+		}
+		first = false
+	}
+	return 0, "", fmt.Errorf("malformed pattern %q: missing ]", pattern)
+}
@@ -0,0 +1,775 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package lua
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Kind represents the kind of a parsed Lua value.
+type Kind int
+
+const (
+	// KindNil represents the nil value
+	KindNil Kind = iota
+	// KindBoolean represents a boolean value
+	KindBoolean
+	// KindNumber represents a number value
+	KindNumber
+	// KindString represents a string value
+	KindString
+	// KindTable represents a table constructor
+	KindTable
+	// KindReference represents a reference to a variable or a field of a
+	// variable that could not be resolved; e.g. typedefs.protocols_http
+	KindReference
+	// KindCall represents a function call
+	KindCall
+	// KindFunction represents an anonymous function
+	KindFunction
+	// KindExpression represents an expression that could not be evaluated
+	KindExpression
+)
+
+// Value represents a parsed Lua value. Only constant values and tables are
+// evaluated; references, calls, functions, and other expressions are kept
+// as-is for the caller to interpret.
+type Value struct {
+	// Kind represents the kind of the value
+	Kind Kind
+	// Boolean represents the value of a boolean
+	Boolean bool
+	// Number represents the value of a number
+	Number float64
+	// String represents the value of a string, the name of a reference or a
+	// called function, or the source of an expression
+	String string
+	// Table represents the value of a table
+	Table *Table
+	// Arguments represents the arguments of a call
+	Arguments []Value
+}
+
+// Table represents a parsed Lua table constructor.
+type Table struct {
+	// Array represents the positional entries of the table
+	Array []Value
+	// Fields represents the keyed entries of the table in declaration order
+	Fields []Field
+}
+
+// Field represents a keyed entry of a table.
+type Field struct {
+	// Key represents the key of the entry
+	Key Value
+	// Value represents the value of the entry
+	Value Value
+}
+
+// Get will get the value of a string keyed entry of the table.
+func (t *Table) Get(key string) (Value, bool) {
+	if t == nil {
+		return Value{}, false
+	}
+	for _, field := range t.Fields {
+		if field.Key.Kind == KindString && field.Key.String == key {
+			return field.Value, true
+		}
+	}
+	return Value{}, false
+}
+
+// Strings will get the positional string entries of the table; other entries
+// are ignored.
+func (t *Table) Strings() []string {
+	if t == nil {
+		return nil
+	}
+	var values []string
+	for _, value := range t.Array {
+		if value.Kind == KindString {
+			values = append(values, value.String)
+		}
+	}
+	return values
+}
+
+// IsInteger will determine if the value is a number without a fractional
+// part.
+func (v Value) IsInteger() bool {
+	return v.Kind == KindNumber && v.Number == math.Trunc(v.Number)
+}
+
+// Source will get a Lua like representation of the value; this is mainly used
+// to display values that could not be evaluated.
+func (v Value) Source() string {
+	switch v.Kind {
+	case KindNil:
+		return "nil"
+	case KindBoolean:
+		return strconv.FormatBool(v.Boolean)
+	case KindNumber:
+		return strconv.FormatFloat(v.Number, 'f', -1, 64)
+	case KindString:
+		return strconv.Quote(v.String)
+	case KindTable:
+		entries := make([]string, 0, len(v.Table.Array)+len(v.Table.Fields))
+		for _, value := range v.Table.Array {
+			entries = append(entries, value.Source())
+		}
+		for _, field := range v.Table.Fields {
+			key := field.Key.Source()
+			if field.Key.Kind == KindString && isIdentifier(field.Key.String) {
+				key = field.Key.String
+			} else {
+				key = "[" + key + "]"
+			}
+			entries = append(entries, key+" = "+field.Value.Source())
+		}
+		return "{ " + strings.Join(entries, ", ") + " }"
+	case KindCall:
+		arguments := make([]string, 0, len(v.Arguments))
+		for _, argument := range v.Arguments {
+			arguments = append(arguments, argument.Source())
+		}
+		return v.String + "(" + strings.Join(arguments, ", ") + ")"
+	case KindFunction:
+		return "function"
+	case KindReference, KindExpression:
+		return v.String
+	}
+	return v.String
+}
+
+// ErrNoReturn represents a chunk that does not return a value.
+var ErrNoReturn = errors.New("chunk does not return a value")
+
+// Chunk represents the evaluated top level of a Lua chunk.
+type Chunk struct {
+	// Locals represents the values of the local variables assigned at the top
+	// level of the chunk before its return statement
+	Locals map[string]Value
+	// Return represents the value returned by the chunk
+	Return Value
+	// Returns represents whether the chunk returns a value
+	Returns bool
+}
+
+// Parse will parse a Lua chunk evaluating its top level local variables and
+// the value it returns. Local variables are resolved when they are referenced;
+// other statements are skipped.
+func Parse(source string) (Chunk, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return Chunk{}, err
+	}
+	p := &parser{
+		tokens: tokens,
+		locals: make(map[string]Value),
+	}
+	value, returns, err := p.parseChunk()
+	if err != nil {
+		return Chunk{}, err
+	}
+	return Chunk{
+		Locals:  p.locals,
+		Return:  value,
+		Returns: returns,
+	}, nil
+}
+
+// ParseReturn will parse a Lua chunk and evaluate the value it returns;
+// ErrNoReturn is returned when the chunk does not return a value.
+func ParseReturn(source string) (Value, error) {
+	chunk, err := Parse(source)
+	if err != nil {
+		return Value{}, err
+	}
+	if !chunk.Returns {
+		return Value{}, ErrNoReturn
+	}
+	return chunk.Return, nil
+}
+
+// ParseExpression will parse and evaluate a single Lua expression.
+func ParseExpression(source string) (Value, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return Value{}, err
+	}
+	p := &parser{
+		tokens: tokens,
+		locals: make(map[string]Value),
+	}
+	value, err := p.parseExpression(0)
+	if err != nil {
+		return Value{}, err
+	}
+	if !p.at(tokenEOF, "") {
+		return Value{}, p.errorf("unexpected %s after expression", p.peek().text)
+	}
+	return value, nil
+}
+
+// isIdentifier will determine if a string is a valid Lua identifier.
+func isIdentifier(s string) bool {
+	if len(s) == 0 || keywords[s] {
+		return false
+	}
+	for i, r := range s {
+		if !(r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (i > 0 && r >= '0' && r <= '9')) {
+			return false
+		}
+	}
+	return true
+}
+
+// parser represents the state of the parsing of a Lua chunk.
+type parser struct {
+	// tokens represents the tokens of the chunk
+	tokens []token
+	// position represents the index of the current token
+	position int
+	// locals represents the values of the top level local variables
+	locals map[string]Value
+}
+
+// peek will get the current token.
+func (p *parser) peek() token {
+	return p.tokens[p.position]
+}
+
+// next will consume and get the current token.
+func (p *parser) next() token {
+	t := p.tokens[p.position]
+	if t.kind != tokenEOF {
+		p.position++
+	}
+	return t
+}
+
+// at will determine if the current token has the given kind and text; an
+// empty text matches any token of the kind.
+func (p *parser) at(kind tokenKind, text string) bool {
+	t := p.peek()
+	return t.kind == kind && (len(text) == 0 || t.text == text)
+}
+
+// accept will consume the current token when it has the given kind and text.
+func (p *parser) accept(kind tokenKind, text string) bool {
+	if p.at(kind, text) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+// expect will consume the current token ensuring it has the given kind and
+// text.
+func (p *parser) expect(kind tokenKind, text string) (token, error) {
+	if !p.at(kind, text) {
+		return token{}, p.errorf("expected %s but found %s", text, p.peek().text)
+	}
+	return p.next(), nil
+}
+
+// errorf will create a parsing error for the current token.
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("line %d: %s", p.peek().line, fmt.Sprintf(format, args...))
+}
+
+// parseChunk will parse the top level statements of a chunk until the return
+// statement; whether the chunk returns a value is also returned.
+func (p *parser) parseChunk() (Value, bool, error) {
+	for !p.at(tokenEOF, "") {
+		switch {
+		case p.accept(tokenKeyword, "return"):
+			value, err := p.parseExpression(0)
+			return value, err == nil, err
+		case p.at(tokenKeyword, "local"):
+			p.parseLocal()
+		default:
+			if err := p.skipStatement(); err != nil {
+				return Value{}, false, err
+			}
+		}
+	}
+	return Value{}, false, nil
+}
+
+// parseLocal will parse a local variable assignment keeping the values of the
+// variables; local functions and unparsable values are skipped.
+func (p *parser) parseLocal() {
+	p.next()
+	if p.at(tokenKeyword, "function") {
+		_ = p.skipBlock()
+		return
+	}
+
+	var names []string
+	for p.at(tokenName, "") {
+		names = append(names, p.next().text)
+		// Skip attributes; e.g. <const>
+		if p.accept(tokenSymbol, "<") {
+			p.next()
+			p.accept(tokenSymbol, ">")
+		}
+		if !p.accept(tokenSymbol, ",") {
+			break
+		}
+	}
+	if !p.accept(tokenSymbol, "=") {
+		return
+	}
+	for i := 0; ; i++ {
+		start := p.position
+		value, err := p.parseExpression(0)
+		if err != nil {
+			// Skip the remainder of the statement
+			p.position = start
+			_ = p.skipStatement()
+			return
+		}
+		if i < len(names) {
+			p.locals[names[i]] = value
+		}
+		if !p.accept(tokenSymbol, ",") {
+			return
+		}
+	}
+}
+
+// skipStatement will skip a statement including any nested blocks.
+func (p *parser) skipStatement() error {
+	depth := 0
+	skipDo := 0
+	for {
+		t := p.peek()
+		if t.kind == tokenEOF {
+			if depth > 0 {
+				return p.errorf("unexpected end of chunk")
+			}
+			return nil
+		}
+		if t.kind == tokenKeyword {
+			switch t.text {
+			case "function", "if", "repeat":
+				depth++
+			case "while", "for":
+				depth++
+				skipDo++
+			case "do":
+				if skipDo > 0 {
+					skipDo--
+				} else {
+					depth++
+				}
+			case "end", "until":
+				depth--
+			case "return", "local":
+				if depth == 0 {
+					return nil
+				}
+			}
+		}
+		p.next()
+		if depth == 0 && (t.kind != tokenKeyword || t.text == "end" || t.text == "until") &&
+			!p.continuesStatement() {
+			return nil
+		}
+		if depth < 0 {
+			return p.errorf("unexpected %s", t.text)
+		}
+	}
+}
+
+// skipBlock will skip a block starting at the current token up to and
+// including its matching end; e.g. a function body.
+func (p *parser) skipBlock() error {
+	depth := 0
+	skipDo := 0
+	for {
+		t := p.next()
+		if t.kind == tokenEOF {
+			return p.errorf("unexpected end of chunk")
+		}
+		if t.kind != tokenKeyword {
+			continue
+		}
+		switch t.text {
+		case "function", "if", "repeat":
+			depth++
+		case "while", "for":
+			depth++
+			skipDo++
+		case "do":
+			if skipDo > 0 {
+				skipDo--
+			} else {
+				depth++
+			}
+		case "end", "until":
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// continuesStatement will determine if the current token continues the
+// statement being skipped.
+func (p *parser) continuesStatement() bool {
+	t := p.peek()
+	switch t.kind {
+	case tokenSymbol:
+		return t.text != ";"
+	case tokenKeyword:
+		switch t.text {
+		case "and", "or", "not", "nil", "true", "false", "function", "then", "else", "elseif", "do", "in":
+			return true
+		}
+		return false
+	case tokenName:
+		// A name directly following a name, string, or closing symbol starts
+		// a new statement
+		previous := p.tokens[p.position-1]
+		return previous.kind == tokenSymbol && previous.text != ")" && previous.text != "]" &&
+			previous.text != "}"
+	case tokenEOF:
+		return false
+	}
+	previous := p.tokens[p.position-1]
+	return previous.kind == tokenSymbol || previous.kind == tokenKeyword
+}
+
+// binaryPrecedence represents the left and right precedence of the binary
+// operators.
+var binaryPrecedence = map[string][2]int{
+	"or":  {1, 1},
+	"and": {2, 2},
+	"<":   {3, 3}, ">": {3, 3}, "<=": {3, 3}, ">=": {3, 3}, "~=": {3, 3}, "==": {3, 3},
+	"|":  {4, 4},
+	"~":  {5, 5},
+	"&":  {6, 6},
+	"<<": {7, 7}, ">>": {7, 7},
+	"..": {9, 8},
+	"+":  {10, 10}, "-": {10, 10},
+	"*": {11, 11}, "/": {11, 11}, "//": {11, 11}, "%": {11, 11},
+	"^": {14, 13},
+}
+
+// unaryPrecedence represents the precedence of the unary operators.
+const unaryPrecedence = 12
+
+// parseExpression will parse an expression with operators binding tighter than
+// the given precedence.
+func (p *parser) parseExpression(limit int) (Value, error) {
+	var left Value
+	var err error
+	if t := p.peek(); (t.kind == tokenSymbol && (t.text == "-" || t.text == "#" || t.text == "~")) ||
+		(t.kind == tokenKeyword && t.text == "not") {
+		p.next()
+		operand, err := p.parseExpression(unaryPrecedence)
+		if err != nil {
+			return Value{}, err
+		}
+		left = evaluateUnary(t.text, operand)
+	} else {
+		left, err = p.parseSimpleExpression()
+		if err != nil {
+			return Value{}, err
+		}
+	}
+
+	for {
+		t := p.peek()
+		if t.kind != tokenSymbol && t.kind != tokenKeyword {
+			return left, nil
+		}
+		precedence, ok := binaryPrecedence[t.text]
+		if !ok || precedence[0] <= limit {
+			return left, nil
+		}
+		p.next()
+		right, err := p.parseExpression(precedence[1])
+		if err != nil {
+			return Value{}, err
+		}
+		left = evaluateBinary(t.text, left, right)
+	}
+}
+
+// evaluateUnary will evaluate a unary operation on constant operands.
+func evaluateUnary(operator string, operand Value) Value {
+	switch {
+	case operator == "-" && operand.Kind == KindNumber:
+		return Value{Kind: KindNumber, Number: -operand.Number}
+	case operator == "not" && (operand.Kind == KindBoolean || operand.Kind == KindNil):
+		return Value{Kind: KindBoolean, Boolean: operand.Kind == KindNil || !operand.Boolean}
+	case operator == "#" && operand.Kind == KindString:
+		return Value{Kind: KindNumber, Number: float64(len(operand.String))}
+	}
+	source := operator + operand.Source()
+	if operator == "not" {
+		source = "not " + operand.Source()
+	}
+	return Value{Kind: KindExpression, String: source}
+}
+
+// evaluateBinary will evaluate a binary operation on constant operands.
+func evaluateBinary(operator string, left Value, right Value) Value {
+	if operator == ".." && isConcatenable(left) && isConcatenable(right) {
+		return Value{Kind: KindString, String: concatenable(left) + concatenable(right)}
+	}
+	if left.Kind == KindNumber && right.Kind == KindNumber {
+		switch operator {
+		case "+":
+			return Value{Kind: KindNumber, Number: left.Number + right.Number}
+		case "-":
+			return Value{Kind: KindNumber, Number: left.Number - right.Number}
+		case "*":
+			return Value{Kind: KindNumber, Number: left.Number * right.Number}
+		case "/":
+			return Value{Kind: KindNumber, Number: left.Number / right.Number}
+		case "^":
+			return Value{Kind: KindNumber, Number: math.Pow(left.Number, right.Number)}
+		}
+	}
+	return Value{Kind: KindExpression, String: left.Source() + " " + operator + " " + right.Source()}
+}
+
+// isConcatenable will determine if a value can be concatenated as a constant.
+func isConcatenable(v Value) bool {
+	return v.Kind == KindString || v.Kind == KindNumber
+}
+
+// concatenable will get the string of a concatenable value.
+func concatenable(v Value) string {
+	if v.Kind == KindNumber {
+		return strconv.FormatFloat(v.Number, 'f', -1, 64)
+	}
+	return v.String
+}
+
+// parseSimpleExpression will parse a constant, a table constructor, a function,
+// or a suffixed expression.
+func (p *parser) parseSimpleExpression() (Value, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokenNumber:
+		p.next()
+		return Value{Kind: KindNumber, Number: t.number}, nil
+	case tokenString:
+		p.next()
+		return Value{Kind: KindString, String: t.text}, nil
+	case tokenKeyword:
+		switch t.text {
+		case "nil":
+			p.next()
+			return Value{Kind: KindNil}, nil
+		case "true", "false":
+			p.next()
+			return Value{Kind: KindBoolean, Boolean: t.text == "true"}, nil
+		case "function":
+			if err := p.skipBlock(); err != nil {
+				return Value{}, err
+			}
+			return Value{Kind: KindFunction}, nil
+		}
+	case tokenSymbol:
+		switch t.text {
+		case "{":
+			return p.parseTable()
+		case "...":
+			p.next()
+			return Value{Kind: KindExpression, String: "..."}, nil
+		}
+	case tokenName, tokenEOF:
+	}
+	return p.parseSuffixedExpression()
+}
+
+// parseSuffixedExpression will parse a name or parenthesized expression
+// followed by field accesses, indexing, and calls.
+func (p *parser) parseSuffixedExpression() (Value, error) {
+	var value Value
+	switch {
+	case p.at(tokenName, ""):
+		name := p.next().text
+		if local, ok := p.locals[name]; ok {
+			value = local
+		} else {
+			value = Value{Kind: KindReference, String: name}
+		}
+	case p.accept(tokenSymbol, "("):
+		inner, err := p.parseExpression(0)
+		if err != nil {
+			return Value{}, err
+		}
+		if _, err := p.expect(tokenSymbol, ")"); err != nil {
+			return Value{}, err
+		}
+		value = inner
+	default:
+		return Value{}, p.errorf("unexpected %s", p.peek().text)
+	}
+
+	for {
+		switch {
+		case p.accept(tokenSymbol, "."):
+			name, err := p.expect(tokenName, "")
+			if err != nil {
+				return Value{}, err
+			}
+			value = index(value, Value{Kind: KindString, String: name.text})
+		case p.accept(tokenSymbol, "["):
+			key, err := p.parseExpression(0)
+			if err != nil {
+				return Value{}, err
+			}
+			if _, err := p.expect(tokenSymbol, "]"); err != nil {
+				return Value{}, err
+			}
+			value = index(value, key)
+		case p.accept(tokenSymbol, ":"):
+			name, err := p.expect(tokenName, "")
+			if err != nil {
+				return Value{}, err
+			}
+			arguments, err := p.parseArguments()
+			if err != nil {
+				return Value{}, err
+			}
+			value = Value{Kind: KindCall, String: value.Source() + ":" + name.text, Arguments: arguments}
+		case p.at(tokenSymbol, "("), p.at(tokenSymbol, "{"), p.at(tokenString, ""):
+			arguments, err := p.parseArguments()
+			if err != nil {
+				return Value{}, err
+			}
+			value = Value{Kind: KindCall, String: value.Source(), Arguments: arguments}
+		default:
+			return value, nil
+		}
+	}
+}
+
+// index will evaluate the indexing of a value; tables are indexed while other
+// values become references.
+func index(value Value, key Value) Value {
+	if value.Kind == KindTable {
+		for _, field := range value.Table.Fields {
+			if field.Key.Kind == key.Kind && field.Key.String == key.String && field.Key.Number == key.Number {
+				return field.Value
+			}
+		}
+		if key.IsInteger() && key.Number >= 1 && int(key.Number) <= len(value.Table.Array) {
+			return value.Table.Array[int(key.Number)-1]
+		}
+		return Value{Kind: KindNil}
+	}
+	if key.Kind == KindString && isIdentifier(key.String) {
+		return Value{Kind: KindReference, String: value.Source() + "." + key.String}
+	}
+	return Value{Kind: KindReference, String: value.Source() + "[" + key.Source() + "]"}
+}
+
+// parseArguments will parse the arguments of a call.
+func (p *parser) parseArguments() ([]Value, error) {
+	switch {
+	case p.at(tokenString, ""):
+		return []Value{{Kind: KindString, String: p.next().text}}, nil
+	case p.at(tokenSymbol, "{"):
+		table, err := p.parseTable()
+		if err != nil {
+			return nil, err
+		}
+		return []Value{table}, nil
+	}
+	if _, err := p.expect(tokenSymbol, "("); err != nil {
+		return nil, err
+	}
+	var arguments []Value
+	for !p.accept(tokenSymbol, ")") {
+		argument, err := p.parseExpression(0)
+		if err != nil {
+			return nil, err
+		}
+		arguments = append(arguments, argument)
+		if !p.accept(tokenSymbol, ",") {
+			if _, err := p.expect(tokenSymbol, ")"); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	return arguments, nil
+}
+
+// parseTable will parse a table constructor.
+func (p *parser) parseTable() (Value, error) {
+	if _, err := p.expect(tokenSymbol, "{"); err != nil {
+		return Value{}, err
+	}
+	table := &Table{}
+	for !p.accept(tokenSymbol, "}") {
+		switch {
+		case p.at(tokenSymbol, "["):
+			p.next()
+			key, err := p.parseExpression(0)
+			if err != nil {
+				return Value{}, err
+			}
+			if _, err := p.expect(tokenSymbol, "]"); err != nil {
+				return Value{}, err
+			}
+			if _, err := p.expect(tokenSymbol, "="); err != nil {
+				return Value{}, err
+			}
+			value, err := p.parseExpression(0)
+			if err != nil {
+				return Value{}, err
+			}
+			table.Fields = append(table.Fields, Field{Key: key, Value: value})
+		case p.at(tokenName, "") && p.tokens[p.position+1].kind == tokenSymbol &&
+			p.tokens[p.position+1].text == "=":
+			key := p.next().text
+			p.next()
+			value, err := p.parseExpression(0)
+			if err != nil {
+				return Value{}, err
+			}
+			table.Fields = append(table.Fields, Field{Key: Value{Kind: KindString, String: key}, Value: value})
+		default:
+			value, err := p.parseExpression(0)
+			if err != nil {
+				return Value{}, err
+			}
+			table.Array = append(table.Array, value)
+		}
+		if !p.accept(tokenSymbol, ",") && !p.accept(tokenSymbol, ";") {
+			if _, err := p.expect(tokenSymbol, "}"); err != nil {
+				return Value{}, err
+			}
+			break
+		}
+	}
+	return Value{Kind: KindTable, Table: table}, nil
+}
@@ -0,0 +1,184 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package lua
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("lua", Label("lua"), func() {
+	When("parsing a chunk returning a table", func() {
+		It("will evaluate keyed and positional entries", func() {
+			value, err := ParseReturn(`
+-- Any dataplane older than 3.1.0
+return {
+  [3001000000] = {
+    -- OSS
+    acme = {
+      "enable_ipv4_common_name",
+      'storage_config.redis.ssl',
+    },
+    ["rate-limiting"] = { [[error_code]], "error_\109essage" },
+  },
+  --[[ long
+  comment ]]
+  [0x10] = { enabled = true, ratio = -0.5, name = "a" .. "b" .. 1 },
+}
+`)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(value.Kind).Should(Equal(KindTable))
+			Expect(value.Table.Fields).To(HaveLen(2))
+
+			version := value.Table.Fields[0]
+			Expect(version.Key.IsInteger()).To(BeTrue())
+			Expect(version.Key.Number).Should(Equal(float64(3001000000)))
+			acme, ok := version.Value.Table.Get("acme")
+			Expect(ok).To(BeTrue())
+			Expect(acme.Table.Strings()).Should(Equal([]string{"enable_ipv4_common_name", "storage_config.redis.ssl"}))
+			rateLimiting, ok := version.Value.Table.Get("rate-limiting")
+			Expect(ok).To(BeTrue())
+			Expect(rateLimiting.Table.Strings()).Should(Equal([]string{"error_code", "error_message"}))
+
+			constants := value.Table.Fields[1]
+			Expect(constants.Key.Number).Should(Equal(float64(16)))
+			enabled, _ := constants.Value.Table.Get("enabled")
+			Expect(enabled.Boolean).To(BeTrue())
+			ratio, _ := constants.Value.Table.Get("ratio")
+			Expect(ratio.Number).Should(Equal(-0.5))
+			name, _ := constants.Value.Table.Get("name")
+			Expect(name.String).Should(Equal("ab1"))
+		})
+
+		It("will resolve local variables and keep unresolved references and calls", func() {
+			value, err := ParseReturn(`
+local typedefs = require "kong.db.schema.typedefs"
+local PERIODS = { "second", "minute" }
+
+local function validate(config)
+  if not config.second then
+    return nil, "error"
+  end
+  for _, period in ipairs(PERIODS) do
+    print(period)
+  end
+  return true
+end
+
+local _M = {}
+_M.x = 1
+
+return {
+  name = "rate-limiting",
+  fields = {
+    { protocols = typedefs.protocols_http },
+    { config = {
+        type = "record",
+        fields = {
+          { period = { type = "string", one_of = PERIODS } },
+          { limit = { type = "number", gt = 0 } },
+        },
+        custom_validator = validate,
+        entity_checks = { { at_least_one_of = PERIODS } },
+        shorthand = function(value) return { value } end,
+    } },
+  },
+}
+`)
+			Expect(err).NotTo(HaveOccurred())
+			name, _ := value.Table.Get("name")
+			Expect(name.String).Should(Equal("rate-limiting"))
+
+			fields, _ := value.Table.Get("fields")
+			Expect(fields.Table.Array).To(HaveLen(2))
+			protocols, _ := fields.Table.Array[0].Table.Get("protocols")
+			Expect(protocols.Kind).Should(Equal(KindReference))
+			Expect(protocols.String).Should(Equal(`require("kong.db.schema.typedefs").protocols_http`))
+
+			config, _ := fields.Table.Array[1].Table.Get("config")
+			configFields, _ := config.Table.Get("fields")
+			period, _ := configFields.Table.Array[0].Table.Get("period")
+			oneOf, _ := period.Table.Get("one_of")
+			Expect(oneOf.Table.Strings()).Should(Equal([]string{"second", "minute"}))
+			validator, _ := config.Table.Get("custom_validator")
+			Expect(validator.Kind).Should(Equal(KindReference))
+			shorthand, _ := config.Table.Get("shorthand")
+			Expect(shorthand.Kind).Should(Equal(KindFunction))
+		})
+	})
+
+	When("parsing a chunk with local variables", func() {
+		It("the top level local variables will be evaluated", func() {
+			chunk, err := Parse(`
+local plugins = {
+  "jwt",
+  "acl",
+}
+
+local plugin_map = {}
+for i = 1, #plugins do
+  plugin_map[plugins[i]] = true
+end
+
+return { BUNDLED_PLUGINS = plugin_map }
+`)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(chunk.Returns).To(BeTrue())
+			Expect(chunk.Locals["plugins"].Table.Strings()).Should(Equal([]string{"jwt", "acl"}))
+			Expect(chunk.Locals["plugin_map"].Kind).Should(Equal(KindTable))
+		})
+
+		It("a chunk without a return statement will be parsed", func() {
+			chunk, err := Parse("local a, b = 1, 'b'")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(chunk.Returns).To(BeFalse())
+			Expect(chunk.Locals["a"].Number).Should(Equal(float64(1)))
+			Expect(chunk.Locals["b"].String).Should(Equal("b"))
+		})
+	})
+
+	When("parsing an expression", func() {
+		It("will fold constant operations", func() {
+			value, err := ParseExpression("2 ^ 3 * 2 + -1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(value.Number).Should(Equal(float64(15)))
+		})
+
+		It("will keep operations on unresolved values as expressions", func() {
+			value, err := ParseExpression("ngx.null or 5")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(value.Kind).Should(Equal(KindExpression))
+			Expect(value.Source()).Should(Equal("ngx.null or 5"))
+		})
+	})
+
+	When("the chunk is invalid", func() {
+		It("will error on an unfinished table", func() {
+			_, err := ParseReturn("return { a = 1")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("will error on an unfinished string", func() {
+			_, err := ParseReturn(`return { "a }`)
+			Expect(err).Should(MatchError(ContainSubstring("unfinished string")))
+		})
+
+		It("will error when nothing is returned", func() {
+			_, err := ParseReturn("local a = 1")
+			Expect(err).Should(MatchError(ErrNoReturn))
+		})
+	})
+})
@@ -0,0 +1,358 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package lua
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// tokenKind represents the kind of a token.
+type tokenKind int
+
+const (
+	// tokenEOF represents the end of the chunk
+	tokenEOF tokenKind = iota
+	// tokenName represents an identifier
+	tokenName
+	// tokenKeyword represents a reserved word
+	tokenKeyword
+	// tokenNumber represents a numeric constant
+	tokenNumber
+	// tokenString represents a string constant
+	tokenString
+	// tokenSymbol represents an operator or punctuation
+	tokenSymbol
+)
+
+// token represents a lexical token of a Lua chunk.
+type token struct {
+	// kind represents the kind of the token
+	kind tokenKind
+	// text represents the source of the token or the value of a string
+	text string
+	// number represents the value of a number
+	number float64
+	// line represents the line of the token in the chunk
+	line int
+}
+
+// keywords represents the reserved words of Lua.
+var keywords = map[string]bool{
+	"and": true, "break": true, "do": true, "else": true, "elseif": true, "end": true,
+	"false": true, "for": true, "function": true, "goto": true, "if": true, "in": true,
+	"local": true, "nil": true, "not": true, "or": true, "repeat": true, "return": true,
+	"then": true, "true": true, "until": true, "while": true,
+}
+
+// symbols represents the operators and punctuation of Lua ordered by length
+// to match the longest symbol first.
+var symbols = []string{
+	"...", "..", "==", "~=", "<=", ">=", "//", "::", "<<", ">>",
+	"+", "-", "*", "/", "%", "^", "#", "&", "~", "|", "<", ">", "=",
+	"(", ")", "{", "}", "[", "]", ";", ":", ",", ".",
+}
+
+// lexer represents the state of the tokenization of a Lua chunk.
+type lexer struct {
+	// source represents the chunk being tokenized
+	source string
+	// position represents the offset of the next character
+	position int
+	// line represents the current line
+	line int
+}
+
+// tokenize will split a Lua chunk into tokens; comments are discarded.
+func tokenize(source string) ([]token, error) {
+	l := &lexer{source: source, line: 1}
+	// Skip the shebang line
+	if strings.HasPrefix(source, "#") {
+		l.skipLine()
+	}
+	var tokens []token
+	for {
+		t, err := l.next()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", l.line, err)
+		}
+		tokens = append(tokens, t)
+		if t.kind == tokenEOF {
+			return tokens, nil
+		}
+	}
+}
+
+// skipLine will skip the remainder of the current line.
+func (l *lexer) skipLine() {
+	for l.position < len(l.source) && l.source[l.position] != '\n' {
+		l.position++
+	}
+}
+
+// next will get the next token.
+func (l *lexer) next() (token, error) {
+	for l.position < len(l.source) {
+		c := l.source[l.position]
+		switch {
+		case c == '\n':
+			l.line++
+			l.position++
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v':
+			l.position++
+		case strings.HasPrefix(l.source[l.position:], "--"):
+			l.position += 2
+			if level, ok := l.longBracketLevel(); ok {
+				if _, err := l.longString(level); err != nil {
+					return token{}, err
+				}
+				continue
+			}
+			l.skipLine()
+		default:
+			return l.scan()
+		}
+	}
+	return token{kind: tokenEOF, text: "<eof>", line: l.line}, nil
+}
+
+// scan will scan the token starting at the current position.
+func (l *lexer) scan() (token, error) {
+	line := l.line
+	c := l.source[l.position]
+	switch {
+	case c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+		start := l.position
+		for l.position < len(l.source) && isNameCharacter(l.source[l.position]) {
+			l.position++
+		}
+		text := l.source[start:l.position]
+		if keywords[text] {
+			return token{kind: tokenKeyword, text: text, line: line}, nil
+		}
+		return token{kind: tokenName, text: text, line: line}, nil
+	case c >= '0' && c <= '9',
+		c == '.' && l.position+1 < len(l.source) && l.source[l.position+1] >= '0' && l.source[l.position+1] <= '9':
+		return l.number()
+	case c == '"' || c == '\'':
+		text, err := l.quotedString(c)
+		if err != nil {
+			return token{}, err
+		}
+		return token{kind: tokenString, text: text, line: line}, nil
+	case c == '[':
+		if level, ok := l.longBracketLevel(); ok {
+			text, err := l.longString(level)
+			if err != nil {
+				return token{}, err
+			}
+			return token{kind: tokenString, text: text, line: line}, nil
+		}
+	}
+	for _, symbol := range symbols {
+		if strings.HasPrefix(l.source[l.position:], symbol) {
+			l.position += len(symbol)
+			return token{kind: tokenSymbol, text: symbol, line: line}, nil
+		}
+	}
+	return token{}, fmt.Errorf("unexpected character %q", c)
+}
+
+// isNameCharacter will determine if a character can be part of a name.
+func isNameCharacter(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// number will scan a numeric constant.
+func (l *lexer) number() (token, error) {
+	line := l.line
+	start := l.position
+	hexadecimal := strings.HasPrefix(strings.ToLower(l.source[l.position:]), "0x")
+	if hexadecimal {
+		l.position += 2
+	}
+	for l.position < len(l.source) {
+		c := l.source[l.position]
+		exponent := (!hexadecimal && (c == 'e' || c == 'E')) || (hexadecimal && (c == 'p' || c == 'P'))
+		switch {
+		case exponent:
+			l.position++
+			if l.position < len(l.source) && (l.source[l.position] == '+' || l.source[l.position] == '-') {
+				l.position++
+			}
+		case isNameCharacter(c) || c == '.':
+			l.position++
+		default:
+			return l.numberToken(start, line, hexadecimal)
+		}
+	}
+	return l.numberToken(start, line, hexadecimal)
+}
+
+// numberToken will create the token of the numeric constant starting at the
+// given offset.
+func (l *lexer) numberToken(start int, line int, hexadecimal bool) (token, error) {
+	text := l.source[start:l.position]
+	if hexadecimal && !strings.ContainsAny(text, ".pP") {
+		value, err := strconv.ParseUint(text[2:], 16, 64)
+		if err != nil {
+			return token{}, fmt.Errorf("invalid number %s", text)
+		}
+		return token{kind: tokenNumber, text: text, number: float64(value), line: line}, nil
+	}
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return token{}, fmt.Errorf("invalid number %s", text)
+	}
+	return token{kind: tokenNumber, text: text, number: value, line: line}, nil
+}
+
+// longBracketLevel will determine if a long bracket starts at the current
+// position and get its level; e.g. [==[ has a level of 2.
+func (l *lexer) longBracketLevel() (int, bool) {
+	if l.position >= len(l.source) || l.source[l.position] != '[' {
+		return 0, false
+	}
+	level := 0
+	for i := l.position + 1; i < len(l.source); i++ {
+		switch l.source[i] {
+		case '=':
+			level++
+		case '[':
+			return level, true
+		default:
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+// longString will scan a long string or comment of the given level.
+func (l *lexer) longString(level int) (string, error) {
+	l.position += level + 2
+	// A newline immediately following the opening bracket is skipped
+	if strings.HasPrefix(l.source[l.position:], "\r\n") {
+		l.position += 2
+		l.line++
+	} else if strings.HasPrefix(l.source[l.position:], "\n") {
+		l.position++
+		l.line++
+	}
+	closing := "]" + strings.Repeat("=", level) + "]"
+	end := strings.Index(l.source[l.position:], closing)
+	if end < 0 {
+		return "", fmt.Errorf("unfinished long string")
+	}
+	text := l.source[l.position : l.position+end]
+	l.line += strings.Count(text, "\n")
+	l.position += end + len(closing)
+	return text, nil
+}
+
+// quotedString will scan a string delimited by quotes resolving its escape
+// sequences.
+func (l *lexer) quotedString(quote byte) (string, error) {
+	l.position++
+	var b strings.Builder
+	for l.position < len(l.source) {
+		c := l.source[l.position]
+		switch c {
+		case quote:
+			l.position++
+			return b.String(), nil
+		case '\n':
+			return "", fmt.Errorf("unfinished string")
+		case '\\':
+			if err := l.escape(&b); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteByte(c)
+			l.position++
+		}
+	}
+	return "", fmt.Errorf("unfinished string")
+}
+
+// escapes represents the single character escape sequences of Lua.
+var escapes = map[byte]byte{
+	'a': '\a', 'b': '\b', 'f': '\f', 'n': '\n', 'r': '\r', 't': '\t', 'v': '\v',
+	'\\': '\\', '"': '"', '\'': '\'', '\n': '\n',
+}
+
+// escape will resolve the escape sequence at the current position.
+func (l *lexer) escape(b *strings.Builder) error {
+	l.position++
+	if l.position >= len(l.source) {
+		return fmt.Errorf("unfinished string")
+	}
+	c := l.source[l.position]
+	if escaped, ok := escapes[c]; ok {
+		if c == '\n' {
+			l.line++
+		}
+		b.WriteByte(escaped)
+		l.position++
+		return nil
+	}
+	switch {
+	case c == 'z':
+		l.position++
+		for l.position < len(l.source) && strings.IndexByte(" \t\r\n\f\v", l.source[l.position]) >= 0 {
+			if l.source[l.position] == '\n' {
+				l.line++
+			}
+			l.position++
+		}
+	case c == 'x':
+		if l.position+3 > len(l.source) {
+			return fmt.Errorf("invalid escape sequence")
+		}
+		value, err := strconv.ParseUint(l.source[l.position+1:l.position+3], 16, 8)
+		if err != nil {
+			return fmt.Errorf("invalid escape sequence")
+		}
+		b.WriteByte(byte(value))
+		l.position += 3
+	case c == 'u':
+		end := strings.IndexByte(l.source[l.position:], '}')
+		if !strings.HasPrefix(l.source[l.position:], "u{") || end < 0 {
+			return fmt.Errorf("invalid escape sequence")
+		}
+		value, err := strconv.ParseUint(l.source[l.position+2:l.position+end], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(value)) {
+			return fmt.Errorf("invalid escape sequence")
+		}
+		b.WriteRune(rune(value))
+		l.position += end + 1
+	case c >= '0' && c <= '9':
+		start := l.position
+		for l.position < len(l.source) && l.position-start < 3 &&
+			l.source[l.position] >= '0' && l.source[l.position] <= '9' {
+			l.position++
+		}
+		value, err := strconv.ParseUint(l.source[start:l.position], 10, 8)
+		if err != nil {
+			return fmt.Errorf("invalid escape sequence")
+		}
+		b.WriteByte(byte(value))
+	default:
+		return fmt.Errorf("invalid escape sequence \\%c", c)
+	}
+	return nil
+}
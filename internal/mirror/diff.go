@@ -0,0 +1,85 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package mirror
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kong/koko-slack-bot/internal/github"
+)
+
+// statuses represents the GitHub file statuses keyed by git diff status
+// letter.
+var statuses = map[byte]string{
+	'A': "added",
+	'C': "copied",
+	'D': "removed",
+	'M': "modified",
+	'R': "renamed",
+	'T': "changed",
+}
+
+// parseNameStatus will parse the NUL separated output of git diff
+// --name-status -z into files using the GitHub file statuses.
+func parseNameStatus(output string) ([]github.File, error) {
+	if len(output) == 0 {
+		return nil, nil
+	}
+	fields := strings.Split(strings.TrimSuffix(output, "\x00"), "\x00")
+	var files []github.File
+	for i := 0; i < len(fields); i++ {
+		code := fields[i]
+		if len(code) == 0 {
+			return nil, fmt.Errorf("unable to parse diff: empty status at entry %d", i)
+		}
+		status, ok := statuses[code[0]]
+		if !ok {
+			status = "modified"
+		}
+		if code[0] == 'R' || code[0] == 'C' {
+			if i+2 >= len(fields) {
+				return nil, fmt.Errorf("unable to parse diff: missing paths for status %s", code)
+			}
+			files = append(files, github.File{
+				Filename:         fields[i+2],
+				PreviousFilename: fields[i+1],
+				Status:           status,
+			})
+			i += 2
+			continue
+		}
+		if i+1 >= len(fields) {
+			return nil, fmt.Errorf("unable to parse diff: missing path for status %s", code)
+		}
+		files = append(files, github.File{
+			Filename: fields[i+1],
+			Status:   status,
+		})
+		i++
+	}
+	return files, nil
+}
+
+// hunks will remove the headers of a git diff to keep its hunks like the
+// patches of GitHub's API.
+func hunks(patch string) string {
+	index := strings.Index(patch, "\n@@")
+	if index < 0 {
+		return ""
+	}
+	return strings.TrimSuffix(patch[index+1:], "\n")
+}
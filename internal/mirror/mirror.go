@@ -0,0 +1,319 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package mirror
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kong/koko-slack-bot/internal/github"
+	"go.uber.org/zap"
+)
+
+// defaultURL represents the URL repositories are mirrored from when not set.
+const defaultURL = "https://github.com"
+
+// ErrUnavailable represents a query the mirror is unable to answer; e.g. the
+// repository is not mirrored yet or the ref was not fetched yet.
+var ErrUnavailable = errors.New("mirror unavailable")
+
+// Options contain the parameters to create a new mirror instance.
+type Options struct {
+	// Path represents the directory the bare mirrors are kept in
+	Path string
+	// Repositories represents the repositories to mirror; e.g. kong/kong
+	Repositories []string
+	// URL represents the URL repositories are mirrored from; github.com is
+	// used when empty
+	URL string
+	// Token represents the GitHub token used to fetch private repositories
+	Token string
+	// Logger represents the base logger to use for the mirror package
+	Logger *zap.Logger
+}
+
+// Mirror represents local bare git mirrors of repositories answering file and
+// diff queries without GitHub's API.
+type Mirror struct {
+	// path represents the directory the bare mirrors are kept in
+	path string
+	// repositories represents the mirrored repositories keyed by lowercase
+	// organization/repository
+	repositories map[string]string
+	// url represents the URL repositories are mirrored from
+	url string
+	// token represents the GitHub token used to fetch private repositories
+	token string
+	// mutex represents the lock guarding the synchronized repositories
+	mutex sync.RWMutex
+	// synced represents the repositories mirrored at least once
+	synced map[string]struct{}
+	// logger represents the logger to use for the mirror package
+	logger *zap.Logger
+}
+
+// NewMirror will validate options and instantiate a new mirror instance.
+func NewMirror(opts Options) (*Mirror, error) {
+	if len(strings.TrimSpace(opts.Path)) == 0 {
+		return nil, errors.New("mirror path is not set")
+	}
+	if len(opts.Repositories) == 0 {
+		return nil, errors.New("mirror repositories are not set")
+	}
+	if opts.Logger == nil {
+		return nil, errors.New("logger is not set")
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("git is not available: %w", err)
+	}
+	repositories := make(map[string]string, len(opts.Repositories))
+	for _, reference := range opts.Repositories {
+		organization, repository, ok := strings.Cut(strings.TrimSpace(reference), "/")
+		if !ok || len(organization) == 0 || len(repository) == 0 || strings.Contains(repository, "/") {
+			return nil, fmt.Errorf("invalid mirror repository %q", reference)
+		}
+		repositories[key(organization, repository)] = organization + "/" + repository
+	}
+	url := strings.TrimSuffix(opts.URL, "/")
+	if len(url) == 0 {
+		url = defaultURL
+	}
+
+	return &Mirror{
+		path:         opts.Path,
+		repositories: repositories,
+		url:          url,
+		token:        opts.Token,
+		synced:       make(map[string]struct{}),
+		logger:       opts.Logger.With(zap.String("component", "mirror")),
+	}, nil
+}
+
+// key will create the key of a repository.
+func key(organization string, repository string) string {
+	return strings.ToLower(organization + "/" + repository)
+}
+
+// Watch will synchronize the mirrored repositories immediately and then
+// periodically; the periodic synchronization is disabled when the interval is
+// not positive.
+func (m *Mirror) Watch(interval time.Duration) {
+	m.SyncAll()
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		m.SyncAll()
+	}
+}
+
+// SyncAll will synchronize every mirrored repository; failures are logged.
+func (m *Mirror) SyncAll() {
+	for _, reference := range m.repositories {
+		organization, repository, _ := strings.Cut(reference, "/")
+		if err := m.Sync(organization, repository); err != nil {
+			m.logger.Error("unable to synchronize mirror", zap.String("repository", reference), zap.Error(err))
+		}
+	}
+}
+
+// Sync will clone the bare mirror of a repository or fetch it incrementally
+// when it already exists.
+func (m *Mirror) Sync(organization string, repository string) error {
+	reference, ok := m.repositories[key(organization, repository)]
+	if !ok {
+		return fmt.Errorf("repository %s/%s is not mirrored: %w", organization, repository, ErrUnavailable)
+	}
+	directory := m.directory(organization, repository)
+	start := time.Now()
+	if _, err := os.Stat(directory); errors.Is(err, os.ErrNotExist) {
+		if err := os.MkdirAll(filepath.Dir(directory), 0o755); err != nil {
+			return fmt.Errorf("unable to create mirror directory: %w", err)
+		}
+		if _, err := m.git("", "clone", "--mirror", "--quiet", fmt.Sprintf("%s/%s.git", m.url, reference),
+			directory); err != nil {
+			return fmt.Errorf("unable to clone mirror: %w", err)
+		}
+	} else if _, err := m.git(directory, "remote", "update", "--prune"); err != nil {
+		return fmt.Errorf("unable to fetch mirror: %w", err)
+	}
+	m.logger.Debug("mirror synchronized", zap.String("repository", reference), zap.Duration("duration", time.Since(start)))
+
+	m.mutex.Lock()
+	m.synced[key(organization, repository)] = struct{}{}
+	m.mutex.Unlock()
+	return nil
+}
+
+// FileContent will get the content of a file at the given ref;
+// github.ErrNotFound is returned when the file does not exist at the ref and
+// ErrUnavailable when the repository or the ref is not mirrored yet.
+func (m *Mirror) FileContent(organization string, repository string, path string, ref string) (string, error) {
+	directory, err := m.repository(organization, repository)
+	if err != nil {
+		return "", err
+	}
+	commit, err := m.resolve(directory, ref)
+	if err != nil {
+		return "", err
+	}
+	entry, err := m.git(directory, "ls-tree", commit, "--", path)
+	if err != nil {
+		return "", fmt.Errorf("unable to find %s at %s: %w", path, ref, err)
+	}
+	if len(strings.TrimSpace(entry)) == 0 || !strings.Contains(entry, " blob ") {
+		return "", fmt.Errorf("unable to retrieve %s at %s: %w", path, ref, github.ErrNotFound)
+	}
+	content, err := m.git(directory, "show", commit+":"+path)
+	if err != nil {
+		return "", fmt.Errorf("unable to retrieve %s at %s: %w", path, ref, err)
+	}
+	return content, nil
+}
+
+// Tree will get the blob SHAs of the files at a ref keyed by path.
+func (m *Mirror) Tree(organization string, repository string, ref string) (map[string]string, error) {
+	directory, err := m.repository(organization, repository)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := m.resolve(directory, ref)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := m.git(directory, "ls-tree", "-r", "-z", commit)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list tree at %s: %w", ref, err)
+	}
+	tree := make(map[string]string)
+	for _, entry := range strings.Split(strings.TrimSuffix(entries, "\x00"), "\x00") {
+		// Each entry is formatted as "<mode> <type> <object>\t<path>"
+		info, path, ok := strings.Cut(entry, "\t")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(info)
+		if len(fields) == 3 && fields[1] == "blob" {
+			tree[path] = fields[2]
+		}
+	}
+	return tree, nil
+}
+
+// CompareFiles will get the files modified between the merge base of two refs
+// and the head ref, along with their patches.
+func (m *Mirror) CompareFiles(organization string, repository string, base string, head string) ([]github.File, error) {
+	directory, err := m.repository(organization, repository)
+	if err != nil {
+		return nil, err
+	}
+	baseCommit, err := m.resolve(directory, base)
+	if err != nil {
+		return nil, err
+	}
+	headCommit, err := m.resolve(directory, head)
+	if err != nil {
+		return nil, err
+	}
+	mergeBase, err := m.git(directory, "merge-base", baseCommit, headCommit)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find merge base of %s and %s: %w", base, head, err)
+	}
+	mergeBase = strings.TrimSpace(mergeBase)
+
+	statuses, err := m.git(directory, "diff", "--no-color", "--no-ext-diff", "-M", "--name-status", "-z",
+		mergeBase, headCommit)
+	if err != nil {
+		return nil, fmt.Errorf("unable to diff %s and %s: %w", base, head, err)
+	}
+	files, err := parseNameStatus(statuses)
+	if err != nil {
+		return nil, err
+	}
+	for i, file := range files {
+		paths := []string{file.Filename}
+		if len(file.PreviousFilename) > 0 {
+			paths = append(paths, file.PreviousFilename)
+		}
+		args := append([]string{"diff", "--no-color", "--no-ext-diff", "-M", mergeBase, headCommit, "--"}, paths...)
+		patch, err := m.git(directory, args...)
+		if err != nil {
+			return nil, fmt.Errorf("unable to diff %s: %w", file.Filename, err)
+		}
+		files[i].Patch = hunks(patch)
+	}
+	return files, nil
+}
+
+// repository will get the directory of a mirrored repository; ErrUnavailable
+// is returned when the repository is not mirrored yet.
+func (m *Mirror) repository(organization string, repository string) (string, error) {
+	m.mutex.RLock()
+	_, ok := m.synced[key(organization, repository)]
+	m.mutex.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("repository %s/%s is not mirrored: %w", organization, repository, ErrUnavailable)
+	}
+	return m.directory(organization, repository), nil
+}
+
+// directory will get the directory of the bare mirror of a repository.
+func (m *Mirror) directory(organization string, repository string) string {
+	return filepath.Join(m.path, strings.ToLower(organization), strings.ToLower(repository)+".git")
+}
+
+// resolve will resolve a ref to its commit; ErrUnavailable is returned when
+// the ref was not fetched yet.
+func (m *Mirror) resolve(directory string, ref string) (string, error) {
+	commit, err := m.git(directory, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("ref %s is not mirrored: %w", ref, ErrUnavailable)
+	}
+	return strings.TrimSpace(commit), nil
+}
+
+// git will run a git command in a directory and get its output; the token is
+// given through the environment to keep it out of the process arguments.
+func (m *Mirror) git(directory string, args ...string) (string, error) {
+	command := args[0]
+	if len(directory) > 0 {
+		args = append([]string{"-C", directory}, args...)
+	}
+	cmd := exec.Command("git", args...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if len(m.token) > 0 {
+		credentials := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + m.token))
+		cmd.Env = append(cmd.Env, "GIT_CONFIG_COUNT=1", "GIT_CONFIG_KEY_0=http.extraHeader",
+			"GIT_CONFIG_VALUE_0=Authorization: Basic "+credentials)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", command, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,207 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package mirror
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/kong/koko-slack-bot/internal/github"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("mirror", Label("mirror"), func() {
+	var logger *zap.Logger
+	var origin string
+	var m *Mirror
+
+	// run will run a git command in the origin repository
+	run := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", origin, "-c", "user.name=Koko", "-c", "user.email=koko@konghq.com"},
+			args...)...)
+		output, err := cmd.CombinedOutput()
+		Expect(err).NotTo(HaveOccurred(), string(output))
+	}
+	// write will write a file in the origin repository
+	write := func(path string, content string) {
+		Expect(os.MkdirAll(filepath.Join(origin, filepath.Dir(path)), 0o755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(origin, path), []byte(content), 0o600)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		if _, err := exec.LookPath("git"); err != nil {
+			Skip("git is not available")
+		}
+		var err error
+		logger, err = zap.NewDevelopment()
+		Expect(err).NotTo(HaveOccurred())
+
+		// The origin is served from a local directory laid out like GitHub
+		url := GinkgoT().TempDir()
+		origin = filepath.Join(url, "kong", "kong.git")
+		Expect(os.MkdirAll(origin, 0o755)).To(Succeed())
+		run("init", "--quiet", "--initial-branch", "master")
+		write("kong/plugins/acme/schema.lua", "return { fields = {} }\n")
+		write("kong/plugins/acme/handler.lua", "return {}\n")
+		run("add", ".")
+		run("commit", "--quiet", "-m", "initial")
+		run("tag", "3.4.0")
+		write("kong/plugins/acme/schema.lua", "return { fields = { { config = { type = \"record\" } } } }\n")
+		run("mv", "kong/plugins/acme/handler.lua", "kong/plugins/acme/init.lua")
+		write("kong/plugins/rate-limiting/schema.lua", "return {}\n")
+		run("add", ".")
+		run("commit", "--quiet", "-m", "feat: add config")
+		run("tag", "3.5.0")
+
+		m, err = NewMirror(Options{
+			Path:         GinkgoT().TempDir(),
+			Repositories: []string{"kong/kong"},
+			URL:          url,
+			Logger:       logger,
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Describe("creating a new mirror instance", Label("mirror-options"), func() {
+		It("a new mirror instance will not be instantiated without a path", func() {
+			_, err := NewMirror(Options{Repositories: []string{"kong/kong"}, Logger: logger})
+			Expect(err).Should(MatchError("mirror path is not set"))
+		})
+
+		It("a new mirror instance will not be instantiated with an invalid repository", func() {
+			_, err := NewMirror(Options{Path: "mirrors", Repositories: []string{"kong"}, Logger: logger})
+			Expect(err).Should(MatchError(`invalid mirror repository "kong"`))
+		})
+	})
+
+	It("queries will be unavailable until the repository is mirrored", func() {
+		_, err := m.FileContent("kong", "kong", "kong/plugins/acme/schema.lua", "3.4.0")
+		Expect(err).Should(MatchError(ErrUnavailable))
+	})
+
+	It("the content of a file at a ref will be retrieved", func() {
+		Expect(m.Sync("kong", "kong")).To(Succeed())
+		content, err := m.FileContent("Kong", "kong", "kong/plugins/acme/schema.lua", "3.4.0")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(content).Should(Equal("return { fields = {} }\n"))
+	})
+
+	It("a not found error will occur when the file does not exist at a ref", func() {
+		Expect(m.Sync("kong", "kong")).To(Succeed())
+		_, err := m.FileContent("kong", "kong", "kong/plugins/rate-limiting/schema.lua", "3.4.0")
+		Expect(err).Should(MatchError(github.ErrNotFound))
+	})
+
+	It("watching without a positive interval will synchronize once and return", func() {
+		m.Watch(0)
+		content, err := m.FileContent("kong", "kong", "kong/plugins/acme/schema.lua", "3.4.0")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(content).Should(Equal("return { fields = {} }\n"))
+	})
+
+	It("new refs will be fetched incrementally", func() {
+		Expect(m.Sync("kong", "kong")).To(Succeed())
+		_, err := m.FileContent("kong", "kong", "kong/plugins/acme/schema.lua", "3.6.0")
+		Expect(err).Should(MatchError(ErrUnavailable))
+
+		run("tag", "3.6.0")
+		Expect(m.Sync("kong", "kong")).To(Succeed())
+		_, err = m.FileContent("kong", "kong", "kong/plugins/acme/schema.lua", "3.6.0")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("the files modified between two refs will be retrieved with their patches", func() {
+		Expect(m.Sync("kong", "kong")).To(Succeed())
+		files, err := m.CompareFiles("kong", "kong", "3.4.0", "3.5.0")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files).To(HaveLen(3))
+		Expect(files[0]).Should(Equal(github.File{
+			Filename:         "kong/plugins/acme/init.lua",
+			PreviousFilename: "kong/plugins/acme/handler.lua",
+			Status:           "renamed",
+		}))
+		Expect(files[1].Filename).Should(Equal("kong/plugins/acme/schema.lua"))
+		Expect(files[1].Status).Should(Equal("modified"))
+		Expect(files[1].Patch).Should(Equal("@@ -1 +1 @@\n-return { fields = {} }\n" +
+			"+return { fields = { { config = { type = \"record\" } } } }"))
+		Expect(files[2].Status).Should(Equal("added"))
+	})
+
+	It("the blob SHAs of the files at a ref will be retrieved", func() {
+		Expect(m.Sync("kong", "kong")).To(Succeed())
+		tree, err := m.Tree("kong", "kong", "3.5.0")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tree).To(HaveLen(3))
+		Expect(tree).To(HaveKey("kong/plugins/acme/init.lua"))
+		Expect(tree).To(HaveKey("kong/plugins/rate-limiting/schema.lua"))
+		previous, err := m.Tree("kong", "kong", "3.4.0")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(previous["kong/plugins/acme/handler.lua"]).Should(Equal(tree["kong/plugins/acme/init.lua"]))
+		Expect(previous["kong/plugins/acme/schema.lua"]).NotTo(Equal(tree["kong/plugins/acme/schema.lua"]))
+	})
+
+	It("the lines of the default branch containing the terms will be found", func() {
+		Expect(m.Sync("kong", "kong")).To(Succeed())
+		matches, err := m.SearchCode("kong", "kong", []string{"config", "rate-limiting"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(matches).Should(Equal([]github.CodeMatch{{
+			Path: "kong/plugins/acme/schema.lua",
+			Line: 1,
+			Text: `return { fields = { { config = { type = "record" } } } }`,
+			Term: "config",
+		}}))
+	})
+
+	It("a search without matching lines will find nothing", func() {
+		Expect(m.Sync("kong", "kong")).To(Succeed())
+		matches, err := m.SearchCode("kong", "kong", []string{"sync_rate"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(matches).To(BeEmpty())
+	})
+
+	It("terms will only be found as whole words", func() {
+		Expect(m.Sync("kong", "kong")).To(Succeed())
+		matches, err := m.SearchCode("kong", "kong", []string{"field", "record"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(matches).To(HaveLen(1))
+		Expect(matches[0].Term).Should(Equal("record"))
+	})
+
+	It("the lines found for a term will be limited", func() {
+		var output strings.Builder
+		for i := 1; i <= github.MaxCodeMatchesPerTerm+5; i++ {
+			fmt.Fprintf(&output, "HEAD:a.go\x00%d\x00sync_rate := %d\n", i, i)
+		}
+		output.WriteString("HEAD:b.go\x001\x00policy := 1\n")
+		matches := parseGrep(output.String(), "HEAD", []string{"sync_rate", "policy"})
+		Expect(matches).To(HaveLen(github.MaxCodeMatchesPerTerm + 1))
+		Expect(matches[len(matches)-1].Term).Should(Equal("policy"))
+	})
+
+	It("the lines matching only as part of a hyphenated word will be dropped", func() {
+		output := "HEAD:a.go\x001\x00name := \"rate-limiting-advanced\"\n" +
+			"HEAD:a.go\x002\x00name := \"rate-limiting\"\n"
+		matches := parseGrep(output, "HEAD", []string{"rate-limiting"})
+		Expect(matches).Should(Equal([]github.CodeMatch{
+			{Path: "a.go", Line: 2, Text: `name := "rate-limiting"`, Term: "rate-limiting"},
+		}))
+	})
+})
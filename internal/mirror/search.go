@@ -0,0 +1,90 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package mirror
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/kong/koko-slack-bot/internal/github"
+)
+
+// SearchCode will get the lines of the default branch of a mirrored
+// repository containing any of the terms as whole words; at most
+// github.MaxCodeMatchesPerTerm lines are kept for each term.
+func (m *Mirror) SearchCode(organization string, repository string, terms []string) ([]github.CodeMatch, error) {
+	directory, err := m.repository(organization, repository)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := m.resolve(directory, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"grep", "-n", "-z", "-I", "-F", "-w", "--full-name"}
+	for _, term := range terms {
+		args = append(args, "-e", term)
+	}
+	output, err := m.git(directory, append(args, commit)...)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		// No line matched
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to search %s/%s: %w", organization, repository, err)
+	}
+	return parseGrep(output, commit, terms), nil
+}
+
+// parseGrep will parse the NUL separated output of git grep on a commit; the
+// first term found in a line is its term and lines beyond the maximum of
+// their term are dropped. git grep does not treat hyphens as part of words, so
+// lines without any term as a whole word are dropped as well.
+func parseGrep(output string, commit string, terms []string) []github.CodeMatch {
+	var matches []github.CodeMatch
+	found := make(map[string]int, len(terms))
+	for _, line := range strings.Split(strings.TrimSuffix(output, "\n"), "\n") {
+		fields := strings.SplitN(line, "\x00", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		number, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		match := github.CodeMatch{
+			Path: strings.TrimPrefix(fields[0], commit+":"),
+			Line: number,
+			Text: strings.TrimSpace(fields[2]),
+		}
+		for _, term := range terms {
+			if github.ContainsWord(fields[2], term) {
+				match.Term = term
+				break
+			}
+		}
+		if len(match.Term) == 0 || found[match.Term] == github.MaxCodeMatchesPerTerm {
+			continue
+		}
+		found[match.Term]++
+		matches = append(matches, match)
+	}
+	return matches
+}
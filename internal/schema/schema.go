@@ -0,0 +1,268 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/kong/koko-slack-bot/internal/lua"
+)
+
+// Kind represents the kind of a gateway schema.
+type Kind string
+
+const (
+	// KindPlugin represents the schema of a plugin configuration
+	KindPlugin Kind = "plugin"
+	// KindEntity represents the schema of a core entity; e.g. services
+	KindEntity Kind = "entity"
+)
+
+// ChangeKind represents the kind of change of a schema field.
+type ChangeKind string
+
+const (
+	// ChangeAdded represents a field added to a schema
+	ChangeAdded ChangeKind = "added"
+	// ChangeRemoved represents a field removed from a schema
+	ChangeRemoved ChangeKind = "removed"
+	// ChangeModified represents a field whose definition changed
+	ChangeModified ChangeKind = "modified"
+)
+
+var (
+	// pluginSchemaPattern represents the pattern of the paths of the gateway
+	// plugin schemas
+	pluginSchemaPattern = regexp.MustCompile(`^kong/plugins/([^/]+)/schema\.lua$`)
+	// entitySchemaPattern represents the pattern of the paths of the gateway
+	// entity schemas
+	entitySchemaPattern = regexp.MustCompile(`^kong/db/schema/entities/([^/]+)\.lua$`)
+	// requirePattern represents the pattern of a resolved reference to a field
+	// of a required module; e.g. require("kong.db.schema.typedefs").port
+	requirePattern = regexp.MustCompile(`^require\("(?:[^"]*\.)?([^".]+)"\)\.(.+)$`)
+)
+
+// Schema represents the parsed fields of a gateway schema.
+type Schema struct {
+	// Name represents the name declared by the schema
+	Name string
+	// Fields represents the fields of the schema flattened in declaration
+	// order; nested record fields are dotted and array elements use []
+	Fields []Field
+}
+
+// Field represents a field of a gateway schema.
+type Field struct {
+	// Path represents the dotted path of the field; e.g. config.sync_rate
+	Path string
+	// Type represents the type of the field or the typedef it uses; e.g.
+	// string, array<string>, or typedefs.protocols_http
+	Type string
+	// Required represents whether the field is required
+	Required bool
+	// Default represents the Lua source of the default value of the field;
+	// empty when the field has no default
+	Default string
+}
+
+// Change represents a field that differs between two revisions of a schema.
+type Change struct {
+	// Kind represents the kind of change
+	Kind ChangeKind
+	// Path represents the dotted path of the field
+	Path string
+	// Base represents the field in the base revision; nil when added
+	Base *Field
+	// Head represents the field in the head revision; nil when removed
+	Head *Field
+}
+
+// Classify will determine if a path is a gateway schema and get its kind and
+// the name of the plugin or entity it belongs to.
+func Classify(path string) (Kind, string, bool) {
+	if matches := pluginSchemaPattern.FindStringSubmatch(path); matches != nil {
+		return KindPlugin, matches[1], true
+	}
+	if matches := entitySchemaPattern.FindStringSubmatch(path); matches != nil {
+		return KindEntity, matches[1], true
+	}
+	return "", "", false
+}
+
+// Parse will parse the content of a gateway schema file; an empty content
+// results in an empty schema.
+func Parse(source string) (Schema, error) {
+	if len(strings.TrimSpace(source)) == 0 {
+		return Schema{}, nil
+	}
+	value, err := lua.ParseReturn(source)
+	if err != nil {
+		return Schema{}, fmt.Errorf("unable to parse schema: %w", err)
+	}
+	if value.Kind != lua.KindTable {
+		return Schema{}, fmt.Errorf("unable to parse schema: expected a table but found %s", value.Source())
+	}
+	var schema Schema
+	if name, ok := value.Table.Get("name"); ok && name.Kind == lua.KindString {
+		schema.Name = name.String
+	}
+	if fields, ok := value.Table.Get("fields"); ok {
+		schema.Fields = parseFields(fields, "")
+	}
+	return schema, nil
+}
+
+// parseFields will flatten the field entries of a fields table; entries that
+// are not fields are ignored.
+func parseFields(fields lua.Value, prefix string) []Field {
+	if fields.Kind != lua.KindTable {
+		return nil
+	}
+	var parsed []Field
+	for _, entry := range fields.Table.Array {
+		if entry.Kind != lua.KindTable || len(entry.Table.Fields) != 1 || entry.Table.Fields[0].Key.Kind != lua.KindString {
+			continue
+		}
+		name := entry.Table.Fields[0].Key.String
+		parsed = append(parsed, parseField(prefix+name, entry.Table.Fields[0].Value)...)
+	}
+	return parsed
+}
+
+// parseField will parse the definition of a field along with its nested record
+// fields. Typedefs are kept by reference, and the attributes given to a
+// called typedef are applied to it.
+func parseField(path string, definition lua.Value) []Field {
+	field := Field{Path: path}
+	var attributes *lua.Table
+	switch definition.Kind {
+	case lua.KindTable:
+		attributes = definition.Table
+	case lua.KindCall:
+		field.Type = TypedefName(definition.String)
+		if len(definition.Arguments) > 0 && definition.Arguments[0].Kind == lua.KindTable {
+			attributes = definition.Arguments[0].Table
+		}
+	default:
+		field.Type = TypedefName(definition.Source())
+	}
+	if attributes == nil {
+		return []Field{field}
+	}
+
+	if value, ok := attributes.Get("type"); ok && value.Kind == lua.KindString {
+		field.Type = value.String
+	}
+	if value, ok := attributes.Get("required"); ok && value.Kind == lua.KindBoolean {
+		field.Required = value.Boolean
+	}
+	if value, ok := attributes.Get("default"); ok {
+		field.Default = value.Source()
+	}
+	elements, ok := attributes.Get("elements")
+	if !ok || elements.Kind != lua.KindTable {
+		elements = lua.Value{}
+	}
+	if elementType, ok := elements.Table.Get("type"); ok && elementType.Kind == lua.KindString {
+		field.Type = fmt.Sprintf("%s<%s>", field.Type, elementType.String)
+	}
+
+	fields := []Field{field}
+	if nested, ok := attributes.Get("fields"); ok {
+		fields = append(fields, parseFields(nested, path+".")...)
+	}
+	if nested, ok := elements.Table.Get("fields"); ok {
+		fields = append(fields, parseFields(nested, path+"[].")...)
+	}
+	return fields
+}
+
+// TypedefName will shorten a reference to a field of a required module to
+// the name of the module and the field; e.g. typedefs.port.
+func TypedefName(reference string) string {
+	if matches := requirePattern.FindStringSubmatch(reference); matches != nil {
+		return matches[1] + "." + matches[2]
+	}
+	return reference
+}
+
+// Diff will get the fields that differ between the base and head revisions of
+// a schema ordered by path.
+func Diff(base Schema, head Schema) []Change {
+	baseFields := make(map[string]Field, len(base.Fields))
+	for _, field := range base.Fields {
+		baseFields[field.Path] = field
+	}
+	headFields := make(map[string]Field, len(head.Fields))
+	for _, field := range head.Fields {
+		headFields[field.Path] = field
+	}
+
+	var changes []Change
+	for path, headField := range headFields {
+		headField := headField
+		baseField, ok := baseFields[path]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Kind: ChangeAdded, Path: path, Head: &headField})
+		case baseField != headField:
+			baseField := baseField
+			changes = append(changes, Change{Kind: ChangeModified, Path: path, Base: &baseField, Head: &headField})
+		}
+	}
+	for path, baseField := range baseFields {
+		baseField := baseField
+		if _, ok := headFields[path]; !ok {
+			changes = append(changes, Change{Kind: ChangeRemoved, Path: path, Base: &baseField})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
+
+// String will get a short description of the definition of the field; e.g.
+// number, required, default -1.
+func (f Field) String() string {
+	parts := []string{f.Type}
+	if len(f.Type) == 0 {
+		parts[0] = "untyped"
+	}
+	if f.Required {
+		parts = append(parts, "required")
+	}
+	if len(f.Default) > 0 {
+		parts = append(parts, "default "+f.Default)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// String will get a short description of the change; e.g. added
+// config.sync_rate (number, default -1).
+func (c Change) String() string {
+	switch c.Kind {
+	case ChangeAdded:
+		return fmt.Sprintf("added %s (%s)", c.Path, c.Head)
+	case ChangeRemoved:
+		return fmt.Sprintf("removed %s (%s)", c.Path, c.Base)
+	default:
+		return fmt.Sprintf("modified %s (%s → %s)", c.Path, c.Base, c.Head)
+	}
+}
@@ -0,0 +1,127 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package schema
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("schema", Label("schema"), func() {
+	const base = `
+local typedefs = require "kong.db.schema.typedefs"
+
+return {
+  name = "rate-limiting",
+  fields = {
+    { protocols = typedefs.protocols_http },
+    { config = {
+        type = "record",
+        fields = {
+          { second = { type = "number", gt = 0 } },
+          { policy = { type = "string", default = "cluster", one_of = { "local", "cluster", "redis" } } },
+          { redis_port = typedefs.port({ default = 6379 }) },
+          { header_name = { type = "string" } },
+        },
+      },
+    },
+  },
+}
+`
+	const head = `
+local typedefs = require "kong.db.schema.typedefs"
+
+return {
+  name = "rate-limiting",
+  fields = {
+    { protocols = typedefs.protocols_http },
+    { config = {
+        type = "record",
+        fields = {
+          { second = { type = "number", gt = 0 } },
+          { policy = { type = "string", default = "local", required = true } },
+          { redis_port = typedefs.port({ default = 6379 }) },
+          { sync_rate = { type = "number", default = -1 } },
+          { limits = { type = "array", elements = {
+              type = "record",
+              fields = { { window = { type = "integer" } } },
+            },
+          } },
+        },
+      },
+    },
+  },
+}
+`
+
+	It("gateway schema paths will be classified", func() {
+		kind, name, ok := Classify("kong/plugins/rate-limiting/schema.lua")
+		Expect(ok).To(BeTrue())
+		Expect(kind).Should(Equal(KindPlugin))
+		Expect(name).Should(Equal("rate-limiting"))
+		kind, name, ok = Classify("kong/db/schema/entities/services.lua")
+		Expect(ok).To(BeTrue())
+		Expect(kind).Should(Equal(KindEntity))
+		Expect(name).Should(Equal("services"))
+		_, _, ok = Classify("kong/plugins/rate-limiting/handler.lua")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("the fields of a schema will be flattened with their definitions", func() {
+		schema, err := Parse(head)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(schema.Name).Should(Equal("rate-limiting"))
+		Expect(schema.Fields).Should(Equal([]Field{
+			{Path: "protocols", Type: "typedefs.protocols_http"},
+			{Path: "config", Type: "record"},
+			{Path: "config.second", Type: "number"},
+			{Path: "config.policy", Type: "string", Required: true, Default: `"local"`},
+			{Path: "config.redis_port", Type: "typedefs.port", Default: "6379"},
+			{Path: "config.sync_rate", Type: "number", Default: "-1"},
+			{Path: "config.limits", Type: "array<record>"},
+			{Path: "config.limits[].window", Type: "integer"},
+		}))
+	})
+
+	It("the fields differing between two revisions will be found", func() {
+		baseSchema, err := Parse(base)
+		Expect(err).NotTo(HaveOccurred())
+		headSchema, err := Parse(head)
+		Expect(err).NotTo(HaveOccurred())
+		var descriptions []string
+		for _, change := range Diff(baseSchema, headSchema) {
+			descriptions = append(descriptions, change.String())
+		}
+		Expect(descriptions).Should(Equal([]string{
+			"removed config.header_name (string)",
+			"added config.limits (array<record>)",
+			"added config.limits[].window (integer)",
+			`modified config.policy (string, default "cluster" → string, required, default "local")`,
+			"added config.sync_rate (number, default -1)",
+		}))
+	})
+
+	It("an empty schema will have no fields", func() {
+		schema, err := Parse("")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(schema.Fields).To(BeEmpty())
+	})
+
+	It("an error will occur when the schema does not return a table", func() {
+		_, err := Parse(`return "rate-limiting"`)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,141 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kong/koko-slack-bot/internal/github"
+	"github.com/kong/koko-slack-bot/internal/store"
+	"github.com/kong/koko-slack-bot/internal/templates"
+	"github.com/slack-go/slack"
+)
+
+// maxListedCommits represents the maximum number of commits listed in an
+// answer to keep the message within Slack's limits.
+const maxListedCommits = 25
+
+// markdownText will create a markdown text object.
+func markdownText(text string) *slack.TextBlockObject {
+	return slack.NewTextBlockObject(slack.MarkdownType, text, false, false)
+}
+
+// plainText will create a plain text object.
+func plainText(text string) *slack.TextBlockObject {
+	return slack.NewTextBlockObject(slack.PlainTextType, text, false, false)
+}
+
+// textBlocks will create the blocks for a simple markdown answer.
+func textBlocks(text string) []slack.Block {
+	return []slack.Block{
+		slack.NewSectionBlock(markdownText(text), nil, nil),
+	}
+}
+
+// pullRequestLink will create a markdown link to the pull request of a gateway
+// schema change.
+func pullRequestLink(gsc gatewaySchemaChange) string {
+	reference := fmt.Sprintf("%s/%s#%d", gsc.organization, gsc.repository, gsc.pullRequest)
+	if len(gsc.details.URL) == 0 {
+		return reference
+	}
+	return fmt.Sprintf("<%s|%s>", gsc.details.URL, reference)
+}
+
+// gatewaySchemaChangeBlocks will create the blocks for the reply to a gateway
+// schema change event.
+func gatewaySchemaChangeBlocks(gsc gatewaySchemaChange) []slack.Block {
+	state := gsc.details.State
+	if gsc.details.Merged {
+		state = "merged"
+	}
+	title := ":gear: *Gateway schema change*"
+	switch gsc.event() {
+	case templates.EventNewPlugin:
+		title = ":new: *New bundled gateway plugin*"
+	case templates.EventBreakingChange:
+		title = ":rotating_light: *Breaking gateway schema change*"
+	case templates.EventCompatOnly:
+		title = ":electric_plug: *Gateway compat change*"
+	case templates.EventSchemaChange:
+	}
+	blocks := []slack.Block{
+		slack.NewSectionBlock(markdownText(fmt.Sprintf("%s %s\n%s", title, pullRequestLink(gsc),
+			gsc.details.Title)), nil, nil),
+		slack.NewContextBlock("",
+			markdownText(fmt.Sprintf("Author: *%s*", gsc.details.Author)),
+			markdownText(fmt.Sprintf("State: *%s*", state)),
+			markdownText(fmt.Sprintf("Branch: `%s` ← `%s`", gsc.details.BaseRef, gsc.details.HeadRef)),
+		),
+	}
+	if context := descriptionContextBlock(gsc.description); context != nil {
+		blocks = append(blocks, context)
+	}
+	if len(gsc.changelog) > 0 {
+		blocks = append(blocks, slack.NewSectionBlock(markdownText(":memo: "+changelogText(gsc.changelog)), nil, nil))
+	}
+	if gsc.bundledPlugins != nil {
+		blocks = append(blocks, slack.NewSectionBlock(markdownText(":jigsaw: "+bundledPluginsText(gsc.bundledPlugins)),
+			nil, nil))
+	}
+	if gsc.migrations != nil {
+		blocks = append(blocks, slack.NewSectionBlock(markdownText(":card_file_box: "+migrationsText(gsc.migrations)),
+			nil, nil))
+	}
+	if gsc.compat != nil {
+		blocks = append(blocks, compatBlock(gsc.compat))
+	}
+	if gsc.impact != nil {
+		blocks = append(blocks, impactBlock(gsc.impact))
+	}
+	return append(blocks, triageActionsBlock(gsc.key()))
+}
+
+// schemaChangesBlocks will create the blocks for the answer of the schema
+// changes command.
+func schemaChangesBlocks(plugin string, since string, commits []github.Commit) []slack.Block {
+	if len(commits) == 0 {
+		return textBlocks(fmt.Sprintf("No schema changes for `%s` since %s", plugin, since))
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "*%d schema change(s) for `%s` since %s:*", len(commits), plugin, since)
+	for i, commit := range commits {
+		if i == maxListedCommits {
+			fmt.Fprintf(&sb, "\n… and %d more", len(commits)-maxListedCommits)
+			break
+		}
+		fmt.Fprintf(&sb, "\n• <%s|%s> %s (%s, %s)", commit.URL, commit.SHA[:7], commit.Message,
+			commit.Author, commit.Date.Format("2006-01-02"))
+	}
+	return textBlocks(sb.String())
+}
+
+// releaseBlocks will create the blocks announcing the schema changes shipped in
+// a gateway release.
+func releaseBlocks(release string, schemaChanges []store.SchemaChange) []slack.Block {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, ":package: *%d gateway schema change(s) shipped in %s:*", len(schemaChanges), release)
+	for i, sc := range schemaChanges {
+		if i == maxListedCommits {
+			fmt.Fprintf(&sb, "\n… and %d more", len(schemaChanges)-maxListedCommits)
+			break
+		}
+		fmt.Fprintf(&sb, "\n• <%s|%s> %s", sc.URL, sc.Key(), sc.Title)
+	}
+	return textBlocks(sb.String())
+}
@@ -0,0 +1,46 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kong/koko-slack-bot/internal/github"
+)
+
+// changelogText will create the text listing the changelog entries of a
+// gateway schema change.
+func changelogText(entries []github.ChangelogEntry) string {
+	var sb strings.Builder
+	sb.WriteString("Changelog:")
+	for _, entry := range entries {
+		sb.WriteString("\n• ")
+		if len(entry.Type) > 0 {
+			sb.WriteString(entry.Type)
+			if len(entry.Scope) > 0 {
+				fmt.Fprintf(&sb, " (%s)", entry.Scope)
+			}
+			sb.WriteString(": ")
+		}
+		// Multiline messages are joined to keep one entry per line
+		sb.WriteString(strings.Join(strings.Fields(entry.Message), " "))
+		if len(entry.Jiras) > 0 && !strings.Contains(entry.Message, entry.Jiras[0]) {
+			fmt.Fprintf(&sb, " [%s]", strings.Join(entry.Jiras, ", "))
+		}
+	}
+	return sb.String()
+}
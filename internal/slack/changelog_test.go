@@ -0,0 +1,45 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"github.com/kong/koko-slack-bot/internal/github"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("changelog", Label("changelog"), func() {
+	It("the changelog entries will be listed one per line", func() {
+		text := changelogText([]github.ChangelogEntry{
+			{
+				Type:    "feature",
+				Scope:   "Plugin",
+				Message: "**Rate Limiting**: Added the\nsync_rate option.",
+				Jiras:   []string{"KAG-1234", "FTI-5678"},
+			},
+			{
+				Type:    "bugfix",
+				Message: "fix crash [KAG-1]",
+				Jiras:   []string{"KAG-1"},
+			},
+			{Message: "Improve the error message"},
+		})
+		Expect(text).Should(Equal(`Changelog:
+• feature (Plugin): **Rate Limiting**: Added the sync_rate option. [KAG-1234, FTI-5678]
+• bugfix: fix crash [KAG-1]
+• Improve the error message`))
+	})
+})
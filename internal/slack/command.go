@@ -0,0 +1,276 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"github.com/slack-go/slack/socketmode"
+	"go.uber.org/zap"
+)
+
+const (
+	// gatewayOrganization represents the GitHub organization of Kong Gateway
+	gatewayOrganization = "kong"
+	// gatewayRepository represents the GitHub repository of Kong Gateway
+	gatewayRepository = "kong"
+	// slashCommandName represents the slash command registered for the application
+	slashCommandName = "/koko"
+)
+
+// commandKind represents the type of conversational command.
+type commandKind int
+
+const (
+	// commandHelp represents the command to display the command usage
+	commandHelp commandKind = iota
+	// commandSchemaChanges represents the command to list the schema changes of
+	// a plugin since a Kong Gateway version
+	commandSchemaChanges
+	// commandReprocess represents the command to reprocess a pull request as a
+	// gateway schema change event
+	commandReprocess
+)
+
+var (
+	// mentionPattern represents the pattern of a user mention in message text
+	mentionPattern = regexp.MustCompile(`<@[A-Z0-9]+(\|[^>]*)?>`)
+	// schemaChangesPattern represents the pattern of the schema changes command
+	schemaChangesPattern = regexp.MustCompile(
+		`^schema changes for ([a-z0-9_-]+) since v?(\d+\.\d+(\.\d+)?)$`)
+	// reprocessPattern represents the pattern of the reprocess command
+	reprocessPattern = regexp.MustCompile(`^reprocess (\S+)$`)
+	// pullRequestShortPattern represents the pattern of a pull request
+	// reference in the form of organization/repository#number
+	pullRequestShortPattern = regexp.MustCompile(`^([\w.-]+)/([\w.-]+)#(\d+)$`)
+	// pullRequestURLPattern represents the pattern of a pull request URL
+	pullRequestURLPattern = regexp.MustCompile(`https://github\.com/([\w.-]+)/([\w.-]+)/pull/(\d+)`)
+)
+
+// commandUsage represents the usage of the conversational commands.
+const commandUsage = "*Available commands:*\n" +
+	"• `schema changes for <plugin> since <version>`: list the schema changes of a Kong Gateway plugin " +
+	"since a release (e.g. `schema changes for rate-limiting since 3.4`)\n" +
+	"• `reprocess <organization>/<repository>#<pull request>`: reprocess a pull request as a gateway " +
+	"schema change (e.g. `reprocess kong/kong#11234`)\n" +
+	"• `help`: display this message"
+
+// command represents a parsed conversational command.
+type command struct {
+	// kind represents the type of the command
+	kind commandKind
+	// plugin represents the Kong Gateway plugin name for the schema changes
+	// command
+	plugin string
+	// since represents the Kong Gateway version for the schema changes command
+	since string
+	// reference represents the pull request for the reprocess command
+	reference gatewaySchemaChange
+}
+
+// parseCommand will parse the text of an application mention or slash command
+// into a command.
+func parseCommand(text string) (command, error) {
+	text = mentionPattern.ReplaceAllString(text, "")
+	text = strings.ToLower(strings.Join(strings.Fields(text), " "))
+
+	if len(text) == 0 || text == "help" {
+		return command{kind: commandHelp}, nil
+	}
+	if matches := schemaChangesPattern.FindStringSubmatch(text); matches != nil {
+		return command{
+			kind:   commandSchemaChanges,
+			plugin: matches[1],
+			since:  matches[2],
+		}, nil
+	}
+	if matches := reprocessPattern.FindStringSubmatch(text); matches != nil {
+		reference, err := parsePullRequestReference(matches[1])
+		if err != nil {
+			return command{}, err
+		}
+		return command{
+			kind:      commandReprocess,
+			reference: reference,
+		}, nil
+	}
+	return command{}, fmt.Errorf("unknown command: %s", text)
+}
+
+// parsePullRequestReference will parse a pull request reference in the form of
+// organization/repository#number or a GitHub pull request URL.
+func parsePullRequestReference(reference string) (gatewaySchemaChange, error) {
+	// Slack wraps URLs with angle brackets and an optional label
+	reference = strings.TrimSuffix(strings.TrimPrefix(reference, "<"), ">")
+	reference, _, _ = strings.Cut(reference, "|")
+
+	matches := pullRequestShortPattern.FindStringSubmatch(reference)
+	if matches == nil {
+		matches = pullRequestURLPattern.FindStringSubmatch(reference)
+	}
+	if matches == nil {
+		return gatewaySchemaChange{}, fmt.Errorf("invalid pull request reference: %s", reference)
+	}
+	pullRequest, err := strconv.Atoi(matches[3])
+	if err != nil {
+		return gatewaySchemaChange{}, fmt.Errorf("unable to convert pull request to number: %s", matches[3])
+	}
+	return gatewaySchemaChange{
+		organization: matches[1],
+		pullRequest:  pullRequest,
+		repository:   matches[2],
+	}, nil
+}
+
+// versionTag will convert a Kong Gateway version into its release tag.
+func versionTag(version string) string {
+	if strings.Count(version, ".") == 1 {
+		return version + ".0"
+	}
+	return version
+}
+
+// pluginSchemaPath will get the path of the schema for a Kong Gateway plugin.
+func pluginSchemaPath(plugin string) string {
+	return fmt.Sprintf("kong/plugins/%s/schema.lua", plugin)
+}
+
+// executeCommand will execute a command and return the blocks of the answer.
+func (s *Slack) executeCommand(cmd command) ([]slack.Block, error) {
+	switch cmd.kind {
+	case commandSchemaChanges:
+		commits, err := s.gitHubClient.FileCommitsSince(gatewayOrganization, gatewayRepository,
+			pluginSchemaPath(cmd.plugin), versionTag(cmd.since))
+		if err != nil {
+			return nil, fmt.Errorf("unable to get schema changes for %s: %w", cmd.plugin, err)
+		}
+		return schemaChangesBlocks(cmd.plugin, cmd.since, commits), nil
+	case commandReprocess:
+		gsc := cmd.reference
+		if !s.knownGatewayRepository(gsc.organization, gsc.repository) {
+			return nil, fmt.Errorf("unknown gateway repository %s/%s", gsc.organization, gsc.repository)
+		}
+		if err := s.processGatewaySchemaChange(&gsc); err != nil {
+			return nil, fmt.Errorf("unable to reprocess gateway schema change: %w", err)
+		}
+		s.refreshHome()
+		return s.gatewaySchemaChangeReply(gsc), nil
+	case commandHelp:
+		return textBlocks(commandUsage), nil
+	}
+	return nil, errors.New("unhandled command")
+}
+
+// knownGatewayRepository will determine if a repository is a gateway
+// repository; the gateway repository, the repositories whose releases are
+// reported, and the repositories of the stored schema changes are known.
+func (s *Slack) knownGatewayRepository(organization string, repository string) bool {
+	key := releaseRepository{organization: organization, repository: repository}.key()
+	if key == gatewayOrganization+"/"+gatewayRepository {
+		return true
+	}
+	if _, ok := s.releaseRepositories[key]; ok {
+		return true
+	}
+	for _, sc := range s.store.SchemaChanges() {
+		if strings.EqualFold(sc.Organization, organization) && strings.EqualFold(sc.Repository, repository) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleCommand will parse and execute the text of a command and return the
+// blocks of the answer; errors are answered so the user is aware of them.
+func (s *Slack) handleCommand(text string) []slack.Block {
+	cmd, err := parseCommand(text)
+	if err != nil {
+		return textBlocks(fmt.Sprintf(":warning: %s\n\n%s", err.Error(), commandUsage))
+	}
+	blocks, err := s.executeCommand(cmd)
+	if err != nil {
+		s.logger.Error("unable to execute command", zap.Error(err))
+		return textBlocks(fmt.Sprintf(":x: %s", err.Error()))
+	}
+	return blocks
+}
+
+// handleAppMentionEvent will process application mentions as conversational
+// commands and answer them in the thread of the mention.
+func (s *Slack) handleAppMentionEvent(e *socketmode.Event, c *socketmode.Client) {
+	// Ensure this is the correct event
+	eventsAPIEvent, ok := e.Data.(slackevents.EventsAPIEvent)
+	if !ok {
+		s.logger.Error("event ignored as it is not an API event", zap.Any("event", e))
+		return
+	}
+	c.Ack(*e.Request)
+
+	mentionEvent, ok := eventsAPIEvent.InnerEvent.Data.(*slackevents.AppMentionEvent)
+	if !ok {
+		s.logger.Debug("event ignored as it is not an application mention event", zap.Any("event", e))
+		return
+	}
+	// Do not handle mentions from Koko's Slack bot
+	if mentionEvent.BotID == s.botID {
+		s.logger.Debug("event ignored as the mention originated from our application",
+			zap.String("bot-id", mentionEvent.BotID))
+		return
+	}
+	s.logger.Debug("application mention received", zap.String("channel", mentionEvent.Channel),
+		zap.String("user", mentionEvent.User),
+		zap.String("message", mentionEvent.Text))
+
+	// Answer in the thread of the mention; starting a thread when necessary
+	threadTimestamp := mentionEvent.ThreadTimeStamp
+	if len(threadTimestamp) == 0 {
+		threadTimestamp = mentionEvent.TimeStamp
+	}
+	blocks := s.handleCommand(mentionEvent.Text)
+	_, _, err := s.client.PostMessage(mentionEvent.Channel,
+		slack.MsgOptionBlocks(blocks...),
+		slack.MsgOptionTS(threadTimestamp))
+	if err != nil {
+		s.logger.Error("unable to answer application mention", zap.Error(err))
+	}
+}
+
+// handleSlashCommand will process the slash command of the application and
+// answer in the channel the command was issued from.
+func (s *Slack) handleSlashCommand(e *socketmode.Event, c *socketmode.Client) {
+	// Ensure this is the correct event
+	slashCommand, ok := e.Data.(slack.SlashCommand)
+	if !ok {
+		s.logger.Error("event ignored as it is not a slash command", zap.Any("event", e))
+		return
+	}
+	c.Ack(*e.Request)
+	s.logger.Debug("slash command received", zap.String("channel", slashCommand.ChannelName),
+		zap.String("username", slashCommand.UserName),
+		zap.String("text", slashCommand.Text))
+
+	blocks := s.handleCommand(slashCommand.Text)
+	_, _, err := s.client.PostMessage(slashCommand.ChannelID, slack.MsgOptionBlocks(blocks...))
+	if err != nil {
+		s.logger.Error("unable to answer slash command", zap.Error(err))
+	}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"path/filepath"
+
+	"github.com/kong/koko-slack-bot/internal/github"
+	"github.com/kong/koko-slack-bot/internal/store"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("commands", Label("commands"), func() {
+	Describe("parsing a command", func() {
+		When("the command is valid", func() {
+			DescribeTable("the command will be parsed",
+				func(text string, expected command) {
+					cmd, err := parseCommand(text)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(cmd).Should(Equal(expected))
+				},
+				Entry("empty mention", "<@U056LB75L5R>", command{kind: commandHelp}),
+				Entry("help", "<@U056LB75L5R> help", command{kind: commandHelp}),
+				Entry("schema changes",
+					"<@U056LB75L5R> schema changes for rate-limiting since 3.4",
+					command{kind: commandSchemaChanges, plugin: "rate-limiting", since: "3.4"}),
+				Entry("schema changes with extra whitespace and case",
+					"<@U056LB75L5R>   Schema Changes for   ACME since v3.3.1 ",
+					command{kind: commandSchemaChanges, plugin: "acme", since: "3.3.1"}),
+				Entry("reprocess with short reference",
+					"<@U056LB75L5R> reprocess kong/kong#11234",
+					command{kind: commandReprocess, reference: gatewaySchemaChange{
+						organization: "kong",
+						pullRequest:  11234,
+						repository:   "kong",
+					}}),
+				Entry("reprocess with pull request URL",
+					"<@U056LB75L5R> reprocess <https://github.com/kong/kong-ee/pull/5291>",
+					command{kind: commandReprocess, reference: gatewaySchemaChange{
+						organization: "kong",
+						pullRequest:  5291,
+						repository:   "kong-ee",
+					}}),
+			)
+		})
+
+		When("the command is invalid", func() {
+			DescribeTable("the command will not be parsed",
+				func(text string, expectedError string) {
+					_, err := parseCommand(text)
+					Expect(err).To(HaveOccurred())
+					Expect(err).Should(MatchError(expectedError))
+				},
+				Entry("unknown command", "<@U056LB75L5R> deploy koko", "unknown command: deploy koko"),
+				Entry("schema changes without version",
+					"<@U056LB75L5R> schema changes for rate-limiting",
+					"unknown command: schema changes for rate-limiting"),
+				Entry("reprocess with invalid reference",
+					"<@U056LB75L5R> reprocess kong/kong",
+					"invalid pull request reference: kong/kong"),
+			)
+		})
+	})
+
+	Describe("converting a version to a release tag", func() {
+		It("the patch version will be added when missing", func() {
+			Expect(versionTag("3.4")).Should(Equal("3.4.0"))
+			Expect(versionTag("3.4.1")).Should(Equal("3.4.1"))
+		})
+	})
+
+	Describe("reprocessing a pull request", func() {
+		var s *Slack
+
+		BeforeEach(func() {
+			logger, err := zap.NewDevelopment()
+			Expect(err).NotTo(HaveOccurred())
+			st, err := store.NewStore(store.Options{
+				Path:   filepath.Join(GinkgoT().TempDir(), "store.json"),
+				Logger: logger,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = st.UpdateSchemaChange("kong/kong-ee#5291", func(sc *store.SchemaChange) {
+				sc.Organization = "kong"
+				sc.Repository = "kong-ee"
+				sc.PullRequest = 5291
+			})
+			Expect(err).NotTo(HaveOccurred())
+			s, err = NewSlack(Options{
+				AppToken:            "xapp-",
+				BotToken:            "xoxb-",
+				Logger:              logger,
+				GitHubClient:        &github.Client{},
+				Store:               st,
+				ReleaseRepositories: []string{"kong/kong-enterprise"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("the gateway, release, and stored repositories will be known", func() {
+			Expect(s.knownGatewayRepository("Kong", "kong")).To(BeTrue())
+			Expect(s.knownGatewayRepository("kong", "kong-enterprise")).To(BeTrue())
+			Expect(s.knownGatewayRepository("kong", "kong-ee")).To(BeTrue())
+			Expect(s.knownGatewayRepository("kong", "koko")).To(BeFalse())
+		})
+
+		It("a pull request of an unknown repository will not be reprocessed", func() {
+			cmd, err := parseCommand("<@U056LB75L5R> reprocess someone/else#1")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = s.executeCommand(cmd)
+			Expect(err).To(MatchError("unknown gateway repository someone/else"))
+		})
+	})
+})
@@ -0,0 +1,88 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kong/koko-slack-bot/internal/store"
+)
+
+// pullRequestCommentMarker represents the hidden marker identifying the
+// tracking comment of the bot on gateway pull requests.
+const pullRequestCommentMarker = "<!-- koko-slack-bot:tracking -->"
+
+// schemaChangeSeverity will get the severity of a gateway schema change and
+// whether it was assessed; the severity is estimated from the schema change
+// when it was not assessed.
+func schemaChangeSeverity(gsc gatewaySchemaChange, sc store.SchemaChange) (store.Severity, bool) {
+	if sc.Assessment != nil && len(sc.Assessment.Severity) > 0 {
+		return sc.Assessment.Severity, true
+	}
+	switch {
+	case len(breakingChanges(gsc)) > 0:
+		return store.SeverityHigh, false
+	case gsc.compat != nil:
+		return store.SeverityMedium, false
+	}
+	return store.SeverityLow, false
+}
+
+// pullRequestCommentText will create the markdown of the tracking comment of a
+// gateway schema change pull request.
+func pullRequestCommentText(gsc gatewaySchemaChange, sc store.SchemaChange) string {
+	var sb strings.Builder
+	sb.WriteString("This schema change was picked up by Koko.\n")
+	switch {
+	case sc.Ticket != nil && len(sc.Ticket.URL) > 0:
+		fmt.Fprintf(&sb, "\n**Ticket:** [%s](%s)", sc.Ticket.Key, sc.Ticket.URL)
+	case sc.Ticket != nil:
+		fmt.Fprintf(&sb, "\n**Ticket:** %s", sc.Ticket.Key)
+	default:
+		sb.WriteString("\n**Ticket:** not opened yet")
+	}
+	severity, assessed := schemaChangeSeverity(gsc, sc)
+	fmt.Fprintf(&sb, "\n**Severity:** %s", severity)
+	if !assessed {
+		sb.WriteString(" (estimated)")
+	}
+	if gsc.compat != nil {
+		fmt.Fprintf(&sb, "\n\n### Compatibility notes\n\n%s", compatText(gsc.compat, 0))
+	}
+	return sb.String()
+}
+
+// syncPullRequestComment will post or update the tracking comment of a gateway
+// schema change on its pull request; the schema change is processed when it
+// was not already. Nothing is done when pull request comments are disabled.
+func (s *Slack) syncPullRequestComment(gsc gatewaySchemaChange) error {
+	if !s.pullRequestComments {
+		return nil
+	}
+	if len(gsc.details.URL) == 0 {
+		if err := s.processGatewaySchemaChange(&gsc); err != nil {
+			return err
+		}
+	}
+	sc, _ := s.store.SchemaChange(gsc.key())
+	err := s.gitHubClient.UpsertIssueComment(gsc.organization, gsc.repository, gsc.pullRequest,
+		pullRequestCommentMarker, pullRequestCommentText(gsc, sc))
+	if err != nil {
+		return fmt.Errorf("unable to comment on pull request: %w", err)
+	}
+	return nil
+}
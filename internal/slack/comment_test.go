@@ -0,0 +1,163 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+
+	"github.com/kong/koko-slack-bot/internal/compat"
+	"github.com/kong/koko-slack-bot/internal/github"
+	"github.com/kong/koko-slack-bot/internal/store"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("pull request comment", Label("comment"), func() {
+	compatGSC := gatewaySchemaChange{
+		organization: "kong",
+		repository:   "kong",
+		pullRequest:  11234,
+		details:      github.PullRequest{URL: "https://github.com/kong/kong/pull/11234"},
+		compat: &compatChange{
+			removedFields: []compat.RemovedFieldsEntry{
+				{Version: 3005000000, Plugin: "rate_limiting", Added: []string{"sync_rate"}},
+			},
+		},
+	}
+
+	It("the severity will be estimated unless assessed", func() {
+		severity, assessed := schemaChangeSeverity(gatewaySchemaChange{}, store.SchemaChange{})
+		Expect(severity).Should(Equal(store.SeverityLow))
+		Expect(assessed).To(BeFalse())
+		severity, assessed = schemaChangeSeverity(compatGSC, store.SchemaChange{})
+		Expect(severity).Should(Equal(store.SeverityMedium))
+		Expect(assessed).To(BeFalse())
+		severity, assessed = schemaChangeSeverity(gatewaySchemaChange{
+			bundledPlugins: &bundledPluginsChange{removed: []string{"acme"}},
+		}, store.SchemaChange{})
+		Expect(severity).Should(Equal(store.SeverityHigh))
+		Expect(assessed).To(BeFalse())
+		severity, assessed = schemaChangeSeverity(compatGSC, store.SchemaChange{
+			Assessment: &store.Assessment{Severity: store.SeverityCritical},
+		})
+		Expect(severity).Should(Equal(store.SeverityCritical))
+		Expect(assessed).To(BeTrue())
+	})
+
+	It("the comment will link the ticket and list the compatibility notes", func() {
+		Expect(pullRequestCommentText(compatGSC, store.SchemaChange{
+			Ticket:     &store.Ticket{Key: "KOKO-1", URL: "https://konghq.atlassian.net/browse/KOKO-1"},
+			Assessment: &store.Assessment{Severity: store.SeverityHigh},
+		})).Should(Equal(`This schema change was picked up by Koko.
+
+**Ticket:** [KOKO-1](https://konghq.atlassian.net/browse/KOKO-1)
+**Severity:** high
+
+### Compatibility notes
+
+Koko compat entries needed (kong/clustering/compat/removed_fields.lua):
+3.5.0 (3005000000)
+• rate_limiting: add sync_rate`))
+	})
+
+	It("the comment will report a missing ticket and an estimated severity", func() {
+		Expect(pullRequestCommentText(gatewaySchemaChange{}, store.SchemaChange{})).Should(Equal(
+			"This schema change was picked up by Koko.\n\n**Ticket:** not opened yet\n**Severity:** low (estimated)"))
+	})
+
+	When("synchronizing the comment", func() {
+		var server *httptest.Server
+		var comments []string
+		var st *store.Store
+		var logger *zap.Logger
+		var client *github.Client
+
+		BeforeEach(func() {
+			var err error
+			logger, err = zap.NewDevelopment()
+			Expect(err).NotTo(HaveOccurred())
+			comments = nil
+			mux := http.NewServeMux()
+			mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"login": "koko-bot"}`))
+			})
+			mux.HandleFunc("/repos/kong/kong/issues/11234/comments", func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodPost {
+					var request map[string]any
+					Expect(json.NewDecoder(r.Body).Decode(&request)).To(Succeed())
+					comments = append(comments, request["body"].(string))
+					w.WriteHeader(http.StatusCreated)
+					_, _ = w.Write([]byte(`{"id": 1}`))
+					return
+				}
+				_, _ = w.Write([]byte(`[]`))
+			})
+			server = httptest.NewServer(mux)
+			client, err = github.NewClient(github.Options{
+				Token:   "token",
+				BaseURL: server.URL,
+				Logger:  logger,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			st, err = store.NewStore(store.Options{
+				Path:   filepath.Join(GinkgoT().TempDir(), "store.json"),
+				Logger: logger,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = st.UpdateSchemaChange(compatGSC.key(), func(sc *store.SchemaChange) {
+				sc.Ticket = &store.Ticket{Key: "KOKO-1"}
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("the marked comment will be posted when enabled", func() {
+			s, err := NewSlack(Options{
+				AppToken:            "xapp-",
+				BotToken:            "xoxb-",
+				Logger:              logger,
+				GitHubClient:        client,
+				Store:               st,
+				PullRequestComments: true,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.syncPullRequestComment(compatGSC)).To(Succeed())
+			Expect(comments).To(HaveLen(1))
+			Expect(comments[0]).Should(HavePrefix(pullRequestCommentMarker + "\n"))
+			Expect(comments[0]).Should(ContainSubstring("**Ticket:** KOKO-1"))
+		})
+
+		It("nothing will be posted when disabled", func() {
+			s, err := NewSlack(Options{
+				AppToken:     "xapp-",
+				BotToken:     "xoxb-",
+				Logger:       logger,
+				GitHubClient: client,
+				Store:        st,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.syncPullRequestComment(compatGSC)).To(Succeed())
+			Expect(comments).To(BeEmpty())
+		})
+	})
+})
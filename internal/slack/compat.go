@@ -0,0 +1,146 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kong/koko-slack-bot/internal/compat"
+	"github.com/kong/koko-slack-bot/internal/github"
+	"github.com/slack-go/slack"
+)
+
+// compatChange represents the changes to the gateway compatibility files that
+// Koko has to mirror to downgrade the configuration of older data planes.
+type compatChange struct {
+	// removedFields represents the removed fields entries that changed
+	removedFields []compat.RemovedFieldsEntry
+	// checkersModified represents whether checkers.lua was modified
+	checkersModified bool
+	// checkers represents the gateway version numbers of the added checkers
+	checkers []int64
+	// analysisError represents the error that occurred analyzing the changes
+	analysisError string
+}
+
+// touchesCompat will determine if a pull request modified a gateway
+// compatibility file.
+func touchesCompat(files []github.File) bool {
+	for _, file := range files {
+		if compat.IsCompatFile(file.Filename) || compat.IsCompatFile(file.PreviousFilename) {
+			return true
+		}
+	}
+	return false
+}
+
+// analyzeCompat will diff the merge base and head revisions of the
+// compatibility files modified, or renamed, by a gateway schema change.
+func (s *Slack) analyzeCompat(gsc gatewaySchemaChange) (*compatChange, error) {
+	change := &compatChange{}
+	if touchesFile(gsc.files, compat.RemovedFieldsPath) {
+		base, head, err := s.compatRevisions(gsc, compat.RemovedFieldsPath)
+		if err != nil {
+			return nil, err
+		}
+		baseFields, err := compat.ParseRemovedFields(base)
+		if err != nil {
+			return nil, fmt.Errorf("base revision: %w", err)
+		}
+		headFields, err := compat.ParseRemovedFields(head)
+		if err != nil {
+			return nil, fmt.Errorf("head revision: %w", err)
+		}
+		change.removedFields = compat.DiffRemovedFields(baseFields, headFields)
+	}
+	if touchesFile(gsc.files, compat.CheckersPath) {
+		change.checkersModified = true
+		base, head, err := s.compatRevisions(gsc, compat.CheckersPath)
+		if err != nil {
+			return nil, err
+		}
+		baseCheckers, err := compat.ParseCheckers(base)
+		if err != nil {
+			return nil, fmt.Errorf("base revision: %w", err)
+		}
+		headCheckers, err := compat.ParseCheckers(head)
+		if err != nil {
+			return nil, fmt.Errorf("head revision: %w", err)
+		}
+		change.checkers = compat.DiffCheckers(baseCheckers, headCheckers)
+	}
+	return change, nil
+}
+
+// compatRevisions will get the content of a compatibility file at the merge
+// base and at the head of a gateway schema change; a file renamed away or
+// into the path is empty at one of the revisions.
+func (s *Slack) compatRevisions(gsc gatewaySchemaChange, path string) (string, string, error) {
+	base, err := s.gatewayFileContent(gsc, path, gsc.baseRevision())
+	if err != nil {
+		return "", "", err
+	}
+	head, err := s.gatewayFileContent(gsc, path, gsc.details.HeadSHA)
+	if err != nil {
+		return "", "", err
+	}
+	return base, head, nil
+}
+
+// compatText will create the text listing the compatibility entries Koko has
+// to add for each gateway version; at most limit entries are listed when the
+// limit is positive.
+func compatText(change *compatChange, limit int) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Koko compat entries needed (%s):", compat.RemovedFieldsPath)
+	if len(change.analysisError) > 0 {
+		fmt.Fprintf(&sb, "\n⚠ unable to analyze the compatibility changes: %s", change.analysisError)
+	}
+	if len(change.removedFields) == 0 && len(change.analysisError) == 0 {
+		sb.WriteString("\nNo removed fields changes")
+	}
+	var version int64
+	for i, entry := range change.removedFields {
+		if limit > 0 && i == limit {
+			fmt.Fprintf(&sb, "\n… and %d more", len(change.removedFields)-limit)
+			break
+		}
+		if entry.Version != version {
+			version = entry.Version
+			fmt.Fprintf(&sb, "\n%s (%d)", compat.VersionString(version), version)
+		}
+		if len(entry.Added) > 0 {
+			fmt.Fprintf(&sb, "\n• %s: add %s", entry.Plugin, strings.Join(entry.Added, ", "))
+		}
+		if len(entry.Removed) > 0 {
+			fmt.Fprintf(&sb, "\n• %s: stop removing %s", entry.Plugin, strings.Join(entry.Removed, ", "))
+		}
+	}
+	if change.checkersModified {
+		fmt.Fprintf(&sb, "\n⚠ %s was modified; review the checkers for Koko", compat.CheckersPath)
+		for _, checker := range change.checkers {
+			fmt.Fprintf(&sb, "\n• new checker for %s (%d)", compat.VersionString(checker), checker)
+		}
+	}
+	return sb.String()
+}
+
+// compatBlock will create the block listing the compatibility entries Koko
+// has to add for each gateway version.
+func compatBlock(change *compatChange) slack.Block {
+	return slack.NewSectionBlock(markdownText(":electric_plug: "+compatText(change, maxListedCommits)), nil, nil)
+}
@@ -0,0 +1,150 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/kong/koko-slack-bot/internal/compat"
+	"github.com/kong/koko-slack-bot/internal/github"
+	"github.com/kong/koko-slack-bot/internal/store"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("compat", Label("compat"), func() {
+	It("pull requests modifying the compatibility files will be detected", func() {
+		Expect(touchesCompat([]github.File{{Filename: "kong/plugins/acme/schema.lua"}})).To(BeFalse())
+		Expect(touchesCompat([]github.File{
+			{Filename: "kong/plugins/acme/schema.lua"},
+			{Filename: compat.CheckersPath},
+		})).To(BeTrue())
+	})
+
+	It("the entries will be listed for each gateway version", func() {
+		text := compatText(&compatChange{
+			removedFields: []compat.RemovedFieldsEntry{
+				{Version: 3001000000, Plugin: "acme", Added: []string{"storage_config.redis.ssl"}},
+				{Version: 3001000000, Plugin: "rate_limiting", Removed: []string{"error_message"}},
+				{Version: 3005000000, Plugin: "rate_limiting", Added: []string{"sync_rate", "sync_period"}},
+			},
+			checkersModified: true,
+			checkers:         []int64{3005000000},
+		}, 0)
+		Expect(text).Should(Equal(`Koko compat entries needed (kong/clustering/compat/removed_fields.lua):
+3.1.0 (3001000000)
+• acme: add storage_config.redis.ssl
+• rate_limiting: stop removing error_message
+3.5.0 (3005000000)
+• rate_limiting: add sync_rate, sync_period
+⚠ kong/clustering/compat/checkers.lua was modified; review the checkers for Koko
+• new checker for 3.5.0 (3005000000)`))
+	})
+
+	It("the entries listed will be limited", func() {
+		text := compatText(&compatChange{
+			removedFields: []compat.RemovedFieldsEntry{
+				{Version: 3001000000, Plugin: "acme", Added: []string{"a"}},
+				{Version: 3001000000, Plugin: "cors", Added: []string{"b"}},
+			},
+		}, 1)
+		Expect(text).Should(HaveSuffix("• acme: add a\n… and 1 more"))
+	})
+
+	It("analysis errors will be reported", func() {
+		text := compatText(&compatChange{analysisError: "head revision: line 3: unfinished string"}, 0)
+		Expect(text).Should(HaveSuffix("⚠ unable to analyze the compatibility changes: head revision: line 3: unfinished string"))
+	})
+
+	Describe("analyzing the compatibility files", func() {
+		var s *Slack
+		var server *httptest.Server
+
+		BeforeEach(func() {
+			logger, err := zap.NewDevelopment()
+			Expect(err).NotTo(HaveOccurred())
+			revisions := map[string]string{
+				"merge-base": `return { [3001000000] = { acme = { "a" } } }`,
+				// The base branch moved on after the pull request was opened
+				"base": `return { [3001000000] = { acme = { "a" }, cors = { "b" } } }`,
+				"head": `return { [3001000000] = { acme = { "a", "c" } } }`,
+			}
+			mux := http.NewServeMux()
+			mux.HandleFunc("/repos/kong/kong/contents/"+compat.RemovedFieldsPath, func(w http.ResponseWriter, r *http.Request) {
+				source, ok := revisions[r.URL.Query().Get("ref")]
+				if !ok {
+					w.WriteHeader(http.StatusNotFound)
+					_, _ = w.Write([]byte(`{"message": "Not Found"}`))
+					return
+				}
+				serveContent(w, source)
+			})
+			server = httptest.NewServer(mux)
+			client, err := github.NewClient(github.Options{
+				Token:   "token",
+				BaseURL: server.URL,
+				Logger:  logger,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			s, err = NewSlack(Options{
+				AppToken:     "xapp-",
+				BotToken:     "xoxb-",
+				Logger:       logger,
+				GitHubClient: client,
+				Store:        &store.Store{},
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("the head will be diffed against the merge base", func() {
+			change, err := s.analyzeCompat(gatewaySchemaChange{
+				organization: "kong",
+				repository:   "kong",
+				details:      github.PullRequest{BaseSHA: "base", HeadSHA: "head"},
+				files:        []github.File{{Filename: compat.RemovedFieldsPath, Status: "modified"}},
+				mergeBase:    "merge-base",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(change.removedFields).Should(Equal([]compat.RemovedFieldsEntry{
+				{Version: 3001000000, Plugin: "acme", Added: []string{"c"}},
+			}))
+		})
+
+		It("a compatibility file renamed away will be analyzed", func() {
+			change, err := s.analyzeCompat(gatewaySchemaChange{
+				organization: "kong",
+				repository:   "kong",
+				details:      github.PullRequest{BaseSHA: "base", HeadSHA: "renamed"},
+				files: []github.File{{
+					Filename:         "kong/clustering/compat/legacy_removed_fields.lua",
+					PreviousFilename: compat.RemovedFieldsPath,
+					Status:           "renamed",
+				}},
+				mergeBase: "merge-base",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(change.removedFields).Should(Equal([]compat.RemovedFieldsEntry{
+				{Version: 3001000000, Plugin: "acme", Removed: []string{"a"}},
+			}))
+		})
+	})
+})
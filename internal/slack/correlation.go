@@ -0,0 +1,291 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/kong/koko-slack-bot/internal/store"
+	"go.uber.org/zap"
+)
+
+const (
+	// correlationCherryPick represents a pull request correlated from the
+	// cherry-pick trailer of one of its commits
+	correlationCherryPick = "cherry-pick"
+	// correlationReference represents a pull request correlated from a
+	// backport or sync reference in its description
+	correlationReference = "reference"
+	// correlationPatchID represents a pull request correlated from the
+	// identifier of its changes
+	correlationPatchID = "patch-id"
+	// correlationTitle represents a pull request correlated from its title
+	correlationTitle = "title"
+)
+
+var (
+	// cherryPickPattern represents the pattern of the trailer git adds to
+	// cherry-picked commits; e.g. (cherry picked from commit abcdef0)
+	cherryPickPattern = regexp.MustCompile(`(?i)\(cherry picked from commit ([0-9a-f]{7,40})\)`)
+	// backportReferencePattern represents the pattern of a backport or sync
+	// reference to a pull request; e.g. Backport of kong/kong#11234 or
+	// Backport abcdef0 from #11234
+	backportReferencePattern = regexp.MustCompile(
+		`(?i)\b(?:backport|cherry[- ]pick|sync)(?:ed|s)?\b[^\n#]{0,60}?(?:([\w.-]+)/([\w.-]+))?#(\d+)`)
+	// backportURLPattern represents the pattern of a backport or sync
+	// reference to a pull request URL; e.g. Backport of
+	// https://github.com/kong/kong/pull/11234
+	backportURLPattern = regexp.MustCompile(
+		`(?i)\b(?:backport|cherry[- ]pick|sync)(?:ed|s)?\b[^\n]{0,60}?https://github\.com/([\w.-]+)/([\w.-]+)/pull/(\d+)`)
+	// titlePrefixPattern represents the backport markers prefixing pull
+	// request titles; e.g. [backport release/3.4.x] or [3.4] or backport:
+	titlePrefixPattern = regexp.MustCompile(
+		`(?i)^(?:\s*\[[^\]]*\]|\s*(?:backport|cherry[- ]pick|sync)(?:ed|s)?\b[^:]{0,30}:)+`)
+	// titleSuffixPattern represents the markers suffixing pull request titles;
+	// e.g. (#11234) or (backport)
+	titleSuffixPattern = regexp.MustCompile(`(?i)(?:\s*\((?:#\d+|backport[^)]*|cherry[- ]pick[^)]*)\))+\s*$`)
+	// titleReferencePattern represents a pull request number referenced by a
+	// title; e.g. (#11234)
+	titleReferencePattern = regexp.MustCompile(`#(\d+)\b`)
+)
+
+// correlateSchemaChange will detect whether the pull request of a gateway
+// schema change is a backport or a sync of a stored schema change and group it
+// under the logical change of the latter; the ticket of the logical change is
+// shared and annotated.
+func (s *Slack) correlateSchemaChange(gsc gatewaySchemaChange) error {
+	sc, ok := s.store.SchemaChange(gsc.key())
+	if !ok || len(sc.Origin) > 0 || len(sc.Reverts) > 0 {
+		return nil
+	}
+	related, correlatedBy, ok := findRelated(sc, gsc.details.Description, gsc.commitMessages,
+		s.store.SchemaChanges())
+	if !ok {
+		return nil
+	}
+	originKey := related.Key()
+	if len(related.Origin) > 0 {
+		originKey = related.Origin
+	}
+	if originKey == sc.Key() {
+		return nil
+	}
+	s.logger.Info("schema change correlated", zap.String("schema-change", sc.Key()),
+		zap.String("origin", originKey), zap.String("correlated-by", correlatedBy))
+
+	origin, err := s.store.UpdateSchemaChange(originKey, func(origin *store.SchemaChange) {
+		origin.Correlated = appendUnique(origin.Correlated, sc.Key())
+	})
+	if err != nil {
+		return fmt.Errorf("unable to store correlation: %w", err)
+	}
+	sharedTicket := false
+	sc, err = s.store.UpdateSchemaChange(sc.Key(), func(sc *store.SchemaChange) {
+		sc.Origin = originKey
+		sc.CorrelatedBy = correlatedBy
+		if sc.Ticket == nil && origin.Ticket != nil {
+			ticket := *origin.Ticket
+			sc.Ticket = &ticket
+			sharedTicket = true
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("unable to store correlation: %w", err)
+	}
+	if sharedTicket && s.tracker != nil {
+		comment := fmt.Sprintf("The change also landed as %s (correlated by %s).", sc.URL, correlatedBy)
+		if err := s.tracker.Comment(sc.Ticket.Key, comment); err != nil {
+			return fmt.Errorf("unable to comment on ticket: %w", err)
+		}
+	}
+	if len(origin.Channel) > 0 && len(origin.Timestamp) > 0 {
+		if err := s.updateReply(origin.Channel, origin.Timestamp, origin); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// groupTicket will get the ticket of the logical change a schema change
+// belongs to; false is returned when no schema change of the group has a
+// ticket.
+func (s *Slack) groupTicket(sc store.SchemaChange) (store.Ticket, bool) {
+	for _, member := range s.groupMembers(sc) {
+		if member.Ticket != nil {
+			return *member.Ticket, true
+		}
+	}
+	return store.Ticket{}, false
+}
+
+// groupMembers will get the other schema changes of the logical change a
+// schema change belongs to; i.e. its origin and the backports and syncs of
+// its origin.
+func (s *Slack) groupMembers(sc store.SchemaChange) []store.SchemaChange {
+	originKey := sc.Key()
+	if len(sc.Origin) > 0 {
+		originKey = sc.Origin
+	}
+	var members []store.SchemaChange
+	for _, member := range s.store.SchemaChanges() {
+		if member.Key() == sc.Key() {
+			continue
+		}
+		if member.Key() == originKey || member.Origin == originKey {
+			members = append(members, member)
+		}
+	}
+	return members
+}
+
+// findRelated will find the stored schema change a schema change is a
+// backport or a sync of. Cherry-pick trailers take precedence over backport
+// references, which take precedence over identical changes and then matching
+// titles; the earliest matching schema change is used. Titles are often
+// generic, so a matching title is only trusted when the pull request targets
+// another branch or repository and its title references the pull request of
+// the candidate; e.g. [backport release/3.4.x] feat: add sync rate (#11234).
+func findRelated(sc store.SchemaChange, description string, messages []string,
+	schemaChanges []store.SchemaChange,
+) (store.SchemaChange, string, bool) {
+	var candidates []store.SchemaChange
+	for _, candidate := range schemaChanges {
+		if candidate.Key() != sc.Key() && candidate.Origin != sc.Key() && len(candidate.Reverts) == 0 {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	var commits []string
+	for _, message := range messages {
+		for _, matches := range cherryPickPattern.FindAllStringSubmatch(message, -1) {
+			commits = append(commits, strings.ToLower(matches[1]))
+		}
+	}
+	if related, ok := earliest(candidates, func(candidate store.SchemaChange) bool {
+		for _, commit := range commits {
+			if len(candidate.MergeCommitSHA) > 0 && strings.HasPrefix(strings.ToLower(candidate.MergeCommitSHA), commit) {
+				return true
+			}
+		}
+		return false
+	}); ok {
+		return related, correlationCherryPick, true
+	}
+
+	references := backportReferences(description, sc.Organization, sc.Repository)
+	if related, ok := earliest(candidates, func(candidate store.SchemaChange) bool {
+		_, ok := references[candidate.Key()]
+		return ok
+	}); ok {
+		return related, correlationReference, true
+	}
+
+	if related, ok := earliest(candidates, func(candidate store.SchemaChange) bool {
+		return len(sc.PatchID) > 0 && candidate.PatchID == sc.PatchID
+	}); ok {
+		return related, correlationPatchID, true
+	}
+
+	title := normalizeTitle(sc.Title)
+	numbers := titleReferences(sc.Title)
+	if related, ok := earliest(candidates, func(candidate store.SchemaChange) bool {
+		_, referenced := numbers[candidate.PullRequest]
+		return len(title) > 0 && normalizeTitle(candidate.Title) == title && referenced &&
+			targetsElsewhere(sc, candidate)
+	}); ok {
+		return related, correlationTitle, true
+	}
+	return store.SchemaChange{}, "", false
+}
+
+// backportReferences will get the store keys of the pull requests a
+// description references as backported or synced; references without a
+// repository belong to the given repository.
+func backportReferences(description string, organization string, repository string) map[string]struct{} {
+	references := make(map[string]struct{})
+	for _, pattern := range []*regexp.Regexp{backportReferencePattern, backportURLPattern} {
+		for _, matches := range pattern.FindAllStringSubmatch(description, -1) {
+			number, err := strconv.Atoi(matches[3])
+			if err != nil {
+				continue
+			}
+			referenceOrganization, referenceRepository := organization, repository
+			if len(matches[1]) > 0 {
+				referenceOrganization, referenceRepository = matches[1], matches[2]
+			}
+			references[store.Key(referenceOrganization, referenceRepository, number)] = struct{}{}
+		}
+	}
+	return references
+}
+
+// titleReferences will get the pull request numbers referenced by a title.
+func titleReferences(title string) map[int]struct{} {
+	numbers := make(map[int]struct{})
+	for _, matches := range titleReferencePattern.FindAllStringSubmatch(title, -1) {
+		if number, err := strconv.Atoi(matches[1]); err == nil {
+			numbers[number] = struct{}{}
+		}
+	}
+	return numbers
+}
+
+// targetsElsewhere will determine whether the pull requests of two schema
+// changes target different repositories or different known branches.
+func targetsElsewhere(sc store.SchemaChange, other store.SchemaChange) bool {
+	if sc.Organization != other.Organization || sc.Repository != other.Repository {
+		return true
+	}
+	return len(sc.BaseRef) > 0 && len(other.BaseRef) > 0 && sc.BaseRef != other.BaseRef
+}
+
+// normalizeTitle will remove the backport markers of a pull request title for
+// comparison; e.g. [backport release/3.4.x] feat: add sync rate (#11234) is
+// feat: add sync rate.
+func normalizeTitle(title string) string {
+	title = titlePrefixPattern.ReplaceAllString(title, "")
+	title = titleSuffixPattern.ReplaceAllString(title, "")
+	return strings.ToLower(strings.Join(strings.Fields(title), " "))
+}
+
+// earliest will get the earliest created schema change matching a predicate.
+func earliest(schemaChanges []store.SchemaChange, matches func(sc store.SchemaChange) bool) (store.SchemaChange, bool) {
+	var found store.SchemaChange
+	ok := false
+	for _, sc := range schemaChanges {
+		if !matches(sc) {
+			continue
+		}
+		if !ok || sc.CreatedAt.Before(found.CreatedAt) {
+			found = sc
+			ok = true
+		}
+	}
+	return found, ok
+}
+
+// appendUnique will append a value to a slice unless it is already present.
+func appendUnique(values []string, value string) []string {
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -0,0 +1,213 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/kong/koko-slack-bot/internal/github"
+	"github.com/kong/koko-slack-bot/internal/store"
+	"github.com/kong/koko-slack-bot/internal/templates"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("correlation", Label("correlation"), func() {
+	original := store.SchemaChange{
+		Organization:   "kong",
+		Repository:     "kong",
+		PullRequest:    11234,
+		Title:          "feat(rate-limiting): add sync rate",
+		BaseRef:        "master",
+		MergeCommitSHA: "abcdef0123456789",
+		PatchID:        "patch",
+		CreatedAt:      time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC),
+	}
+	synced := store.SchemaChange{
+		Organization: "kong",
+		Repository:   "kong-ee",
+		PullRequest:  5000,
+		Title:        "chore(deps): bump the gateway",
+		Origin:       "kong/kong#11234",
+		CreatedAt:    time.Date(2023, 5, 2, 0, 0, 0, 0, time.UTC),
+	}
+	schemaChanges := []store.SchemaChange{synced, original}
+
+	Describe("finding the related schema change", func() {
+		It("a cherry-picked commit will be correlated with the merge commit", func() {
+			backport := store.SchemaChange{Organization: "kong", Repository: "kong", PullRequest: 11300}
+			related, correlatedBy, ok := findRelated(backport, "",
+				[]string{"feat(rate-limiting): add sync rate\n\n(cherry picked from commit abcdef0123)"}, schemaChanges)
+			Expect(ok).To(BeTrue())
+			Expect(related.Key()).Should(Equal("kong/kong#11234"))
+			Expect(correlatedBy).Should(Equal(correlationCherryPick))
+		})
+
+		It("a backport reference will be correlated with the referenced pull request", func() {
+			backport := store.SchemaChange{Organization: "kong", Repository: "kong", PullRequest: 11300}
+			related, correlatedBy, ok := findRelated(backport, "Backport abcdef0 from #11234.", nil, schemaChanges)
+			Expect(ok).To(BeTrue())
+			Expect(related.Key()).Should(Equal("kong/kong#11234"))
+			Expect(correlatedBy).Should(Equal(correlationReference))
+		})
+
+		It("an identical change will be correlated by its patch ID", func() {
+			backport := store.SchemaChange{Organization: "kong", Repository: "kong-ee", PullRequest: 6000, PatchID: "patch"}
+			related, correlatedBy, ok := findRelated(backport, "", nil, schemaChanges)
+			Expect(ok).To(BeTrue())
+			Expect(related.Key()).Should(Equal("kong/kong#11234"))
+			Expect(correlatedBy).Should(Equal(correlationPatchID))
+		})
+
+		It("a backport title will be correlated with the original title", func() {
+			backport := store.SchemaChange{
+				Organization: "kong",
+				Repository:   "kong",
+				PullRequest:  11300,
+				Title:        "[backport release/3.4.x] feat(rate-limiting): add sync rate (#11234)",
+				BaseRef:      "release/3.4.x",
+			}
+			related, correlatedBy, ok := findRelated(backport, "", nil, schemaChanges)
+			Expect(ok).To(BeTrue())
+			Expect(related.Key()).Should(Equal("kong/kong#11234"))
+			Expect(correlatedBy).Should(Equal(correlationTitle))
+		})
+
+		It("a matching title alone will not be correlated", func() {
+			sameBranch := store.SchemaChange{
+				Organization: "kong",
+				Repository:   "kong",
+				PullRequest:  11300,
+				Title:        "feat(rate-limiting): add sync rate (#11234)",
+				BaseRef:      "master",
+			}
+			_, _, ok := findRelated(sameBranch, "", nil, schemaChanges)
+			Expect(ok).To(BeFalse())
+			unreferenced := store.SchemaChange{
+				Organization: "kong",
+				Repository:   "kong",
+				PullRequest:  11300,
+				Title:        "[backport release/3.4.x] feat(rate-limiting): add sync rate",
+				BaseRef:      "release/3.4.x",
+			}
+			_, _, ok = findRelated(unreferenced, "", nil, schemaChanges)
+			Expect(ok).To(BeFalse())
+		})
+
+		It("an unrelated pull request will not be correlated", func() {
+			unrelated := store.SchemaChange{
+				Organization: "kong",
+				Repository:   "kong",
+				PullRequest:  11400,
+				Title:        "feat(acl): add groups",
+			}
+			_, _, ok := findRelated(unrelated, "Fixes #11233", nil, schemaChanges)
+			Expect(ok).To(BeFalse())
+		})
+
+		It("words starting with a backport marker will not be read as backport references", func() {
+			Expect(backportReferences("Adds sync_rate, follow-up to #11234. Synchronize with #11235.", "kong", "kong")).
+				To(BeEmpty())
+			Expect(backportReferences("Synced from #11234", "kong", "kong")).
+				Should(HaveKey("kong/kong#11234"))
+		})
+	})
+
+	It("backport markers will be removed from titles", func() {
+		Expect(normalizeTitle("[3.4] Backport to release/3.4.x: Feat: add  sync rate (#11234) (backport)")).
+			Should(Equal("feat: add sync rate"))
+	})
+
+	It("backport references will be found in descriptions", func() {
+		Expect(backportReferences("Backport of https://github.com/kong/kong/pull/11234\nsynced from Kong/kong#11235",
+			"kong", "kong-ee")).Should(Equal(map[string]struct{}{
+			"kong/kong#11234": {},
+			"kong/kong#11235": {},
+		}))
+	})
+
+	Describe("grouping related schema changes", func() {
+		var s *Slack
+		var st *store.Store
+		var t *recordingTracker
+
+		BeforeEach(func() {
+			logger, err := zap.NewDevelopment()
+			Expect(err).NotTo(HaveOccurred())
+			st, err = store.NewStore(store.Options{
+				Path:   filepath.Join(GinkgoT().TempDir(), "store.json"),
+				Logger: logger,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = st.UpdateSchemaChange("kong/kong#11234", func(sc *store.SchemaChange) {
+				sc.Organization = "kong"
+				sc.Repository = "kong"
+				sc.PullRequest = 11234
+				sc.Title = "feat(rate-limiting): add sync rate"
+				sc.BaseRef = "master"
+				sc.Ticket = &store.Ticket{Key: "KOKO-1"}
+			})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = st.UpdateSchemaChange("kong/kong#11300", func(sc *store.SchemaChange) {
+				sc.Organization = "kong"
+				sc.Repository = "kong"
+				sc.PullRequest = 11300
+				sc.Title = "[backport release/3.4.x] feat(rate-limiting): add sync rate (#11234)"
+				sc.BaseRef = "release/3.4.x"
+				sc.URL = "https://github.com/kong/kong/pull/11300"
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			ticketTemplates, err := templates.New(templates.Options{Logger: logger})
+			Expect(err).NotTo(HaveOccurred())
+			t = newRecordingTracker()
+			s, err = NewSlack(Options{
+				AppToken:     "xapp-",
+				BotToken:     "xoxb-",
+				Logger:       logger,
+				GitHubClient: &github.Client{},
+				Store:        st,
+				Templates:    ticketTemplates,
+				Tracker:      t,
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("a backport will share the ticket of its origin", func() {
+			gsc := gatewaySchemaChange{organization: "kong", repository: "kong", pullRequest: 11300}
+			Expect(s.correlateSchemaChange(gsc)).To(Succeed())
+
+			backport, _ := st.SchemaChange("kong/kong#11300")
+			Expect(backport.Origin).Should(Equal("kong/kong#11234"))
+			Expect(backport.CorrelatedBy).Should(Equal(correlationTitle))
+			Expect(backport.Ticket).Should(Equal(&store.Ticket{Key: "KOKO-1"}))
+			origin, _ := st.SchemaChange("kong/kong#11234")
+			Expect(origin.Correlated).Should(Equal([]string{"kong/kong#11300"}))
+			Expect(t.comments["KOKO-1"]).Should(Equal([]string{
+				"The change also landed as https://github.com/kong/kong/pull/11300 (correlated by title).",
+			}))
+		})
+
+		It("a backport will only be correlated once", func() {
+			gsc := gatewaySchemaChange{organization: "kong", repository: "kong", pullRequest: 11300}
+			Expect(s.correlateSchemaChange(gsc)).To(Succeed())
+			Expect(s.correlateSchemaChange(gsc)).To(Succeed())
+			Expect(t.comments["KOKO-1"]).To(HaveLen(1))
+		})
+	})
+})
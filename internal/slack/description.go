@@ -0,0 +1,89 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kong/koko-slack-bot/internal/github"
+	"github.com/slack-go/slack"
+)
+
+// referencesText will join the short form of references.
+func referencesText(references []github.Reference) string {
+	values := make([]string, 0, len(references))
+	for _, reference := range references {
+		values = append(values, reference.String())
+	}
+	return strings.Join(values, ", ")
+}
+
+// descriptionContextBlock will create the block listing the checklist state
+// and the references of a pull request description; nil when there is nothing
+// to list.
+func descriptionContextBlock(description github.Description) *slack.ContextBlock {
+	var elements []slack.MixedElement
+	if len(description.Checklist) > 0 {
+		elements = append(elements, markdownText(fmt.Sprintf("Checklist: *%d/%d*",
+			description.ChecklistDone(), len(description.Checklist))))
+	}
+	if len(description.JiraKeys) > 0 {
+		elements = append(elements, markdownText(fmt.Sprintf("Jira: %s", strings.Join(description.JiraKeys, ", "))))
+	}
+	if len(description.Issues) > 0 {
+		elements = append(elements, markdownText(fmt.Sprintf("Issues: %s", referencesText(description.Issues))))
+	}
+	if len(description.PullRequests) > 0 {
+		elements = append(elements, markdownText(fmt.Sprintf("Linked pull requests: %s",
+			referencesText(description.PullRequests))))
+	}
+	if len(elements) == 0 {
+		return nil
+	}
+	return slack.NewContextBlock("", elements...)
+}
+
+// descriptionText will create the markdown of a pull request description for a
+// ticket; the summary keeps the markdown of the pull request while the
+// checklist is rendered as a task list.
+func descriptionText(description github.Description) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "### Summary\n\n%s", description.Summary())
+	if len(description.Checklist) > 0 {
+		fmt.Fprintf(&sb, "\n\n### Checklist (%d/%d done)\n", description.ChecklistDone(), len(description.Checklist))
+		for _, item := range description.Checklist {
+			mark := "[ ]"
+			if item.Checked {
+				mark = "[x]"
+			}
+			fmt.Fprintf(&sb, "\n- %s %s", mark, item.Text)
+		}
+	}
+	if len(description.JiraKeys) > 0 || len(description.Issues) > 0 || len(description.PullRequests) > 0 {
+		sb.WriteString("\n\n### References\n")
+		if len(description.JiraKeys) > 0 {
+			fmt.Fprintf(&sb, "\n- Jira: %s", strings.Join(description.JiraKeys, ", "))
+		}
+		if len(description.Issues) > 0 {
+			fmt.Fprintf(&sb, "\n- Issues: %s", referencesText(description.Issues))
+		}
+		if len(description.PullRequests) > 0 {
+			fmt.Fprintf(&sb, "\n- Linked pull requests: %s", referencesText(description.PullRequests))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,65 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"github.com/kong/koko-slack-bot/internal/github"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("description", Label("description"), func() {
+	description := github.ParseDescription(`### Summary
+
+Adds the sync_rate option.
+
+### Checklist
+
+- [x] The Pull Request has tests
+- [ ] A changelog file has been created
+
+### Issue reference
+
+Fix #11233 and KAG-1234, see https://github.com/kong/docs.konghq.com/pull/5000
+`, "kong", "kong")
+
+	It("the ticket markdown will contain the summary, checklist, and references", func() {
+		Expect(descriptionText(description)).Should(Equal(`### Summary
+
+Adds the sync_rate option.
+
+### Checklist (1/2 done)
+
+- [x] The Pull Request has tests
+- [ ] A changelog file has been created
+
+### References
+
+- Jira: KAG-1234
+- Issues: kong/kong#11233
+- Linked pull requests: kong/docs.konghq.com#5000`))
+	})
+
+	It("the reply context will list the checklist state and references", func() {
+		context := descriptionContextBlock(description)
+		Expect(context).NotTo(BeNil())
+		Expect(context.ContextElements.Elements).To(HaveLen(4))
+	})
+
+	It("the reply context will be omitted for an empty description", func() {
+		Expect(descriptionContextBlock(github.ParseDescription("", "kong", "kong"))).To(BeNil())
+	})
+})
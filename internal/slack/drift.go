@@ -0,0 +1,285 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kong/koko-slack-bot/internal/drift"
+	"github.com/kong/koko-slack-bot/internal/github"
+	"github.com/kong/koko-slack-bot/internal/jsonschema"
+	"github.com/kong/koko-slack-bot/internal/tracker"
+	"github.com/slack-go/slack"
+	"go.uber.org/zap"
+)
+
+const (
+	// gatewayPluginsPath represents the directory of the gateway plugins
+	gatewayPluginsPath = "kong/plugins"
+	// driftLabel represents the label of the drift tickets
+	driftLabel = "koko-schema-drift"
+)
+
+// pluginDrift represents the drift of a plugin between the gateway and Koko.
+type pluginDrift struct {
+	// plugin represents the name of the plugin
+	plugin string
+	// drifts represents the differences between the schemas of the plugin
+	drifts []drift.Drift
+}
+
+// watchDrift will periodically compare the gateway plugin schemas with the
+// Koko plugin schemas.
+func (s *Slack) watchDrift() {
+	s.pollDrift()
+	ticker := time.NewTicker(s.driftPollInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.pollDrift()
+	}
+}
+
+// pollDrift will compare the gateway plugin schemas with the Koko plugin
+// schemas and report the drift when it changed since the previous
+// comparison; tickets are filed for the drifting plugins when enabled.
+func (s *Slack) pollDrift() {
+	plugins, err := s.detectDrift()
+	if err != nil {
+		s.logger.Warn("unable to detect plugin schema drift", zap.Error(err))
+		return
+	}
+	var fingerprint string
+	if len(plugins) > 0 {
+		fingerprint = driftMarkdown(s.driftRef, plugins)
+	}
+	if fingerprint == s.driftReported {
+		return
+	}
+	s.driftReported = fingerprint
+
+	if len(s.driftChannel) > 0 {
+		if err := s.reportDrift(plugins); err != nil {
+			s.logger.Error("unable to report plugin schema drift", zap.Error(err))
+		}
+	}
+	if s.driftTickets && s.tracker != nil {
+		for _, pd := range plugins {
+			if err := s.fileDriftTicket(pd); err != nil {
+				s.logger.Error("unable to file plugin schema drift ticket", zap.String("plugin", pd.plugin),
+					zap.Error(err))
+			}
+		}
+	}
+}
+
+// detectDrift will get the drift of every gateway plugin at the configured
+// ref and every Koko plugin schema at the default branch of Koko ordered by
+// plugin. Plugins whose schemas cannot be retrieved or converted are skipped.
+func (s *Slack) detectDrift() ([]pluginDrift, error) {
+	gatewayEntries, err := s.gitHubClient.Directory(gatewayOrganization, gatewayRepository, gatewayPluginsPath,
+		s.driftRef)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list gateway plugins: %w", err)
+	}
+	kokoEntries, err := s.gitHubClient.Directory(s.kokoOrganization, s.kokoRepository, s.kokoSchemasPath, "")
+	if err != nil {
+		return nil, fmt.Errorf("unable to list Koko plugin schemas: %w", err)
+	}
+	kokoSchemas := make(map[string]string)
+	for _, entry := range kokoEntries {
+		if name, ok := strings.CutSuffix(entry.Name, ".json"); ok && entry.Type == "file" {
+			kokoSchemas[name] = path.Join(s.kokoSchemasPath, entry.Name)
+		}
+	}
+
+	var plugins []pluginDrift
+	for _, entry := range gatewayEntries {
+		if entry.Type != "dir" {
+			continue
+		}
+		// The Koko schema of a gateway plugin is never unknown, even when the
+		// gateway schema cannot be retrieved
+		kokoPath, ok := kokoSchemas[entry.Name]
+		delete(kokoSchemas, entry.Name)
+		logger := s.logger.With(zap.String("plugin", entry.Name))
+		gateway, err := s.gatewayPluginFields(entry.Name)
+		if errors.Is(err, github.ErrNotFound) {
+			// Not every directory is a plugin with a schema
+			continue
+		}
+		if err != nil {
+			logger.Warn("unable to normalize gateway plugin schema", zap.Error(err))
+			continue
+		}
+		if !ok {
+			plugins = append(plugins, pluginDrift{
+				plugin: entry.Name,
+				drifts: []drift.Drift{{Plugin: entry.Name, Kind: drift.KindMissingPlugin}},
+			})
+			continue
+		}
+		content, err := s.gitHubClient.FileContent(s.kokoOrganization, s.kokoRepository, kokoPath, "")
+		if err != nil {
+			logger.Warn("unable to retrieve Koko plugin schema", zap.Error(err))
+			continue
+		}
+		koko, err := drift.Normalize([]byte(content))
+		if err != nil {
+			logger.Warn("unable to normalize Koko plugin schema", zap.Error(err))
+			continue
+		}
+		if drifts := drift.Compare(entry.Name, gateway, koko); len(drifts) > 0 {
+			plugins = append(plugins, pluginDrift{plugin: entry.Name, drifts: drifts})
+		}
+	}
+	for name := range kokoSchemas {
+		plugins = append(plugins, pluginDrift{
+			plugin: name,
+			drifts: []drift.Drift{{Plugin: name, Kind: drift.KindUnknownPlugin}},
+		})
+	}
+	sort.Slice(plugins, func(i, j int) bool {
+		return plugins[i].plugin < plugins[j].plugin
+	})
+	return plugins, nil
+}
+
+// gatewayPluginFields will get the normalized configuration fields of a
+// gateway plugin at the configured ref.
+func (s *Slack) gatewayPluginFields(plugin string) ([]drift.Field, error) {
+	content, err := s.gitHubClient.FileContent(gatewayOrganization, gatewayRepository,
+		gatewayPluginSchemaPath(plugin), s.driftRef)
+	if err != nil {
+		return nil, err
+	}
+	converted, err := jsonschema.Convert(content)
+	if err != nil {
+		return nil, err
+	}
+	document, err := json.Marshal(converted)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode JSON schema: %w", err)
+	}
+	return drift.Normalize(document)
+}
+
+// gatewayPluginSchemaPath will get the path of the schema of a gateway plugin.
+func gatewayPluginSchemaPath(plugin string) string {
+	return path.Join(gatewayPluginsPath, plugin, "schema.lua")
+}
+
+// reportDrift will post the summary of the drift to the drift channel with
+// the detailed report file in its thread; a drift resolved since the previous
+// report is announced as such.
+func (s *Slack) reportDrift(plugins []pluginDrift) error {
+	channel, timestamp, err := s.client.PostMessage(s.driftChannel,
+		slack.MsgOptionBlocks(driftBlocks(s.driftRef, plugins)...))
+	if err != nil {
+		return fmt.Errorf("unable to post drift summary: %w", err)
+	}
+	if len(plugins) == 0 {
+		return nil
+	}
+	_, err = s.client.UploadFile(slack.FileUploadParameters{
+		Content:         driftMarkdown(s.driftRef, plugins),
+		Filename:        "koko-plugin-schema-drift.md",
+		Filetype:        "markdown",
+		Title:           fmt.Sprintf("Koko plugin schema drift from the gateway at %s", s.driftRef),
+		Channels:        []string{channel},
+		ThreadTimestamp: timestamp,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to upload drift report: %w", err)
+	}
+	return nil
+}
+
+// fileDriftTicket will create a ticket for the drift of a plugin unless an
+// open ticket already tracks it.
+func (s *Slack) fileDriftTicket(pd pluginDrift) error {
+	reference := tracker.Reference{
+		Label: driftLabel + "-" + pd.plugin,
+		URL:   gatewayPluginSchemaURL(pd.plugin, s.driftRef),
+	}
+	if _, found, err := s.tracker.FindByReference(reference); err != nil || found {
+		return err
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "The Koko schema of the `%s` plugin drifted from the gateway schema %s:\n", pd.plugin, reference.URL)
+	for _, d := range pd.drifts {
+		fmt.Fprintf(&sb, "\n- %s", d)
+	}
+	ticket, err := s.tracker.Create(tracker.NewTicket{
+		Title:       fmt.Sprintf("Koko plugin schema drift: %s", pd.plugin),
+		Description: sb.String(),
+		Labels:      []string{driftLabel},
+		Reference:   reference,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to create ticket: %w", err)
+	}
+	s.logger.Info("plugin schema drift ticket created", zap.String("plugin", pd.plugin),
+		zap.String("ticket", ticket.Key))
+	return nil
+}
+
+// gatewayPluginSchemaURL will get the browsable URL of the schema of a
+// gateway plugin at a ref.
+func gatewayPluginSchemaURL(plugin string, ref string) string {
+	return fmt.Sprintf("https://github.com/%s/%s/blob/%s/%s", gatewayOrganization, gatewayRepository, ref,
+		gatewayPluginSchemaPath(plugin))
+}
+
+// driftBlocks will create the blocks summarizing the drift of the plugins.
+func driftBlocks(ref string, plugins []pluginDrift) []slack.Block {
+	if len(plugins) == 0 {
+		return textBlocks(fmt.Sprintf(":white_check_mark: *Koko plugin schemas match the gateway at `%s`*", ref))
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, ":warning: *%d Koko plugin schema(s) drifted from the gateway at `%s`:*", len(plugins), ref)
+	for i, pd := range plugins {
+		if i == maxListedCommits {
+			fmt.Fprintf(&sb, "\n… and %d more", len(plugins)-maxListedCommits)
+			break
+		}
+		if len(pd.drifts) == 1 && len(pd.drifts[0].Path) == 0 {
+			fmt.Fprintf(&sb, "\n• `%s`: %s", pd.plugin, pd.drifts[0])
+			continue
+		}
+		fmt.Fprintf(&sb, "\n• `%s`: %d difference(s)", pd.plugin, len(pd.drifts))
+	}
+	return textBlocks(sb.String())
+}
+
+// driftMarkdown will create the detailed markdown report of the drift of the
+// plugins.
+func driftMarkdown(ref string, plugins []pluginDrift) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Koko plugin schema drift from the gateway at %s\n", ref)
+	for _, pd := range plugins {
+		fmt.Fprintf(&sb, "\n## %s\n\n", pd.plugin)
+		for _, d := range pd.drifts {
+			fmt.Fprintf(&sb, "- %s\n", d)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,184 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/kong/koko-slack-bot/internal/drift"
+	"github.com/kong/koko-slack-bot/internal/github"
+	"github.com/kong/koko-slack-bot/internal/store"
+	"github.com/kong/koko-slack-bot/internal/templates"
+	"github.com/kong/koko-slack-bot/internal/tracker"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("plugin schema drift", Label("drift"), func() {
+	var s *Slack
+	var server *httptest.Server
+	var t *recordingTracker
+
+	BeforeEach(func() {
+		logger, err := zap.NewDevelopment()
+		Expect(err).NotTo(HaveOccurred())
+		mux := http.NewServeMux()
+		mux.HandleFunc("/repos/kong/kong/contents/kong/plugins", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Query().Get("ref")).Should(Equal("3.5.0"))
+			_, _ = w.Write([]byte(`[
+				{"type": "dir", "name": "acme"},
+				{"type": "dir", "name": "broken"},
+				{"type": "dir", "name": "rate-limiting"},
+				{"type": "dir", "name": "session"},
+				{"type": "dir", "name": "shared"},
+				{"type": "file", "name": "base_plugin.lua"}
+			]`))
+		})
+		mux.HandleFunc("/repos/kong/kong/contents/kong/plugins/acme/schema.lua",
+			func(w http.ResponseWriter, r *http.Request) {
+				serveContent(w, `return { fields = { { config = { type = "record", fields = {
+					{ email = { type = "string" } } } } } } }`)
+			})
+		mux.HandleFunc("/repos/kong/kong/contents/kong/plugins/broken/schema.lua",
+			func(w http.ResponseWriter, r *http.Request) {
+				serveContent(w, `return "broken"`)
+			})
+		mux.HandleFunc("/repos/kong/kong/contents/kong/plugins/rate-limiting/schema.lua",
+			func(w http.ResponseWriter, r *http.Request) {
+				serveContent(w, `return { fields = { { config = { type = "record", fields = {
+					{ policy = { type = "string", default = "local" } },
+					{ sync_rate = { type = "number", default = -1 } } } } } } }`)
+			})
+		mux.HandleFunc("/repos/kong/kong/contents/kong/plugins/session/schema.lua",
+			func(w http.ResponseWriter, r *http.Request) {
+				serveContent(w, `return { fields = {} }`)
+			})
+		mux.HandleFunc("/repos/kong/kong/contents/kong/plugins/shared/schema.lua",
+			func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"message": "Not Found"}`))
+			})
+		mux.HandleFunc("/repos/kong/koko/contents/internal/plugin/schemas", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Query().Get("ref")).To(BeEmpty())
+			_, _ = w.Write([]byte(`[
+				{"type": "file", "name": "acme.json"},
+				{"type": "file", "name": "broken.json"},
+				{"type": "file", "name": "rate-limiting.json"},
+				{"type": "file", "name": "legacy.json"},
+				{"type": "file", "name": "README.md"}
+			]`))
+		})
+		mux.HandleFunc("/repos/kong/koko/contents/internal/plugin/schemas/acme.json",
+			func(w http.ResponseWriter, r *http.Request) {
+				serveContent(w, `{"properties": {"config": {"properties": {"email": {"type": "string"}}}}}`)
+			})
+		mux.HandleFunc("/repos/kong/koko/contents/internal/plugin/schemas/rate-limiting.json",
+			func(w http.ResponseWriter, r *http.Request) {
+				serveContent(w, `{"properties": {"config": {"properties": {
+					"policy": {"type": "string", "default": "cluster"}}}}}`)
+			})
+		server = httptest.NewServer(mux)
+		client, err := github.NewClient(github.Options{
+			Token:   "token",
+			BaseURL: server.URL,
+			Logger:  logger,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		ticketTemplates, err := templates.New(templates.Options{Logger: logger})
+		Expect(err).NotTo(HaveOccurred())
+		t = newRecordingTracker()
+		s, err = NewSlack(Options{
+			AppToken:          "xapp-",
+			BotToken:          "xoxb-",
+			Logger:            logger,
+			GitHubClient:      client,
+			Store:             &store.Store{},
+			Templates:         ticketTemplates,
+			Tracker:           t,
+			DriftPollInterval: time.Hour,
+			DriftRef:          "3.5.0",
+			DriftTickets:      true,
+			KokoRepository:    "kong/koko",
+			KokoSchemasPath:   "internal/plugin/schemas",
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("missing plugins and drifting fields will be detected", func() {
+		plugins, err := s.detectDrift()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plugins).Should(Equal([]pluginDrift{
+			{plugin: "legacy", drifts: []drift.Drift{{Plugin: "legacy", Kind: drift.KindUnknownPlugin}}},
+			{plugin: "rate-limiting", drifts: []drift.Drift{
+				{Plugin: "rate-limiting", Kind: drift.KindDefault, Path: "policy", Gateway: `"local"`, Koko: `"cluster"`},
+				{Plugin: "rate-limiting", Kind: drift.KindMissingField, Path: "sync_rate", Gateway: "number"},
+			}},
+			{plugin: "session", drifts: []drift.Drift{{Plugin: "session", Kind: drift.KindMissingPlugin}}},
+		}))
+		Expect(driftBlocks("3.5.0", plugins)[0]).Should(Equal(textBlocks(":warning: *3 Koko plugin schema(s) " +
+			"drifted from the gateway at `3.5.0`:*" +
+			"\n• `legacy`: Koko schema of a plugin the gateway does not have" +
+			"\n• `rate-limiting`: 2 difference(s)" +
+			"\n• `session`: no Koko schema")[0]))
+	})
+
+	It("a Koko schema will not be reported unknown when the gateway schema cannot be converted", func() {
+		plugins, err := s.detectDrift()
+		Expect(err).NotTo(HaveOccurred())
+		for _, plugin := range plugins {
+			Expect(plugin.plugin).NotTo(Equal("broken"))
+		}
+	})
+
+	It("a ticket will be filed once for each drifting plugin", func() {
+		s.pollDrift()
+		Expect(t.created).To(HaveLen(3))
+		Expect(t.created[1].Title).Should(Equal("Koko plugin schema drift: rate-limiting"))
+		Expect(t.created[1].Reference).Should(Equal(tracker.Reference{
+			Label: "koko-schema-drift-rate-limiting",
+			URL:   "https://github.com/kong/kong/blob/3.5.0/kong/plugins/rate-limiting/schema.lua",
+		}))
+		Expect(t.created[1].Description).Should(Equal("The Koko schema of the `rate-limiting` plugin drifted from " +
+			"the gateway schema https://github.com/kong/kong/blob/3.5.0/kong/plugins/rate-limiting/schema.lua:\n" +
+			"\n- policy: default \"local\" in the gateway but \"cluster\" in Koko" +
+			"\n- sync_rate: missing from Koko"))
+
+		s.driftReported = ""
+		s.pollDrift()
+		Expect(t.created).To(HaveLen(3))
+	})
+
+	It("an invalid Koko repository will not be accepted", func() {
+		_, err := NewSlack(Options{
+			AppToken:          "xapp-",
+			BotToken:          "xoxb-",
+			Logger:            zap.NewNop(),
+			GitHubClient:      s.gitHubClient,
+			Store:             &store.Store{},
+			DriftPollInterval: time.Hour,
+			DriftRef:          "master",
+			KokoRepository:    "koko",
+		})
+		Expect(err).Should(MatchError(`invalid Koko repository "koko"`))
+	})
+})
@@ -0,0 +1,87 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+
+	"github.com/kong/koko-slack-bot/internal/tracker"
+)
+
+// serveContent will serve the content of a file as GitHub's contents API.
+func serveContent(w http.ResponseWriter, source string) {
+	_, _ = w.Write([]byte(fmt.Sprintf(`{"type": "file", "encoding": "base64", "content": %q}`,
+		base64.StdEncoding.EncodeToString([]byte(source)))))
+}
+
+// recordingTracker represents a tracker recording the created tickets and the
+// comments, closures, and reopenings of tickets.
+type recordingTracker struct {
+	tracker.Tracker
+	// created represents the created tickets
+	created []tracker.NewTicket
+	// open represents the labels of the open tickets
+	open map[string]struct{}
+	// comments represents the comments added to the tickets keyed by ticket
+	comments map[string][]string
+	// closed represents the resolutions of the closed tickets keyed by ticket
+	closed map[string]tracker.Resolution
+	// reopened represents the keys of the reopened tickets
+	reopened []string
+}
+
+// newRecordingTracker will create a tracker recording the tickets without any
+// ticket.
+func newRecordingTracker() *recordingTracker {
+	return &recordingTracker{
+		open:     map[string]struct{}{},
+		comments: map[string][]string{},
+		closed:   map[string]tracker.Resolution{},
+	}
+}
+
+// Create will record a created ticket.
+func (t *recordingTracker) Create(ticket tracker.NewTicket) (tracker.Ticket, error) {
+	t.created = append(t.created, ticket)
+	t.open[ticket.Reference.Label] = struct{}{}
+	return tracker.Ticket{Key: fmt.Sprintf("KOKO-%d", len(t.created))}, nil
+}
+
+// FindByReference will find the open ticket with the label of a reference.
+func (t *recordingTracker) FindByReference(reference tracker.Reference) (tracker.Ticket, bool, error) {
+	_, ok := t.open[reference.Label]
+	return tracker.Ticket{}, ok, nil
+}
+
+// Comment will record a comment added to a ticket.
+func (t *recordingTracker) Comment(key string, comment string) error {
+	t.comments[key] = append(t.comments[key], comment)
+	return nil
+}
+
+// Close will record the resolution of a closed ticket.
+func (t *recordingTracker) Close(key string, resolution tracker.Resolution) error {
+	t.closed[key] = resolution
+	return nil
+}
+
+// Reopen will record a reopened ticket.
+func (t *recordingTracker) Reopen(key string) error {
+	t.reopened = append(t.reopened, key)
+	return nil
+}
@@ -0,0 +1,277 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kong/koko-slack-bot/internal/store"
+	"github.com/kong/koko-slack-bot/internal/tracker"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"github.com/slack-go/slack/socketmode"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxHomeSchemaChanges represents the maximum number of schema changes
+	// listed in each section of the App Home to stay within Slack's limits
+	maxHomeSchemaChanges = 10
+	// recentBreakingChangesPeriod represents how far back breaking changes are
+	// listed in the App Home
+	recentBreakingChangesPeriod = 30 * 24 * time.Hour
+)
+
+// health represents the health of the bot shown in the App Home.
+type health struct {
+	// mutex represents the lock guarding the health
+	mutex sync.Mutex
+	// startedAt represents the time the bot started processing events
+	startedAt time.Time
+	// lastEventAt represents the time the last schema change event was
+	// processed
+	lastEventAt time.Time
+	// eventsProcessed represents the number of schema change events processed
+	eventsProcessed int
+	// eventErrors represents the number of schema change events that failed
+	eventErrors int
+	// lastError represents the last error that occurred processing a schema
+	// change event
+	lastError string
+}
+
+// healthStatus represents a snapshot of the health of the bot.
+type healthStatus struct {
+	// startedAt represents the time the bot started processing events
+	startedAt time.Time
+	// lastEventAt represents the time the last schema change event was
+	// processed
+	lastEventAt time.Time
+	// eventsProcessed represents the number of schema change events processed
+	eventsProcessed int
+	// eventErrors represents the number of schema change events that failed
+	eventErrors int
+	// lastError represents the last error that occurred processing a schema
+	// change event
+	lastError string
+	// ticketTracker represents the issue tracker of the tickets; empty when
+	// ticket tracking is not configured
+	ticketTracker tracker.Backend
+}
+
+// recordEvent will record the outcome of processing a schema change event.
+func (h *health) recordEvent(err error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.lastEventAt = time.Now().UTC()
+	h.eventsProcessed++
+	if err != nil {
+		h.eventErrors++
+		h.lastError = err.Error()
+	}
+}
+
+// status will get a snapshot of the health.
+func (h *health) status() healthStatus {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return healthStatus{
+		startedAt:       h.startedAt,
+		lastEventAt:     h.lastEventAt,
+		eventsProcessed: h.eventsProcessed,
+		eventErrors:     h.eventErrors,
+		lastError:       h.lastError,
+	}
+}
+
+// isBreaking will determine if a schema change is considered breaking for
+// Koko.
+func isBreaking(sc store.SchemaChange) bool {
+	if sc.Triage != nil && sc.Triage.Decision == store.DecisionNeedsCompat {
+		return true
+	}
+	if sc.Assessment != nil {
+		switch sc.Assessment.Severity {
+		case store.SeverityHigh, store.SeverityCritical:
+			return true
+		case store.SeverityLow, store.SeverityMedium:
+		}
+	}
+	return false
+}
+
+// homeSchemaChangeText will create the text of a schema change listed in the
+// App Home.
+func homeSchemaChangeText(sc store.SchemaChange) string {
+	text := fmt.Sprintf("*<%s|%s>* %s", sc.URL, sc.Key(), sc.Title)
+	if len(sc.NewPlugins) > 0 {
+		text = ":new: " + text
+	}
+	if sc.Ticket != nil {
+		text += " · " + ticketText(*sc.Ticket)
+	}
+	return text
+}
+
+// homeSection will create the blocks of a section of the App Home; the
+// triage buttons are added to each schema change when requested.
+func homeSection(title string, empty string, schemaChanges []store.SchemaChange, withActions bool) []slack.Block {
+	blocks := []slack.Block{
+		slack.NewHeaderBlock(plainText(fmt.Sprintf("%s (%d)", title, len(schemaChanges)))),
+	}
+	if len(schemaChanges) == 0 {
+		return append(blocks, slack.NewContextBlock("", markdownText(empty)))
+	}
+	for i, sc := range schemaChanges {
+		if i == maxHomeSchemaChanges {
+			blocks = append(blocks, slack.NewContextBlock("",
+				markdownText(fmt.Sprintf("… and %d more", len(schemaChanges)-maxHomeSchemaChanges))))
+			break
+		}
+		blocks = append(blocks, slack.NewSectionBlock(markdownText(homeSchemaChangeText(sc)), nil, nil))
+		if status := triageStatusBlock(sc); status != nil {
+			status.BlockID = ""
+			blocks = append(blocks, status)
+		}
+		if withActions {
+			actions := triageActionsBlock(sc.Key())
+			actions.BlockID = ""
+			blocks = append(blocks, actions)
+		}
+	}
+	return blocks
+}
+
+// homeView will create the App Home dashboard of the schema changes for a
+// user.
+func homeView(schemaChanges []store.SchemaChange, user string, status healthStatus,
+	now time.Time,
+) slack.HomeTabViewRequest {
+	var untriaged, assigned, breaking []store.SchemaChange
+	for _, sc := range schemaChanges {
+		if sc.Triage == nil && sc.Assessment == nil {
+			untriaged = append(untriaged, sc)
+		}
+		if sc.Triage != nil && sc.Triage.Decision == store.DecisionAssigned && sc.Triage.Assignee == user {
+			assigned = append(assigned, sc)
+		}
+		if isBreaking(sc) && now.Sub(sc.CreatedAt) <= recentBreakingChangesPeriod {
+			breaking = append(breaking, sc)
+		}
+	}
+
+	var blocks []slack.Block
+	blocks = append(blocks, homeSection(":inbox_tray: Untriaged schema changes",
+		"Nothing to triage :tada:", untriaged, true)...)
+	blocks = append(blocks, slack.NewDividerBlock())
+	blocks = append(blocks, homeSection(":bust_in_silhouette: Assigned to you",
+		"Nothing assigned to you", assigned, false)...)
+	blocks = append(blocks, slack.NewDividerBlock())
+	blocks = append(blocks, homeSection(":rotating_light: Recent breaking changes",
+		"No breaking changes in the last 30 days", breaking, false)...)
+	blocks = append(blocks, slack.NewDividerBlock())
+	blocks = append(blocks, healthBlocks(status, len(schemaChanges), now)...)
+
+	return slack.HomeTabViewRequest{
+		Type:   slack.VTHomeTab,
+		Blocks: slack.Blocks{BlockSet: blocks},
+	}
+}
+
+// healthBlocks will create the blocks showing the health of the bot.
+func healthBlocks(status healthStatus, schemaChanges int, now time.Time) []slack.Block {
+	lastEvent := "never"
+	if !status.lastEventAt.IsZero() {
+		lastEvent = fmt.Sprintf("%s ago", now.Sub(status.lastEventAt).Round(time.Second))
+	}
+	ticketTracking := "disabled"
+	if len(status.ticketTracker) > 0 {
+		ticketTracking = fmt.Sprintf("enabled (%s)", status.ticketTracker)
+	}
+	fields := []*slack.TextBlockObject{
+		markdownText(fmt.Sprintf("*Uptime:*\n%s", now.Sub(status.startedAt).Round(time.Second))),
+		markdownText(fmt.Sprintf("*Last schema change event:*\n%s", lastEvent)),
+		markdownText(fmt.Sprintf("*Events processed:*\n%d (%d failed)", status.eventsProcessed, status.eventErrors)),
+		markdownText(fmt.Sprintf("*Schema changes tracked:*\n%d", schemaChanges)),
+		markdownText(fmt.Sprintf("*Ticket tracking:*\n%s", ticketTracking)),
+	}
+	blocks := []slack.Block{
+		slack.NewHeaderBlock(plainText(":heartbeat: Bot health")),
+		slack.NewSectionBlock(nil, fields, nil),
+	}
+	if len(status.lastError) > 0 {
+		blocks = append(blocks, slack.NewContextBlock("",
+			markdownText(fmt.Sprintf(":warning: Last error: %s", status.lastError))))
+	}
+	return blocks
+}
+
+// handleAppHomeOpenedEvent will publish the App Home dashboard for the user
+// opening it.
+func (s *Slack) handleAppHomeOpenedEvent(e *socketmode.Event, c *socketmode.Client) {
+	// Ensure this is the correct event
+	eventsAPIEvent, ok := e.Data.(slackevents.EventsAPIEvent)
+	if !ok {
+		s.logger.Error("event ignored as it is not an API event", zap.Any("event", e))
+		return
+	}
+	c.Ack(*e.Request)
+
+	homeEvent, ok := eventsAPIEvent.InnerEvent.Data.(*slackevents.AppHomeOpenedEvent)
+	if !ok {
+		s.logger.Debug("event ignored as it is not an App Home opened event", zap.Any("event", e))
+		return
+	}
+	if homeEvent.Tab != "home" {
+		return
+	}
+
+	s.homeMutex.Lock()
+	s.homeUsers[homeEvent.User] = struct{}{}
+	s.homeMutex.Unlock()
+	s.publishHome(homeEvent.User)
+}
+
+// publishHome will publish the App Home dashboard for a user.
+func (s *Slack) publishHome(user string) {
+	status := s.health.status()
+	if s.tracker != nil {
+		status.ticketTracker = s.tracker.Backend()
+	}
+	view := homeView(s.store.SchemaChanges(), user, status, time.Now().UTC())
+	if _, err := s.client.PublishView(user, view, ""); err != nil {
+		s.logger.Error("unable to publish App Home", zap.String("user", user), zap.Error(err))
+	}
+}
+
+// refreshHome will republish the App Home dashboard for every user who has
+// opened it.
+func (s *Slack) refreshHome() {
+	s.homeMutex.Lock()
+	users := make([]string, 0, len(s.homeUsers))
+	for user := range s.homeUsers {
+		users = append(users, user)
+	}
+	s.homeMutex.Unlock()
+
+	go func() {
+		for _, user := range users {
+			s.publishHome(user)
+		}
+	}()
+}
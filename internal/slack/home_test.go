@@ -0,0 +1,119 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"errors"
+	"time"
+
+	"github.com/kong/koko-slack-bot/internal/store"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/slack-go/slack"
+)
+
+// headerTexts will get the text of the header blocks of a view.
+func headerTexts(blocks []slack.Block) []string {
+	var texts []string
+	for _, block := range blocks {
+		if header, ok := block.(*slack.HeaderBlock); ok {
+			texts = append(texts, header.Text.Text)
+		}
+	}
+	return texts
+}
+
+var _ = Describe("App Home", Label("app-home"), func() {
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	It("the schema changes will be grouped into the dashboard sections", func() {
+		schemaChanges := []store.SchemaChange{
+			{
+				Organization: "kong",
+				Repository:   "kong",
+				PullRequest:  1,
+				CreatedAt:    now.Add(-time.Hour),
+			},
+			{
+				Organization: "kong",
+				Repository:   "kong",
+				PullRequest:  2,
+				CreatedAt:    now.Add(-time.Hour),
+				Triage: &store.Triage{
+					Decision: store.DecisionAssigned,
+					User:     "U123",
+					Assignee: "U123",
+				},
+			},
+			{
+				Organization: "kong",
+				Repository:   "kong",
+				PullRequest:  3,
+				CreatedAt:    now.Add(-24 * time.Hour),
+				Triage: &store.Triage{
+					Decision: store.DecisionNeedsCompat,
+					User:     "U456",
+				},
+			},
+			{
+				Organization: "kong",
+				Repository:   "kong",
+				PullRequest:  4,
+				CreatedAt:    now.Add(-60 * 24 * time.Hour),
+				Assessment: &store.Assessment{
+					Severity: store.SeverityCritical,
+				},
+			},
+		}
+
+		view := homeView(schemaChanges, "U123", healthStatus{startedAt: now.Add(-time.Minute)}, now)
+		Expect(view.Type).Should(Equal(slack.VTHomeTab))
+		Expect(headerTexts(view.Blocks.BlockSet)).Should(Equal([]string{
+			":inbox_tray: Untriaged schema changes (1)",
+			":bust_in_silhouette: Assigned to you (1)",
+			":rotating_light: Recent breaking changes (1)",
+			":heartbeat: Bot health",
+		}))
+	})
+
+	It("the dashboard will not be assigned changes of another user", func() {
+		view := homeView([]store.SchemaChange{
+			{
+				Triage: &store.Triage{
+					Decision: store.DecisionAssigned,
+					Assignee: "U456",
+				},
+			},
+		}, "U123", healthStatus{}, now)
+		Expect(headerTexts(view.Blocks.BlockSet)).Should(ContainElement(":bust_in_silhouette: Assigned to you (0)"))
+	})
+
+	It("the bot health will include the last error", func() {
+		h := &health{startedAt: now}
+		h.recordEvent(nil)
+		h.recordEvent(errors.New("unable to get pull request"))
+		status := h.status()
+		Expect(status.eventsProcessed).Should(Equal(2))
+		Expect(status.eventErrors).Should(Equal(1))
+
+		blocks := healthBlocks(status, 3, now.Add(time.Hour))
+		Expect(blocks).To(HaveLen(3))
+		section, ok := blocks[1].(*slack.SectionBlock)
+		Expect(ok).To(BeTrue())
+		Expect(section.Fields[0].Text).Should(Equal("*Uptime:*\n1h0m0s"))
+		Expect(section.Fields[2].Text).Should(Equal("*Events processed:*\n2 (1 failed)"))
+	})
+})
@@ -0,0 +1,155 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kong/koko-slack-bot/internal/github"
+	"github.com/kong/koko-slack-bot/internal/schema"
+	"github.com/slack-go/slack"
+)
+
+const (
+	// maxImpactTerms represents the maximum number of terms searched for a
+	// schema change to keep within the rate limits of the code search API
+	maxImpactTerms = 10
+	// maxImpactLineLength represents the maximum length in characters of a
+	// listed matching line
+	maxImpactLineLength = 100
+)
+
+// impactChange represents the places of the Koko codebase referencing the
+// plugins and fields affected by a gateway schema change.
+type impactChange struct {
+	// repository represents the searched Koko repository; e.g. kong/koko
+	repository string
+	// terms represents the searched plugin and field names
+	terms []string
+	// matches represents the lines referencing the terms
+	matches []github.CodeMatch
+	// analysisError represents the error that occurred analyzing the impact
+	analysisError string
+}
+
+// touchesPluginSchema will determine if a pull request modified a gateway
+// plugin schema.
+func touchesPluginSchema(files []github.File) bool {
+	for _, file := range files {
+		if kind, _, ok := schema.Classify(file.Filename); ok && kind == schema.KindPlugin {
+			return true
+		}
+	}
+	return false
+}
+
+// analyzeImpact will search the Koko codebase for the names of the plugins
+// whose schemas a gateway schema change modified and the names of their
+// changed fields.
+func (s *Slack) analyzeImpact(gsc gatewaySchemaChange) (*impactChange, error) {
+	change := &impactChange{repository: s.kokoOrganization + "/" + s.kokoRepository}
+	seen := make(map[string]struct{})
+	add := func(term string) {
+		if _, ok := seen[term]; !ok && len(term) > 0 && len(change.terms) < maxImpactTerms {
+			seen[term] = struct{}{}
+			change.terms = append(change.terms, term)
+		}
+	}
+	for _, file := range gsc.files {
+		kind, name, ok := schema.Classify(file.Filename)
+		if !ok || kind != schema.KindPlugin {
+			continue
+		}
+		add(name)
+		base, err := s.gatewayFileContent(gsc, file.Filename, gsc.baseRevision())
+		if err != nil {
+			return nil, err
+		}
+		head, err := s.gatewayFileContent(gsc, file.Filename, gsc.details.HeadSHA)
+		if err != nil {
+			return nil, err
+		}
+		baseSchema, err := schema.Parse(base)
+		if err != nil {
+			return nil, fmt.Errorf("base revision: %w", err)
+		}
+		headSchema, err := schema.Parse(head)
+		if err != nil {
+			return nil, fmt.Errorf("head revision: %w", err)
+		}
+		for _, c := range schema.Diff(baseSchema, headSchema) {
+			add(fieldName(c.Path))
+		}
+	}
+
+	matches, err := s.gitHubClient.SearchCode(s.kokoOrganization, s.kokoRepository, change.terms)
+	if err != nil {
+		return nil, err
+	}
+	change.matches = matches
+	return change, nil
+}
+
+// fieldName will get the name of a field from its dotted path; e.g.
+// sync_rate for config.sync_rate.
+func fieldName(path string) string {
+	path = strings.TrimSuffix(path, "[]")
+	if index := strings.LastIndex(path, "."); index >= 0 {
+		return path[index+1:]
+	}
+	return path
+}
+
+// impactText will create the text listing the Koko lines referencing the
+// affected plugins and fields; at most limit lines are listed when the limit
+// is positive.
+func impactText(change *impactChange, limit int) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Koko references (%s)", change.repository)
+	if len(change.terms) > 0 {
+		fmt.Fprintf(&sb, " of `%s`", strings.Join(change.terms, "`, `"))
+	}
+	sb.WriteString(":")
+	if len(change.analysisError) > 0 {
+		fmt.Fprintf(&sb, "\n⚠ unable to analyze the Koko impact: %s", change.analysisError)
+	} else if len(change.matches) == 0 {
+		sb.WriteString("\nNo references found")
+	}
+	for i, match := range change.matches {
+		if limit > 0 && i == limit {
+			fmt.Fprintf(&sb, "\n… and %d more", len(change.matches)-limit)
+			break
+		}
+		location := match.Path
+		if match.Line > 0 {
+			location = fmt.Sprintf("%s:%d", match.Path, match.Line)
+		}
+		text := match.Text
+		if utf8.RuneCountInString(text) > maxImpactLineLength {
+			text = string([]rune(text)[:maxImpactLineLength]) + "…"
+		}
+		fmt.Fprintf(&sb, "\n• `%s` %s", location, strings.ReplaceAll(text, "`", "'"))
+	}
+	return sb.String()
+}
+
+// impactBlock will create the block listing the Koko lines referencing the
+// affected plugins and fields.
+func impactBlock(change *impactChange) slack.Block {
+	return slack.NewSectionBlock(markdownText(":mag: "+impactText(change, maxListedCommits)), nil, nil)
+}
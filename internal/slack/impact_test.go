@@ -0,0 +1,132 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/kong/koko-slack-bot/internal/github"
+	"github.com/kong/koko-slack-bot/internal/store"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("Koko impact", Label("impact"), func() {
+	var s *Slack
+	var server *httptest.Server
+	var queries []string
+	gsc := gatewaySchemaChange{
+		organization: "kong",
+		repository:   "kong",
+		pullRequest:  11234,
+		details:      github.PullRequest{BaseSHA: "base", HeadSHA: "head"},
+		files: []github.File{
+			{Filename: "kong/plugins/rate-limiting/schema.lua", Status: "modified"},
+			{Filename: "kong/plugins/rate-limiting/handler.lua", Status: "modified"},
+		},
+	}
+
+	BeforeEach(func() {
+		logger, err := zap.NewDevelopment()
+		Expect(err).NotTo(HaveOccurred())
+		queries = nil
+		mux := http.NewServeMux()
+		mux.HandleFunc("/repos/kong/kong/contents/kong/plugins/rate-limiting/schema.lua",
+			func(w http.ResponseWriter, r *http.Request) {
+				source := `return { fields = { { config = { type = "record", fields = {
+					{ policy = { type = "string" } } } } } } }`
+				if r.URL.Query().Get("ref") == "head" {
+					source = `return { fields = { { config = { type = "record", fields = {
+						{ policy = { type = "string" } },
+						{ sync_rate = { type = "number" } } } } } } }`
+				}
+				serveContent(w, source)
+			})
+		mux.HandleFunc("/search/code", func(w http.ResponseWriter, r *http.Request) {
+			queries = append(queries, r.URL.Query().Get("q"))
+			if r.URL.Query().Get("q") != `"sync_rate" repo:kong/koko` {
+				_, _ = w.Write([]byte(`{"total_count": 0, "items": []}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"total_count": 1, "items": [{
+				"path": "internal/plugin/validators/rate_limiting.go",
+				"text_matches": [{"fragment": "if cfg.SyncRate < 0 { // sync_rate"}]
+			}]}`))
+		})
+		server = httptest.NewServer(mux)
+		client, err := github.NewClient(github.Options{
+			Token:   "token",
+			BaseURL: server.URL,
+			Logger:  logger,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		s, err = NewSlack(Options{
+			AppToken:       "xapp-",
+			BotToken:       "xoxb-",
+			Logger:         logger,
+			GitHubClient:   client,
+			Store:          &store.Store{},
+			ImpactAnalysis: true,
+			KokoRepository: "kong/koko",
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("only pull requests modifying plugin schemas will be analyzed", func() {
+		Expect(touchesPluginSchema(gsc.files)).To(BeTrue())
+		Expect(touchesPluginSchema([]github.File{{Filename: "kong/db/schema/entities/services.lua"}})).To(BeFalse())
+	})
+
+	It("the plugin and its changed fields will be searched in Koko", func() {
+		change, err := s.analyzeImpact(gsc)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(queries).Should(Equal([]string{`"rate-limiting" repo:kong/koko`, `"sync_rate" repo:kong/koko`}))
+		Expect(impactText(change, maxListedCommits)).Should(Equal("Koko references (kong/koko) of " +
+			"`rate-limiting`, `sync_rate`:\n• `internal/plugin/validators/rate_limiting.go` " +
+			"if cfg.SyncRate < 0 { // sync_rate"))
+	})
+
+	It("the lines will be listed with their line numbers up to the limit", func() {
+		Expect(impactText(&impactChange{
+			repository: "kong/koko",
+			terms:      []string{"sync_rate"},
+			matches: []github.CodeMatch{
+				{Path: "a.go", Line: 3, Text: "x := `sync_rate`", Term: "sync_rate"},
+				{Path: "b.go", Line: 7, Text: "sync_rate", Term: "sync_rate"},
+			},
+		}, 1)).Should(Equal("Koko references (kong/koko) of `sync_rate`:\n• `a.go:3` x := 'sync_rate'\n… and 1 more"))
+	})
+
+	It("long lines will be truncated without splitting characters", func() {
+		line := strings.Repeat("é", maxImpactLineLength+1)
+		Expect(impactText(&impactChange{
+			repository: "kong/koko",
+			matches:    []github.CodeMatch{{Path: "a.go", Line: 1, Text: line}},
+		}, 0)).Should(HaveSuffix(strings.Repeat("é", maxImpactLineLength) + "…"))
+	})
+
+	It("an analysis error will be shown", func() {
+		Expect(impactText(&impactChange{repository: "kong/koko", analysisError: "rate limited"}, 0)).
+			Should(Equal("Koko references (kong/koko):\n⚠ unable to analyze the Koko impact: rate limited"))
+	})
+})
@@ -0,0 +1,66 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/kong/koko-slack-bot/internal/jsonschema"
+	"github.com/kong/koko-slack-bot/internal/schema"
+	"go.uber.org/zap"
+)
+
+// pluginJSONSchema will convert a plugin schema of a gateway schema change
+// into an indented JSON schema.
+func (s *Slack) pluginJSONSchema(gsc gatewaySchemaChange, path string) (string, error) {
+	content, err := s.gatewayFileContent(gsc, path, gsc.details.HeadSHA)
+	if err != nil {
+		return "", err
+	}
+	converted, err := jsonschema.Convert(content)
+	if err != nil {
+		return "", err
+	}
+	document, err := json.MarshalIndent(converted, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("unable to encode JSON schema: %w", err)
+	}
+	return string(document), nil
+}
+
+// attachJSONSchemas will comment the ready to review JSON schemas of the plugin
+// schemas added or modified by a gateway schema change on its ticket; failures
+// are logged as the ticket is still usable without them.
+func (s *Slack) attachJSONSchemas(gsc gatewaySchemaChange, key string) {
+	logger := s.logger.With(zap.String("schema-change", gsc.key()), zap.String("ticket", key))
+	for _, file := range gsc.files {
+		kind, name, ok := schema.Classify(file.Filename)
+		if !ok || kind != schema.KindPlugin || file.Status == "removed" {
+			continue
+		}
+		document, err := s.pluginJSONSchema(gsc, file.Filename)
+		if err != nil {
+			logger.Warn("unable to convert plugin schema to JSON schema", zap.String("plugin", name), zap.Error(err))
+			continue
+		}
+		comment := fmt.Sprintf("JSON schema of the %s plugin as of this pull request:\n\n```json\n%s\n```",
+			name, document)
+		if err := s.tracker.Comment(key, comment); err != nil {
+			logger.Error("unable to comment JSON schema on ticket", zap.String("plugin", name), zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,113 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/kong/koko-slack-bot/internal/github"
+	"github.com/kong/koko-slack-bot/internal/store"
+	"github.com/kong/koko-slack-bot/internal/templates"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("plugin JSON schemas", Label("jsonschema"), func() {
+	var s *Slack
+	var server *httptest.Server
+	var t *recordingTracker
+
+	BeforeEach(func() {
+		logger, err := zap.NewDevelopment()
+		Expect(err).NotTo(HaveOccurred())
+		mux := http.NewServeMux()
+		mux.HandleFunc("/repos/kong/kong/contents/kong/plugins/rate-limiting/schema.lua",
+			func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.URL.Query().Get("ref")).Should(Equal("abc123"))
+				source := `return { name = "rate-limiting", fields = { { config = { type = "record", required = true, fields = {
+					{ sync_rate = { type = "number", default = -1 } } } } } } }`
+				serveContent(w, source)
+			})
+		mux.HandleFunc("/repos/kong/kong/contents/kong/plugins/acme/schema.lua",
+			func(w http.ResponseWriter, r *http.Request) {
+				serveContent(w, `return "acme"`)
+			})
+		server = httptest.NewServer(mux)
+		client, err := github.NewClient(github.Options{
+			Token:   "token",
+			BaseURL: server.URL,
+			Logger:  logger,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		ticketTemplates, err := templates.New(templates.Options{Logger: logger})
+		Expect(err).NotTo(HaveOccurred())
+		t = newRecordingTracker()
+		s, err = NewSlack(Options{
+			AppToken:     "xapp-",
+			BotToken:     "xoxb-",
+			Logger:       logger,
+			GitHubClient: client,
+			Store:        &store.Store{},
+			Templates:    ticketTemplates,
+			Tracker:      t,
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("the JSON schemas of the modified plugin schemas will be commented on the ticket", func() {
+		s.attachJSONSchemas(gatewaySchemaChange{
+			organization: "kong",
+			repository:   "kong",
+			pullRequest:  11234,
+			details:      github.PullRequest{HeadSHA: "abc123"},
+			files: []github.File{
+				{Filename: "kong/plugins/rate-limiting/schema.lua", Status: "modified"},
+				{Filename: "kong/plugins/rate-limiting/handler.lua", Status: "modified"},
+				{Filename: "kong/plugins/acme/schema.lua", Status: "modified"},
+				{Filename: "kong/plugins/session/schema.lua", Status: "removed"},
+				{Filename: "kong/db/schema/entities/services.lua", Status: "modified"},
+			},
+		}, "KOKO-1")
+
+		Expect(t.comments["KOKO-1"]).Should(Equal([]string{"JSON schema of the rate-limiting plugin as of this " +
+			"pull request:\n\n```json\n" + `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "title": "rate-limiting",
+  "type": "object",
+  "properties": {
+    "config": {
+      "type": "object",
+      "properties": {
+        "sync_rate": {
+          "type": "number",
+          "default": -1
+        }
+      },
+      "additionalProperties": false
+    }
+  },
+  "required": [
+    "config"
+  ]
+}` + "\n```"}))
+	})
+})
@@ -0,0 +1,339 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kong/koko-slack-bot/internal/github"
+	"github.com/kong/koko-slack-bot/internal/store"
+	"github.com/kong/koko-slack-bot/internal/tracker"
+	"go.uber.org/zap"
+)
+
+// closedPullRequestGracePeriod represents how long pull requests closed
+// without being merged are polled as they can be reopened.
+const closedPullRequestGracePeriod = 30 * 24 * time.Hour
+
+var (
+	// revertTitlePattern represents the pattern of the title GitHub gives to
+	// revert pull requests; e.g. Revert "feat(rate-limiting): add sync rate"
+	revertTitlePattern = regexp.MustCompile(`^Revert "(.+)"$`)
+	// revertReferencePattern represents the pattern of the reference GitHub
+	// adds to the body of revert pull requests; e.g. Reverts kong/kong#11234
+	revertReferencePattern = regexp.MustCompile(`(?im)^Reverts\s+([\w.-]+)/([\w.-]+)#(\d+)\s*$`)
+	// revertCommitPattern represents the pattern of the trailer git adds to
+	// revert commits; e.g. This reverts commit abcdef0.
+	revertCommitPattern = regexp.MustCompile(`(?i)This reverts commit ([0-9a-f]{7,40})`)
+)
+
+// revert represents the references of a revert pull request to the change it
+// reverts.
+type revert struct {
+	// title represents the title of the reverted pull request
+	title string
+	// keys represents the store keys of the reverted pull requests
+	keys []string
+	// commits represents the SHAs of the reverted commits
+	commits []string
+}
+
+// pullRequestState will determine the state of a pull request.
+func pullRequestState(details github.PullRequest) store.PullRequestState {
+	switch {
+	case details.Merged:
+		return store.PullRequestMerged
+	case details.State == "closed":
+		return store.PullRequestClosed
+	default:
+		return store.PullRequestOpen
+	}
+}
+
+// setPullRequestState will set the state, the merge commit, and the closing
+// time of a pull request on the stored state of its schema change.
+func setPullRequestState(sc *store.SchemaChange, details github.PullRequest) {
+	sc.State = pullRequestState(details)
+	if details.Merged {
+		sc.MergeCommitSHA = details.MergeCommitSHA
+		sc.MergedAt = details.MergedAt
+	}
+	sc.ClosedAt = time.Time{}
+	if sc.State == store.PullRequestClosed {
+		sc.ClosedAt = details.ClosedAt
+	}
+}
+
+// settled will determine if the state of the pull request of a schema change
+// is final; merged pull requests cannot change, while pull requests closed
+// for longer than the grace period are unlikely to be reopened. Schema
+// changes stored before the closing time was known use their last update.
+func settled(sc store.SchemaChange, now time.Time) bool {
+	switch sc.State {
+	case store.PullRequestMerged:
+		return true
+	case store.PullRequestClosed:
+		closedAt := sc.ClosedAt
+		if closedAt.IsZero() {
+			closedAt = sc.UpdatedAt
+		}
+		return now.Sub(closedAt) > closedPullRequestGracePeriod
+	}
+	return false
+}
+
+// watchPullRequests will periodically refresh the state of the pull requests
+// of the stored schema changes.
+func (s *Slack) watchPullRequests() {
+	ticker := time.NewTicker(s.pullRequestPollInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.pollPullRequests()
+	}
+}
+
+// pollPullRequests will refresh the state of the pull requests of the stored
+// schema changes that are not settled yet; closed pull requests are polled
+// during a grace period as they can be reopened. The tickets of pull requests
+// closed without being merged, or reopened, are annotated.
+func (s *Slack) pollPullRequests() {
+	changed := false
+	now := time.Now().UTC()
+	for _, sc := range s.store.SchemaChanges() {
+		if settled(sc, now) {
+			continue
+		}
+		logger := s.logger.With(zap.String("schema-change", sc.Key()))
+		details, err := s.gitHubClient.PullRequest(sc.Organization, sc.Repository, sc.PullRequest)
+		if err != nil {
+			logger.Warn("unable to refresh pull request state", zap.Error(err))
+			continue
+		}
+		if pullRequestState(details) == sc.State {
+			continue
+		}
+		updated, err := s.store.UpdateSchemaChange(sc.Key(), func(sc *store.SchemaChange) {
+			setPullRequestState(sc, details)
+		})
+		if err != nil {
+			logger.Error("unable to store pull request state", zap.Error(err))
+			continue
+		}
+		changed = true
+		if err := s.applyPullRequestState(sc.State, updated); err != nil {
+			logger.Error("unable to apply pull request state", zap.Error(err))
+		}
+	}
+	if changed {
+		s.refreshHome()
+	}
+}
+
+// applyPullRequestState will apply a change of the state of the pull request
+// of a schema change; the release inclusion of merged pull requests is
+// recorded in the background as it takes many API calls, the tickets of pull
+// requests closed without being merged are annotated, and the tickets of
+// reopened pull requests are resumed.
+func (s *Slack) applyPullRequestState(previous store.PullRequestState, sc store.SchemaChange) error {
+	if sc.State == previous {
+		return nil
+	}
+	switch sc.State {
+	case store.PullRequestMerged:
+		go func() {
+			if err := s.recordReleaseInclusion(sc); err != nil {
+				s.logger.Warn("unable to record release inclusion", zap.String("schema-change", sc.Key()), zap.Error(err))
+			}
+		}()
+		return nil
+	case store.PullRequestClosed:
+		return s.abandonTicket(sc)
+	case store.PullRequestOpen:
+		if previous == store.PullRequestClosed {
+			return s.resumeTicket(sc)
+		}
+	}
+	return nil
+}
+
+// abandonTicket will annotate the ticket of a schema change whose pull request
+// was closed without being merged, optionally closing it as won't do, then
+// update the schema change reply. A ticket shared with backports or syncs is
+// left untouched while another pull request of the logical change is open or
+// merged.
+func (s *Slack) abandonTicket(sc store.SchemaChange) error {
+	if s.tracker != nil && sc.Ticket != nil && !s.groupActive(sc) {
+		comment := fmt.Sprintf("The gateway pull request %s was closed without being merged.", sc.URL)
+		if err := s.tracker.Comment(sc.Ticket.Key, comment); err != nil {
+			return fmt.Errorf("unable to comment on ticket: %w", err)
+		}
+		if s.closeAbandonedTickets {
+			if err := s.tracker.Close(sc.Ticket.Key, tracker.ResolutionWontDo); err != nil {
+				return fmt.Errorf("unable to close ticket: %w", err)
+			}
+		}
+	}
+	if len(sc.Channel) > 0 && len(sc.Timestamp) > 0 {
+		if err := s.updateReply(sc.Channel, sc.Timestamp, sc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resumeTicket will annotate the ticket of a schema change whose pull request
+// was reopened, reopening it when abandoned tickets are closed, then update
+// the schema change reply. A ticket shared with backports or syncs is left
+// untouched while another pull request of the logical change is open or
+// merged as it was never abandoned.
+func (s *Slack) resumeTicket(sc store.SchemaChange) error {
+	if s.tracker != nil && sc.Ticket != nil && !s.groupActive(sc) {
+		comment := fmt.Sprintf("The gateway pull request %s was reopened.", sc.URL)
+		if err := s.tracker.Comment(sc.Ticket.Key, comment); err != nil {
+			return fmt.Errorf("unable to comment on ticket: %w", err)
+		}
+		if s.closeAbandonedTickets {
+			if err := s.tracker.Reopen(sc.Ticket.Key); err != nil {
+				return fmt.Errorf("unable to reopen ticket: %w", err)
+			}
+		}
+	}
+	if len(sc.Channel) > 0 && len(sc.Timestamp) > 0 {
+		if err := s.updateReply(sc.Channel, sc.Timestamp, sc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// groupActive will determine whether another pull request of the logical
+// change a schema change belongs to is open or merged.
+func (s *Slack) groupActive(sc store.SchemaChange) bool {
+	for _, member := range s.groupMembers(sc) {
+		if member.State != store.PullRequestClosed {
+			return true
+		}
+	}
+	return false
+}
+
+// linkRevert will detect whether the pull request of a gateway schema change
+// reverts a stored schema change and link both together; the ticket of the
+// reverted schema change is annotated.
+func (s *Slack) linkRevert(gsc gatewaySchemaChange) error {
+	if sc, ok := s.store.SchemaChange(gsc.key()); ok && len(sc.Reverts) > 0 {
+		return nil
+	}
+	r, ok := parseRevert(gsc.details, gsc.commitMessages)
+	if !ok {
+		return nil
+	}
+	original, ok := r.match(s.store.SchemaChanges(), gsc)
+	if !ok {
+		s.logger.Debug("reverted schema change is not tracked", zap.String("schema-change", gsc.key()))
+		return nil
+	}
+
+	if _, err := s.store.UpdateSchemaChange(gsc.key(), func(sc *store.SchemaChange) {
+		sc.Reverts = original.Key()
+	}); err != nil {
+		return fmt.Errorf("unable to store revert: %w", err)
+	}
+	original, err := s.store.UpdateSchemaChange(original.Key(), func(sc *store.SchemaChange) {
+		sc.RevertedBy = gsc.key()
+	})
+	if err != nil {
+		return fmt.Errorf("unable to store revert: %w", err)
+	}
+	if s.tracker != nil && original.Ticket != nil {
+		comment := fmt.Sprintf("The gateway pull request was reverted by %s", gsc.details.URL)
+		if err := s.tracker.Comment(original.Ticket.Key, comment); err != nil {
+			return fmt.Errorf("unable to comment on ticket: %w", err)
+		}
+	}
+	if len(original.Channel) > 0 && len(original.Timestamp) > 0 {
+		if err := s.updateReply(original.Channel, original.Timestamp, original); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseRevert will parse the references of a pull request to the change it
+// reverts from its title, its description, and the messages of its commits;
+// false is returned when the pull request is not a revert.
+func parseRevert(details github.PullRequest, messages []string) (revert, bool) {
+	var r revert
+	if matches := revertTitlePattern.FindStringSubmatch(strings.TrimSpace(details.Title)); matches != nil {
+		r.title = matches[1]
+	}
+	for _, matches := range revertReferencePattern.FindAllStringSubmatch(details.Description, -1) {
+		number, err := strconv.Atoi(matches[3])
+		if err != nil {
+			continue
+		}
+		r.keys = append(r.keys, store.Key(matches[1], matches[2], number))
+	}
+	for _, text := range append([]string{details.Description}, messages...) {
+		for _, matches := range revertCommitPattern.FindAllStringSubmatch(text, -1) {
+			r.commits = append(r.commits, strings.ToLower(matches[1]))
+		}
+	}
+	return r, len(r.title) > 0 || len(r.keys) > 0 || len(r.commits) > 0
+}
+
+// match will find the stored schema change reverted by the pull request with
+// the given gateway schema change; references take precedence over reverted
+// commits, which take precedence over the most recent schema change with the
+// reverted title.
+func (r revert) match(schemaChanges []store.SchemaChange, gsc gatewaySchemaChange) (store.SchemaChange, bool) {
+	key := gsc.key()
+	for _, reference := range r.keys {
+		for _, sc := range schemaChanges {
+			if sc.Key() == reference && reference != key {
+				return sc, true
+			}
+		}
+	}
+	for _, commit := range r.commits {
+		for _, sc := range schemaChanges {
+			if len(sc.MergeCommitSHA) > 0 && sc.Key() != key &&
+				strings.HasPrefix(strings.ToLower(sc.MergeCommitSHA), commit) {
+				return sc, true
+			}
+		}
+	}
+	if len(r.title) == 0 {
+		return store.SchemaChange{}, false
+	}
+	var found store.SchemaChange
+	ok := false
+	for _, sc := range schemaChanges {
+		if sc.Key() == key || sc.Title != r.title || !strings.EqualFold(sc.Organization, gsc.organization) ||
+			!strings.EqualFold(sc.Repository, gsc.repository) {
+			continue
+		}
+		if !ok || sc.CreatedAt.After(found.CreatedAt) {
+			found = sc
+			ok = true
+		}
+	}
+	return found, ok
+}
@@ -0,0 +1,286 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"time"
+
+	"github.com/kong/koko-slack-bot/internal/github"
+	"github.com/kong/koko-slack-bot/internal/store"
+	"github.com/kong/koko-slack-bot/internal/templates"
+	"github.com/kong/koko-slack-bot/internal/tracker"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/slack-go/slack"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("pull request lifecycle", Label("lifecycle"), func() {
+	Describe("determining the state of a pull request", func() {
+		It("a merged pull request will be merged", func() {
+			Expect(pullRequestState(github.PullRequest{State: "closed", Merged: true})).
+				Should(Equal(store.PullRequestMerged))
+		})
+
+		It("a closed pull request that is not merged will be closed", func() {
+			Expect(pullRequestState(github.PullRequest{State: "closed"})).Should(Equal(store.PullRequestClosed))
+		})
+
+		It("an open pull request will be open", func() {
+			Expect(pullRequestState(github.PullRequest{State: "open"})).Should(Equal(store.PullRequestOpen))
+		})
+	})
+
+	Describe("detecting revert pull requests", func() {
+		schemaChanges := []store.SchemaChange{
+			{
+				Organization:   "kong",
+				Repository:     "kong",
+				PullRequest:    11234,
+				Title:          "feat(rate-limiting): add sync rate",
+				MergeCommitSHA: "abcdef0123456789",
+				CreatedAt:      time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC),
+			},
+			{
+				Organization: "kong",
+				Repository:   "kong",
+				PullRequest:  11300,
+				Title:        "feat(rate-limiting): add sync rate",
+				CreatedAt:    time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC),
+			},
+			{
+				Organization: "kong",
+				Repository:   "kong-ee",
+				PullRequest:  5000,
+				Title:        "feat(acl): add groups",
+			},
+		}
+		gsc := gatewaySchemaChange{organization: "kong", repository: "kong", pullRequest: 11400}
+
+		It("a pull request that is not a revert will not be detected", func() {
+			_, ok := parseRevert(github.PullRequest{Title: "feat(acl): add groups"}, []string{"feat(acl): add groups"})
+			Expect(ok).To(BeFalse())
+		})
+
+		It("the reverted pull request will be matched from the reference in the description", func() {
+			r, ok := parseRevert(github.PullRequest{
+				Title:       `Revert "feat(rate-limiting): add sync rate"`,
+				Description: "Reverts Kong/kong#11234\n\nBreaks the hybrid mode.",
+			}, nil)
+			Expect(ok).To(BeTrue())
+			sc, ok := r.match(schemaChanges, gsc)
+			Expect(ok).To(BeTrue())
+			Expect(sc.Key()).Should(Equal("kong/kong#11234"))
+		})
+
+		It("the reverted pull request will be matched from the revert commit trailer", func() {
+			r, ok := parseRevert(github.PullRequest{Title: "fix(rate-limiting): back out sync rate"},
+				[]string{"Revert \"feat(rate-limiting): add sync rate\"\n\nThis reverts commit ABCDEF0123."})
+			Expect(ok).To(BeTrue())
+			sc, ok := r.match(schemaChanges, gsc)
+			Expect(ok).To(BeTrue())
+			Expect(sc.Key()).Should(Equal("kong/kong#11234"))
+		})
+
+		It("the most recent pull request with the reverted title will be matched", func() {
+			r, ok := parseRevert(github.PullRequest{Title: `Revert "feat(rate-limiting): add sync rate"`}, nil)
+			Expect(ok).To(BeTrue())
+			sc, ok := r.match(schemaChanges, gsc)
+			Expect(ok).To(BeTrue())
+			Expect(sc.Key()).Should(Equal("kong/kong#11300"))
+		})
+
+		It("a pull request of another repository will not be matched by title", func() {
+			r, ok := parseRevert(github.PullRequest{Title: `Revert "feat(acl): add groups"`}, nil)
+			Expect(ok).To(BeTrue())
+			_, ok = r.match(schemaChanges, gsc)
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Describe("showing the pull request lifecycle", func() {
+		It("a closed and reverted pull request will be shown in the status", func() {
+			status := triageStatusBlock(store.SchemaChange{
+				State:      store.PullRequestClosed,
+				RevertedBy: "kong/kong#11400",
+			})
+			Expect(status).NotTo(BeNil())
+			Expect(status.ContextElements.Elements).To(HaveLen(2))
+			text, ok := status.ContextElements.Elements[1].(*slack.TextBlockObject)
+			Expect(ok).To(BeTrue())
+			Expect(text.Text).Should(Equal(":leftwards_arrow_with_hook: Reverted by kong/kong#11400"))
+		})
+	})
+
+	Describe("polling the state of pull requests", func() {
+		var s *Slack
+		var st *store.Store
+		var server *httptest.Server
+		var t *recordingTracker
+		var state string
+		var closedAt time.Time
+
+		BeforeEach(func() {
+			logger, err := zap.NewDevelopment()
+			Expect(err).NotTo(HaveOccurred())
+			state = "closed"
+			closedAt = time.Now().UTC().Add(-time.Hour)
+			mux := http.NewServeMux()
+			mux.HandleFunc("/repos/kong/kong/pulls/11234", func(w http.ResponseWriter, r *http.Request) {
+				if state == "open" {
+					_, _ = w.Write([]byte(`{"number": 11234, "state": "open", "merged": false}`))
+					return
+				}
+				_, _ = w.Write([]byte(fmt.Sprintf(`{"number": 11234, "state": %q, "merged": false, "closed_at": %q}`,
+					state, closedAt.Format(time.RFC3339))))
+			})
+			mux.HandleFunc("/repos/kong/kong/pulls/11235", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"number": 11235, "state": "closed", "merged": true, "merge_commit_sha": "1234567"}`))
+			})
+			mux.HandleFunc("/repos/kong/kong/tags", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`[]`))
+			})
+			mux.HandleFunc("/repos/kong/kong/branches", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`[]`))
+			})
+			server = httptest.NewServer(mux)
+			client, err := github.NewClient(github.Options{
+				Token:   "token",
+				BaseURL: server.URL,
+				Logger:  logger,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			st, err = store.NewStore(store.Options{
+				Path:   filepath.Join(GinkgoT().TempDir(), "store.json"),
+				Logger: logger,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			for _, pullRequest := range []int{11234, 11235} {
+				key := store.Key("kong", "kong", pullRequest)
+				_, err = st.UpdateSchemaChange(key, func(sc *store.SchemaChange) {
+					sc.Organization = "kong"
+					sc.Repository = "kong"
+					sc.PullRequest = pullRequest
+					sc.URL = "https://github.com/" + key
+					sc.State = store.PullRequestOpen
+					sc.Ticket = &store.Ticket{Key: "KOKO-" + key[len(key)-1:]}
+				})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			ticketTemplates, err := templates.New(templates.Options{Logger: logger})
+			Expect(err).NotTo(HaveOccurred())
+			t = newRecordingTracker()
+			s, err = NewSlack(Options{
+				AppToken:              "xapp-",
+				BotToken:              "xoxb-",
+				Logger:                logger,
+				GitHubClient:          client,
+				Store:                 st,
+				Templates:             ticketTemplates,
+				Tracker:               t,
+				CloseAbandonedTickets: true,
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("the ticket of a pull request closed without being merged will be annotated and closed", func() {
+			s.pollPullRequests()
+
+			sc, ok := st.SchemaChange("kong/kong#11234")
+			Expect(ok).To(BeTrue())
+			Expect(sc.State).Should(Equal(store.PullRequestClosed))
+			Expect(t.comments["KOKO-4"]).Should(Equal([]string{
+				"The gateway pull request https://github.com/kong/kong#11234 was closed without being merged.",
+			}))
+			Expect(t.closed).Should(Equal(map[string]tracker.Resolution{"KOKO-4": tracker.ResolutionWontDo}))
+		})
+
+		It("the ticket shared with a merged backport will not be annotated nor closed", func() {
+			for key, origin := range map[string]string{"kong/kong#11234": "", "kong/kong#11235": "kong/kong#11234"} {
+				origin := origin
+				_, err := st.UpdateSchemaChange(key, func(sc *store.SchemaChange) {
+					sc.Origin = origin
+					sc.Ticket = &store.Ticket{Key: "KOKO-4"}
+				})
+				Expect(err).NotTo(HaveOccurred())
+			}
+			s.pollPullRequests()
+
+			sc, ok := st.SchemaChange("kong/kong#11234")
+			Expect(ok).To(BeTrue())
+			Expect(sc.State).Should(Equal(store.PullRequestClosed))
+			Expect(t.comments).NotTo(HaveKey("KOKO-4"))
+			Expect(t.closed).To(BeEmpty())
+		})
+
+		It("the merge commit of a merged pull request will be stored without annotating its ticket", func() {
+			s.pollPullRequests()
+
+			sc, ok := st.SchemaChange("kong/kong#11235")
+			Expect(ok).To(BeTrue())
+			Expect(sc.State).Should(Equal(store.PullRequestMerged))
+			Expect(sc.MergeCommitSHA).Should(Equal("1234567"))
+			Expect(t.comments).NotTo(HaveKey("KOKO-5"))
+		})
+
+		It("the ticket of a closed pull request will be annotated only once", func() {
+			s.pollPullRequests()
+			s.pollPullRequests()
+
+			Expect(t.comments["KOKO-4"]).To(HaveLen(1))
+		})
+
+		It("the ticket of a reopened pull request will be annotated and reopened", func() {
+			s.pollPullRequests()
+			state = "open"
+			s.pollPullRequests()
+
+			sc, ok := st.SchemaChange("kong/kong#11234")
+			Expect(ok).To(BeTrue())
+			Expect(sc.State).Should(Equal(store.PullRequestOpen))
+			Expect(t.comments["KOKO-4"]).Should(Equal([]string{
+				"The gateway pull request https://github.com/kong/kong#11234 was closed without being merged.",
+				"The gateway pull request https://github.com/kong/kong#11234 was reopened.",
+			}))
+			Expect(t.reopened).Should(Equal([]string{"KOKO-4"}))
+		})
+
+		It("a pull request closed for longer than the grace period will no longer be polled", func() {
+			closedAt = time.Now().UTC().Add(-closedPullRequestGracePeriod - time.Hour)
+			s.pollPullRequests()
+			sc, ok := st.SchemaChange("kong/kong#11234")
+			Expect(ok).To(BeTrue())
+			Expect(sc.ClosedAt).Should(BeTemporally("==", closedAt.Truncate(time.Second)))
+
+			state = "open"
+			s.pollPullRequests()
+			sc, ok = st.SchemaChange("kong/kong#11234")
+			Expect(ok).To(BeTrue())
+			Expect(sc.State).Should(Equal(store.PullRequestClosed))
+			Expect(t.reopened).To(BeEmpty())
+		})
+	})
+})
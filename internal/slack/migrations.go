@@ -0,0 +1,89 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kong/koko-slack-bot/internal/gateway"
+)
+
+// migrationsChange represents the new database migrations of a gateway schema
+// change that may come with entity field changes Koko has to mirror.
+type migrationsChange struct {
+	// migrations represents the Postgres changes of the new migrations
+	migrations []gateway.Migration
+	// analysisError represents the error that occurred analyzing the changes
+	analysisError string
+}
+
+// hasNewMigrations will determine if a pull request adds database migrations.
+func hasNewMigrations(gsc gatewaySchemaChange) bool {
+	for _, file := range gsc.files {
+		if gateway.IsNewMigration(file.Filename, file.Status) {
+			return true
+		}
+	}
+	return false
+}
+
+// analyzeMigrations will parse the database migrations added by a gateway
+// schema change.
+func (s *Slack) analyzeMigrations(gsc gatewaySchemaChange) (*migrationsChange, error) {
+	change := &migrationsChange{}
+	for _, file := range gsc.files {
+		if !gateway.IsNewMigration(file.Filename, file.Status) {
+			continue
+		}
+		content, err := s.gatewayFileContent(gsc, file.Filename, gsc.details.HeadSHA)
+		if err != nil {
+			return nil, err
+		}
+		migration, err := gateway.ParseMigration(file.Filename, content)
+		if err != nil {
+			return nil, err
+		}
+		change.migrations = append(change.migrations, migration)
+	}
+	return change, nil
+}
+
+// migrationsText will create the summary of the Postgres changes of the new
+// database migrations.
+func migrationsText(change *migrationsChange) string {
+	var sb strings.Builder
+	sb.WriteString("New database migrations:")
+	if len(change.analysisError) > 0 {
+		fmt.Fprintf(&sb, "\n⚠ unable to analyze the database migrations: %s", change.analysisError)
+	}
+	for _, migration := range change.migrations {
+		fmt.Fprintf(&sb, "\n%s", migration.Path)
+		if len(migration.CreatedTables) == 0 && len(migration.Columns) == 0 {
+			sb.WriteString("\n• no Postgres table or column changes found")
+		}
+		for _, table := range migration.CreatedTables {
+			fmt.Fprintf(&sb, "\n• %s: table created", table)
+		}
+		for _, column := range migration.Columns {
+			fmt.Fprintf(&sb, "\n• %s.%s: %s", column.Table, column.Column, column.Operation)
+			if len(column.Definition) > 0 {
+				fmt.Fprintf(&sb, " (%s)", column.Definition)
+			}
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,57 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"github.com/kong/koko-slack-bot/internal/gateway"
+	"github.com/kong/koko-slack-bot/internal/github"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("migrations", Label("migrations"), func() {
+	It("pull requests adding database migrations will be detected", func() {
+		Expect(hasNewMigrations(gatewaySchemaChange{files: []github.File{
+			{Filename: "kong/db/migrations/core/020_330_to_340.lua", Status: "modified"},
+		}})).To(BeFalse())
+		Expect(hasNewMigrations(gatewaySchemaChange{files: []github.File{
+			{Filename: "kong/db/migrations/core/020_330_to_340.lua", Status: "added"},
+		}})).To(BeTrue())
+	})
+
+	It("the summary will list the changes of each migration", func() {
+		text := migrationsText(&migrationsChange{
+			migrations: []gateway.Migration{
+				{
+					Path:          "kong/db/migrations/core/020_330_to_340.lua",
+					CreatedTables: []string{"keys"},
+					Columns: []gateway.ColumnChange{
+						{Table: "upstreams", Column: "use_srv_name", Operation: gateway.ColumnAdded, Definition: "BOOLEAN"},
+						{Table: "routes", Column: "regex_priority", Operation: gateway.ColumnDropped},
+					},
+				},
+				{Path: "kong/db/migrations/core/021_340_to_350.lua"},
+			},
+		})
+		Expect(text).Should(Equal(`New database migrations:
+kong/db/migrations/core/020_330_to_340.lua
+• keys: table created
+• upstreams.use_srv_name: added (BOOLEAN)
+• routes.regex_priority: dropped
+kong/db/migrations/core/021_340_to_350.lua
+• no Postgres table or column changes found`))
+	})
+})
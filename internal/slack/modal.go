@@ -0,0 +1,388 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/kong/koko-slack-bot/internal/store"
+	"github.com/kong/koko-slack-bot/internal/tracker"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/socketmode"
+	"go.uber.org/zap"
+)
+
+const (
+	// actionTriageModal represents the action ID of the "Triage" button
+	actionTriageModal = "triage-modal"
+	// triageModalCallbackID represents the callback ID of the triage modal
+	triageModalCallbackID = "triage-modal"
+	// componentsBlockID represents the block ID of the components input
+	componentsBlockID = "components"
+	// releaseBlockID represents the block ID of the target release input
+	releaseBlockID = "release"
+	// severityBlockID represents the block ID of the severity input
+	severityBlockID = "severity"
+	// notesBlockID represents the block ID of the notes input
+	notesBlockID = "notes"
+	// inputActionID represents the action ID of the element of each input
+	inputActionID = "input"
+)
+
+// kokoComponents represents the Koko components that can be affected by a
+// schema change along with the label shown in the triage modal.
+var kokoComponents = []struct {
+	value string
+	label string
+}{
+	{value: "admin-api", label: "Admin API"},
+	{value: "plugin-schemas", label: "Plugin schemas"},
+	{value: "entity-schemas", label: "Entity schemas"},
+	{value: "dp-compat", label: "Data plane compatibility"},
+	{value: "persistence", label: "Persistence"},
+	{value: "wrpc", label: "wRPC"},
+	{value: "ui", label: "UI"},
+}
+
+// severities represents the severities that can override the severity of a
+// schema change.
+var severities = []store.Severity{
+	store.SeverityLow,
+	store.SeverityMedium,
+	store.SeverityHigh,
+	store.SeverityCritical,
+}
+
+// releasePattern represents the pattern of a Koko release.
+var releasePattern = regexp.MustCompile(`^v?\d+\.\d+(\.\d+)?$`)
+
+// triageModalMetadata represents the private metadata of the triage modal.
+type triageModalMetadata struct {
+	// Key represents the store key of the schema change
+	Key string `json:"key"`
+	// Channel represents the channel of the schema change reply
+	Channel string `json:"channel"`
+	// Timestamp represents the timestamp of the schema change reply
+	Timestamp string `json:"timestamp"`
+}
+
+// triageModalView will create the triage modal for a schema change; any
+// previous assessment is used as the initial values.
+func triageModalView(sc store.SchemaChange, metadata string) slack.ModalViewRequest {
+	var assessment store.Assessment
+	if sc.Assessment != nil {
+		assessment = *sc.Assessment
+	}
+
+	// Affected Koko components
+	componentOptions := make([]*slack.OptionBlockObject, 0, len(kokoComponents))
+	var initialComponents []*slack.OptionBlockObject
+	for _, component := range kokoComponents {
+		option := slack.NewOptionBlockObject(component.value, plainText(component.label), nil)
+		componentOptions = append(componentOptions, option)
+		for _, selected := range assessment.Components {
+			if selected == component.value {
+				initialComponents = append(initialComponents, option)
+			}
+		}
+	}
+	components := slack.NewOptionsMultiSelectBlockElement(slack.MultiOptTypeStatic,
+		plainText("Select components"), inputActionID, componentOptions...)
+	components.InitialOptions = initialComponents
+
+	// Target Koko release
+	release := slack.NewPlainTextInputBlockElement(plainText("e.g. 1.2.0"), inputActionID)
+	release.InitialValue = assessment.TargetRelease
+
+	// Severity override
+	severityOptions := make([]*slack.OptionBlockObject, 0, len(severities))
+	var initialSeverity *slack.OptionBlockObject
+	for _, severity := range severities {
+		option := slack.NewOptionBlockObject(string(severity), plainText(string(severity)), nil)
+		severityOptions = append(severityOptions, option)
+		if severity == assessment.Severity {
+			initialSeverity = option
+		}
+	}
+	severity := slack.NewOptionsSelectBlockElement(slack.OptTypeStatic, plainText("Select severity"),
+		inputActionID, severityOptions...)
+	severity.InitialOption = initialSeverity
+	severityInput := slack.NewInputBlock(severityBlockID, plainText("Severity override"), nil, severity)
+	severityInput.Optional = true
+
+	// Notes
+	notes := slack.NewPlainTextInputBlockElement(plainText("Compatibility notes, migration concerns, ..."),
+		inputActionID)
+	notes.Multiline = true
+	notes.InitialValue = assessment.Notes
+	notesInput := slack.NewInputBlock(notesBlockID, plainText("Notes"), nil, notes)
+	notesInput.Optional = true
+
+	return slack.ModalViewRequest{
+		Type:            slack.VTModal,
+		CallbackID:      triageModalCallbackID,
+		PrivateMetadata: metadata,
+		Title:           plainText("Triage schema change"),
+		Submit:          plainText("Save"),
+		Close:           plainText("Cancel"),
+		Blocks: slack.Blocks{
+			BlockSet: []slack.Block{
+				slack.NewSectionBlock(markdownText(fmt.Sprintf("*<%s|%s>*\n%s", sc.URL, sc.Key(), sc.Title)),
+					nil, nil),
+				slack.NewInputBlock(componentsBlockID, plainText("Affected Koko components"), nil, components),
+				slack.NewInputBlock(releaseBlockID, plainText("Target Koko release"), nil, release),
+				severityInput,
+				notesInput,
+			},
+		},
+	}
+}
+
+// parseTriageModal will validate the submitted state of the triage modal and
+// convert it into an assessment; validation errors are keyed by block ID.
+func parseTriageModal(state *slack.ViewState) (store.Assessment, map[string]string) {
+	var assessment store.Assessment
+	validationErrors := make(map[string]string)
+	if state == nil {
+		state = &slack.ViewState{}
+	}
+
+	for _, option := range state.Values[componentsBlockID][inputActionID].SelectedOptions {
+		assessment.Components = append(assessment.Components, option.Value)
+	}
+	if len(assessment.Components) == 0 {
+		validationErrors[componentsBlockID] = "Select at least one affected component"
+	}
+
+	assessment.TargetRelease = strings.TrimSpace(state.Values[releaseBlockID][inputActionID].Value)
+	if !releasePattern.MatchString(assessment.TargetRelease) {
+		validationErrors[releaseBlockID] = "Enter a release version such as 1.2 or 1.2.0"
+	}
+
+	severity := store.Severity(state.Values[severityBlockID][inputActionID].SelectedOption.Value)
+	if len(severity) > 0 {
+		valid := false
+		for _, s := range severities {
+			valid = valid || s == severity
+		}
+		if !valid {
+			validationErrors[severityBlockID] = fmt.Sprintf("Unknown severity %s", severity)
+		}
+		assessment.Severity = severity
+	}
+
+	assessment.Notes = strings.TrimSpace(state.Values[notesBlockID][inputActionID].Value)
+	if len(validationErrors) > 0 {
+		return store.Assessment{}, validationErrors
+	}
+	return assessment, nil
+}
+
+// assessmentText will get the human readable text of an assessment.
+func assessmentText(assessment store.Assessment) string {
+	labels := make([]string, 0, len(assessment.Components))
+	for _, value := range assessment.Components {
+		label := value
+		for _, component := range kokoComponents {
+			if component.value == value {
+				label = component.label
+			}
+		}
+		labels = append(labels, label)
+	}
+	text := fmt.Sprintf("Components: %s; target Koko release: %s", strings.Join(labels, ", "),
+		assessment.TargetRelease)
+	if len(assessment.Severity) > 0 {
+		text += fmt.Sprintf("; severity: %s", assessment.Severity)
+	}
+	return text
+}
+
+// handleTriageModalAction will open the triage modal from a schema change
+// reply.
+func (s *Slack) handleTriageModalAction(e *socketmode.Event, c *socketmode.Client) {
+	// Ensure this is the correct event
+	callback, ok := e.Data.(slack.InteractionCallback)
+	if !ok {
+		s.logger.Error("event ignored as it is not an interaction callback", zap.Any("event", e))
+		return
+	}
+	c.Ack(*e.Request)
+	if len(callback.ActionCallback.BlockActions) == 0 {
+		s.logger.Debug("event ignored as it does not contain a block action", zap.Any("event", e))
+		return
+	}
+	key := callback.ActionCallback.BlockActions[0].Value
+	gsc, err := parsePullRequestReference(key)
+	if err != nil {
+		s.logger.Error("unable to parse schema change of triage modal", zap.Error(err))
+		return
+	}
+	sc, ok := s.store.SchemaChange(gsc.key())
+	if !ok {
+		setSchemaChangeReference(&sc, gsc)
+	}
+
+	metadata, err := json.Marshal(triageModalMetadata{
+		Key:       gsc.key(),
+		Channel:   callback.Channel.ID,
+		Timestamp: callback.Message.Timestamp,
+	})
+	if err != nil {
+		s.logger.Error("unable to encode triage modal metadata", zap.Error(err))
+		return
+	}
+	if _, err := s.client.OpenView(callback.TriggerID, triageModalView(sc, string(metadata))); err != nil {
+		s.logger.Error("unable to open triage modal", zap.Error(err))
+	}
+}
+
+// handleViewSubmission will process the submission of modals.
+func (s *Slack) handleViewSubmission(e *socketmode.Event, c *socketmode.Client) {
+	// Ensure this is the correct event
+	callback, ok := e.Data.(slack.InteractionCallback)
+	if !ok {
+		s.logger.Error("event ignored as it is not an interaction callback", zap.Any("event", e))
+		return
+	}
+
+	switch callback.View.CallbackID {
+	case triageModalCallbackID:
+		assessment, validationErrors := parseTriageModal(callback.View.State)
+		if validationErrors != nil {
+			c.Ack(*e.Request, slack.NewErrorsViewSubmissionResponse(validationErrors))
+			return
+		}
+		c.Ack(*e.Request)
+		if err := s.assess(callback.View.PrivateMetadata, assessment, callback.User.ID); err != nil {
+			s.logger.Error("unable to store triage assessment", zap.Error(err))
+		}
+	case trackModalCallbackID:
+		references, validationErrors := parseTrackModal(callback.View.State)
+		if validationErrors != nil {
+			c.Ack(*e.Request, slack.NewErrorsViewSubmissionResponse(validationErrors))
+			return
+		}
+		c.Ack(*e.Request)
+		go s.trackSchemaChanges(references, "", "", callback.User.ID)
+	default:
+		c.Ack(*e.Request)
+		s.logger.Debug("view submission ignored", zap.String("callback-id", callback.View.CallbackID))
+	}
+}
+
+// assess will persist the assessment of a schema change, push it to the
+// ticket of the schema change, and update the schema change reply.
+func (s *Slack) assess(metadata string, assessment store.Assessment, user string) error {
+	var modalMetadata triageModalMetadata
+	if err := json.Unmarshal([]byte(metadata), &modalMetadata); err != nil {
+		return fmt.Errorf("unable to decode triage modal metadata: %w", err)
+	}
+	gsc, err := parsePullRequestReference(modalMetadata.Key)
+	if err != nil {
+		return err
+	}
+
+	assessment.User = user
+	assessment.Timestamp = time.Now().UTC()
+	sc, err := s.store.UpdateSchemaChange(gsc.key(), func(sc *store.SchemaChange) {
+		setSchemaChangeReference(sc, gsc)
+		sc.Assessment = &assessment
+	})
+	if err != nil {
+		return fmt.Errorf("unable to store assessment: %w", err)
+	}
+
+	if err := s.syncAssessment(sc); err != nil {
+		s.logger.Error("unable to synchronize assessment with ticket", zap.String("schema-change", sc.Key()),
+			zap.Error(err))
+	}
+	if err := s.syncPullRequestComment(gsc); err != nil {
+		s.logger.Error("unable to synchronize pull request comment", zap.String("schema-change", sc.Key()),
+			zap.Error(err))
+	}
+	s.refreshHome()
+
+	// Modals opened from the App Home update the stored reply
+	channel, timestamp := modalMetadata.Channel, modalMetadata.Timestamp
+	if len(timestamp) == 0 {
+		channel, timestamp = sc.Channel, sc.Timestamp
+	}
+	if len(timestamp) > 0 {
+		if err := s.updateReply(channel, timestamp, sc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncAssessment will push the assessment of a schema change to its ticket;
+// nothing is done when the schema change has no ticket.
+func (s *Slack) syncAssessment(sc store.SchemaChange) error {
+	if s.tracker == nil || sc.Ticket == nil || sc.Assessment == nil {
+		return nil
+	}
+
+	labels := make([]string, 0, len(sc.Assessment.Components)+1)
+	for _, component := range sc.Assessment.Components {
+		labels = append(labels, "koko-"+component)
+	}
+	if len(sc.Assessment.Severity) > 0 {
+		labels = append(labels, "severity-"+string(sc.Assessment.Severity))
+	}
+	if err := s.tracker.Update(sc.Ticket.Key, tracker.Update{Labels: labels}); err != nil {
+		return fmt.Errorf("unable to label ticket: %w", err)
+	}
+
+	comment := fmt.Sprintf("Triage assessment: %s", assessmentText(*sc.Assessment))
+	if len(sc.Assessment.Notes) > 0 {
+		comment += "\n\n" + sc.Assessment.Notes
+	}
+	if err := s.tracker.Comment(sc.Ticket.Key, comment); err != nil {
+		return fmt.Errorf("unable to comment on ticket: %w", err)
+	}
+	return nil
+}
+
+// updateReply will update the triage status of a schema change reply.
+func (s *Slack) updateReply(channel string, timestamp string, sc store.SchemaChange) error {
+	messages, _, _, err := s.client.GetConversationReplies(&slack.GetConversationRepliesParameters{
+		ChannelID: channel,
+		Timestamp: timestamp,
+		Latest:    timestamp,
+		Oldest:    timestamp,
+		Inclusive: true,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to get schema change reply: %w", err)
+	}
+	for _, message := range messages {
+		if message.Timestamp != timestamp {
+			continue
+		}
+		_, _, _, err := s.client.UpdateMessage(channel, timestamp,
+			slack.MsgOptionBlocks(withTriageStatus(message.Blocks.BlockSet, sc)...))
+		if err != nil {
+			return fmt.Errorf("unable to update schema change reply: %w", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("schema change reply %s not found", timestamp)
+}
@@ -0,0 +1,112 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"github.com/kong/koko-slack-bot/internal/store"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/slack-go/slack"
+)
+
+// triageModalState will create the submitted state of the triage modal.
+func triageModalState(components []string, release string, severity string, notes string) *slack.ViewState {
+	selectedComponents := make([]slack.OptionBlockObject, 0, len(components))
+	for _, component := range components {
+		selectedComponents = append(selectedComponents, slack.OptionBlockObject{Value: component})
+	}
+	return &slack.ViewState{
+		Values: map[string]map[string]slack.BlockAction{
+			componentsBlockID: {inputActionID: {SelectedOptions: selectedComponents}},
+			releaseBlockID:    {inputActionID: {Value: release}},
+			severityBlockID:   {inputActionID: {SelectedOption: slack.OptionBlockObject{Value: severity}}},
+			notesBlockID:      {inputActionID: {Value: notes}},
+		},
+	}
+}
+
+var _ = Describe("triage modal", Label("triage-modal"), func() {
+	Describe("submitting the triage modal", func() {
+		When("the submission is valid", func() {
+			It("the assessment will be parsed", func() {
+				assessment, validationErrors := parseTriageModal(triageModalState(
+					[]string{"plugin-schemas", "dp-compat"}, " 1.2.0 ", "high", "needs a compat shim"))
+				Expect(validationErrors).To(BeNil())
+				Expect(assessment).Should(Equal(store.Assessment{
+					Components:    []string{"plugin-schemas", "dp-compat"},
+					TargetRelease: "1.2.0",
+					Severity:      store.SeverityHigh,
+					Notes:         "needs a compat shim",
+				}))
+				Expect(assessmentText(assessment)).Should(Equal(
+					"Components: Plugin schemas, Data plane compatibility; target Koko release: 1.2.0; severity: high"))
+			})
+
+			It("the severity and notes are optional", func() {
+				assessment, validationErrors := parseTriageModal(triageModalState(
+					[]string{"admin-api"}, "1.3", "", ""))
+				Expect(validationErrors).To(BeNil())
+				Expect(assessment.Severity).To(BeEmpty())
+				Expect(assessment.Notes).To(BeEmpty())
+			})
+		})
+
+		When("the submission is invalid", func() {
+			It("the validation errors will be keyed by block", func() {
+				_, validationErrors := parseTriageModal(triageModalState(nil, "next", "urgent", ""))
+				Expect(validationErrors).Should(HaveKey(componentsBlockID))
+				Expect(validationErrors).Should(HaveKey(releaseBlockID))
+				Expect(validationErrors).Should(HaveKeyWithValue(severityBlockID, "Unknown severity urgent"))
+			})
+
+			It("a missing state will fail validation", func() {
+				_, validationErrors := parseTriageModal(nil)
+				Expect(validationErrors).To(HaveLen(2))
+			})
+		})
+	})
+
+	Describe("opening the triage modal", func() {
+		It("a previous assessment will be used as the initial values", func() {
+			view := triageModalView(store.SchemaChange{
+				Organization: "kong",
+				Repository:   "kong",
+				PullRequest:  11234,
+				Assessment: &store.Assessment{
+					Components:    []string{"wrpc"},
+					TargetRelease: "1.2.0",
+					Severity:      store.SeverityLow,
+				},
+			}, "metadata")
+			Expect(view.CallbackID).Should(Equal(triageModalCallbackID))
+			Expect(view.PrivateMetadata).Should(Equal("metadata"))
+
+			components, ok := view.Blocks.BlockSet[1].(*slack.InputBlock)
+			Expect(ok).To(BeTrue())
+			multiSelect, ok := components.Element.(*slack.MultiSelectBlockElement)
+			Expect(ok).To(BeTrue())
+			Expect(multiSelect.InitialOptions).To(HaveLen(1))
+			Expect(multiSelect.InitialOptions[0].Value).Should(Equal("wrpc"))
+
+			severity, ok := view.Blocks.BlockSet[3].(*slack.InputBlock)
+			Expect(ok).To(BeTrue())
+			Expect(severity.Optional).To(BeTrue())
+			singleSelect, ok := severity.Element.(*slack.SelectBlockElement)
+			Expect(ok).To(BeTrue())
+			Expect(singleSelect.InitialOption.Value).Should(Equal("low"))
+		})
+	})
+})
@@ -0,0 +1,79 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kong/koko-slack-bot/internal/gateway"
+)
+
+// bundledPluginsChange represents the changes to the gateway bundled plugins
+// that Koko has to mirror.
+type bundledPluginsChange struct {
+	// added represents the plugins added to the bundled plugins
+	added []string
+	// removed represents the plugins removed from the bundled plugins
+	removed []string
+	// analysisError represents the error that occurred analyzing the changes
+	analysisError string
+}
+
+// analyzeBundledPlugins will diff the bundled plugins of the base and head
+// revisions of constants.lua modified by a gateway schema change.
+func (s *Slack) analyzeBundledPlugins(gsc gatewaySchemaChange) (*bundledPluginsChange, error) {
+	base, err := s.gatewayFileContent(gsc, gateway.ConstantsPath, gsc.baseRevision())
+	if err != nil {
+		return nil, err
+	}
+	head, err := s.gatewayFileContent(gsc, gateway.ConstantsPath, gsc.details.HeadSHA)
+	if err != nil {
+		return nil, err
+	}
+	basePlugins, err := gateway.ParseBundledPlugins(base)
+	if err != nil {
+		return nil, fmt.Errorf("base revision: %w", err)
+	}
+	headPlugins, err := gateway.ParseBundledPlugins(head)
+	if err != nil {
+		return nil, fmt.Errorf("head revision: %w", err)
+	}
+	added, removed := gateway.DiffBundledPlugins(basePlugins, headPlugins)
+	return &bundledPluginsChange{
+		added:   added,
+		removed: removed,
+	}, nil
+}
+
+// bundledPluginsText will create the text listing the plugins Koko has to add
+// or remove.
+func bundledPluginsText(change *bundledPluginsChange) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Bundled plugins changes (%s):", gateway.ConstantsPath)
+	if len(change.analysisError) > 0 {
+		fmt.Fprintf(&sb, "\n⚠ unable to analyze the bundled plugins changes: %s", change.analysisError)
+	} else if len(change.added) == 0 && len(change.removed) == 0 {
+		sb.WriteString("\nNo bundled plugins added or removed")
+	}
+	for _, plugin := range change.added {
+		fmt.Fprintf(&sb, "\n• added %s; Koko must add its schema", plugin)
+	}
+	for _, plugin := range change.removed {
+		fmt.Fprintf(&sb, "\n• removed %s; Koko must remove its schema", plugin)
+	}
+	return sb.String()
+}
@@ -0,0 +1,103 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"github.com/kong/koko-slack-bot/internal/github"
+	"github.com/kong/koko-slack-bot/internal/templates"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/slack-go/slack"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("bundled plugins", Label("plugins"), func() {
+	gsc := gatewaySchemaChange{
+		details: github.PullRequest{
+			Author: "gateway-engineer",
+			Title:  "feat(ai-proxy): add plugin",
+			URL:    "https://github.com/kong/kong/pull/11234",
+		},
+		organization: "kong",
+		pullRequest:  11234,
+		repository:   "kong",
+	}
+
+	var ticketTemplates *templates.Templates
+
+	BeforeEach(func() {
+		var err error
+		ticketTemplates, err = templates.New(templates.Options{Logger: zap.NewNop()})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	When("the pull request removes bundled plugins", func() {
+		It("a high priority breaking change ticket will be created", func() {
+			removed := gsc
+			removed.bundledPlugins = &bundledPluginsChange{removed: []string{"acl"}}
+			Expect(removed.event()).Should(Equal(templates.EventBreakingChange))
+			ticket, err := newTicket(ticketTemplates, removed, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ticket.Title).Should(Equal("Breaking gateway schema change: feat(ai-proxy): add plugin (kong/kong#11234)"))
+			Expect(ticket.Labels).Should(Equal([]string{"gateway-schema-change", "gateway-breaking-change"}))
+			Expect(ticket.Priority).Should(Equal("High"))
+			Expect(ticket.Description).Should(ContainSubstring("Breaking changes:\n• bundled plugin removed: acl"))
+			Expect(ticket.Description).Should(ContainSubstring("• removed acl; Koko must remove its schema"))
+		})
+	})
+
+	When("the pull request does not change bundled plugins", func() {
+		It("a schema change ticket will be created", func() {
+			unchanged := gsc
+			unchanged.bundledPlugins = &bundledPluginsChange{}
+			Expect(unchanged.event()).Should(Equal(templates.EventSchemaChange))
+			ticket, err := newTicket(ticketTemplates, unchanged, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ticket.Title).Should(Equal("Gateway schema change: feat(ai-proxy): add plugin (kong/kong#11234)"))
+			Expect(ticket.Labels).Should(Equal([]string{"gateway-schema-change"}))
+			Expect(ticket.Priority).Should(BeEmpty())
+			Expect(ticket.Description).Should(ContainSubstring("No bundled plugins added or removed"))
+			Expect(ticket.Reference.Label).Should(Equal("gateway-pr-kong-kong-11234"))
+		})
+	})
+
+	When("the pull request adds bundled plugins", func() {
+		It("a high priority new plugin ticket will be created", func() {
+			added := gsc
+			added.bundledPlugins = &bundledPluginsChange{added: []string{"ai-proxy", "ai-prompt-guard"}}
+			Expect(added.event()).Should(Equal(templates.EventNewPlugin))
+			ticket, err := newTicket(ticketTemplates, added, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ticket.Title).Should(Equal("New bundled gateway plugin: ai-proxy, ai-prompt-guard (kong/kong#11234)"))
+			Expect(ticket.Labels).Should(Equal([]string{"gateway-schema-change", "gateway-new-plugin"}))
+			Expect(ticket.Priority).Should(Equal("High"))
+			Expect(ticket.Description).Should(ContainSubstring("New bundled gateway plugins: ai-proxy, ai-prompt-guard"))
+		})
+
+		It("the reply will be titled as a new plugin", func() {
+			added := gsc
+			added.bundledPlugins = &bundledPluginsChange{added: []string{"ai-proxy"}}
+			blocks := gatewaySchemaChangeBlocks(added)
+			Expect(blocks).To(HaveLen(4))
+			title, ok := blocks[0].(*slack.SectionBlock)
+			Expect(ok).To(BeTrue())
+			Expect(title.Text.Text).Should(HavePrefix(":new: *New bundled gateway plugin*"))
+			plugins, ok := blocks[2].(*slack.SectionBlock)
+			Expect(ok).To(BeTrue())
+			Expect(plugins.Text.Text).Should(ContainSubstring("• added ai-proxy; Koko must add its schema"))
+		})
+	})
+})
@@ -0,0 +1,245 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kong/koko-slack-bot/internal/github"
+	"github.com/kong/koko-slack-bot/internal/store"
+	"github.com/slack-go/slack"
+	"go.uber.org/zap"
+)
+
+// releaseRepository represents a gateway repository watched for releases.
+type releaseRepository struct {
+	// organization represents the GitHub organization/owner
+	organization string
+	// repository represents the GitHub repository
+	repository string
+}
+
+// recordReleaseInclusion will store the release tags and the release branches
+// containing the merge commit of a schema change, then update the schema
+// change reply.
+func (s *Slack) recordReleaseInclusion(sc store.SchemaChange) error {
+	if len(sc.MergeCommitSHA) == 0 {
+		return nil
+	}
+	inclusion, err := s.gitHubClient.ReleaseInclusion(sc.Organization, sc.Repository, sc.MergeCommitSHA,
+		sc.MergedAt)
+	if err != nil {
+		return fmt.Errorf("unable to find releases containing merge commit: %w", err)
+	}
+	sc, err = s.store.UpdateSchemaChange(sc.Key(), func(sc *store.SchemaChange) {
+		sc.Release = inclusion.Release()
+		sc.ReleaseBranches = inclusion.Branches
+	})
+	if err != nil {
+		return fmt.Errorf("unable to store release inclusion: %w", err)
+	}
+	if len(sc.Channel) > 0 && len(sc.Timestamp) > 0 {
+		if err := s.updateReply(sc.Channel, sc.Timestamp, sc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// watchReleases will periodically look for new gateway release tags in the
+// repositories of the merged schema changes.
+func (s *Slack) watchReleases() {
+	s.pollReleases()
+	ticker := time.NewTicker(s.releasePollInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.pollReleases()
+	}
+}
+
+// pollReleases will look for new release tags in the configured gateway
+// repositories and the repositories of the merged schema changes, then
+// announce the schema changes they ship; the releases of the configured
+// repositories are reported. The tags of a repository seen for the first time
+// are only recorded, and the release inclusion of its unreleased schema
+// changes is determined without announcement to catch up with the releases
+// made while the bot was down.
+func (s *Slack) pollReleases() {
+	repositories := make(map[string]releaseRepository, len(s.releaseRepositories))
+	for key, repository := range s.releaseRepositories {
+		repositories[key] = repository
+	}
+	unreleased := make(map[string][]store.SchemaChange)
+	for _, sc := range s.store.SchemaChanges() {
+		if sc.State != store.PullRequestMerged || len(sc.Release) > 0 {
+			continue
+		}
+		repository := releaseRepository{organization: sc.Organization, repository: sc.Repository}
+		repositories[repository.key()] = repository
+		unreleased[repository.key()] = append(unreleased[repository.key()], sc)
+	}
+
+	for key, repository := range repositories {
+		logger := s.logger.With(zap.String("repository", key))
+		schemaChanges := unreleased[key]
+		tags, err := s.gitHubClient.Tags(repository.organization, repository.repository)
+		if err != nil {
+			logger.Warn("unable to list release tags", zap.Error(err))
+			continue
+		}
+		known, ok := s.releaseTags[key]
+		s.releaseTags[key] = tagNames(tags)
+		if !ok {
+			for _, sc := range schemaChanges {
+				if err := s.recordReleaseInclusion(sc); err != nil {
+					logger.Warn("unable to record release inclusion", zap.String("schema-change", sc.Key()),
+						zap.Error(err))
+				}
+			}
+			continue
+		}
+
+		_, reported := s.releaseRepositories[key]
+		for _, release := range newReleases(tags, known) {
+			shipped := s.shipRelease(release, schemaChanges)
+			schemaChanges = unreleasedSchemaChanges(schemaChanges, shipped)
+			if reported {
+				if err := s.reportRelease(repository, previousRelease(tags, release), release, shipped); err != nil {
+					logger.Error("unable to report release", zap.String("release", release), zap.Error(err))
+				}
+			} else if len(shipped) > 0 && len(s.releaseChannel) > 0 {
+				_, _, err := s.client.PostMessage(s.releaseChannel, slack.MsgOptionBlocks(releaseBlocks(release, shipped)...))
+				if err != nil {
+					logger.Error("unable to announce release", zap.String("release", release), zap.Error(err))
+				}
+			}
+			if len(shipped) > 0 {
+				s.refreshHome()
+			}
+		}
+	}
+}
+
+// shipRelease will store a release on the unreleased schema changes whose
+// merge commit it contains; the shipped schema changes are returned.
+func (s *Slack) shipRelease(release string, schemaChanges []store.SchemaChange) []store.SchemaChange {
+	var shipped []store.SchemaChange
+	for _, sc := range schemaChanges {
+		logger := s.logger.With(zap.String("schema-change", sc.Key()), zap.String("release", release))
+		if len(sc.MergeCommitSHA) == 0 {
+			continue
+		}
+		contains, err := s.gitHubClient.Contains(sc.Organization, sc.Repository, release, sc.MergeCommitSHA)
+		if err != nil {
+			logger.Warn("unable to determine if release contains schema change", zap.Error(err))
+			continue
+		}
+		if !contains {
+			continue
+		}
+		updated, err := s.store.UpdateSchemaChange(sc.Key(), func(sc *store.SchemaChange) {
+			sc.Release = release
+		})
+		if err != nil {
+			logger.Error("unable to store release", zap.Error(err))
+			continue
+		}
+		shipped = append(shipped, updated)
+		if len(updated.Channel) > 0 && len(updated.Timestamp) > 0 {
+			if err := s.updateReply(updated.Channel, updated.Timestamp, updated); err != nil {
+				logger.Error("unable to update schema change reply with release", zap.Error(err))
+			}
+		}
+	}
+	return shipped
+}
+
+// key will get the key of the gateway repository.
+func (r releaseRepository) key() string {
+	return strings.ToLower(r.organization + "/" + r.repository)
+}
+
+// previousRelease will get the newest release older than a release; empty
+// when the release is the first one.
+func previousRelease(tags []github.Tag, release string) string {
+	current, ok := github.ParseReleaseVersion(release)
+	if !ok {
+		return ""
+	}
+	var previous github.ReleaseVersion
+	found := false
+	for _, tag := range tags {
+		version, ok := github.ParseReleaseVersion(tag.Name)
+		if !ok || !version.Less(current) {
+			continue
+		}
+		if !found || previous.Less(version) {
+			previous = version
+			found = true
+		}
+	}
+	return previous.Tag
+}
+
+// tagNames will get the set of the names of tags.
+func tagNames(tags []github.Tag) map[string]struct{} {
+	names := make(map[string]struct{}, len(tags))
+	for _, tag := range tags {
+		names[tag.Name] = struct{}{}
+	}
+	return names
+}
+
+// newReleases will get the release tags that are not known yet from the
+// oldest to the newest release.
+func newReleases(tags []github.Tag, known map[string]struct{}) []string {
+	var versions []github.ReleaseVersion
+	for _, tag := range tags {
+		if _, ok := known[tag.Name]; ok {
+			continue
+		}
+		if version, ok := github.ParseReleaseVersion(tag.Name); ok {
+			versions = append(versions, version)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Less(versions[j])
+	})
+	releases := make([]string, 0, len(versions))
+	for _, version := range versions {
+		releases = append(releases, version.Tag)
+	}
+	return releases
+}
+
+// unreleasedSchemaChanges will remove the shipped schema changes from the
+// unreleased schema changes.
+func unreleasedSchemaChanges(schemaChanges []store.SchemaChange, shipped []store.SchemaChange) []store.SchemaChange {
+	keys := make(map[string]struct{}, len(shipped))
+	for _, sc := range shipped {
+		keys[sc.Key()] = struct{}{}
+	}
+	var unreleased []store.SchemaChange
+	for _, sc := range schemaChanges {
+		if _, ok := keys[sc.Key()]; !ok {
+			unreleased = append(unreleased, sc)
+		}
+	}
+	return unreleased
+}
@@ -0,0 +1,134 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+
+	"github.com/kong/koko-slack-bot/internal/github"
+	"github.com/kong/koko-slack-bot/internal/store"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/slack-go/slack"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("releases", Label("releases"), func() {
+	var s *Slack
+	var st *store.Store
+	var server *httptest.Server
+	var tags []string
+
+	BeforeEach(func() {
+		logger, err := zap.NewDevelopment()
+		Expect(err).NotTo(HaveOccurred())
+		tags = []string{"3.4.0"}
+		containing := map[string]bool{"3.5.0": true, "3.5.1": true}
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/repos/kong/kong/tags", func(w http.ResponseWriter, r *http.Request) {
+			var names []string
+			for _, tag := range tags {
+				names = append(names, fmt.Sprintf(`{"name": %q}`, tag))
+			}
+			_, _ = w.Write([]byte("[" + strings.Join(names, ",") + "]"))
+		})
+		mux.HandleFunc("/repos/kong/kong/branches", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`[]`))
+		})
+		mux.HandleFunc("/repos/kong/kong/compare/", func(w http.ResponseWriter, r *http.Request) {
+			_, head, _ := strings.Cut(r.URL.Path, "...")
+			status := "behind"
+			if containing[head] {
+				status = "ahead"
+			}
+			_, _ = w.Write([]byte(fmt.Sprintf(`{"status": %q}`, status)))
+		})
+		server = httptest.NewServer(mux)
+		client, err := github.NewClient(github.Options{
+			Token:   "token",
+			BaseURL: server.URL,
+			Logger:  logger,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		st, err = store.NewStore(store.Options{
+			Path:   filepath.Join(GinkgoT().TempDir(), "store.json"),
+			Logger: logger,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = st.UpdateSchemaChange("kong/kong#11234", func(sc *store.SchemaChange) {
+			sc.Organization = "kong"
+			sc.Repository = "kong"
+			sc.PullRequest = 11234
+			sc.Title = "feat(rate-limiting): add sync rate"
+			sc.URL = "https://github.com/kong/kong/pull/11234"
+			sc.State = store.PullRequestMerged
+			sc.MergeCommitSHA = "abcdef0"
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		s, err = NewSlack(Options{
+			AppToken:     "xapp-",
+			BotToken:     "xoxb-",
+			Logger:       logger,
+			GitHubClient: client,
+			Store:        st,
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("the first release shipping a schema change will be stored once its tag appears", func() {
+		s.pollReleases()
+		sc, _ := st.SchemaChange("kong/kong#11234")
+		Expect(sc.Release).Should(BeEmpty())
+
+		tags = append(tags, "3.5.1", "3.5.0", "3.5.0-rc.1")
+		s.pollReleases()
+		sc, _ = st.SchemaChange("kong/kong#11234")
+		Expect(sc.Release).Should(Equal("3.5.0"))
+	})
+
+	It("the release inclusion will be caught up when the tags of a repository are first seen", func() {
+		tags = append(tags, "3.5.0")
+		s.pollReleases()
+		sc, _ := st.SchemaChange("kong/kong#11234")
+		Expect(sc.Release).Should(Equal("3.5.0"))
+	})
+
+	It("new releases will be ordered from the oldest to the newest", func() {
+		Expect(newReleases([]github.Tag{{Name: "3.5.1"}, {Name: "3.4.0"}, {Name: "3.5.0"}, {Name: "next"}},
+			map[string]struct{}{"3.4.0": {}})).Should(Equal([]string{"3.5.0", "3.5.1"}))
+	})
+
+	It("the shipped schema changes will be announced", func() {
+		sc, _ := st.SchemaChange("kong/kong#11234")
+		blocks := releaseBlocks("3.5.0", []store.SchemaChange{sc})
+		Expect(blocks).To(HaveLen(1))
+		section, ok := blocks[0].(*slack.SectionBlock)
+		Expect(ok).To(BeTrue())
+		Expect(section.Text.Text).Should(Equal(":package: *1 gateway schema change(s) shipped in 3.5.0:*\n" +
+			"• <https://github.com/kong/kong/pull/11234|kong/kong#11234> feat(rate-limiting): add sync rate"))
+	})
+})
@@ -0,0 +1,250 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kong/koko-slack-bot/internal/github"
+	"github.com/kong/koko-slack-bot/internal/schema"
+	"github.com/kong/koko-slack-bot/internal/store"
+	"github.com/slack-go/slack"
+)
+
+// releaseReport represents the schema changes between two gateway releases.
+type releaseReport struct {
+	// repository represents the gateway repository of the releases
+	repository releaseRepository
+	// previous represents the tag of the previous release
+	previous string
+	// release represents the tag of the new release
+	release string
+	// schemas represents the plugin and entity schemas that changed ordered
+	// by kind and name
+	schemas []schemaDiff
+	// shipped represents the tracked schema changes shipped in the release
+	shipped []store.SchemaChange
+}
+
+// schemaDiff represents the changes of a gateway schema between two
+// releases.
+type schemaDiff struct {
+	// kind represents the kind of the schema
+	kind schema.Kind
+	// name represents the name of the plugin or entity
+	name string
+	// path represents the path of the schema file
+	path string
+	// added represents whether the schema is new in the release
+	added bool
+	// removed represents whether the schema was removed in the release
+	removed bool
+	// changes represents the fields that changed
+	changes []schema.Change
+	// analysisError represents the error that occurred while diffing the
+	// schema; empty on success
+	analysisError string
+}
+
+// reportRelease will diff the plugin and entity schemas of a new release
+// against the previous release and post the report to the release channel as
+// a summary with a thread holding the shipped schema changes and the detailed
+// report file.
+func (s *Slack) reportRelease(repository releaseRepository, previous string, release string,
+	shipped []store.SchemaChange,
+) error {
+	if len(s.releaseChannel) == 0 || len(previous) == 0 {
+		return nil
+	}
+	report, err := s.buildReleaseReport(repository, previous, release)
+	if err != nil {
+		return err
+	}
+	report.shipped = shipped
+
+	channel, timestamp, err := s.client.PostMessage(s.releaseChannel, slack.MsgOptionBlocks(releaseSummaryBlocks(report)...))
+	if err != nil {
+		return fmt.Errorf("unable to post release report: %w", err)
+	}
+	if len(shipped) > 0 {
+		_, _, err := s.client.PostMessage(channel, slack.MsgOptionBlocks(releaseBlocks(release, shipped)...),
+			slack.MsgOptionTS(timestamp))
+		if err != nil {
+			return fmt.Errorf("unable to post shipped schema changes: %w", err)
+		}
+	}
+	_, err = s.client.UploadFile(slack.FileUploadParameters{
+		Content:         releaseReportMarkdown(report),
+		Filename:        fmt.Sprintf("gateway-%s-schema-changes.md", release),
+		Filetype:        "markdown",
+		Title:           fmt.Sprintf("Gateway schema changes from %s to %s", previous, release),
+		Channels:        []string{channel},
+		ThreadTimestamp: timestamp,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to upload release report: %w", err)
+	}
+	return nil
+}
+
+// buildReleaseReport will diff the plugin and entity schemas modified between
+// two releases; schemas that fail to parse are reported with their error. The
+// trees of the releases are compared as a release modifies more files than a
+// comparison of commits lists.
+func (s *Slack) buildReleaseReport(repository releaseRepository, previous string, release string) (releaseReport, error) {
+	files, err := s.gitHubClient.CompareTrees(repository.organization, repository.repository, previous, release)
+	if err != nil {
+		return releaseReport{}, fmt.Errorf("unable to get files modified by release: %w", err)
+	}
+	report := releaseReport{
+		repository: repository,
+		previous:   previous,
+		release:    release,
+	}
+	for _, file := range files {
+		kind, name, ok := schema.Classify(file.Filename)
+		if !ok {
+			continue
+		}
+		diff := s.diffSchema(repository, file.Filename, previous, release)
+		diff.kind = kind
+		diff.name = name
+		if len(diff.analysisError) > 0 || diff.added || diff.removed || len(diff.changes) > 0 {
+			report.schemas = append(report.schemas, diff)
+		}
+	}
+	sort.Slice(report.schemas, func(i, j int) bool {
+		if report.schemas[i].kind != report.schemas[j].kind {
+			return report.schemas[i].kind == schema.KindPlugin
+		}
+		return report.schemas[i].name < report.schemas[j].name
+	})
+	return report, nil
+}
+
+// diffSchema will diff a schema file between two refs; a file that does not
+// exist at a ref has no fields.
+func (s *Slack) diffSchema(repository releaseRepository, path string, base string, head string) schemaDiff {
+	diff := schemaDiff{path: path}
+	var revisions [2]schema.Schema
+	for i, ref := range []string{base, head} {
+		content, err := s.gitHubClient.FileContent(repository.organization, repository.repository, path, ref)
+		if errors.Is(err, github.ErrNotFound) {
+			diff.added = diff.added || i == 0
+			diff.removed = diff.removed || i == 1
+			continue
+		}
+		if err != nil {
+			diff.analysisError = err.Error()
+			return diff
+		}
+		revisions[i], err = schema.Parse(content)
+		if err != nil {
+			diff.analysisError = err.Error()
+			return diff
+		}
+	}
+	diff.changes = schema.Diff(revisions[0], revisions[1])
+	return diff
+}
+
+// releaseURL will get the URL of the GitHub release of a tag.
+func releaseURL(repository releaseRepository, release string) string {
+	return fmt.Sprintf("https://github.com/%s/%s/releases/tag/%s", repository.organization, repository.repository,
+		release)
+}
+
+// releaseSummaryBlocks will create the summary blocks of a release report.
+func releaseSummaryBlocks(report releaseReport) []slack.Block {
+	counts := make(map[schema.Kind]int)
+	var names []string
+	for _, diff := range report.schemas {
+		counts[diff.kind]++
+		name := fmt.Sprintf("`%s`", diff.name)
+		switch {
+		case diff.added:
+			name += " (new)"
+		case diff.removed:
+			name += " (removed)"
+		}
+		names = append(names, name)
+	}
+
+	text := fmt.Sprintf(":rocket: *Gateway <%s|%s> released* (since %s)", releaseURL(report.repository, report.release),
+		report.release, report.previous)
+	if len(names) > 0 {
+		text += "\nSchemas changed: " + strings.Join(names, ", ")
+	}
+	return []slack.Block{
+		slack.NewSectionBlock(markdownText(text), nil, nil),
+		slack.NewContextBlock("",
+			markdownText(fmt.Sprintf("%d plugin schema(s) changed", counts[schema.KindPlugin])),
+			markdownText(fmt.Sprintf("%d entity schema(s) changed", counts[schema.KindEntity])),
+			markdownText(fmt.Sprintf("%d tracked schema change(s) shipped", len(report.shipped))),
+		),
+	}
+}
+
+// releaseReportMarkdown will create the detailed markdown report of a
+// release.
+func releaseReportMarkdown(report releaseReport) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Gateway schema changes from %s to %s\n", report.previous, report.release)
+	if len(report.schemas) == 0 {
+		sb.WriteString("\nNo plugin or entity schema changed.\n")
+	}
+	for _, section := range []struct {
+		kind  schema.Kind
+		title string
+	}{
+		{kind: schema.KindPlugin, title: "Plugins"},
+		{kind: schema.KindEntity, title: "Entities"},
+	} {
+		written := false
+		for _, diff := range report.schemas {
+			if diff.kind != section.kind {
+				continue
+			}
+			if !written {
+				fmt.Fprintf(&sb, "\n## %s\n", section.title)
+				written = true
+			}
+			fmt.Fprintf(&sb, "\n### %s (`%s`)\n\n", diff.name, diff.path)
+			switch {
+			case len(diff.analysisError) > 0:
+				fmt.Fprintf(&sb, "Unable to diff the schema: %s\n", diff.analysisError)
+				continue
+			case diff.added:
+				sb.WriteString("New schema.\n\n")
+			case diff.removed:
+				sb.WriteString("Removed schema.\n\n")
+			}
+			for _, change := range diff.changes {
+				fmt.Fprintf(&sb, "- %s\n", change)
+			}
+		}
+	}
+	if len(report.shipped) > 0 {
+		sb.WriteString("\n## Tracked schema changes\n\n")
+		for _, sc := range report.shipped {
+			fmt.Fprintf(&sb, "- [%s](%s) %s\n", sc.Key(), sc.URL, sc.Title)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,147 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/kong/koko-slack-bot/internal/github"
+	"github.com/kong/koko-slack-bot/internal/store"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/slack-go/slack"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("release report", Label("release-report"), func() {
+	var s *Slack
+	var server *httptest.Server
+	repository := releaseRepository{organization: "kong", repository: "kong"}
+
+	BeforeEach(func() {
+		logger, err := zap.NewDevelopment()
+		Expect(err).NotTo(HaveOccurred())
+		mux := http.NewServeMux()
+		mux.HandleFunc("/repos/kong/kong/git/trees/3.4.2", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"truncated": false, "tree": [
+				{"path": "kong/plugins/rate-limiting/schema.lua", "type": "blob", "sha": "a1"},
+				{"path": "kong/plugins/rate-limiting/handler.lua", "type": "blob", "sha": "a2"},
+				{"path": "kong/plugins/acme/schema.lua", "type": "blob", "sha": "a3"},
+				{"path": "kong/plugins/session/schema.lua", "type": "blob", "sha": "a4"}
+			]}`))
+		})
+		mux.HandleFunc("/repos/kong/kong/git/trees/3.5.0", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"truncated": false, "tree": [
+				{"path": "kong/plugins/rate-limiting/schema.lua", "type": "blob", "sha": "b1"},
+				{"path": "kong/plugins/rate-limiting/handler.lua", "type": "blob", "sha": "b2"},
+				{"path": "kong/plugins/acme/schema.lua", "type": "blob", "sha": "b3"},
+				{"path": "kong/plugins/session/schema.lua", "type": "blob", "sha": "a4"},
+				{"path": "kong/db/schema/entities/vaults.lua", "type": "blob", "sha": "b5"}
+			]}`))
+		})
+		mux.HandleFunc("/repos/kong/kong/contents/kong/plugins/rate-limiting/schema.lua",
+			func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Query().Get("ref") == "3.4.2" {
+					serveContent(w, `return { fields = { { config = { type = "record", fields = {} } } } }`)
+					return
+				}
+				serveContent(w, `return { fields = { { config = { type = "record", fields = {
+					{ sync_rate = { type = "number", default = -1 } } } } } } }`)
+			})
+		mux.HandleFunc("/repos/kong/kong/contents/kong/plugins/acme/schema.lua",
+			func(w http.ResponseWriter, r *http.Request) {
+				// Only comments changed
+				serveContent(w, `return { fields = {} }`)
+			})
+		mux.HandleFunc("/repos/kong/kong/contents/kong/db/schema/entities/vaults.lua",
+			func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Query().Get("ref") == "3.4.2" {
+					w.WriteHeader(http.StatusNotFound)
+					_, _ = w.Write([]byte(`{"message": "Not Found"}`))
+					return
+				}
+				serveContent(w, `return { name = "vaults", fields = { { prefix = { type = "string", required = true } } } }`)
+			})
+		server = httptest.NewServer(mux)
+		client, err := github.NewClient(github.Options{
+			Token:   "token",
+			BaseURL: server.URL,
+			Logger:  logger,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		s, err = NewSlack(Options{
+			AppToken:     "xapp-",
+			BotToken:     "xoxb-",
+			Logger:       logger,
+			GitHubClient: client,
+			Store:        &store.Store{},
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("the previous release will be the newest older release", func() {
+		tags := []github.Tag{{Name: "3.5.0"}, {Name: "3.4.2"}, {Name: "3.4.10"}, {Name: "3.3.0"}, {Name: "next"}}
+		Expect(previousRelease(tags, "3.5.0")).Should(Equal("3.4.10"))
+		Expect(previousRelease(tags, "3.3.0")).Should(BeEmpty())
+	})
+
+	It("the changed plugin and entity schemas will be reported in detail", func() {
+		report, err := s.buildReleaseReport(repository, "3.4.2", "3.5.0")
+		Expect(err).NotTo(HaveOccurred())
+		report.shipped = []store.SchemaChange{{
+			Organization: "kong",
+			Repository:   "kong",
+			PullRequest:  11234,
+			Title:        "feat(rate-limiting): add sync rate",
+			URL:          "https://github.com/kong/kong/pull/11234",
+		}}
+		Expect(releaseReportMarkdown(report)).Should(Equal(`# Gateway schema changes from 3.4.2 to 3.5.0
+
+## Plugins
+
+### rate-limiting (` + "`kong/plugins/rate-limiting/schema.lua`" + `)
+
+- added config.sync_rate (number, default -1)
+
+## Entities
+
+### vaults (` + "`kong/db/schema/entities/vaults.lua`" + `)
+
+New schema.
+
+- added prefix (string, required)
+
+## Tracked schema changes
+
+- [kong/kong#11234](https://github.com/kong/kong/pull/11234) feat(rate-limiting): add sync rate
+`))
+
+		blocks := releaseSummaryBlocks(report)
+		Expect(blocks).To(HaveLen(2))
+		section, ok := blocks[0].(*slack.SectionBlock)
+		Expect(ok).To(BeTrue())
+		Expect(section.Text.Text).Should(Equal(":rocket: *Gateway <https://github.com/kong/kong/releases/tag/3.5.0|3.5.0> " +
+			"released* (since 3.4.2)\nSchemas changed: `rate-limiting`, `vaults` (new)"))
+		context, ok := blocks[1].(*slack.ContextBlock)
+		Expect(ok).To(BeTrue())
+		Expect(context.ContextElements.Elements).To(HaveLen(3))
+	})
+})
@@ -0,0 +1,184 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/kong/koko-slack-bot/internal/store"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/socketmode"
+	"go.uber.org/zap"
+)
+
+const (
+	// trackShortcutCallbackID represents the callback ID of the "Track as Koko
+	// schema change" message and global shortcuts
+	trackShortcutCallbackID = "track-schema-change"
+	// trackModalCallbackID represents the callback ID of the modal opened by
+	// the global shortcut
+	trackModalCallbackID = "track-schema-change-modal"
+	// pullRequestsBlockID represents the block ID of the pull requests input
+	pullRequestsBlockID = "pull-requests"
+)
+
+// findPullRequestReferences will find all the GitHub pull request links in a
+// message text; duplicate links are only returned once.
+func findPullRequestReferences(text string) []gatewaySchemaChange {
+	var references []gatewaySchemaChange
+	seen := make(map[string]struct{})
+	for _, matches := range pullRequestURLPattern.FindAllStringSubmatch(text, -1) {
+		pullRequest, err := strconv.Atoi(matches[3])
+		if err != nil {
+			continue
+		}
+		reference := gatewaySchemaChange{
+			organization: matches[1],
+			pullRequest:  pullRequest,
+			repository:   matches[2],
+		}
+		if _, ok := seen[reference.key()]; ok {
+			continue
+		}
+		seen[reference.key()] = struct{}{}
+		references = append(references, reference)
+	}
+	return references
+}
+
+// trackModalView will create the modal of the global shortcut asking for the
+// pull requests to track.
+func trackModalView() slack.ModalViewRequest {
+	pullRequests := slack.NewPlainTextInputBlockElement(
+		plainText("https://github.com/kong/kong/pull/11234"), inputActionID)
+	pullRequests.Multiline = true
+	return slack.ModalViewRequest{
+		Type:       slack.VTModal,
+		CallbackID: trackModalCallbackID,
+		Title:      plainText("Track schema change"),
+		Submit:     plainText("Track"),
+		Close:      plainText("Cancel"),
+		Blocks: slack.Blocks{
+			BlockSet: []slack.Block{
+				slack.NewInputBlock(pullRequestsBlockID, plainText("GitHub pull request links"), nil, pullRequests),
+			},
+		},
+	}
+}
+
+// handleShortcut will process the "Track as Koko schema change" shortcuts; the
+// message shortcut tracks the pull requests linked in the message while the
+// global shortcut asks for the pull requests to track.
+func (s *Slack) handleShortcut(e *socketmode.Event, c *socketmode.Client) {
+	// Ensure this is the correct event
+	callback, ok := e.Data.(slack.InteractionCallback)
+	if !ok {
+		s.logger.Error("event ignored as it is not an interaction callback", zap.Any("event", e))
+		return
+	}
+	c.Ack(*e.Request)
+	if callback.CallbackID != trackShortcutCallbackID {
+		s.logger.Debug("shortcut ignored", zap.String("callback-id", callback.CallbackID))
+		return
+	}
+
+	switch callback.Type {
+	case slack.InteractionTypeMessageAction:
+		references := findPullRequestReferences(callback.Message.Text)
+		if len(references) == 0 {
+			s.notifyUser(callback.Channel.ID, callback.User.ID,
+				":warning: No GitHub pull request links found in the message")
+			return
+		}
+		threadTimestamp := callback.Message.ThreadTimestamp
+		if len(threadTimestamp) == 0 {
+			threadTimestamp = callback.Message.Timestamp
+		}
+		// Tracking processes the pull requests, which would otherwise block the
+		// event loop
+		go s.trackSchemaChanges(references, callback.Channel.ID, threadTimestamp, callback.User.ID)
+	case slack.InteractionTypeShortcut:
+		if _, err := s.client.OpenView(callback.TriggerID, trackModalView()); err != nil {
+			s.logger.Error("unable to open track schema change modal", zap.Error(err))
+		}
+	default:
+		s.logger.Debug("shortcut ignored", zap.String("type", string(callback.Type)))
+	}
+}
+
+// parseTrackModal will validate the submitted state of the track schema change
+// modal and extract the pull requests to track; validation errors are keyed by
+// block ID.
+func parseTrackModal(state *slack.ViewState) ([]gatewaySchemaChange, map[string]string) {
+	if state == nil {
+		state = &slack.ViewState{}
+	}
+	references := findPullRequestReferences(state.Values[pullRequestsBlockID][inputActionID].Value)
+	if len(references) == 0 {
+		return nil, map[string]string{
+			pullRequestsBlockID: "Enter at least one GitHub pull request link",
+		}
+	}
+	return references, nil
+}
+
+// trackSchemaChanges will process pull requests as gateway schema changes,
+// create their tickets, and reply with the schema changes; replies are sent as
+// direct messages to the user when there is no channel and are only stored for
+// the schema changes without a reply.
+func (s *Slack) trackSchemaChanges(references []gatewaySchemaChange, channel string, threadTimestamp string,
+	user string,
+) {
+	for _, gsc := range references {
+		logger := s.logger.With(zap.String("schema-change", gsc.key()))
+		err := s.processGatewaySchemaChange(&gsc)
+		s.health.recordEvent(err)
+		if err != nil {
+			logger.Error("unable to track schema change", zap.Error(err))
+			s.notifyUser(channel, user, fmt.Sprintf(":x: unable to track %s: %s", gsc.key(), err.Error()))
+			continue
+		}
+		if _, err := s.openTicket(gsc); err != nil {
+			logger.Error("unable to open ticket for tracked schema change", zap.Error(err))
+			s.notifyUser(channel, user, fmt.Sprintf(":x: unable to open ticket for %s: %s", gsc.key(), err.Error()))
+		}
+
+		options := []slack.MsgOption{slack.MsgOptionBlocks(s.gatewaySchemaChangeReply(gsc)...)}
+		replyChannel := channel
+		if len(replyChannel) == 0 {
+			replyChannel = user
+		} else {
+			options = append(options, slack.MsgOptionTS(threadTimestamp))
+		}
+		replyChannel, timestamp, err := s.client.PostMessage(replyChannel, options...)
+		if err != nil {
+			logger.Error("unable to reply to tracked schema change", zap.Error(err))
+			continue
+		}
+		// The reply in the schema change feed remains the reply to update
+		_, err = s.store.UpdateSchemaChange(gsc.key(), func(sc *store.SchemaChange) {
+			if len(sc.Timestamp) == 0 {
+				sc.Channel = replyChannel
+				sc.Timestamp = timestamp
+			}
+		})
+		if err != nil {
+			logger.Error("unable to store reply to tracked schema change", zap.Error(err))
+		}
+	}
+	s.refreshHome()
+}
@@ -0,0 +1,70 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/slack-go/slack"
+)
+
+var _ = Describe("shortcuts", Label("shortcuts"), func() {
+	Describe("finding pull request links in a message", func() {
+		It("all unique pull request links will be found", func() {
+			references := findPullRequestReferences("Heads up, <https://github.com/Kong/kong/pull/11234|this PR> " +
+				"changes the schema; see also https://github.com/kong/kong-ee/pull/5291/files and " +
+				"<https://github.com/kong/kong/pull/11234> plus kong/kong#1 and https://github.com/kong/kong/issues/2")
+			Expect(references).Should(Equal([]gatewaySchemaChange{
+				{
+					organization: "Kong",
+					pullRequest:  11234,
+					repository:   "kong",
+				},
+				{
+					organization: "kong",
+					pullRequest:  5291,
+					repository:   "kong-ee",
+				},
+			}))
+		})
+
+		It("no pull request will be found in a message without links", func() {
+			Expect(findPullRequestReferences("nothing to see here")).To(BeEmpty())
+		})
+	})
+
+	Describe("submitting the track schema change modal", func() {
+		It("the pull requests will be extracted", func() {
+			references, validationErrors := parseTrackModal(&slack.ViewState{
+				Values: map[string]map[string]slack.BlockAction{
+					pullRequestsBlockID: {inputActionID: {Value: "https://github.com/kong/kong/pull/11234"}},
+				},
+			})
+			Expect(validationErrors).To(BeNil())
+			Expect(references).To(HaveLen(1))
+		})
+
+		It("a submission without pull request links will fail validation", func() {
+			references, validationErrors := parseTrackModal(&slack.ViewState{
+				Values: map[string]map[string]slack.BlockAction{
+					pullRequestsBlockID: {inputActionID: {Value: "kong/kong#11234"}},
+				},
+			})
+			Expect(references).To(BeNil())
+			Expect(validationErrors).Should(HaveKey(pullRequestsBlockID))
+		})
+	})
+})
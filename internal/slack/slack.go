@@ -21,8 +21,14 @@ import (
 	"math"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/kong/koko-slack-bot/internal/gateway"
 	"github.com/kong/koko-slack-bot/internal/github"
+	"github.com/kong/koko-slack-bot/internal/store"
+	"github.com/kong/koko-slack-bot/internal/templates"
+	"github.com/kong/koko-slack-bot/internal/tracker"
 	"github.com/slack-go/slack"
 	"github.com/slack-go/slack/slackevents"
 	"github.com/slack-go/slack/socketmode"
@@ -39,8 +45,70 @@ type Options struct {
 	Debug bool
 	// GitHubClient represents the client for accessing GitHub's API
 	GitHubClient *github.Client
+	// Templates represents the templates of the tickets; required when ticket
+	// tracking is enabled
+	Templates *templates.Templates
+	// Tracker represents the issue tracker of the tickets; ticket tracking is
+	// disabled when not set
+	Tracker tracker.Tracker
+	// PullRequestPollInterval represents the interval at which the state of
+	// the pull requests of stored schema changes is refreshed; polling is
+	// disabled when zero
+	PullRequestPollInterval time.Duration
+	// CloseAbandonedTickets represents a toggling flag to close the tickets of
+	// pull requests closed without being merged as won't do
+	CloseAbandonedTickets bool
+	// ReleasePollInterval represents the interval at which new gateway release
+	// tags are looked for; release tracking is disabled when zero
+	ReleasePollInterval time.Duration
+	// ReleaseChannel represents the Slack channel the shipped schema changes
+	// of new gateway releases are announced in; announcements are disabled
+	// when empty
+	ReleaseChannel string
+	// ReleaseRepositories represents the gateway repositories whose releases
+	// are reported; e.g. kong/kong
+	ReleaseRepositories []string
+	// DriftPollInterval represents the interval at which the gateway plugin
+	// schemas are compared with the Koko plugin schemas; drift detection is
+	// disabled when zero
+	DriftPollInterval time.Duration
+	// DriftRef represents the gateway ref whose plugin schemas are compared;
+	// e.g. master
+	DriftRef string
+	// DriftChannel represents the Slack channel the drift is reported in;
+	// reports are disabled when empty
+	DriftChannel string
+	// DriftTickets represents a toggling flag to file a ticket for each
+	// drifting plugin
+	DriftTickets bool
+	// ImpactAnalysis represents a toggling flag to search the Koko repository
+	// for the plugins and fields affected by schema changes
+	ImpactAnalysis bool
+	// KokoRepository represents the Koko repository holding the plugin
+	// schemas and searched for impact; e.g. kong/koko
+	KokoRepository string
+	// KokoSchemasPath represents the directory of the Koko plugin JSON
+	// schemas, named after their plugin; e.g. rate-limiting.json
+	KokoSchemasPath string
+	// CompatWorkflow represents the file name of the Koko workflow dispatched
+	// for breaking and behavior-changing schema changes; e.g. compat.yaml. The
+	// workflow must include its correlation_id input in its run-name for its
+	// runs to be followed; dispatching is disabled when empty
+	CompatWorkflow string
+	// CompatWorkflowRef represents the Koko ref the compatibility workflow is
+	// dispatched on; e.g. main
+	CompatWorkflowRef string
+	// WorkflowPollInterval represents the interval at which the status of the
+	// dispatched workflow runs is refreshed; results are not reported when
+	// zero
+	WorkflowPollInterval time.Duration
+	// PullRequestComments represents a toggling flag to maintain a tracking
+	// comment on the pull requests of schema changes
+	PullRequestComments bool
 	// Logger represents the base logger to use for the Slack package
 	Logger *zap.Logger
+	// Store represents the store persisting the state of schema changes
+	Store *store.Store
 }
 
 // Slack represents a slack instance.
@@ -55,11 +123,103 @@ type Slack struct {
 	handler *socketmode.SocketmodeHandler
 	// logger represents the logger to use for the Slack package
 	logger *zap.Logger
+	// store represents the store persisting the state of schema changes
+	store *store.Store
+	// templates represents the templates of the tickets
+	templates *templates.Templates
+	// tracker represents the issue tracker of the tickets
+	tracker tracker.Tracker
+	// pullRequestPollInterval represents the interval at which the state of
+	// the pull requests of stored schema changes is refreshed
+	pullRequestPollInterval time.Duration
+	// closeAbandonedTickets represents whether the tickets of pull requests
+	// closed without being merged are closed
+	closeAbandonedTickets bool
+	// releasePollInterval represents the interval at which new gateway release
+	// tags are looked for
+	releasePollInterval time.Duration
+	// releaseChannel represents the Slack channel new gateway releases are
+	// announced in
+	releaseChannel string
+	// releaseRepositories represents the gateway repositories whose releases
+	// are reported keyed by repository
+	releaseRepositories map[string]releaseRepository
+	// releaseTags represents the known tags keyed by repository; only
+	// accessed by the release watcher
+	releaseTags map[string]map[string]struct{}
+	// driftPollInterval represents the interval at which the gateway plugin
+	// schemas are compared with the Koko plugin schemas
+	driftPollInterval time.Duration
+	// driftRef represents the gateway ref whose plugin schemas are compared
+	driftRef string
+	// driftChannel represents the Slack channel the drift is reported in
+	driftChannel string
+	// driftTickets represents whether a ticket is filed for each drifting
+	// plugin
+	driftTickets bool
+	// driftReported represents the last reported drift; only accessed by the
+	// drift watcher
+	driftReported string
+	// impactAnalysis represents whether the Koko repository is searched for
+	// the plugins and fields affected by schema changes
+	impactAnalysis bool
+	// kokoOrganization represents the GitHub organization of Koko
+	kokoOrganization string
+	// kokoRepository represents the GitHub repository of Koko
+	kokoRepository string
+	// kokoSchemasPath represents the directory of the Koko plugin schemas
+	kokoSchemasPath string
+	// compatWorkflow represents the file name of the Koko compatibility
+	// workflow
+	compatWorkflow string
+	// compatWorkflowRef represents the Koko ref the compatibility workflow is
+	// dispatched on
+	compatWorkflowRef string
+	// workflowPollInterval represents the interval at which the status of the
+	// dispatched workflow runs is refreshed
+	workflowPollInterval time.Duration
+	// pullRequestComments represents whether a tracking comment is maintained
+	// on the pull requests of schema changes
+	pullRequestComments bool
+	// health represents the health of the bot
+	health *health
+	// homeMutex represents the lock guarding the App Home users
+	homeMutex sync.Mutex
+	// homeUsers represents the users who have opened the App Home
+	homeUsers map[string]struct{}
 }
 
 // gatewaySchemaChange represents the response from the processing of the
 // gateway schema change event.
 type gatewaySchemaChange struct {
+	// bundledPlugins represents the changes to the gateway bundled plugins;
+	// nil when the pull request does not modify them
+	bundledPlugins *bundledPluginsChange
+	// changelog represents the changelog entries of the pull request
+	changelog []github.ChangelogEntry
+	// commitMessages represents the full messages of the commits of the pull
+	// request
+	commitMessages []string
+	// compat represents the changes to the gateway compatibility files; nil
+	// when the pull request does not modify them
+	compat *compatChange
+	// description represents the structured description of the pull request
+	description github.Description
+	// details represents the details of the pull request associated with the
+	// schema change event
+	details github.PullRequest
+	// files represents the files modified by the pull request
+	files []github.File
+	// mergeBase represents the SHA of the commit the changes of the pull
+	// request are based on; empty when unknown
+	mergeBase string
+	// impact represents the Koko references of the plugins and fields
+	// affected by the pull request; nil when impact analysis is disabled or
+	// the pull request does not modify a plugin schema
+	impact *impactChange
+	// migrations represents the new database migrations; nil when the pull
+	// request does not add any
+	migrations *migrationsChange
 	// organization represents the GitHub organization/owner
 	organization string
 	// pullRequest represents the GitHub pull request number associated with the
@@ -100,6 +260,33 @@ func NewSlack(opts Options) (*Slack, error) {
 	if opts.GitHubClient == nil {
 		return nil, errors.New("client for GitHub is not set")
 	}
+	if opts.Store == nil {
+		return nil, errors.New("store is not set")
+	}
+	if opts.Tracker != nil && opts.Templates == nil {
+		return nil, errors.New("ticket templates are not set")
+	}
+	releaseRepositories := make(map[string]releaseRepository, len(opts.ReleaseRepositories))
+	for _, reference := range opts.ReleaseRepositories {
+		organization, repository, ok := strings.Cut(reference, "/")
+		if !ok || len(organization) == 0 || len(repository) == 0 || strings.Contains(repository, "/") {
+			return nil, fmt.Errorf("invalid release repository %q", reference)
+		}
+		watched := releaseRepository{organization: organization, repository: repository}
+		releaseRepositories[watched.key()] = watched
+	}
+	kokoOrganization, kokoRepository, _ := strings.Cut(opts.KokoRepository, "/")
+	if opts.DriftPollInterval > 0 || opts.ImpactAnalysis || len(opts.CompatWorkflow) > 0 {
+		if len(kokoOrganization) == 0 || len(kokoRepository) == 0 || strings.Contains(kokoRepository, "/") {
+			return nil, fmt.Errorf("invalid Koko repository %q", opts.KokoRepository)
+		}
+	}
+	if opts.DriftPollInterval > 0 && len(opts.DriftRef) == 0 {
+		return nil, errors.New("drift ref is not set")
+	}
+	if len(opts.CompatWorkflow) > 0 && len(opts.CompatWorkflowRef) == 0 {
+		return nil, errors.New("compatibility workflow ref is not set")
+	}
 	if opts.Logger == nil {
 		return nil, errors.New("logger is not set")
 	}
@@ -123,10 +310,33 @@ func NewSlack(opts Options) (*Slack, error) {
 	)
 
 	return &Slack{
-		client:       client,
-		gitHubClient: opts.GitHubClient,
-		handler:      socketmode.NewSocketmodeHandler(socketClient),
-		logger:       logger,
+		client:                  client,
+		gitHubClient:            opts.GitHubClient,
+		handler:                 socketmode.NewSocketmodeHandler(socketClient),
+		templates:               opts.Templates,
+		tracker:                 opts.Tracker,
+		logger:                  logger,
+		store:                   opts.Store,
+		health:                  &health{},
+		homeUsers:               make(map[string]struct{}),
+		pullRequestPollInterval: opts.PullRequestPollInterval,
+		closeAbandonedTickets:   opts.CloseAbandonedTickets,
+		releasePollInterval:     opts.ReleasePollInterval,
+		releaseChannel:          opts.ReleaseChannel,
+		releaseRepositories:     releaseRepositories,
+		releaseTags:             make(map[string]map[string]struct{}),
+		driftPollInterval:       opts.DriftPollInterval,
+		driftRef:                opts.DriftRef,
+		driftChannel:            opts.DriftChannel,
+		driftTickets:            opts.DriftTickets,
+		impactAnalysis:          opts.ImpactAnalysis,
+		kokoOrganization:        kokoOrganization,
+		kokoRepository:          kokoRepository,
+		kokoSchemasPath:         opts.KokoSchemasPath,
+		compatWorkflow:          opts.CompatWorkflow,
+		compatWorkflowRef:       opts.CompatWorkflowRef,
+		workflowPollInterval:    opts.WorkflowPollInterval,
+		pullRequestComments:     opts.PullRequestComments,
 	}, nil
 }
 
@@ -138,6 +348,7 @@ func (s *Slack) Run() error {
 		return fmt.Errorf("unable to determine bot ID: %w", err)
 	}
 	s.botID = res.BotID
+	s.health.startedAt = time.Now().UTC()
 
 	// Handle basic socketmode events
 	s.handler.Handle(socketmode.EventTypeConnecting, func(e *socketmode.Event, c *socketmode.Client) {
@@ -156,6 +367,44 @@ func (s *Slack) Run() error {
 	// Handle message events from channels, DMs, and groups
 	s.handler.HandleEvents(slackevents.Message, s.handleMessageEvent)
 
+	// Handle conversational commands from application mentions and the slash
+	// command
+	s.handler.HandleEvents(slackevents.AppMention, s.handleAppMentionEvent)
+	s.handler.HandleSlashCommand(slashCommandName, s.handleSlashCommand)
+
+	// Handle the App Home dashboard
+	s.handler.HandleEvents(slackevents.AppHomeOpened, s.handleAppHomeOpenedEvent)
+
+	// Handle the triage buttons of the schema change replies
+	for _, actionID := range []string{
+		actionTriageAssign,
+		actionTriageNotRelevant,
+		actionTriageNeedsCompat,
+		actionTriageOpenTicket,
+	} {
+		s.handler.HandleInteractionBlockAction(actionID, s.handleTriageAction)
+	}
+	s.handler.HandleInteractionBlockAction(actionTriageModal, s.handleTriageModalAction)
+	s.handler.HandleInteraction(slack.InteractionTypeViewSubmission, s.handleViewSubmission)
+
+	// Handle the shortcuts tracking pull requests as schema changes
+	s.handler.HandleInteraction(slack.InteractionTypeMessageAction, s.handleShortcut)
+	s.handler.HandleInteraction(slack.InteractionTypeShortcut, s.handleShortcut)
+
+	// Refresh the state of the pull requests of stored schema changes
+	if s.pullRequestPollInterval > 0 {
+		go s.watchPullRequests()
+	}
+	if s.releasePollInterval > 0 {
+		go s.watchReleases()
+	}
+	if s.driftPollInterval > 0 && (len(s.driftChannel) > 0 || s.driftTickets) {
+		go s.watchDrift()
+	}
+	if len(s.compatWorkflow) > 0 && s.workflowPollInterval > 0 {
+		go s.watchWorkflowRuns()
+	}
+
 	// Start handling Slack events
 	err = s.handler.RunEventLoop()
 	if err != nil {
@@ -225,23 +474,56 @@ func (s *Slack) handleMessageEvent(e *socketmode.Event, c *socketmode.Client) {
 func (s *Slack) handleBotMessage(messageEvent *slackevents.MessageEvent, channelName string) error {
 	switch channelName {
 	case "gateway-schema-change-feed":
-		gsc, err := s.handleGatewaySchemaChangeEvent(messageEvent)
-		if err != nil {
-			return fmt.Errorf("unable to handle gateway schema change event: %w", err)
-		}
-
-		// Get the pull request description for the change
-		// TODO(fero): add description variable to use with next steps; Jira integration
-		_, err = s.gitHubClient.PRDescription(gsc.organization, gsc.repository, gsc.pullRequest)
-		if err != nil {
-			return fmt.Errorf("unable to get PR description for gateway schema change event: %w", err)
-		}
+		err := s.handleGatewaySchemaChangeFeed(messageEvent)
+		s.health.recordEvent(err)
+		s.refreshHome()
+		return err
 	default:
 		s.logger.Debug("bot message received", zap.String("bot-id", messageEvent.BotID), zap.String("channel", channelName))
 	}
 	return nil
 }
 
+// handleGatewaySchemaChangeFeed will process a message from the gateway schema
+// change feed and reply to it in its thread.
+func (s *Slack) handleGatewaySchemaChangeFeed(messageEvent *slackevents.MessageEvent) error {
+	gsc, err := s.handleGatewaySchemaChangeEvent(messageEvent)
+	if err != nil {
+		return fmt.Errorf("unable to handle gateway schema change event: %w", err)
+	}
+	if err := s.processGatewaySchemaChange(&gsc); err != nil {
+		return fmt.Errorf("unable to process gateway schema change event: %w", err)
+	}
+
+	// Reply to the schema change event in its thread
+	channel, timestamp, err := s.client.PostMessage(messageEvent.Channel,
+		slack.MsgOptionBlocks(s.gatewaySchemaChangeReply(gsc)...),
+		slack.MsgOptionTS(messageEvent.TimeStamp))
+	if err != nil {
+		return fmt.Errorf("unable to reply to gateway schema change event: %w", err)
+	}
+	_, err = s.store.UpdateSchemaChange(gsc.key(), func(sc *store.SchemaChange) {
+		sc.Channel = channel
+		sc.Timestamp = timestamp
+	})
+	if err != nil {
+		return fmt.Errorf("unable to store reply to gateway schema change event: %w", err)
+	}
+
+	if err := s.syncPullRequestComment(gsc); err != nil {
+		s.logger.Error("unable to synchronize pull request comment", zap.String("schema-change", gsc.key()),
+			zap.Error(err))
+	}
+
+	// Check breaking and behavior-changing schema changes against Koko
+	if len(s.compatWorkflow) > 0 && needsCompatWorkflow(gsc) {
+		if err := s.dispatchCompatWorkflow(gsc, channel, messageEvent.TimeStamp); err != nil {
+			return fmt.Errorf("unable to dispatch Koko compatibility workflow: %w", err)
+		}
+	}
+	return nil
+}
+
 // handleGatewaySchemaChangeEvent will process all Kong Gateway schema change
 // events that occur from #gateway-schema-change-feed on Slack.
 func (s *Slack) handleGatewaySchemaChangeEvent(messageEvent *slackevents.MessageEvent) (gatewaySchemaChange, error) {
@@ -315,3 +597,166 @@ func (s *Slack) handleGatewaySchemaChangeEvent(messageEvent *slackevents.Message
 		repository:   repository,
 	}, nil
 }
+
+// key will get the store key of the gateway schema change.
+func (gsc gatewaySchemaChange) key() string {
+	return store.Key(gsc.organization, gsc.repository, gsc.pullRequest)
+}
+
+// baseRevision will get the revision the head of the pull request is diffed
+// against; the merge base when known, otherwise the base branch commit which
+// may include changes made to the base branch after the pull request was
+// opened.
+func (gsc gatewaySchemaChange) baseRevision() string {
+	if len(gsc.mergeBase) > 0 {
+		return gsc.mergeBase
+	}
+	return gsc.details.BaseSHA
+}
+
+// processGatewaySchemaChange will enrich a gateway schema change with the
+// details of its pull request and store it.
+func (s *Slack) processGatewaySchemaChange(gsc *gatewaySchemaChange) error {
+	if err := s.analyzeGatewaySchemaChange(gsc); err != nil {
+		return err
+	}
+
+	previous, _ := s.store.SchemaChange(gsc.key())
+	sc, err := s.store.UpdateSchemaChange(gsc.key(), func(sc *store.SchemaChange) {
+		setSchemaChangeReference(sc, *gsc)
+	})
+	if err != nil {
+		return fmt.Errorf("unable to store gateway schema change: %w", err)
+	}
+	if err := s.applyPullRequestState(previous.State, sc); err != nil {
+		s.logger.Warn("unable to apply pull request state", zap.String("schema-change", gsc.key()), zap.Error(err))
+	}
+	if err := s.linkRevert(*gsc); err != nil {
+		s.logger.Warn("unable to link reverted schema change", zap.String("schema-change", gsc.key()),
+			zap.Error(err))
+	}
+	if err := s.correlateSchemaChange(*gsc); err != nil {
+		s.logger.Warn("unable to correlate schema change", zap.String("schema-change", gsc.key()), zap.Error(err))
+	}
+	return nil
+}
+
+// analyzeGatewaySchemaChange will enrich a gateway schema change with the
+// details of its pull request and the analyses of its changes without storing
+// it.
+func (s *Slack) analyzeGatewaySchemaChange(gsc *gatewaySchemaChange) error {
+	details, err := s.gitHubClient.PullRequest(gsc.organization, gsc.repository, gsc.pullRequest)
+	if err != nil {
+		return fmt.Errorf("unable to get pull request for gateway schema change: %w", err)
+	}
+	gsc.details = details
+	gsc.description = github.ParseDescription(details.Description, gsc.organization, gsc.repository)
+	files, err := s.gitHubClient.PullRequestFiles(gsc.organization, gsc.repository, gsc.pullRequest)
+	if err != nil {
+		return fmt.Errorf("unable to get pull request files for gateway schema change: %w", err)
+	}
+	gsc.files = files
+	mergeBase, err := s.gitHubClient.MergeBase(gsc.organization, gsc.repository, details.BaseSHA, details.HeadSHA)
+	if err != nil {
+		s.logger.Warn("unable to find merge base", zap.String("schema-change", gsc.key()), zap.Error(err))
+	}
+	gsc.mergeBase = mergeBase
+	messages, err := s.gitHubClient.PullRequestCommitMessages(gsc.organization, gsc.repository, gsc.pullRequest)
+	if err != nil {
+		s.logger.Warn("unable to get pull request commits", zap.String("schema-change", gsc.key()), zap.Error(err))
+	}
+	gsc.commitMessages = messages
+
+	gsc.changelog = s.gitHubClient.ChangelogEntries(gsc.organization, gsc.repository, details, files)
+
+	if touchesCompat(files) {
+		change, err := s.analyzeCompat(*gsc)
+		if err != nil {
+			s.logger.Warn("unable to analyze compatibility changes", zap.String("schema-change", gsc.key()),
+				zap.Error(err))
+			change = &compatChange{analysisError: err.Error()}
+		}
+		gsc.compat = change
+	}
+	if touchesFile(files, gateway.ConstantsPath) {
+		change, err := s.analyzeBundledPlugins(*gsc)
+		if err != nil {
+			s.logger.Warn("unable to analyze bundled plugins changes", zap.String("schema-change", gsc.key()),
+				zap.Error(err))
+			change = &bundledPluginsChange{analysisError: err.Error()}
+		}
+		gsc.bundledPlugins = change
+	}
+	if hasNewMigrations(*gsc) {
+		change, err := s.analyzeMigrations(*gsc)
+		if err != nil {
+			s.logger.Warn("unable to analyze database migrations", zap.String("schema-change", gsc.key()),
+				zap.Error(err))
+			change = &migrationsChange{analysisError: err.Error()}
+		}
+		gsc.migrations = change
+	}
+	if s.impactAnalysis && touchesPluginSchema(files) {
+		change, err := s.analyzeImpact(*gsc)
+		if err != nil {
+			s.logger.Warn("unable to analyze Koko impact", zap.String("schema-change", gsc.key()), zap.Error(err))
+			change = &impactChange{
+				repository:    s.kokoOrganization + "/" + s.kokoRepository,
+				analysisError: err.Error(),
+			}
+		}
+		gsc.impact = change
+	}
+	return nil
+}
+
+// gatewayFileContent will get the content of a file of a gateway schema change
+// repository at a ref; a file that does not exist at the ref has no content.
+func (s *Slack) gatewayFileContent(gsc gatewaySchemaChange, path string, ref string) (string, error) {
+	content, err := s.gitHubClient.FileContent(gsc.organization, gsc.repository, path, ref)
+	if errors.Is(err, github.ErrNotFound) {
+		return "", nil
+	}
+	return content, err
+}
+
+// touchesFile will determine if a pull request modified a file.
+func touchesFile(files []github.File, path string) bool {
+	for _, file := range files {
+		if file.Filename == path || file.PreviousFilename == path {
+			return true
+		}
+	}
+	return false
+}
+
+// setSchemaChangeReference will set the pull request reference and details of
+// a gateway schema change on its stored state.
+func setSchemaChangeReference(sc *store.SchemaChange, gsc gatewaySchemaChange) {
+	sc.Organization = gsc.organization
+	sc.Repository = gsc.repository
+	sc.PullRequest = gsc.pullRequest
+	if len(gsc.details.URL) > 0 {
+		sc.Title = gsc.details.Title
+		sc.URL = gsc.details.URL
+		sc.Author = gsc.details.Author
+		sc.BaseRef = gsc.details.BaseRef
+		setPullRequestState(sc, gsc.details)
+	}
+	if patchID := github.PatchID(gsc.files); len(patchID) > 0 {
+		sc.PatchID = patchID
+	}
+	if gsc.bundledPlugins != nil {
+		sc.NewPlugins = gsc.bundledPlugins.added
+	}
+}
+
+// gatewaySchemaChangeReply will create the blocks of the reply to a gateway
+// schema change including its current triage status.
+func (s *Slack) gatewaySchemaChangeReply(gsc gatewaySchemaChange) []slack.Block {
+	blocks := gatewaySchemaChangeBlocks(gsc)
+	if sc, ok := s.store.SchemaChange(gsc.key()); ok {
+		blocks = withTriageStatus(blocks, sc)
+	}
+	return blocks
+}
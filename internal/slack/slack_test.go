@@ -19,6 +19,8 @@ import (
 	"encoding/json"
 
 	"github.com/kong/koko-slack-bot/internal/github"
+	"github.com/kong/koko-slack-bot/internal/store"
+	"github.com/kong/koko-slack-bot/internal/tracker"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/slack-go/slack"
@@ -43,6 +45,7 @@ var _ = Describe("slack", func() {
 					BotToken:     "xoxb-",
 					Logger:       logger,
 					GitHubClient: &github.Client{},
+					Store:        &store.Store{},
 				})
 				Expect(err).NotTo(HaveOccurred())
 				Expect(s).NotTo(BeNil())
@@ -105,12 +108,58 @@ var _ = Describe("slack", func() {
 				})
 			})
 
+			Context("and the store is missing", func() {
+				It("a new slack instance will not be instantiated", func() {
+					s, err := NewSlack(Options{
+						AppToken:     "xapp-",
+						BotToken:     "xoxb-",
+						GitHubClient: &github.Client{},
+					})
+					Expect(err).To(HaveOccurred())
+					Expect(err).Should(MatchError("store is not set"))
+					Expect(s).To(BeNil())
+				})
+			})
+
+			Context("and the ticket templates are missing", func() {
+				It("a new slack instance will not be instantiated", func() {
+					s, err := NewSlack(Options{
+						AppToken:     "xapp-",
+						BotToken:     "xoxb-",
+						GitHubClient: &github.Client{},
+						Store:        &store.Store{},
+						Tracker:      &tracker.GitHub{},
+						Logger:       logger,
+					})
+					Expect(err).To(HaveOccurred())
+					Expect(err).Should(MatchError("ticket templates are not set"))
+					Expect(s).To(BeNil())
+				})
+			})
+
+			Context("and a release repository is invalid", func() {
+				It("a new slack instance will not be instantiated", func() {
+					s, err := NewSlack(Options{
+						AppToken:            "xapp-",
+						BotToken:            "xoxb-",
+						GitHubClient:        &github.Client{},
+						Store:               &store.Store{},
+						ReleaseRepositories: []string{"kong"},
+						Logger:              logger,
+					})
+					Expect(err).To(HaveOccurred())
+					Expect(err).Should(MatchError(`invalid release repository "kong"`))
+					Expect(s).To(BeNil())
+				})
+			})
+
 			Context("and the logger is missing", func() {
 				It("a new slack instance will not be instantiated", func() {
 					s, err := NewSlack(Options{
 						AppToken:     "xapp-",
 						BotToken:     "xoxb-",
 						GitHubClient: &github.Client{},
+						Store:        &store.Store{},
 					})
 					Expect(err).To(HaveOccurred())
 					Expect(err).Should(MatchError("logger is not set"))
@@ -130,6 +179,7 @@ var _ = Describe("slack", func() {
 				BotToken:     "xoxb-",
 				Logger:       logger,
 				GitHubClient: &github.Client{},
+				Store:        &store.Store{},
 			})
 			Expect(err).NotTo(HaveOccurred())
 			Expect(s).NotTo(BeNil())
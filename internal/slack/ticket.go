@@ -0,0 +1,154 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kong/koko-slack-bot/internal/compat"
+	"github.com/kong/koko-slack-bot/internal/gateway"
+	"github.com/kong/koko-slack-bot/internal/github"
+	"github.com/kong/koko-slack-bot/internal/store"
+	"github.com/kong/koko-slack-bot/internal/templates"
+	"github.com/kong/koko-slack-bot/internal/tracker"
+)
+
+// breakingChangelogType represents the type of the changelog entries of
+// breaking changes.
+const breakingChangelogType = "breaking_change"
+
+// event will get the type of a gateway schema change event; new plugins take
+// precedence over breaking changes, which take precedence over compat only
+// changes.
+func (gsc gatewaySchemaChange) event() templates.Event {
+	switch {
+	case gsc.bundledPlugins != nil && len(gsc.bundledPlugins.added) > 0:
+		return templates.EventNewPlugin
+	case len(breakingChanges(gsc)) > 0:
+		return templates.EventBreakingChange
+	case isCompatOnly(gsc):
+		return templates.EventCompatOnly
+	}
+	return templates.EventSchemaChange
+}
+
+// breakingChanges will get why a gateway schema change breaks existing
+// configurations; e.g. a removed bundled plugin or a dropped column.
+func breakingChanges(gsc gatewaySchemaChange) []string {
+	var reasons []string
+	if gsc.bundledPlugins != nil {
+		for _, plugin := range gsc.bundledPlugins.removed {
+			reasons = append(reasons, fmt.Sprintf("bundled plugin removed: %s", plugin))
+		}
+	}
+	if gsc.migrations != nil {
+		for _, migration := range gsc.migrations.migrations {
+			for _, column := range migration.Columns {
+				switch column.Operation {
+				case gateway.ColumnDropped:
+					reasons = append(reasons, fmt.Sprintf("column dropped: %s.%s", column.Table, column.Column))
+				case gateway.ColumnRenamed:
+					reasons = append(reasons, fmt.Sprintf("column renamed: %s.%s to %s", column.Table, column.Column,
+						column.Definition))
+				case gateway.ColumnAdded, gateway.ColumnAltered:
+				}
+			}
+		}
+	}
+	for _, entry := range gsc.changelog {
+		if entry.Type == breakingChangelogType {
+			reasons = append(reasons, fmt.Sprintf("breaking changelog entry: %s", entry.Message))
+		}
+	}
+	return reasons
+}
+
+// isCompatOnly will determine if a gateway schema change only modifies the
+// compatibility files besides its changelog entries and tests.
+func isCompatOnly(gsc gatewaySchemaChange) bool {
+	if gsc.compat == nil || len(gsc.files) == 0 {
+		return false
+	}
+	for _, file := range gsc.files {
+		if !compat.IsCompatFile(file.Filename) && !github.IsChangelogFile(file.Filename) &&
+			!strings.HasPrefix(file.Filename, "spec/") {
+			return false
+		}
+	}
+	return true
+}
+
+// ticketSchemaChange will create the schema change given to the ticket
+// templates; the assessment is nil when the schema change was not assessed.
+func ticketSchemaChange(gsc gatewaySchemaChange, assessment *store.Assessment) templates.SchemaChange {
+	sc := templates.SchemaChange{
+		Event:        gsc.event(),
+		Key:          fmt.Sprintf("%s/%s#%d", gsc.organization, gsc.repository, gsc.pullRequest),
+		Organization: gsc.organization,
+		Repository:   gsc.repository,
+		PullRequest:  gsc.details,
+		Description:  gsc.description,
+		Changelog:    gsc.changelog,
+		Breaking:     breakingChanges(gsc),
+		Sections: templates.Sections{
+			Description: descriptionText(gsc.description),
+		},
+	}
+	if len(gsc.changelog) > 0 {
+		sc.Sections.Changelog = changelogText(gsc.changelog)
+	}
+	if gsc.compat != nil {
+		sc.FieldChanges = gsc.compat.removedFields
+		sc.Sections.Compat = compatText(gsc.compat, 0)
+	}
+	if gsc.bundledPlugins != nil {
+		sc.BundledPlugins = templates.BundledPlugins{
+			Added:   gsc.bundledPlugins.added,
+			Removed: gsc.bundledPlugins.removed,
+		}
+		sc.Sections.BundledPlugins = bundledPluginsText(gsc.bundledPlugins)
+	}
+	if gsc.migrations != nil {
+		sc.Migrations = gsc.migrations.migrations
+		sc.Sections.Migrations = migrationsText(gsc.migrations)
+	}
+	if assessment != nil {
+		sc.Severity = string(assessment.Severity)
+		sc.Components = assessment.Components
+	}
+	return sc
+}
+
+// newTicket will create the ticket for a schema change using the template of
+// its event.
+func newTicket(t *templates.Templates, gsc gatewaySchemaChange, assessment *store.Assessment) (tracker.NewTicket,
+	error,
+) {
+	ticket, err := t.Render(ticketSchemaChange(gsc, assessment))
+	if err != nil {
+		return tracker.NewTicket{}, fmt.Errorf("unable to render ticket: %w", err)
+	}
+	return tracker.NewTicket{
+		Title:       ticket.Title,
+		Description: ticket.Body,
+		Labels:      ticket.Labels,
+		Priority:    ticket.Priority,
+		Reference:   pullRequestReference(gsc),
+		Components:  ticket.Components,
+		Fields:      ticket.Fields,
+	}, nil
+}
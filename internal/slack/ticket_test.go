@@ -0,0 +1,84 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"github.com/kong/koko-slack-bot/internal/gateway"
+	"github.com/kong/koko-slack-bot/internal/github"
+	"github.com/kong/koko-slack-bot/internal/store"
+	"github.com/kong/koko-slack-bot/internal/templates"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ticket", Label("ticket"), func() {
+	gsc := gatewaySchemaChange{
+		details: github.PullRequest{
+			Author: "gateway-engineer",
+			Title:  "fix(clustering): compat for sync_rate",
+			URL:    "https://github.com/kong/kong/pull/11234",
+		},
+		files: []github.File{
+			{Filename: "kong/clustering/compat/removed_fields.lua", Status: "modified"},
+			{Filename: "changelog/unreleased/kong/sync-rate.yml", Status: "added"},
+			{Filename: "spec/01-unit/19-hybrid/03-compat_spec.lua", Status: "modified"},
+		},
+		compat:       &compatChange{},
+		organization: "kong",
+		pullRequest:  11234,
+		repository:   "kong",
+	}
+
+	It("a change only modifying the compatibility files will be a compat only event", func() {
+		Expect(gsc.event()).Should(Equal(templates.EventCompatOnly))
+
+		schemaChange := gsc
+		schemaChange.files = append(schemaChange.files, github.File{Filename: "kong/plugins/rate-limiting/schema.lua"})
+		Expect(schemaChange.event()).Should(Equal(templates.EventSchemaChange))
+	})
+
+	It("dropped columns and breaking changelog entries will be breaking changes", func() {
+		breaking := gsc
+		breaking.migrations = &migrationsChange{migrations: []gateway.Migration{{
+			Path: "kong/db/migrations/core/021_340_to_350.lua",
+			Columns: []gateway.ColumnChange{
+				{Table: "routes", Column: "regex_priority", Operation: gateway.ColumnDropped},
+				{Table: "upstreams", Column: "use_srv_name", Operation: gateway.ColumnAdded, Definition: "BOOLEAN"},
+			},
+		}}}
+		breaking.changelog = []github.ChangelogEntry{
+			{Type: "breaking_change", Message: "Removed the legacy router"},
+			{Type: "feature", Message: "Added the sync_rate option."},
+		}
+		Expect(breaking.event()).Should(Equal(templates.EventBreakingChange))
+		Expect(breakingChanges(breaking)).Should(Equal([]string{
+			"column dropped: routes.regex_priority",
+			"breaking changelog entry: Removed the legacy router",
+		}))
+	})
+
+	It("the ticket templates will receive the assessment of the schema change", func() {
+		sc := ticketSchemaChange(gsc, &store.Assessment{
+			Components: []string{"dp-compat"},
+			Severity:   store.SeverityHigh,
+		})
+		Expect(sc.Key).Should(Equal("kong/kong#11234"))
+		Expect(sc.Severity).Should(Equal("high"))
+		Expect(sc.Components).Should(Equal([]string{"dp-compat"}))
+		Expect(sc.Sections.Compat).Should(HavePrefix("Koko compat entries needed"))
+		Expect(sc.Sections.Changelog).Should(BeEmpty())
+	})
+})
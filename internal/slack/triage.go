@@ -0,0 +1,508 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kong/koko-slack-bot/internal/github"
+	"github.com/kong/koko-slack-bot/internal/jira"
+	"github.com/kong/koko-slack-bot/internal/store"
+	"github.com/kong/koko-slack-bot/internal/tracker"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/socketmode"
+	"go.uber.org/zap"
+)
+
+const (
+	// triageActionsBlockID represents the block ID of the triage buttons
+	triageActionsBlockID = "triage-actions"
+	// triageStatusBlockID represents the block ID of the triage status
+	triageStatusBlockID = "triage-status"
+	// actionTriageAssign represents the action ID of the "Assign to me" button
+	actionTriageAssign = "triage-assign"
+	// actionTriageNotRelevant represents the action ID of the "Not relevant to
+	// Koko" button
+	actionTriageNotRelevant = "triage-not-relevant"
+	// actionTriageNeedsCompat represents the action ID of the "Needs compat
+	// work" button
+	actionTriageNeedsCompat = "triage-needs-compat"
+	// actionTriageOpenTicket represents the action ID of the "Open ticket"
+	// button
+	actionTriageOpenTicket = "triage-open-ticket"
+)
+
+// triageDecisions represents the triage decision of each triage button.
+var triageDecisions = map[string]store.Decision{
+	actionTriageAssign:      store.DecisionAssigned,
+	actionTriageNotRelevant: store.DecisionNotRelevant,
+	actionTriageNeedsCompat: store.DecisionNeedsCompat,
+}
+
+// triageActionsBlock will create the block containing the triage buttons for
+// a gateway schema change.
+func triageActionsBlock(key string) *slack.ActionBlock {
+	assign := slack.NewButtonBlockElement(actionTriageAssign, key, plainText("Assign to me"))
+	assign.Style = slack.StylePrimary
+	notRelevant := slack.NewButtonBlockElement(actionTriageNotRelevant, key, plainText("Not relevant to Koko"))
+	needsCompat := slack.NewButtonBlockElement(actionTriageNeedsCompat, key, plainText("Needs compat work"))
+	needsCompat.Style = slack.StyleDanger
+	triage := slack.NewButtonBlockElement(actionTriageModal, key, plainText("Triage"))
+	openTicket := slack.NewButtonBlockElement(actionTriageOpenTicket, key, plainText("Open ticket"))
+	return slack.NewActionBlock(triageActionsBlockID, assign, notRelevant, needsCompat, triage, openTicket)
+}
+
+// decisionText will get the human readable text of a triage decision.
+func decisionText(triage store.Triage) string {
+	switch triage.Decision {
+	case store.DecisionAssigned:
+		return fmt.Sprintf(":bust_in_silhouette: Assigned to <@%s>", triage.Assignee)
+	case store.DecisionNotRelevant:
+		return ":no_entry_sign: Not relevant to Koko"
+	case store.DecisionNeedsCompat:
+		return ":wrench: Needs compat work"
+	}
+	return string(triage.Decision)
+}
+
+// triageStatusBlock will create the block showing the triage status of a
+// schema change along with its pull request lifecycle; nil is returned when
+// there is nothing to show.
+func triageStatusBlock(sc store.SchemaChange) *slack.ContextBlock {
+	var elements []slack.MixedElement
+	if sc.Triage != nil {
+		elements = append(elements, markdownText(fmt.Sprintf("%s (triaged by <@%s> on %s)",
+			decisionText(*sc.Triage), sc.Triage.User, sc.Triage.Timestamp.Format("2006-01-02"))))
+	}
+	if sc.Assessment != nil {
+		elements = append(elements, markdownText(fmt.Sprintf(":clipboard: %s (assessed by <@%s>)",
+			assessmentText(*sc.Assessment), sc.Assessment.User)))
+	}
+	if sc.Ticket != nil {
+		elements = append(elements, markdownText(ticketText(*sc.Ticket)))
+	}
+	switch {
+	case len(sc.Release) > 0:
+		elements = append(elements, markdownText(fmt.Sprintf(":package: Shipped in %s", sc.Release)))
+	case len(sc.ReleaseBranches) > 0:
+		elements = append(elements, markdownText(fmt.Sprintf(":twisted_rightwards_arrows: On %s",
+			strings.Join(sc.ReleaseBranches, ", "))))
+	}
+	switch {
+	case len(sc.Origin) > 0:
+		elements = append(elements, markdownText(fmt.Sprintf(":link: Same change as %s (by %s)", sc.Origin,
+			sc.CorrelatedBy)))
+	case len(sc.Correlated) > 0:
+		elements = append(elements, markdownText(fmt.Sprintf(":link: Also landed as %s",
+			strings.Join(sc.Correlated, ", "))))
+	}
+	if sc.State == store.PullRequestClosed {
+		elements = append(elements, markdownText(":no_entry_sign: Pull request closed without being merged"))
+	}
+	if len(sc.Reverts) > 0 {
+		elements = append(elements, markdownText(fmt.Sprintf(":leftwards_arrow_with_hook: Reverts %s", sc.Reverts)))
+	}
+	if len(sc.RevertedBy) > 0 {
+		elements = append(elements, markdownText(fmt.Sprintf(":leftwards_arrow_with_hook: Reverted by %s",
+			sc.RevertedBy)))
+	}
+	if len(elements) == 0 {
+		return nil
+	}
+	return slack.NewContextBlock(triageStatusBlockID, elements...)
+}
+
+// ticketText will create the text of a ticket with its related issues and its
+// status once synchronized from Jira.
+func ticketText(ticket store.Ticket) string {
+	text := fmt.Sprintf(":ticket: <%s|%s>", ticket.URL, ticket.Key)
+	if ticket.StatusCategory == jira.StatusCategoryDone {
+		text = fmt.Sprintf(":white_check_mark: Absorbed by Koko in <%s|%s>", ticket.URL, ticket.Key)
+	}
+	if len(ticket.Related) > 0 {
+		text += fmt.Sprintf(" (relates to %s)", strings.Join(ticket.Related, ", "))
+	}
+	var details []string
+	if len(ticket.Status) > 0 {
+		details = append(details, ticket.Status)
+	}
+	if len(ticket.Resolution) > 0 {
+		details = append(details, ticket.Resolution)
+	}
+	if len(ticket.Assignee) > 0 {
+		details = append(details, ticket.Assignee)
+	}
+	if len(details) > 0 {
+		text += " · " + strings.Join(details, " · ")
+	}
+	return text
+}
+
+// blockID will get the block ID of a block.
+func blockID(block slack.Block) string {
+	switch b := block.(type) {
+	case *slack.ActionBlock:
+		return b.BlockID
+	case *slack.ContextBlock:
+		return b.BlockID
+	case *slack.SectionBlock:
+		return b.BlockID
+	case *slack.HeaderBlock:
+		return b.BlockID
+	case *slack.DividerBlock:
+		return b.BlockID
+	}
+	return ""
+}
+
+// withTriageStatus will replace the triage status block of a schema change
+// reply; the status block is inserted before the triage buttons when missing.
+func withTriageStatus(blocks []slack.Block, sc store.SchemaChange) []slack.Block {
+	status := triageStatusBlock(sc)
+	updated := make([]slack.Block, 0, len(blocks)+1)
+	inserted := false
+	for _, block := range blocks {
+		switch blockID(block) {
+		case triageStatusBlockID:
+			continue
+		case triageActionsBlockID:
+			if status != nil {
+				updated = append(updated, status)
+				inserted = true
+			}
+		}
+		updated = append(updated, block)
+	}
+	if status != nil && !inserted {
+		updated = append(updated, status)
+	}
+	return updated
+}
+
+// handleTriageAction will process the triage buttons of a schema change
+// reply; persisting the decision, updating the reply, and synchronizing the
+// decision with the ticket.
+func (s *Slack) handleTriageAction(e *socketmode.Event, c *socketmode.Client) {
+	// Ensure this is the correct event
+	callback, ok := e.Data.(slack.InteractionCallback)
+	if !ok {
+		s.logger.Error("event ignored as it is not an interaction callback", zap.Any("event", e))
+		return
+	}
+	c.Ack(*e.Request)
+	if len(callback.ActionCallback.BlockActions) == 0 {
+		s.logger.Debug("event ignored as it does not contain a block action", zap.Any("event", e))
+		return
+	}
+	// Opening a ticket may process the pull request, which would otherwise
+	// block the event loop
+	go s.applyTriageAction(callback, callback.ActionCallback.BlockActions[0])
+}
+
+// applyTriageAction will persist the decision of a triage action, update the
+// reply of the schema change, and synchronize the decision with the ticket.
+func (s *Slack) applyTriageAction(callback slack.InteractionCallback, action *slack.BlockAction) {
+	logger := s.logger.With(zap.String("action", action.ActionID), zap.String("schema-change", action.Value),
+		zap.String("user", callback.User.ID))
+	logger.Debug("triage action received")
+
+	gsc, err := parsePullRequestReference(action.Value)
+	if err != nil {
+		logger.Error("unable to parse schema change of triage action", zap.Error(err))
+		return
+	}
+
+	var sc store.SchemaChange
+	if action.ActionID == actionTriageOpenTicket {
+		sc, err = s.openTicket(gsc)
+	} else {
+		sc, err = s.triage(gsc, triageDecisions[action.ActionID], callback.User.ID)
+	}
+	if err != nil {
+		logger.Error("unable to triage schema change", zap.Error(err))
+		s.notifyUser(callback.Channel.ID, callback.User.ID, fmt.Sprintf(":x: %s", err.Error()))
+		return
+	}
+
+	s.refreshHome()
+
+	// Update the reply to show who triaged the schema change; actions from the
+	// App Home update the stored reply
+	if len(callback.Message.Timestamp) == 0 {
+		if len(sc.Timestamp) > 0 {
+			if err := s.updateReply(sc.Channel, sc.Timestamp, sc); err != nil {
+				logger.Error("unable to update schema change reply", zap.Error(err))
+			}
+		}
+		return
+	}
+	_, _, _, err = s.client.UpdateMessage(callback.Channel.ID, callback.Message.Timestamp,
+		slack.MsgOptionBlocks(withTriageStatus(callback.Message.Blocks.BlockSet, sc)...))
+	if err != nil {
+		logger.Error("unable to update schema change reply", zap.Error(err))
+	}
+}
+
+// notifyUser will send an ephemeral message to a user in a channel; a direct
+// message is sent when there is no channel (e.g. actions from the App Home).
+func (s *Slack) notifyUser(channel string, user string, text string) {
+	if len(channel) == 0 {
+		if _, _, err := s.client.PostMessage(user, slack.MsgOptionText(text, false)); err != nil {
+			s.logger.Error("unable to notify user", zap.String("user", user), zap.Error(err))
+		}
+		return
+	}
+	if _, err := s.client.PostEphemeral(channel, user, slack.MsgOptionText(text, false)); err != nil {
+		s.logger.Error("unable to notify user", zap.String("user", user), zap.Error(err))
+	}
+}
+
+// triage will persist the triage decision of a schema change and synchronize
+// it with the ticket of the schema change.
+func (s *Slack) triage(gsc gatewaySchemaChange, decision store.Decision, user string) (store.SchemaChange, error) {
+	if len(decision) == 0 {
+		return store.SchemaChange{}, errors.New("unknown triage decision")
+	}
+	sc, err := s.store.UpdateSchemaChange(gsc.key(), func(sc *store.SchemaChange) {
+		setSchemaChangeReference(sc, gsc)
+		sc.Triage = &store.Triage{
+			Decision:  decision,
+			User:      user,
+			Timestamp: time.Now().UTC(),
+		}
+		if decision == store.DecisionAssigned {
+			sc.Triage.Assignee = user
+		}
+	})
+	if err != nil {
+		return store.SchemaChange{}, fmt.Errorf("unable to store triage decision: %w", err)
+	}
+
+	if err := s.syncTriage(sc); err != nil {
+		// The decision is stored; synchronization can be retried on the next
+		// triage of the schema change
+		s.logger.Error("unable to synchronize triage decision with ticket", zap.String("schema-change", sc.Key()),
+			zap.Error(err))
+	}
+	return sc, nil
+}
+
+// syncTriage will synchronize the triage decision of a schema change with its
+// ticket; nothing is done when the schema change has no ticket.
+func (s *Slack) syncTriage(sc store.SchemaChange) error {
+	if s.tracker == nil || sc.Ticket == nil || sc.Triage == nil {
+		return nil
+	}
+	userInfo, err := s.client.GetUserInfo(sc.Triage.User)
+	if err != nil {
+		return fmt.Errorf("unable to get user information: %w", err)
+	}
+
+	if sc.Triage.Decision == store.DecisionAssigned {
+		if err := s.tracker.Update(sc.Ticket.Key, tracker.Update{Assignee: userInfo.Profile.Email}); err != nil {
+			return fmt.Errorf("unable to assign ticket: %w", err)
+		}
+	}
+	comment := fmt.Sprintf("Triaged in Slack by %s: %s", userInfo.RealName, ticketDecisionText(sc.Triage.Decision))
+	if err := s.tracker.Comment(sc.Ticket.Key, comment); err != nil {
+		return fmt.Errorf("unable to comment on ticket: %w", err)
+	}
+	return nil
+}
+
+// ticketDecisionText will get the text of a triage decision for a ticket.
+func ticketDecisionText(decision store.Decision) string {
+	switch decision {
+	case store.DecisionAssigned:
+		return "assigned"
+	case store.DecisionNotRelevant:
+		return "not relevant to Koko"
+	case store.DecisionNeedsCompat:
+		return "needs compatibility work"
+	}
+	return string(decision)
+}
+
+// openTicket will create the ticket of a schema change and synchronize any
+// previous triage decision with it; the schema change is processed when it was
+// not already, while a stored schema change is only analyzed for the content
+// of its ticket.
+func (s *Slack) openTicket(gsc gatewaySchemaChange) (store.SchemaChange, error) {
+	if s.tracker == nil {
+		return store.SchemaChange{}, errors.New("ticket tracking is not configured")
+	}
+	sc, ok := s.store.SchemaChange(gsc.key())
+	if ok && sc.Ticket != nil {
+		return sc, nil
+	}
+	switch {
+	case len(gsc.details.URL) > 0:
+		// Already processed; e.g. by the track shortcut
+	case ok:
+		if err := s.analyzeGatewaySchemaChange(&gsc); err != nil {
+			return store.SchemaChange{}, err
+		}
+	default:
+		if err := s.processGatewaySchemaChange(&gsc); err != nil {
+			return store.SchemaChange{}, err
+		}
+		if sc, ok = s.store.SchemaChange(gsc.key()); ok && sc.Ticket != nil {
+			return sc, nil
+		}
+	}
+
+	// Backports and syncs share the ticket of the logical change they belong
+	// to
+	if ticket, found := s.groupTicket(sc); ok && found {
+		sc, err := s.store.UpdateSchemaChange(gsc.key(), func(sc *store.SchemaChange) {
+			sc.Ticket = &ticket
+		})
+		if err != nil {
+			return store.SchemaChange{}, fmt.Errorf("unable to store ticket %s: %w", ticket.Key, err)
+		}
+		comment := fmt.Sprintf("The change also landed as %s.", sc.URL)
+		if err := s.tracker.Comment(ticket.Key, comment); err != nil {
+			s.logger.Error("unable to comment on shared ticket", zap.String("schema-change", sc.Key()), zap.Error(err))
+		}
+		if err := s.syncPullRequestComment(gsc); err != nil {
+			s.logger.Error("unable to synchronize pull request comment", zap.String("schema-change", sc.Key()),
+				zap.Error(err))
+		}
+		return sc, nil
+	}
+
+	// Reuse the open ticket created for the same pull request outside of the
+	// bot; e.g. manually or before the store was reset
+	ticket, found, err := s.tracker.FindByReference(pullRequestReference(gsc))
+	if err != nil {
+		return store.SchemaChange{}, fmt.Errorf("unable to search for existing ticket: %w", err)
+	}
+	var related []string
+	if found {
+		s.logger.Info("existing ticket found for schema change", zap.String("schema-change", gsc.key()),
+			zap.String("ticket", ticket.Key))
+	} else {
+		request, err := newTicket(s.templates, gsc, sc.Assessment)
+		if err != nil {
+			return store.SchemaChange{}, err
+		}
+		ticket, err = s.tracker.Create(request)
+		if err != nil {
+			return store.SchemaChange{}, fmt.Errorf("unable to create ticket: %w", err)
+		}
+		related = s.linkReferencedTickets(gsc, ticket.Key)
+		s.attachJSONSchemas(gsc, ticket.Key)
+	}
+	sc, err = s.store.UpdateSchemaChange(gsc.key(), func(sc *store.SchemaChange) {
+		sc.Ticket = &store.Ticket{
+			Key:     ticket.Key,
+			URL:     ticket.URL,
+			Related: related,
+		}
+	})
+	if err != nil {
+		return store.SchemaChange{}, fmt.Errorf("unable to store ticket %s: %w", ticket.Key, err)
+	}
+
+	if err := s.syncTriage(sc); err != nil {
+		s.logger.Error("unable to synchronize triage decision with ticket", zap.String("schema-change", sc.Key()),
+			zap.Error(err))
+	}
+	if err := s.syncAssessment(sc); err != nil {
+		s.logger.Error("unable to synchronize assessment with ticket", zap.String("schema-change", sc.Key()),
+			zap.Error(err))
+	}
+	if err := s.syncPullRequestComment(gsc); err != nil {
+		s.logger.Error("unable to synchronize pull request comment", zap.String("schema-change", sc.Key()),
+			zap.Error(err))
+	}
+	return sc, nil
+}
+
+// pullRequestLabel will create the label identifying the tickets of a schema
+// change pull request; e.g. gateway-pr-kong-kong-11234.
+func pullRequestLabel(gsc gatewaySchemaChange) string {
+	return strings.ToLower(fmt.Sprintf("gateway-pr-%s-%s-%d", gsc.organization, gsc.repository, gsc.pullRequest))
+}
+
+// pullRequestReference will create the reference identifying the tickets of a
+// schema change pull request.
+func pullRequestReference(gsc gatewaySchemaChange) tracker.Reference {
+	return tracker.Reference{
+		Label: pullRequestLabel(gsc),
+		URL:   gsc.details.URL,
+	}
+}
+
+// referencedJiraKeys will get the Jira issue keys referenced by the title, the
+// branch, or the description of a schema change pull request.
+func referencedJiraKeys(gsc gatewaySchemaChange) []string {
+	var keys []string
+	seen := make(map[string]struct{})
+	candidates := github.FindJiraKeys(gsc.details.Title)
+	// Branches are usually lowercase; e.g. fix/kag-1234-sync-rate
+	candidates = append(candidates, github.FindJiraKeys(strings.ToUpper(gsc.details.HeadRef))...)
+	candidates = append(candidates, gsc.description.JiraKeys...)
+	for _, key := range candidates {
+		if _, ok := seen[key]; !ok {
+			seen[key] = struct{}{}
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// referencedTicketKeys will get the keys of the tickets possibly referenced by
+// a schema change pull request; Jira issue keys and GitHub issues are both
+// candidates as the tracker ignores the keys that do not belong to it.
+func referencedTicketKeys(gsc gatewaySchemaChange) []string {
+	keys := referencedJiraKeys(gsc)
+	for _, issue := range gsc.description.Issues {
+		keys = append(keys, issue.String())
+	}
+	return keys
+}
+
+// linkReferencedTickets will link a ticket to the existing tickets referenced
+// by its schema change pull request and get the keys of the linked tickets;
+// failures are logged as the ticket is still usable without links.
+func (s *Slack) linkReferencedTickets(gsc gatewaySchemaChange, key string) []string {
+	logger := s.logger.With(zap.String("schema-change", gsc.key()), zap.String("ticket", key))
+	keys := referencedTicketKeys(gsc)
+	if len(keys) == 0 {
+		return nil
+	}
+	tickets, err := s.tracker.Find(keys...)
+	if err != nil {
+		logger.Error("unable to look up referenced tickets", zap.Strings("keys", keys), zap.Error(err))
+		return nil
+	}
+	var related []string
+	for _, ticket := range tickets {
+		if strings.EqualFold(ticket.Key, key) {
+			continue
+		}
+		if err := s.tracker.Link(key, ticket.Key); err != nil {
+			logger.Error("unable to link referenced ticket", zap.String("related", ticket.Key), zap.Error(err))
+			continue
+		}
+		related = append(related, ticket.Key)
+	}
+	return related
+}
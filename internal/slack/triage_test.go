@@ -0,0 +1,231 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"time"
+
+	"github.com/kong/koko-slack-bot/internal/github"
+	"github.com/kong/koko-slack-bot/internal/store"
+	"github.com/kong/koko-slack-bot/internal/templates"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/slack-go/slack"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("triage", Label("triage"), func() {
+	var blocks []slack.Block
+
+	BeforeEach(func() {
+		// Round trip the reply blocks through JSON as they are received from an
+		// interaction callback
+		data, err := json.Marshal(slack.Blocks{BlockSet: gatewaySchemaChangeBlocks(gatewaySchemaChange{
+			details: github.PullRequest{
+				Author: "gateway-engineer",
+				State:  "open",
+				Title:  "feat(rate-limiting): add sync rate",
+				URL:    "https://github.com/kong/kong/pull/11234",
+			},
+			organization: "kong",
+			pullRequest:  11234,
+			repository:   "kong",
+		})})
+		Expect(err).NotTo(HaveOccurred())
+		var received slack.Blocks
+		Expect(json.Unmarshal(data, &received)).To(Succeed())
+		blocks = received.BlockSet
+	})
+
+	It("the triage buttons will reference the schema change", func() {
+		actions, ok := blocks[len(blocks)-1].(*slack.ActionBlock)
+		Expect(ok).To(BeTrue())
+		Expect(actions.BlockID).Should(Equal(triageActionsBlockID))
+		Expect(actions.Elements.ElementSet).To(HaveLen(5))
+		for _, element := range actions.Elements.ElementSet {
+			button, ok := element.(*slack.ButtonBlockElement)
+			Expect(ok).To(BeTrue())
+			Expect(button.Value).Should(Equal("kong/kong#11234"))
+		}
+	})
+
+	When("the schema change was not triaged", func() {
+		It("the reply will not contain a triage status", func() {
+			updated := withTriageStatus(blocks, store.SchemaChange{})
+			Expect(updated).Should(Equal(blocks))
+		})
+	})
+
+	When("the schema change was triaged", func() {
+		sc := store.SchemaChange{
+			Triage: &store.Triage{
+				Decision:  store.DecisionAssigned,
+				User:      "U123",
+				Assignee:  "U123",
+				Timestamp: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC),
+			},
+		}
+
+		It("the triage status will be inserted before the triage buttons", func() {
+			updated := withTriageStatus(blocks, sc)
+			Expect(updated).To(HaveLen(len(blocks) + 1))
+			status, ok := updated[len(updated)-2].(*slack.ContextBlock)
+			Expect(ok).To(BeTrue())
+			Expect(status.BlockID).Should(Equal(triageStatusBlockID))
+			Expect(status.ContextElements.Elements).To(HaveLen(1))
+			text, ok := status.ContextElements.Elements[0].(*slack.TextBlockObject)
+			Expect(ok).To(BeTrue())
+			Expect(text.Text).Should(Equal(":bust_in_silhouette: Assigned to <@U123> (triaged by <@U123> on 2023-05-01)"))
+		})
+
+		It("the triage status will be replaced when triaged again", func() {
+			updated := withTriageStatus(withTriageStatus(blocks, sc), store.SchemaChange{
+				Triage: &store.Triage{
+					Decision: store.DecisionNotRelevant,
+					User:     "U456",
+				},
+				Ticket: &store.Ticket{
+					Key: "KOKO-1",
+					URL: "https://konghq.atlassian.net/browse/KOKO-1",
+				},
+			})
+			Expect(updated).To(HaveLen(len(blocks) + 1))
+			status, ok := updated[len(updated)-2].(*slack.ContextBlock)
+			Expect(ok).To(BeTrue())
+			Expect(status.ContextElements.Elements).To(HaveLen(2))
+			ticket, ok := status.ContextElements.Elements[1].(*slack.TextBlockObject)
+			Expect(ok).To(BeTrue())
+			Expect(ticket.Text).Should(Equal(":ticket: <https://konghq.atlassian.net/browse/KOKO-1|KOKO-1>"))
+		})
+	})
+
+	Describe("ticket references", func() {
+		gsc := gatewaySchemaChange{
+			description: github.ParseDescription("### Issue reference\n\nFix FTI-123 and KAG-1234", "kong", "kong"),
+			details: github.PullRequest{
+				HeadRef: "fix/kag-1234-sync-rate",
+				Title:   "fix(rate-limiting): sync rate [KAG-1234]",
+			},
+			organization: "Kong",
+			pullRequest:  11234,
+			repository:   "kong",
+		}
+
+		It("the pull request label will identify the pull request", func() {
+			Expect(pullRequestLabel(gsc)).Should(Equal("gateway-pr-kong-kong-11234"))
+		})
+
+		It("the Jira keys referenced by the title, branch, and description will be found once", func() {
+			Expect(referencedJiraKeys(gsc)).Should(Equal([]string{"KAG-1234", "FTI-123"}))
+		})
+
+		It("the related issues will be shown with the ticket", func() {
+			status := triageStatusBlock(store.SchemaChange{
+				Ticket: &store.Ticket{
+					Key:     "KOKO-1",
+					URL:     "https://konghq.atlassian.net/browse/KOKO-1",
+					Related: []string{"KAG-1234"},
+				},
+			})
+			text, ok := status.ContextElements.Elements[0].(*slack.TextBlockObject)
+			Expect(ok).To(BeTrue())
+			Expect(text.Text).Should(Equal(":ticket: <https://konghq.atlassian.net/browse/KOKO-1|KOKO-1> (relates to KAG-1234)"))
+		})
+	})
+
+	Describe("opening a ticket", func() {
+		var s *Slack
+		var st *store.Store
+		var server *httptest.Server
+		var t *recordingTracker
+
+		BeforeEach(func() {
+			logger, err := zap.NewDevelopment()
+			Expect(err).NotTo(HaveOccurred())
+			mux := http.NewServeMux()
+			mux.HandleFunc("/repos/kong/kong/pulls/11234", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"number": 11234, "state": "closed", "merged": false,
+					"title": "feat(rate-limiting): add sync rate", "html_url": "https://github.com/kong/kong/pull/11234",
+					"base": {"ref": "master", "sha": "base"}, "head": {"ref": "feat/sync-rate", "sha": "head"}}`))
+			})
+			mux.HandleFunc("/repos/kong/kong/pulls/11234/files", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`[]`))
+			})
+			mux.HandleFunc("/repos/kong/kong/pulls/11234/commits", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`[]`))
+			})
+			mux.HandleFunc("/repos/kong/kong/compare/base...head", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"merge_base_commit": {"sha": "base"}}`))
+			})
+			server = httptest.NewServer(mux)
+			client, err := github.NewClient(github.Options{
+				Token:   "token",
+				BaseURL: server.URL,
+				Logger:  logger,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			st, err = store.NewStore(store.Options{
+				Path:   filepath.Join(GinkgoT().TempDir(), "store.json"),
+				Logger: logger,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = st.UpdateSchemaChange("kong/kong#11234", func(sc *store.SchemaChange) {
+				sc.Organization = "kong"
+				sc.Repository = "kong"
+				sc.PullRequest = 11234
+				sc.URL = "https://github.com/kong/kong/pull/11234"
+				sc.State = store.PullRequestOpen
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			ticketTemplates, err := templates.New(templates.Options{Logger: logger})
+			Expect(err).NotTo(HaveOccurred())
+			t = newRecordingTracker()
+			s, err = NewSlack(Options{
+				AppToken:              "xapp-",
+				BotToken:              "xoxb-",
+				Logger:                logger,
+				GitHubClient:          client,
+				Store:                 st,
+				Templates:             ticketTemplates,
+				Tracker:               t,
+				CloseAbandonedTickets: true,
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("a stored schema change will not be processed again", func() {
+			sc, err := s.openTicket(gatewaySchemaChange{organization: "kong", repository: "kong", pullRequest: 11234})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(t.created).To(HaveLen(1))
+			Expect(t.created[0].Title).Should(ContainSubstring("feat(rate-limiting): add sync rate"))
+			Expect(sc.Ticket.Key).Should(Equal("KOKO-1"))
+			// The state is left to the pull request watcher, so the ticket is
+			// not closed as abandoned
+			Expect(sc.State).Should(Equal(store.PullRequestOpen))
+			Expect(t.closed).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,117 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/kong/koko-slack-bot/internal/jira"
+	"github.com/kong/koko-slack-bot/internal/store"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxWebhookBodySize represents the maximum size of a webhook payload
+	maxWebhookBodySize = 1 << 20
+	// jiraSignatureHeader represents the header containing the signature of
+	// Jira webhook payloads
+	jiraSignatureHeader = "X-Hub-Signature"
+	// jiraIssueDeletedEvent represents the Jira webhook event of a deleted
+	// issue
+	jiraIssueDeletedEvent = "jira:issue_deleted"
+)
+
+// JiraWebhookHandler will create the HTTP handler receiving the Jira issue
+// webhooks of the tickets; payloads must be signed with the secret and every
+// webhook is rejected when the secret is empty.
+func (s *Slack) JiraWebhookHandler(secret string) http.Handler {
+	logger := s.logger.With(zap.String("component", "jira-webhook"))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBodySize))
+		if err != nil {
+			logger.Error("unable to read webhook", zap.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if len(secret) == 0 || !jira.VerifySignature(body, r.Header.Get(jiraSignatureHeader), secret) {
+			logger.Warn("webhook ignored as its signature is invalid")
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		event, err := jira.ParseWebhook(body)
+		if err != nil {
+			logger.Debug("webhook ignored", zap.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if err := s.applyTicketEvent(event); err != nil {
+			logger.Error("unable to apply ticket event", zap.String("ticket", event.Key), zap.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// applyTicketEvent will store the state of a ticket received from a webhook on
+// the schema changes sharing it, then update the schema change replies and the
+// App Home; events of unknown tickets are ignored.
+func (s *Slack) applyTicketEvent(event jira.IssueEvent) error {
+	schemaChanges := s.store.SchemaChangesByTicket(event.Key)
+	if len(schemaChanges) == 0 {
+		s.logger.Debug("ticket event ignored as the ticket is not tracked", zap.String("ticket", event.Key))
+		return nil
+	}
+	for _, sc := range schemaChanges {
+		sc, err := s.store.UpdateSchemaChange(sc.Key(), func(sc *store.SchemaChange) {
+			applyTicketState(sc, event)
+		})
+		if err != nil {
+			return fmt.Errorf("unable to store ticket event: %w", err)
+		}
+
+		if len(sc.Channel) > 0 && len(sc.Timestamp) > 0 {
+			if err := s.updateReply(sc.Channel, sc.Timestamp, sc); err != nil {
+				s.logger.Error("unable to update schema change reply with ticket status", zap.String("ticket", event.Key),
+					zap.Error(err))
+			}
+		}
+	}
+	s.refreshHome()
+	return nil
+}
+
+// applyTicketState will apply the state of a ticket received from a webhook to
+// a schema change; the ticket is removed when it was deleted.
+func applyTicketState(sc *store.SchemaChange, event jira.IssueEvent) {
+	if sc.Ticket == nil {
+		return
+	}
+	if event.Event == jiraIssueDeletedEvent {
+		sc.Ticket = nil
+		return
+	}
+	sc.Ticket.Status = event.Status
+	sc.Ticket.StatusCategory = event.StatusCategory
+	sc.Ticket.Assignee = event.Assignee
+	sc.Ticket.Resolution = event.Resolution
+}
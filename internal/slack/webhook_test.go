@@ -0,0 +1,134 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+
+	"github.com/kong/koko-slack-bot/internal/github"
+	"github.com/kong/koko-slack-bot/internal/jira"
+	"github.com/kong/koko-slack-bot/internal/store"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("jira webhook", Label("jira-webhook"), func() {
+	const payload = `{
+		"webhookEvent": "jira:issue_updated",
+		"issue": {
+			"key": "KOKO-1",
+			"fields": {
+				"status": {"name": "Done", "statusCategory": {"key": "done"}},
+				"assignee": {"displayName": "Koko Engineer"},
+				"resolution": {"name": "Fixed"}
+			}
+		}
+	}`
+	var s *Slack
+	var st *store.Store
+
+	// sign will sign a payload with the webhook secret
+	sign := func(body string) string {
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte(body))
+		return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	BeforeEach(func() {
+		logger, err := zap.NewDevelopment()
+		Expect(err).NotTo(HaveOccurred())
+		st, err = store.NewStore(store.Options{
+			Path:   filepath.Join(GinkgoT().TempDir(), "store.json"),
+			Logger: logger,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = st.UpdateSchemaChange("kong/kong#11234", func(sc *store.SchemaChange) {
+			sc.Organization = "kong"
+			sc.Repository = "kong"
+			sc.PullRequest = 11234
+			sc.Ticket = &store.Ticket{Key: "KOKO-1"}
+		})
+		Expect(err).NotTo(HaveOccurred())
+		s, err = NewSlack(Options{
+			AppToken:     "xapp-",
+			BotToken:     "xoxb-",
+			Logger:       logger,
+			GitHubClient: &github.Client{},
+			Store:        st,
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("the ticket status will be stored on the schema change", func() {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, "/webhooks/jira", strings.NewReader(payload))
+		request.Header.Set("X-Hub-Signature", sign(payload))
+		s.JiraWebhookHandler("secret").ServeHTTP(recorder, request)
+		Expect(recorder.Code).Should(Equal(http.StatusNoContent))
+
+		sc, ok := st.SchemaChange("kong/kong#11234")
+		Expect(ok).To(BeTrue())
+		Expect(*sc.Ticket).Should(Equal(store.Ticket{
+			Key:            "KOKO-1",
+			Status:         "Done",
+			StatusCategory: jira.StatusCategoryDone,
+			Assignee:       "Koko Engineer",
+			Resolution:     "Fixed",
+		}))
+		Expect(ticketText(*sc.Ticket)).Should(HavePrefix(":white_check_mark: Absorbed by Koko in"))
+	})
+
+	It("a webhook with an invalid signature will be rejected", func() {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, "/webhooks/jira", strings.NewReader(payload))
+		request.Header.Set("X-Hub-Signature", sign("other"))
+		s.JiraWebhookHandler("secret").ServeHTTP(recorder, request)
+		Expect(recorder.Code).Should(Equal(http.StatusUnauthorized))
+		sc, _ := st.SchemaChange("kong/kong#11234")
+		Expect(sc.Ticket.Status).Should(BeEmpty())
+	})
+
+	It("a webhook for an untracked ticket will be ignored", func() {
+		body := strings.ReplaceAll(payload, "KOKO-1", "KOKO-2")
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, "/webhooks/jira", strings.NewReader(body))
+		request.Header.Set("X-Hub-Signature", sign(body))
+		s.JiraWebhookHandler("secret").ServeHTTP(recorder, request)
+		Expect(recorder.Code).Should(Equal(http.StatusNoContent))
+	})
+
+	It("every webhook will be rejected without a secret", func() {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, "/webhooks/jira", strings.NewReader(payload))
+		s.JiraWebhookHandler("").ServeHTTP(recorder, request)
+		Expect(recorder.Code).Should(Equal(http.StatusUnauthorized))
+		sc, _ := st.SchemaChange("kong/kong#11234")
+		Expect(sc.Ticket.Status).Should(BeEmpty())
+	})
+
+	It("the ticket will be removed when deleted", func() {
+		sc := store.SchemaChange{Ticket: &store.Ticket{Key: "KOKO-1"}}
+		applyTicketState(&sc, jira.IssueEvent{Event: "jira:issue_deleted", Key: "KOKO-1"})
+		Expect(sc.Ticket).To(BeNil())
+	})
+})
@@ -0,0 +1,208 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kong/koko-slack-bot/internal/github"
+	"github.com/kong/koko-slack-bot/internal/store"
+	"github.com/slack-go/slack"
+	"go.uber.org/zap"
+)
+
+const (
+	// workflowRunClockSkew represents the tolerance applied to the dispatch
+	// time when looking for the run of a dispatch, as the run is created by
+	// GitHub
+	workflowRunClockSkew = time.Minute
+	// workflowRunTimeout represents how long the run of a dispatch is looked
+	// for before giving up
+	workflowRunTimeout = 30 * time.Minute
+	// workflowRunMissing represents the conclusion of a dispatch whose run was
+	// never found
+	workflowRunMissing = "missing"
+)
+
+// needsCompatWorkflow will determine if a gateway schema change breaks or
+// changes the behavior of existing configurations; breaking changes and
+// changes to the compatibility files qualify.
+func needsCompatWorkflow(gsc gatewaySchemaChange) bool {
+	return len(breakingChanges(gsc)) > 0 || gsc.compat != nil
+}
+
+// compatWorkflowCorrelationID will create the unique identifier of the
+// dispatch of the Koko compatibility workflow for the head commit of a gateway
+// schema change; e.g. kong/kong#11234@abcdef0.
+func compatWorkflowCorrelationID(gsc gatewaySchemaChange) string {
+	return gsc.key() + "@" + gsc.details.HeadSHA
+}
+
+// compatWorkflowInputs will create the inputs of the Koko compatibility
+// workflow for a gateway schema change; the workflow must include the
+// correlation_id input in its run-name for its run to be found.
+func compatWorkflowInputs(gsc gatewaySchemaChange) map[string]any {
+	return map[string]any{
+		"gateway_repository": gsc.organization + "/" + gsc.repository,
+		"pull_request":       strconv.Itoa(gsc.pullRequest),
+		"head_sha":           gsc.details.HeadSHA,
+		"correlation_id":     compatWorkflowCorrelationID(gsc),
+	}
+}
+
+// dispatchCompatWorkflow will dispatch the Koko compatibility workflow for
+// the head commit of a gateway schema change and announce it in the thread of
+// the schema change; a head commit is only dispatched once.
+func (s *Slack) dispatchCompatWorkflow(gsc gatewaySchemaChange, channel string, thread string) error {
+	if sc, ok := s.store.SchemaChange(gsc.key()); ok && sc.CompatWorkflow != nil &&
+		sc.CompatWorkflow.HeadSHA == gsc.details.HeadSHA {
+		return nil
+	}
+	dispatchedAt := time.Now().UTC()
+	err := s.gitHubClient.DispatchWorkflow(s.kokoOrganization, s.kokoRepository, s.compatWorkflow,
+		s.compatWorkflowRef, compatWorkflowInputs(gsc))
+	if err != nil {
+		return err
+	}
+	_, err = s.store.UpdateSchemaChange(gsc.key(), func(sc *store.SchemaChange) {
+		sc.CompatWorkflow = &store.WorkflowRun{
+			HeadSHA:       gsc.details.HeadSHA,
+			CorrelationID: compatWorkflowCorrelationID(gsc),
+			Thread:        thread,
+			DispatchedAt:  dispatchedAt,
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("unable to store dispatched workflow: %w", err)
+	}
+	text := fmt.Sprintf(":hourglass_flowing_sand: Koko compatibility workflow `%s` dispatched for `%s`", s.compatWorkflow,
+		shortSHA(gsc.details.HeadSHA))
+	if _, _, err := s.client.PostMessage(channel, slack.MsgOptionText(text, false), slack.MsgOptionTS(thread)); err != nil {
+		return fmt.Errorf("unable to announce dispatched workflow: %w", err)
+	}
+	return nil
+}
+
+// watchWorkflowRuns will periodically refresh the status of the dispatched
+// Koko compatibility workflow runs.
+func (s *Slack) watchWorkflowRuns() {
+	s.pollWorkflowRuns()
+	ticker := time.NewTicker(s.workflowPollInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.pollWorkflowRuns()
+	}
+}
+
+// pollWorkflowRuns will find the runs of the pending dispatches and refresh
+// the status of the unfinished runs, then post the result of the completed
+// runs in the thread of their schema change. Dispatches whose run is not found
+// in time are given up.
+func (s *Slack) pollWorkflowRuns() {
+	now := time.Now().UTC()
+	for _, sc := range s.store.SchemaChanges() {
+		if sc.CompatWorkflow == nil || sc.CompatWorkflow.Status == github.WorkflowRunCompleted {
+			continue
+		}
+		logger := s.logger.With(zap.String("schema-change", sc.Key()))
+		run, found, err := s.currentWorkflowRun(*sc.CompatWorkflow)
+		if err != nil {
+			logger.Warn("unable to refresh Koko compatibility workflow run", zap.Error(err))
+			continue
+		}
+		if !found {
+			if now.Sub(sc.CompatWorkflow.DispatchedAt) < workflowRunTimeout {
+				continue
+			}
+			logger.Warn("no run found for Koko compatibility workflow dispatch",
+				zap.String("correlation-id", sc.CompatWorkflow.CorrelationID))
+			run = github.WorkflowRun{Status: github.WorkflowRunCompleted, Conclusion: workflowRunMissing}
+		}
+		if run.ID == sc.CompatWorkflow.ID && run.Status == sc.CompatWorkflow.Status {
+			continue
+		}
+		updated := *sc.CompatWorkflow
+		updated.ID = run.ID
+		updated.URL = run.URL
+		updated.Status = run.Status
+		updated.Conclusion = run.Conclusion
+		sc, err = s.store.UpdateSchemaChange(sc.Key(), func(sc *store.SchemaChange) {
+			sc.CompatWorkflow = &updated
+		})
+		if err != nil {
+			logger.Error("unable to store Koko compatibility workflow run", zap.Error(err))
+			continue
+		}
+		if run.Status != github.WorkflowRunCompleted || len(sc.Channel) == 0 {
+			continue
+		}
+		_, _, err = s.client.PostMessage(sc.Channel,
+			slack.MsgOptionText(workflowResultText(s.compatWorkflow, *sc.CompatWorkflow), false),
+			slack.MsgOptionTS(sc.CompatWorkflow.Thread))
+		if err != nil {
+			logger.Error("unable to post Koko compatibility workflow result", zap.Error(err))
+		}
+	}
+}
+
+// currentWorkflowRun will get the current state of the run of a dispatch; the
+// run of a pending dispatch is the run created since the dispatch whose name
+// contains the correlation identifier of the dispatch and may not exist yet.
+func (s *Slack) currentWorkflowRun(dispatched store.WorkflowRun) (github.WorkflowRun, bool, error) {
+	if dispatched.ID != 0 {
+		run, err := s.gitHubClient.WorkflowRun(s.kokoOrganization, s.kokoRepository, dispatched.ID)
+		return run, err == nil, err
+	}
+	if len(dispatched.CorrelationID) == 0 {
+		return github.WorkflowRun{}, false, nil
+	}
+	runs, err := s.gitHubClient.DispatchedWorkflowRuns(s.kokoOrganization, s.kokoRepository, s.compatWorkflow,
+		dispatched.DispatchedAt.Add(-workflowRunClockSkew))
+	if err != nil {
+		return github.WorkflowRun{}, false, err
+	}
+	for _, run := range runs {
+		if strings.Contains(run.Name, dispatched.CorrelationID) {
+			return run, true, nil
+		}
+	}
+	return github.WorkflowRun{}, false, nil
+}
+
+// workflowResultText will create the text announcing the result of a
+// completed Koko compatibility workflow run.
+func workflowResultText(workflow string, run store.WorkflowRun) string {
+	if run.Conclusion == workflowRunMissing {
+		return fmt.Sprintf(":warning: no run of the Koko compatibility workflow `%s` was found for `%s`; "+
+			"its run-name must include the correlation_id input", workflow, shortSHA(run.HeadSHA))
+	}
+	link := fmt.Sprintf("<%s|Koko compatibility workflow>", run.URL)
+	if run.Conclusion == "success" {
+		return fmt.Sprintf(":white_check_mark: %s passed for `%s`", link, shortSHA(run.HeadSHA))
+	}
+	return fmt.Sprintf(":x: %s finished with `%s` for `%s`", link, run.Conclusion, shortSHA(run.HeadSHA))
+}
+
+// shortSHA will abbreviate a commit SHA the way GitHub displays it.
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,194 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package slack
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"time"
+
+	"github.com/kong/koko-slack-bot/internal/github"
+	"github.com/kong/koko-slack-bot/internal/store"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("compatibility workflow", Label("workflow"), func() {
+	It("breaking and behavior-changing schema changes will need the workflow", func() {
+		Expect(needsCompatWorkflow(gatewaySchemaChange{})).To(BeFalse())
+		Expect(needsCompatWorkflow(gatewaySchemaChange{compat: &compatChange{}})).To(BeTrue())
+		Expect(needsCompatWorkflow(gatewaySchemaChange{
+			bundledPlugins: &bundledPluginsChange{removed: []string{"acme"}},
+		})).To(BeTrue())
+	})
+
+	It("the workflow inputs will identify the pull request head and the dispatch", func() {
+		Expect(compatWorkflowInputs(gatewaySchemaChange{
+			organization: "kong",
+			repository:   "kong",
+			pullRequest:  11234,
+			details:      github.PullRequest{HeadSHA: "0123456789abcdef"},
+		})).Should(Equal(map[string]any{
+			"gateway_repository": "kong/kong",
+			"pull_request":       "11234",
+			"head_sha":           "0123456789abcdef",
+			"correlation_id":     "kong/kong#11234@0123456789abcdef",
+		}))
+	})
+
+	It("the result will link the run and report its conclusion", func() {
+		run := store.WorkflowRun{
+			URL:        "https://github.com/kong/koko/actions/runs/2",
+			HeadSHA:    "0123456789abcdef",
+			Conclusion: "success",
+		}
+		Expect(workflowResultText("gateway-compat.yaml", run)).Should(Equal(
+			":white_check_mark: <https://github.com/kong/koko/actions/runs/2|Koko compatibility workflow> passed for `0123456`"))
+		run.Conclusion = "failure"
+		Expect(workflowResultText("gateway-compat.yaml", run)).Should(Equal(
+			":x: <https://github.com/kong/koko/actions/runs/2|Koko compatibility workflow> finished with `failure` for `0123456`"))
+		run.Conclusion = workflowRunMissing
+		Expect(workflowResultText("gateway-compat.yaml", run)).Should(HavePrefix(
+			":warning: no run of the Koko compatibility workflow `gateway-compat.yaml` was found for `0123456`"))
+	})
+
+	When("polling the dispatched runs", func() {
+		var s *Slack
+		var st *store.Store
+		var server *httptest.Server
+		var status string
+
+		BeforeEach(func() {
+			logger, err := zap.NewDevelopment()
+			Expect(err).NotTo(HaveOccurred())
+			status = "in_progress"
+			mux := http.NewServeMux()
+			mux.HandleFunc("/repos/kong/koko/actions/workflows/gateway-compat.yaml/runs",
+				func(w http.ResponseWriter, r *http.Request) {
+					Expect(r.URL.Query().Get("event")).Should(Equal("workflow_dispatch"))
+					_, _ = w.Write([]byte(`{"total_count": 3, "workflow_runs": [
+						{"id": 4, "status": "queued", "display_title": "Compat kong/kong#11235@bbb"},
+						{"id": 3, "status": "in_progress", "display_title": "Compat kong/kong#11234@aaa"},
+						{"id": 2, "status": "in_progress", "display_title": "Manual run"}
+					]}`))
+				})
+			mux.HandleFunc("/repos/kong/koko/actions/runs/3", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{
+					"id": 3,
+					"status": "` + status + `",
+					"conclusion": "success",
+					"html_url": "https://github.com/kong/koko/actions/runs/3"
+				}`))
+			})
+			server = httptest.NewServer(mux)
+			client, err := github.NewClient(github.Options{
+				Token:   "token",
+				BaseURL: server.URL,
+				Logger:  logger,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			st, err = store.NewStore(store.Options{
+				Path:   filepath.Join(GinkgoT().TempDir(), "store.json"),
+				Logger: logger,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			now := time.Now().UTC()
+			runs := map[int]store.WorkflowRun{
+				11233: {ID: 1, Status: github.WorkflowRunCompleted, DispatchedAt: now.Add(-time.Hour)},
+				11234: {CorrelationID: "kong/kong#11234@aaa", DispatchedAt: now},
+				11235: {CorrelationID: "kong/kong#11235@bbb", DispatchedAt: now},
+				11236: {CorrelationID: "kong/kong#11236@ccc", DispatchedAt: now.Add(-time.Hour)},
+			}
+			for pullRequest, run := range runs {
+				run := run
+				_, err = st.UpdateSchemaChange(store.Key("kong", "kong", pullRequest), func(sc *store.SchemaChange) {
+					sc.Organization = "kong"
+					sc.Repository = "kong"
+					sc.PullRequest = pullRequest
+					sc.CompatWorkflow = &run
+				})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			s, err = NewSlack(Options{
+				AppToken:             "xapp-",
+				BotToken:             "xoxb-",
+				Logger:               logger,
+				GitHubClient:         client,
+				Store:                st,
+				KokoRepository:       "kong/koko",
+				CompatWorkflow:       "gateway-compat.yaml",
+				CompatWorkflowRef:    "main",
+				WorkflowPollInterval: time.Minute,
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("dispatches will be matched to the runs named after their correlation identifier", func() {
+			s.pollWorkflowRuns()
+			sc, ok := st.SchemaChange(store.Key("kong", "kong", 11234))
+			Expect(ok).To(BeTrue())
+			Expect(sc.CompatWorkflow.ID).Should(Equal(int64(3)))
+			Expect(sc.CompatWorkflow.Status).Should(Equal("in_progress"))
+			sc, ok = st.SchemaChange(store.Key("kong", "kong", 11235))
+			Expect(ok).To(BeTrue())
+			Expect(sc.CompatWorkflow.ID).Should(Equal(int64(4)))
+			Expect(sc.CompatWorkflow.Status).Should(Equal("queued"))
+		})
+
+		It("dispatches without a run will be given up after the timeout", func() {
+			s.pollWorkflowRuns()
+			sc, ok := st.SchemaChange(store.Key("kong", "kong", 11236))
+			Expect(ok).To(BeTrue())
+			Expect(sc.CompatWorkflow.ID).Should(BeZero())
+			Expect(sc.CompatWorkflow.Status).Should(Equal(github.WorkflowRunCompleted))
+			Expect(sc.CompatWorkflow.Conclusion).Should(Equal(workflowRunMissing))
+		})
+
+		It("matched runs will be refreshed until completed", func() {
+			s.pollWorkflowRuns()
+			status = github.WorkflowRunCompleted
+			s.pollWorkflowRuns()
+			sc, ok := st.SchemaChange(store.Key("kong", "kong", 11234))
+			Expect(ok).To(BeTrue())
+			Expect(sc.CompatWorkflow.Status).Should(Equal(github.WorkflowRunCompleted))
+			Expect(sc.CompatWorkflow.Conclusion).Should(Equal("success"))
+			Expect(sc.CompatWorkflow.URL).Should(Equal("https://github.com/kong/koko/actions/runs/3"))
+		})
+	})
+
+	It("a compatibility workflow will require its ref", func() {
+		logger, err := zap.NewDevelopment()
+		Expect(err).NotTo(HaveOccurred())
+		_, err = NewSlack(Options{
+			AppToken:       "xapp-",
+			BotToken:       "xoxb-",
+			Logger:         logger,
+			GitHubClient:   &github.Client{},
+			Store:          &store.Store{},
+			KokoRepository: "kong/koko",
+			CompatWorkflow: "gateway-compat.yaml",
+		})
+		Expect(err).To(MatchError("compatibility workflow ref is not set"))
+	})
+})
@@ -0,0 +1,416 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Decision represents the triage decision of a schema change.
+type Decision string
+
+const (
+	// DecisionAssigned represents a schema change assigned to an engineer
+	DecisionAssigned Decision = "assigned"
+	// DecisionNotRelevant represents a schema change that is not relevant to
+	// Koko
+	DecisionNotRelevant Decision = "not-relevant"
+	// DecisionNeedsCompat represents a schema change that requires
+	// compatibility work in Koko
+	DecisionNeedsCompat Decision = "needs-compat"
+)
+
+// Severity represents the severity of a schema change for Koko.
+type Severity string
+
+const (
+	// SeverityLow represents a schema change with little to no impact
+	SeverityLow Severity = "low"
+	// SeverityMedium represents a schema change requiring work in Koko
+	SeverityMedium Severity = "medium"
+	// SeverityHigh represents a schema change breaking Koko compatibility
+	SeverityHigh Severity = "high"
+	// SeverityCritical represents a schema change blocking a Koko release
+	SeverityCritical Severity = "critical"
+)
+
+// PullRequestState represents the state of the pull request of a schema
+// change.
+type PullRequestState string
+
+const (
+	// PullRequestOpen represents a pull request that is still open
+	PullRequestOpen PullRequestState = "open"
+	// PullRequestClosed represents a pull request closed without being merged
+	PullRequestClosed PullRequestState = "closed"
+	// PullRequestMerged represents a pull request that has been merged
+	PullRequestMerged PullRequestState = "merged"
+)
+
+// Options contain the parameters to create a new store instance.
+type Options struct {
+	// Path represents the file the store is persisted to
+	Path string
+	// Logger represents the base logger to use for the store package
+	Logger *zap.Logger
+}
+
+// Store represents a file backed store of schema changes.
+type Store struct {
+	// mutex represents the lock guarding the schema changes and the file
+	mutex sync.RWMutex
+	// path represents the file the store is persisted to
+	path string
+	// schemaChanges represents the schema changes keyed by pull request
+	schemaChanges map[string]SchemaChange
+	// logger represents the logger to use for the store package
+	logger *zap.Logger
+}
+
+// SchemaChange represents the persisted state of a gateway schema change.
+type SchemaChange struct {
+	// Organization represents the GitHub organization/owner
+	Organization string `json:"organization"`
+	// Repository represents the GitHub repository
+	Repository string `json:"repository"`
+	// PullRequest represents the GitHub pull request number
+	PullRequest int `json:"pull_request"`
+	// Title represents the title of the pull request
+	Title string `json:"title"`
+	// URL represents the HTML URL of the pull request
+	URL string `json:"url"`
+	// Author represents the GitHub login of the pull request author
+	Author string `json:"author"`
+	// BaseRef represents the branch the pull request is merged into
+	BaseRef string `json:"base_ref,omitempty"`
+	// NewPlugins represents the plugins the pull request adds to the gateway
+	// bundled plugins
+	NewPlugins []string `json:"new_plugins,omitempty"`
+	// State represents the last known state of the pull request
+	State PullRequestState `json:"state,omitempty"`
+	// MergeCommitSHA represents the SHA of the merge commit of the pull
+	// request once merged
+	MergeCommitSHA string `json:"merge_commit_sha,omitempty"`
+	// MergedAt represents the time the pull request was merged
+	MergedAt time.Time `json:"merged_at,omitempty"`
+	// ClosedAt represents the time the pull request was closed without being
+	// merged
+	ClosedAt time.Time `json:"closed_at,omitempty"`
+	// Reverts represents the key of the schema change the pull request reverts
+	Reverts string `json:"reverts,omitempty"`
+	// RevertedBy represents the key of the schema change reverting the pull
+	// request
+	RevertedBy string `json:"reverted_by,omitempty"`
+	// Release represents the first gateway release tag shipping the schema
+	// change
+	Release string `json:"release,omitempty"`
+	// ReleaseBranches represents the gateway release branches containing the
+	// merge commit of the pull request
+	ReleaseBranches []string `json:"release_branches,omitempty"`
+	// PatchID represents the identifier of the changes of the pull request
+	// ignoring whitespace and line numbers
+	PatchID string `json:"patch_id,omitempty"`
+	// Origin represents the key of the schema change of the logical change the
+	// pull request belongs to; e.g. the original of a backport
+	Origin string `json:"origin,omitempty"`
+	// CorrelatedBy represents how the pull request was correlated with its
+	// origin; e.g. cherry-pick or title
+	CorrelatedBy string `json:"correlated_by,omitempty"`
+	// Correlated represents the keys of the schema changes correlated with the
+	// pull request as their origin
+	Correlated []string `json:"correlated,omitempty"`
+	// Channel represents the Slack channel of the reply to the schema change
+	Channel string `json:"channel,omitempty"`
+	// Timestamp represents the Slack timestamp of the reply to the schema
+	// change
+	Timestamp string `json:"timestamp,omitempty"`
+	// Triage represents the triage decision of the schema change
+	Triage *Triage `json:"triage,omitempty"`
+	// Assessment represents the detailed triage assessment of the schema change
+	Assessment *Assessment `json:"assessment,omitempty"`
+	// Ticket represents the ticket tracking the schema change
+	Ticket *Ticket `json:"ticket,omitempty"`
+	// CompatWorkflow represents the Koko compatibility workflow run
+	// dispatched for the schema change
+	CompatWorkflow *WorkflowRun `json:"compat_workflow,omitempty"`
+	// CreatedAt represents the time the schema change was first stored
+	CreatedAt time.Time `json:"created_at"`
+	// UpdatedAt represents the time the schema change was last stored
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Triage represents the triage decision of a schema change.
+type Triage struct {
+	// Decision represents the decision made for the schema change
+	Decision Decision `json:"decision"`
+	// User represents the Slack user ID who triaged the schema change
+	User string `json:"user"`
+	// Assignee represents the Slack user ID the schema change is assigned to
+	Assignee string `json:"assignee,omitempty"`
+	// Timestamp represents the time the schema change was triaged
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Assessment represents the detailed triage assessment of a schema change.
+type Assessment struct {
+	// Components represents the Koko components affected by the schema change
+	Components []string `json:"components"`
+	// TargetRelease represents the Koko release the schema change is targeted
+	// for
+	TargetRelease string `json:"target_release"`
+	// Severity represents the severity override of the schema change
+	Severity Severity `json:"severity,omitempty"`
+	// Notes represents the free form notes of the assessment
+	Notes string `json:"notes,omitempty"`
+	// User represents the Slack user ID who assessed the schema change
+	User string `json:"user"`
+	// Timestamp represents the time the schema change was assessed
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// WorkflowRun represents a GitHub Actions workflow run dispatched for a
+// schema change.
+type WorkflowRun struct {
+	// ID represents the identifier of the run; zero until the run of the
+	// dispatch is found
+	ID int64 `json:"id,omitempty"`
+	// URL represents the browsable URL of the run
+	URL string `json:"url,omitempty"`
+	// HeadSHA represents the SHA of the pull request commit the run was
+	// dispatched for
+	HeadSHA string `json:"head_sha"`
+	// CorrelationID represents the unique input of the dispatch the workflow
+	// includes in the name of its run; e.g. kong/kong#11234@abcdef0
+	CorrelationID string `json:"correlation_id,omitempty"`
+	// Status represents the last known status of the run; e.g. queued or
+	// completed
+	Status string `json:"status,omitempty"`
+	// Conclusion represents the result of the completed run; e.g. success
+	Conclusion string `json:"conclusion,omitempty"`
+	// Thread represents the Slack timestamp of the thread the result is posted
+	// in
+	Thread string `json:"thread"`
+	// DispatchedAt represents the time the run was dispatched
+	DispatchedAt time.Time `json:"dispatched_at"`
+}
+
+// Ticket represents the ticket tracking a schema change.
+type Ticket struct {
+	// Key represents the key of the ticket; e.g. KOKO-1234
+	Key string `json:"key"`
+	// URL represents the browsable URL of the ticket
+	URL string `json:"url"`
+	// Related represents the keys of the existing issues referenced by the
+	// pull request that the ticket is linked to
+	Related []string `json:"related,omitempty"`
+	// Status represents the name of the status of the ticket
+	Status string `json:"status,omitempty"`
+	// StatusCategory represents the key of the status category of the ticket;
+	// new, indeterminate, or done
+	StatusCategory string `json:"status_category,omitempty"`
+	// Assignee represents the display name of the assignee of the ticket
+	Assignee string `json:"assignee,omitempty"`
+	// Resolution represents the name of the resolution of the ticket; empty
+	// when unresolved
+	Resolution string `json:"resolution,omitempty"`
+}
+
+// Key will create the key of a schema change from its pull request.
+func Key(organization string, repository string, pullRequest int) string {
+	return fmt.Sprintf("%s/%s#%d", strings.ToLower(organization), strings.ToLower(repository), pullRequest)
+}
+
+// Key will get the key of the schema change.
+func (sc SchemaChange) Key() string {
+	return Key(sc.Organization, sc.Repository, sc.PullRequest)
+}
+
+// NewStore will validate options and instantiate a new store instance loading
+// any previously persisted schema changes.
+func NewStore(opts Options) (*Store, error) {
+	// Validate required options
+	if len(strings.TrimSpace(opts.Path)) == 0 {
+		return nil, errors.New("store path is not set")
+	}
+	if opts.Logger == nil {
+		return nil, errors.New("logger is not set")
+	}
+
+	s := &Store{
+		path:          opts.Path,
+		schemaChanges: make(map[string]SchemaChange),
+		logger:        opts.Logger.With(zap.String("component", "store")),
+	}
+
+	// Load the previously persisted schema changes
+	data, err := os.ReadFile(opts.Path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("unable to read store: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.schemaChanges); err != nil {
+		return nil, fmt.Errorf("unable to decode store: %w", err)
+	}
+	s.logger.Debug("store loaded", zap.String("path", opts.Path), zap.Int("schema-changes", len(s.schemaChanges)))
+	return s, nil
+}
+
+// SchemaChange will get the schema change for the given key.
+func (s *Store) SchemaChange(key string) (SchemaChange, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	sc, ok := s.schemaChanges[key]
+	return sc, ok
+}
+
+// SchemaChangeByTicket will get the schema change tracked by the ticket with
+// the given key.
+func (s *Store) SchemaChangeByTicket(ticket string) (SchemaChange, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	for _, sc := range s.schemaChanges {
+		if sc.Ticket != nil && strings.EqualFold(sc.Ticket.Key, ticket) {
+			return sc, true
+		}
+	}
+	return SchemaChange{}, false
+}
+
+// SchemaChangesByTicket will get the schema changes sharing the ticket with
+// the given key ordered from the most recently created; backports and syncs
+// of a change share its ticket.
+func (s *Store) SchemaChangesByTicket(ticket string) []SchemaChange {
+	var schemaChanges []SchemaChange
+	for _, sc := range s.SchemaChanges() {
+		if sc.Ticket != nil && strings.EqualFold(sc.Ticket.Key, ticket) {
+			schemaChanges = append(schemaChanges, sc)
+		}
+	}
+	return schemaChanges
+}
+
+// SchemaChanges will get all the schema changes ordered from the most recently
+// created.
+func (s *Store) SchemaChanges() []SchemaChange {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	schemaChanges := make([]SchemaChange, 0, len(s.schemaChanges))
+	for _, sc := range s.schemaChanges {
+		schemaChanges = append(schemaChanges, sc)
+	}
+	sort.Slice(schemaChanges, func(i, j int) bool {
+		if schemaChanges[i].CreatedAt.Equal(schemaChanges[j].CreatedAt) {
+			return schemaChanges[i].Key() > schemaChanges[j].Key()
+		}
+		return schemaChanges[i].CreatedAt.After(schemaChanges[j].CreatedAt)
+	})
+	return schemaChanges
+}
+
+// UpdateSchemaChange will apply the update to the schema change for the given
+// key and persist the result; a new schema change is created when the key does
+// not exist.
+func (s *Store) UpdateSchemaChange(key string, update func(sc *SchemaChange)) (SchemaChange, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// The update is applied to a copy, so the stored schema change is left
+	// untouched when it cannot be persisted
+	now := time.Now().UTC()
+	stored, ok := s.schemaChanges[key]
+	sc := stored.clone()
+	if !ok {
+		sc.CreatedAt = now
+	}
+	update(&sc)
+	sc.UpdatedAt = now
+
+	schemaChanges := make(map[string]SchemaChange, len(s.schemaChanges)+1)
+	for k, v := range s.schemaChanges {
+		schemaChanges[k] = v
+	}
+	schemaChanges[key] = sc
+	if err := s.persist(schemaChanges); err != nil {
+		return SchemaChange{}, err
+	}
+	s.schemaChanges = schemaChanges
+	return sc, nil
+}
+
+// clone will copy a schema change along with the values it references.
+func (sc SchemaChange) clone() SchemaChange {
+	sc.NewPlugins = append([]string(nil), sc.NewPlugins...)
+	sc.ReleaseBranches = append([]string(nil), sc.ReleaseBranches...)
+	sc.Correlated = append([]string(nil), sc.Correlated...)
+	if sc.Triage != nil {
+		triage := *sc.Triage
+		sc.Triage = &triage
+	}
+	if sc.Assessment != nil {
+		assessment := *sc.Assessment
+		assessment.Components = append([]string(nil), assessment.Components...)
+		sc.Assessment = &assessment
+	}
+	if sc.Ticket != nil {
+		ticket := *sc.Ticket
+		ticket.Related = append([]string(nil), ticket.Related...)
+		sc.Ticket = &ticket
+	}
+	if sc.CompatWorkflow != nil {
+		run := *sc.CompatWorkflow
+		sc.CompatWorkflow = &run
+	}
+	return sc
+}
+
+// persist will write the given schema changes to the store file; the caller
+// must hold the lock.
+func (s *Store) persist(schemaChanges map[string]SchemaChange) error {
+	data, err := json.MarshalIndent(schemaChanges, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to encode store: %w", err)
+	}
+
+	// Write to a temporary file first to avoid corrupting the store
+	tempFile, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("unable to create temporary store file: %w", err)
+	}
+	defer os.Remove(tempFile.Name())
+	if _, err := tempFile.Write(data); err != nil {
+		tempFile.Close()
+		return fmt.Errorf("unable to write store: %w", err)
+	}
+	if err := tempFile.Close(); err != nil {
+		return fmt.Errorf("unable to close temporary store file: %w", err)
+	}
+	if err := os.Rename(tempFile.Name(), s.path); err != nil {
+		return fmt.Errorf("unable to replace store file: %w", err)
+	}
+	return nil
+}
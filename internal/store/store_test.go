@@ -0,0 +1,209 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package store
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("store", func() {
+	var logger *zap.Logger
+	var path string
+
+	BeforeEach(func() {
+		var err error
+		logger, err = zap.NewDevelopment()
+		Expect(err).NotTo(HaveOccurred())
+		path = filepath.Join(GinkgoT().TempDir(), "store.json")
+	})
+
+	Describe("creating a new store instance", Label("store-options"), func() {
+		When("the options are valid", func() {
+			It("a new store instance will be instantiated", func() {
+				s, err := NewStore(Options{
+					Path:   path,
+					Logger: logger,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(s).NotTo(BeNil())
+				Expect(s.SchemaChanges()).To(BeEmpty())
+			})
+		})
+
+		When("the options are invalid", func() {
+			Context("and the path is missing", func() {
+				It("a new store instance will not be instantiated", func() {
+					s, err := NewStore(Options{})
+					Expect(err).To(HaveOccurred())
+					Expect(err).Should(MatchError("store path is not set"))
+					Expect(s).To(BeNil())
+				})
+			})
+
+			Context("and the logger is missing", func() {
+				It("a new store instance will not be instantiated", func() {
+					s, err := NewStore(Options{
+						Path: path,
+					})
+					Expect(err).To(HaveOccurred())
+					Expect(err).Should(MatchError("logger is not set"))
+					Expect(s).To(BeNil())
+				})
+			})
+
+			Context("and the persisted store is corrupted", func() {
+				It("a new store instance will not be instantiated", func() {
+					Expect(os.WriteFile(path, []byte("{"), 0o600)).To(Succeed())
+					s, err := NewStore(Options{
+						Path:   path,
+						Logger: logger,
+					})
+					Expect(err).To(HaveOccurred())
+					Expect(s).To(BeNil())
+				})
+			})
+		})
+	})
+
+	Describe("updating schema changes", Label("store-schema-changes"), func() {
+		var s *Store
+
+		BeforeEach(func() {
+			var err error
+			s, err = NewStore(Options{
+				Path:   path,
+				Logger: logger,
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("a new schema change will be created and persisted", func() {
+			key := Key("Kong", "Kong", 11234)
+			Expect(key).Should(Equal("kong/kong#11234"))
+			sc, err := s.UpdateSchemaChange(key, func(sc *SchemaChange) {
+				sc.Organization = "kong"
+				sc.Repository = "kong"
+				sc.PullRequest = 11234
+				sc.Triage = &Triage{
+					Decision: DecisionNeedsCompat,
+					User:     "U123",
+				}
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sc.Key()).Should(Equal(key))
+			Expect(sc.CreatedAt).NotTo(BeZero())
+			Expect(sc.UpdatedAt).Should(Equal(sc.CreatedAt))
+
+			// Reload the store to ensure the schema change was persisted
+			reloaded, err := NewStore(Options{
+				Path:   path,
+				Logger: logger,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			persisted, ok := reloaded.SchemaChange(key)
+			Expect(ok).To(BeTrue())
+			Expect(persisted.Triage.Decision).Should(Equal(DecisionNeedsCompat))
+			Expect(persisted.CreatedAt.Equal(sc.CreatedAt)).To(BeTrue())
+		})
+
+		It("an existing schema change will be updated while keeping its creation time", func() {
+			key := Key("kong", "kong", 1)
+			created, err := s.UpdateSchemaChange(key, func(sc *SchemaChange) {
+				sc.Title = "original"
+			})
+			Expect(err).NotTo(HaveOccurred())
+			updated, err := s.UpdateSchemaChange(key, func(sc *SchemaChange) {
+				sc.Ticket = &Ticket{Key: "KOKO-1"}
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updated.Title).Should(Equal("original"))
+			Expect(updated.Ticket.Key).Should(Equal("KOKO-1"))
+			Expect(updated.CreatedAt).Should(Equal(created.CreatedAt))
+			Expect(s.SchemaChanges()).To(HaveLen(1))
+		})
+
+		It("a schema change will be left untouched when it cannot be persisted", func() {
+			key := Key("kong", "kong", 1)
+			_, err := s.UpdateSchemaChange(key, func(sc *SchemaChange) {
+				sc.Ticket = &Ticket{Key: "KOKO-1", Related: []string{"KAG-1"}}
+			})
+			Expect(err).NotTo(HaveOccurred())
+			// The temporary store file cannot be created once the directory is
+			// gone
+			Expect(os.RemoveAll(filepath.Dir(path))).To(Succeed())
+			_, err = s.UpdateSchemaChange(key, func(sc *SchemaChange) {
+				sc.Ticket.Status = "Done"
+				sc.Ticket.Related[0] = "KAG-2"
+			})
+			Expect(err).To(HaveOccurred())
+			_, err = s.UpdateSchemaChange(Key("kong", "kong", 2), func(sc *SchemaChange) {})
+			Expect(err).To(HaveOccurred())
+
+			sc, ok := s.SchemaChange(key)
+			Expect(ok).To(BeTrue())
+			Expect(sc.Ticket).Should(Equal(&Ticket{Key: "KOKO-1", Related: []string{"KAG-1"}}))
+			Expect(s.SchemaChanges()).To(HaveLen(1))
+		})
+
+		It("a schema change will be found by its ticket", func() {
+			key := Key("kong", "kong", 1)
+			_, err := s.UpdateSchemaChange(key, func(sc *SchemaChange) {
+				sc.Organization = "kong"
+				sc.Repository = "kong"
+				sc.PullRequest = 1
+				sc.Ticket = &Ticket{Key: "KOKO-1"}
+			})
+			Expect(err).NotTo(HaveOccurred())
+			sc, ok := s.SchemaChangeByTicket("koko-1")
+			Expect(ok).To(BeTrue())
+			Expect(sc.Key()).Should(Equal(key))
+			_, ok = s.SchemaChangeByTicket("KOKO-2")
+			Expect(ok).To(BeFalse())
+		})
+
+		It("the schema changes sharing a ticket will be found", func() {
+			for _, pullRequest := range []int{1, 2} {
+				_, err := s.UpdateSchemaChange(Key("kong", "kong", pullRequest), func(sc *SchemaChange) {
+					sc.Organization = "kong"
+					sc.Repository = "kong"
+					sc.PullRequest = pullRequest
+					sc.Ticket = &Ticket{Key: "KOKO-1"}
+				})
+				Expect(err).NotTo(HaveOccurred())
+			}
+			Expect(s.SchemaChangesByTicket("koko-1")).To(HaveLen(2))
+			Expect(s.SchemaChangesByTicket("KOKO-2")).To(BeEmpty())
+		})
+
+		It("the schema changes will be ordered from the most recently created", func() {
+			for _, pullRequest := range []int{1, 2, 3} {
+				_, err := s.UpdateSchemaChange(Key("kong", "kong", pullRequest), func(sc *SchemaChange) {
+					sc.PullRequest = pullRequest
+				})
+				Expect(err).NotTo(HaveOccurred())
+			}
+			schemaChanges := s.SchemaChanges()
+			Expect(schemaChanges).To(HaveLen(3))
+			Expect(schemaChanges[0].PullRequest).Should(Equal(3))
+			Expect(schemaChanges[2].PullRequest).Should(Equal(1))
+		})
+	})
+})
@@ -0,0 +1,483 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package templates
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/kong/koko-slack-bot/internal/compat"
+	"github.com/kong/koko-slack-bot/internal/gateway"
+	"github.com/kong/koko-slack-bot/internal/github"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// Event represents the type of a gateway schema change event.
+type Event string
+
+const (
+	// EventSchemaChange represents a gateway change to existing schemas; its
+	// template is used by the events without a template
+	EventSchemaChange Event = "schema-change"
+	// EventNewPlugin represents a gateway change adding bundled plugins
+	EventNewPlugin Event = "new-plugin"
+	// EventBreakingChange represents a gateway change breaking existing
+	// configurations; e.g. a removed plugin or a dropped column
+	EventBreakingChange Event = "breaking-change"
+	// EventCompatOnly represents a gateway change only modifying the
+	// compatibility handling of older data planes
+	EventCompatOnly Event = "compat-only"
+)
+
+// Events represents all the gateway schema change events.
+var Events = []Event{EventSchemaChange, EventNewPlugin, EventBreakingChange, EventCompatOnly}
+
+// defaultTemplates represents the templates used for the events not defined
+// in the configured templates.
+//
+//go:embed default.yaml
+var defaultTemplates []byte
+
+// Options contain the parameters to create a new templates instance.
+type Options struct {
+	// Path represents the path of the YAML file of the ticket templates; the
+	// default templates are used for the events it does not define
+	Path string
+	// Logger represents the base logger to use for the templates package
+	Logger *zap.Logger
+}
+
+// Templates represents the ticket templates of the gateway schema change
+// events.
+type Templates struct {
+	// events represents the parsed templates of each event
+	events map[Event]*eventTemplate
+	// logger represents the logger to use for the templates package
+	logger *zap.Logger
+}
+
+// definition represents the YAML definition of the ticket template of an
+// event.
+type definition struct {
+	// Title represents the template of the ticket title
+	Title string `yaml:"title"`
+	// Body represents the template of the markdown ticket body
+	Body string `yaml:"body"`
+	// Labels represents the templates of the ticket labels
+	Labels []string `yaml:"labels"`
+	// Components represents the templates of the ticket components
+	Components []string `yaml:"components"`
+	// Priority represents the template of the ticket priority
+	Priority string `yaml:"priority"`
+	// Fields represents the custom fields of the ticket keyed by field ID;
+	// string values within the fields are templates
+	Fields map[string]any `yaml:"fields"`
+}
+
+// eventTemplate represents the parsed ticket template of an event.
+type eventTemplate struct {
+	// title represents the template of the ticket title
+	title *template.Template
+	// body represents the template of the markdown ticket body
+	body *template.Template
+	// labels represents the templates of the ticket labels
+	labels []*template.Template
+	// components represents the templates of the ticket components
+	components []*template.Template
+	// priority represents the template of the ticket priority
+	priority *template.Template
+	// fields represents the custom fields of the ticket whose string values
+	// are parsed templates
+	fields map[string]any
+}
+
+// Ticket represents a ticket rendered from a template.
+type Ticket struct {
+	// Title represents the title of the ticket
+	Title string
+	// Body represents the markdown body of the ticket
+	Body string
+	// Labels represents the labels of the ticket
+	Labels []string
+	// Components represents the components of the ticket
+	Components []string
+	// Priority represents the priority of the ticket; empty for the default
+	// priority of the tracker
+	Priority string
+	// Fields represents the custom fields of the ticket keyed by field ID
+	Fields map[string]any
+}
+
+// BundledPlugins represents the changes to the gateway bundled plugins.
+type BundledPlugins struct {
+	// Added represents the plugins added to the bundled plugins
+	Added []string
+	// Removed represents the plugins removed from the bundled plugins
+	Removed []string
+}
+
+// Sections represents the plain text sections of a schema change already
+// formatted as in the Slack replies; empty when there is nothing to show.
+type Sections struct {
+	// BundledPlugins represents the changes to the bundled plugins
+	BundledPlugins string
+	// Changelog represents the changelog entries
+	Changelog string
+	// Compat represents the changes to the compatibility files
+	Compat string
+	// Description represents the markdown of the pull request description
+	Description string
+	// Migrations represents the new database migrations
+	Migrations string
+}
+
+// SchemaChange represents the enriched gateway schema change given to the
+// templates.
+type SchemaChange struct {
+	// Event represents the type of the schema change event
+	Event Event
+	// Key represents the short reference of the pull request; e.g.
+	// kong/kong#11234
+	Key string
+	// Organization represents the GitHub organization of the pull request
+	Organization string
+	// Repository represents the GitHub repository of the pull request
+	Repository string
+	// PullRequest represents the details of the pull request
+	PullRequest github.PullRequest
+	// Description represents the parsed pull request description
+	Description github.Description
+	// Changelog represents the changelog entries of the pull request
+	Changelog []github.ChangelogEntry
+	// FieldChanges represents the changes to the fields removed for older
+	// data planes
+	FieldChanges []compat.RemovedFieldsEntry
+	// BundledPlugins represents the changes to the bundled plugins
+	BundledPlugins BundledPlugins
+	// Migrations represents the new database migrations
+	Migrations []gateway.Migration
+	// Breaking represents why the schema change is breaking
+	Breaking []string
+	// Severity represents the assessed severity; empty when not assessed
+	Severity string
+	// Components represents the assessed Koko components
+	Components []string
+	// Sections represents the formatted sections of the schema change
+	Sections Sections
+}
+
+// funcs represents the functions available to the templates.
+var funcs = template.FuncMap{
+	"join":  strings.Join,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"trim":  strings.TrimSpace,
+}
+
+// New will validate options, load the ticket templates, and validate them by
+// rendering a sample schema change for every event.
+func New(opts Options) (*Templates, error) {
+	// Validate required options
+	if opts.Logger == nil {
+		return nil, errors.New("logger is not set")
+	}
+
+	definitions, err := parseDefinitions(defaultTemplates)
+	if err != nil {
+		return nil, fmt.Errorf("invalid default templates: %w", err)
+	}
+	if len(opts.Path) > 0 {
+		data, err := os.ReadFile(opts.Path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read templates: %w", err)
+		}
+		configured, err := parseDefinitions(data)
+		if err != nil {
+			return nil, err
+		}
+		for event, definition := range configured {
+			definitions[event] = definition
+		}
+	}
+
+	t := &Templates{
+		events: make(map[Event]*eventTemplate, len(definitions)),
+		logger: opts.Logger.With(zap.String("component", "templates")),
+	}
+	for _, event := range Events {
+		definition, ok := definitions[event]
+		if !ok {
+			continue
+		}
+		parsed, err := parseEventTemplate(event, definition)
+		if err != nil {
+			return nil, err
+		}
+		t.events[event] = parsed
+		if _, err := t.Render(sampleContext(event)); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// parseDefinitions will parse the YAML definitions of the ticket templates;
+// unknown events are rejected.
+func parseDefinitions(data []byte) (map[Event]definition, error) {
+	var definitions map[Event]definition
+	if err := yaml.Unmarshal(data, &definitions); err != nil {
+		return nil, fmt.Errorf("unable to parse templates: %w", err)
+	}
+	for event := range definitions {
+		if !isEvent(event) {
+			return nil, fmt.Errorf("unknown event %q in templates", event)
+		}
+	}
+	if definitions == nil {
+		definitions = make(map[Event]definition)
+	}
+	return definitions, nil
+}
+
+// isEvent will determine if an event is a known gateway schema change event.
+func isEvent(event Event) bool {
+	for _, known := range Events {
+		if event == known {
+			return true
+		}
+	}
+	return false
+}
+
+// parseEventTemplate will parse the templates of the definition of an event.
+func parseEventTemplate(event Event, definition definition) (*eventTemplate, error) {
+	if len(strings.TrimSpace(definition.Title)) == 0 {
+		return nil, fmt.Errorf("template %s: title is not set", event)
+	}
+	parse := func(name string, text string) (*template.Template, error) {
+		parsed, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("template %s: %w", event, err)
+		}
+		return parsed, nil
+	}
+
+	var err error
+	parsed := &eventTemplate{}
+	if parsed.title, err = parse("title", definition.Title); err != nil {
+		return nil, err
+	}
+	if parsed.body, err = parse("body", definition.Body); err != nil {
+		return nil, err
+	}
+	if parsed.priority, err = parse("priority", definition.Priority); err != nil {
+		return nil, err
+	}
+	for i, label := range definition.Labels {
+		labelTemplate, err := parse(fmt.Sprintf("labels[%d]", i), label)
+		if err != nil {
+			return nil, err
+		}
+		parsed.labels = append(parsed.labels, labelTemplate)
+	}
+	for i, component := range definition.Components {
+		componentTemplate, err := parse(fmt.Sprintf("components[%d]", i), component)
+		if err != nil {
+			return nil, err
+		}
+		parsed.components = append(parsed.components, componentTemplate)
+	}
+	fields, err := parseValue("fields", definition.Fields, parse)
+	if err != nil {
+		return nil, err
+	}
+	parsed.fields, _ = fields.(map[string]any)
+	return parsed, nil
+}
+
+// parseValue will parse the string values of a custom field value as
+// templates; maps and lists are parsed recursively.
+func parseValue(name string, value any, parse func(string, string) (*template.Template, error)) (any, error) {
+	switch v := value.(type) {
+	case string:
+		return parse(name, v)
+	case map[string]any:
+		parsed := make(map[string]any, len(v))
+		for key, item := range v {
+			parsedItem, err := parseValue(name+"."+key, item, parse)
+			if err != nil {
+				return nil, err
+			}
+			parsed[key] = parsedItem
+		}
+		return parsed, nil
+	case []any:
+		parsed := make([]any, 0, len(v))
+		for i, item := range v {
+			parsedItem, err := parseValue(fmt.Sprintf("%s[%d]", name, i), item, parse)
+			if err != nil {
+				return nil, err
+			}
+			parsed = append(parsed, parsedItem)
+		}
+		return parsed, nil
+	}
+	return value, nil
+}
+
+// Render will render the ticket of a schema change using the template of its
+// event; the schema-change template is used when the event has no template.
+// Labels and components rendering to empty strings are omitted.
+func (t *Templates) Render(sc SchemaChange) (Ticket, error) {
+	eventTemplate, ok := t.events[sc.Event]
+	if !ok {
+		eventTemplate, ok = t.events[EventSchemaChange]
+		if !ok {
+			return Ticket{}, fmt.Errorf("no template for event %s", sc.Event)
+		}
+	}
+
+	var err error
+	ticket := Ticket{}
+	if ticket.Title, err = execute(eventTemplate.title, sc); err != nil {
+		return Ticket{}, fmt.Errorf("template %s: %w", sc.Event, err)
+	}
+	if len(ticket.Title) == 0 {
+		return Ticket{}, fmt.Errorf("template %s: title is empty", sc.Event)
+	}
+	if ticket.Body, err = execute(eventTemplate.body, sc); err != nil {
+		return Ticket{}, fmt.Errorf("template %s: %w", sc.Event, err)
+	}
+	if ticket.Priority, err = execute(eventTemplate.priority, sc); err != nil {
+		return Ticket{}, fmt.Errorf("template %s: %w", sc.Event, err)
+	}
+	if ticket.Labels, err = executeAll(eventTemplate.labels, sc); err != nil {
+		return Ticket{}, fmt.Errorf("template %s: %w", sc.Event, err)
+	}
+	if ticket.Components, err = executeAll(eventTemplate.components, sc); err != nil {
+		return Ticket{}, fmt.Errorf("template %s: %w", sc.Event, err)
+	}
+	if len(eventTemplate.fields) > 0 {
+		fields, err := renderValue(eventTemplate.fields, sc)
+		if err != nil {
+			return Ticket{}, fmt.Errorf("template %s: %w", sc.Event, err)
+		}
+		ticket.Fields, _ = fields.(map[string]any)
+	}
+	return ticket, nil
+}
+
+// execute will render a template; surrounding whitespace is removed.
+func execute(tmpl *template.Template, sc SchemaChange) (string, error) {
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, sc); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+// executeAll will render a list of templates omitting the empty values.
+func executeAll(templates []*template.Template, sc SchemaChange) ([]string, error) {
+	var values []string
+	for _, tmpl := range templates {
+		value, err := execute(tmpl, sc)
+		if err != nil {
+			return nil, err
+		}
+		if len(value) > 0 {
+			values = append(values, value)
+		}
+	}
+	return values, nil
+}
+
+// renderValue will render the templates of a custom field value.
+func renderValue(value any, sc SchemaChange) (any, error) {
+	switch v := value.(type) {
+	case *template.Template:
+		return execute(v, sc)
+	case map[string]any:
+		rendered := make(map[string]any, len(v))
+		for key, item := range v {
+			renderedItem, err := renderValue(item, sc)
+			if err != nil {
+				return nil, err
+			}
+			rendered[key] = renderedItem
+		}
+		return rendered, nil
+	case []any:
+		rendered := make([]any, 0, len(v))
+		for _, item := range v {
+			renderedItem, err := renderValue(item, sc)
+			if err != nil {
+				return nil, err
+			}
+			rendered = append(rendered, renderedItem)
+		}
+		return rendered, nil
+	}
+	return value, nil
+}
+
+// sampleContext will create the schema change used to validate the templates
+// of an event; every field is set so templates referencing them render.
+func sampleContext(event Event) SchemaChange {
+	return SchemaChange{
+		Event:        event,
+		Key:          "kong/kong#11234",
+		Organization: "kong",
+		Repository:   "kong",
+		PullRequest: github.PullRequest{
+			Author:  "gateway-engineer",
+			BaseRef: "master",
+			HeadRef: "feat/sync-rate",
+			Number:  11234,
+			State:   "open",
+			Title:   "feat(rate-limiting): add sync rate",
+			URL:     "https://github.com/kong/kong/pull/11234",
+		},
+		Description: github.Description{
+			Sections: []github.Section{{Title: "Summary", Level: 3, Content: "Adds the sync_rate option."}},
+			JiraKeys: []string{"KAG-1234"},
+		},
+		Changelog: []github.ChangelogEntry{
+			{Type: "feature", Scope: "Plugin", Message: "Added the sync_rate option.", Jiras: []string{"KAG-1234"}},
+		},
+		FieldChanges: []compat.RemovedFieldsEntry{
+			{Version: 3005000000, Plugin: "rate-limiting", Added: []string{"sync_rate"}},
+		},
+		BundledPlugins: BundledPlugins{Added: []string{"ai-proxy"}, Removed: []string{"legacy"}},
+		Migrations: []gateway.Migration{
+			{Path: "kong/db/migrations/core/021_340_to_350.lua", CreatedTables: []string{"keys"}},
+		},
+		Breaking:   []string{"bundled plugin removed: legacy"},
+		Severity:   "high",
+		Components: []string{"dp-compat"},
+		Sections: Sections{
+			BundledPlugins: "Bundled plugins:\n• added: ai-proxy",
+			Changelog:      "Changelog:\n• feature (Plugin): Added the sync_rate option. [KAG-1234]",
+			Compat:         "Koko compat entries needed:\n• rate-limiting: add sync_rate",
+			Description:    "### Summary\n\nAdds the sync_rate option.",
+			Migrations:     "New database migrations:\n• keys: table created",
+		},
+	}
+}
@@ -0,0 +1,169 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package templates
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("templates", Label("templates"), func() {
+	var logger *zap.Logger
+
+	BeforeEach(func() {
+		var err error
+		logger, err = zap.NewDevelopment()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	// writeTemplates will write a templates file and get its path.
+	writeTemplates := func(content string) string {
+		path := filepath.Join(GinkgoT().TempDir(), "templates.yaml")
+		Expect(os.WriteFile(path, []byte(content), 0o600)).To(Succeed())
+		return path
+	}
+
+	It("a new templates instance will not be instantiated without a logger", func() {
+		t, err := New(Options{})
+		Expect(err).Should(MatchError("logger is not set"))
+		Expect(t).To(BeNil())
+	})
+
+	It("the default templates will render every event", func() {
+		t, err := New(Options{Logger: logger})
+		Expect(err).NotTo(HaveOccurred())
+		for _, event := range Events {
+			ticket, err := t.Render(sampleContext(event))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ticket.Title).Should(HaveSuffix("(kong/kong#11234)"))
+			Expect(ticket.Labels).Should(ContainElement("gateway-schema-change"))
+		}
+	})
+
+	It("the default schema change template will render the sections of the schema change", func() {
+		t, err := New(Options{Logger: logger})
+		Expect(err).NotTo(HaveOccurred())
+		sc := sampleContext(EventSchemaChange)
+		sc.Sections.BundledPlugins = ""
+		sc.Sections.Migrations = ""
+		ticket, err := t.Render(sc)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ticket.Title).Should(Equal("Gateway schema change: feat(rate-limiting): add sync rate (kong/kong#11234)"))
+		Expect(ticket.Body).Should(Equal(`Pull request: https://github.com/kong/kong/pull/11234
+Author: gateway-engineer
+
+Changelog:
+• feature (Plugin): Added the sync_rate option. [KAG-1234]
+
+Koko compat entries needed:
+• rate-limiting: add sync_rate
+
+### Summary
+
+Adds the sync_rate option.`))
+		Expect(ticket.Priority).Should(BeEmpty())
+	})
+
+	It("configured templates will override the default template of their events", func() {
+		t, err := New(Options{
+			Path: writeTemplates(`
+breaking-change:
+  title: "[{{ upper .Severity }}] {{ .PullRequest.Title }}"
+  body: "{{ range .FieldChanges }}{{ .Plugin }}: {{ join .Added \", \" }}{{ end }}"
+  labels:
+    - koko
+    - "{{ if .Severity }}severity-{{ .Severity }}{{ end }}"
+  components:
+    - "{{ range .Components }}{{ . }}{{ end }}"
+  priority: Highest
+  fields:
+    customfield_10010: "{{ .Key }}"
+    customfield_10020:
+      value: "{{ .Severity }}"
+    customfield_10030: 3
+`),
+			Logger: logger,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		sc := sampleContext(EventBreakingChange)
+		sc.Components = nil
+		ticket, err := t.Render(sc)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ticket).Should(Equal(Ticket{
+			Title:    "[HIGH] feat(rate-limiting): add sync rate",
+			Body:     "rate-limiting: sync_rate",
+			Labels:   []string{"koko", "severity-high"},
+			Priority: "Highest",
+			Fields: map[string]any{
+				"customfield_10010": "kong/kong#11234",
+				"customfield_10020": map[string]any{"value": "high"},
+				"customfield_10030": 3,
+			},
+		}))
+
+		// Events without a configured template keep their default template
+		ticket, err = t.Render(sampleContext(EventNewPlugin))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ticket.Title).Should(Equal("New bundled gateway plugin: ai-proxy (kong/kong#11234)"))
+	})
+
+	It("the schema change template will be used for events without a template", func() {
+		t, err := New(Options{Logger: logger})
+		Expect(err).NotTo(HaveOccurred())
+		delete(t.events, EventCompatOnly)
+		ticket, err := t.Render(sampleContext(EventCompatOnly))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ticket.Title).Should(HavePrefix("Gateway schema change: "))
+	})
+
+	When("the configured templates are invalid", func() {
+		It("an unknown event will be rejected", func() {
+			_, err := New(Options{Path: writeTemplates("breaking:\n  title: Breaking\n"), Logger: logger})
+			Expect(err).Should(MatchError(`unknown event "breaking" in templates`))
+		})
+
+		It("a template without a title will be rejected", func() {
+			_, err := New(Options{Path: writeTemplates("compat-only:\n  body: Body\n"), Logger: logger})
+			Expect(err).Should(MatchError("template compat-only: title is not set"))
+		})
+
+		It("a template that does not parse will be rejected", func() {
+			_, err := New(Options{Path: writeTemplates("new-plugin:\n  title: \"{{ .Key \"\n"), Logger: logger})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).Should(HavePrefix("template new-plugin: template: title:1: unclosed action"))
+		})
+
+		It("a template referencing an unknown field will be rejected at startup", func() {
+			_, err := New(Options{
+				Path:   writeTemplates("schema-change:\n  title: Title\n  fields:\n    summary: \"{{ .Ticket }}\"\n"),
+				Logger: logger,
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).Should(ContainSubstring("can't evaluate field Ticket"))
+		})
+
+		It("a missing templates file will be rejected", func() {
+			_, err := New(Options{Path: filepath.Join(GinkgoT().TempDir(), "missing.yaml"), Logger: logger})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).Should(HavePrefix("unable to read templates"))
+		})
+	})
+})
@@ -0,0 +1,262 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package tracker
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/kong/koko-slack-bot/internal/github"
+	"go.uber.org/zap"
+)
+
+// gitHubKeyPattern represents the key of a GitHub issue; e.g. kong/koko#1234.
+var gitHubKeyPattern = regexp.MustCompile(`^([\w.-]+)/([\w.-]+)#(\d+)$`)
+
+// GitHubOptions contain the parameters to create a new GitHub Issues tracker
+// instance.
+type GitHubOptions struct {
+	// Client represents the client for accessing GitHub's API
+	Client *github.Client
+	// Organization represents the organization of the repository holding the
+	// tickets
+	Organization string
+	// Repository represents the repository holding the tickets
+	Repository string
+	// Logger represents the base logger to use for the tracker package
+	Logger *zap.Logger
+}
+
+// GitHub represents a tracker of tickets in GitHub Issues.
+type GitHub struct {
+	// client represents the client for accessing GitHub's API
+	client *github.Client
+	// organization represents the organization of the repository holding the
+	// tickets
+	organization string
+	// repository represents the repository holding the tickets
+	repository string
+	// logger represents the logger to use for the tracker package
+	logger *zap.Logger
+}
+
+// NewGitHub will validate options and instantiate a new GitHub Issues tracker
+// instance.
+func NewGitHub(opts GitHubOptions) (*GitHub, error) {
+	// Validate required options
+	if opts.Client == nil {
+		return nil, errors.New("github client is not set")
+	}
+	if len(strings.TrimSpace(opts.Organization)) == 0 {
+		return nil, errors.New("github organization is not set")
+	}
+	if len(strings.TrimSpace(opts.Repository)) == 0 {
+		return nil, errors.New("github repository is not set")
+	}
+	if opts.Logger == nil {
+		return nil, errors.New("logger is not set")
+	}
+
+	return &GitHub{
+		client:       opts.Client,
+		organization: opts.Organization,
+		repository:   opts.Repository,
+		logger:       opts.Logger.With(zap.String("component", "tracker"), zap.String("backend", string(BackendGitHub))),
+	}, nil
+}
+
+// Backend will get the issue tracker backing the tracker.
+func (g *GitHub) Backend() Backend {
+	return BackendGitHub
+}
+
+// Create will create an issue in the configured repository; the priority and
+// the components are added as labels as GitHub issues have neither, while
+// custom fields are ignored.
+func (g *GitHub) Create(ticket NewTicket) (Ticket, error) {
+	labels := append([]string{}, ticket.Labels...)
+	if len(ticket.Priority) > 0 {
+		labels = append(labels, priorityLabel(ticket.Priority))
+	}
+	for _, component := range ticket.Components {
+		labels = append(labels, "component-"+strings.ToLower(component))
+	}
+	if len(ticket.Fields) > 0 {
+		g.logger.Debug("custom fields ignored", zap.String("title", ticket.Title))
+	}
+	if len(ticket.Reference.Label) > 0 {
+		labels = append(labels, ticket.Reference.Label)
+	}
+	issue, err := g.client.CreateIssue(g.organization, g.repository, github.NewIssue{
+		Title:  ticket.Title,
+		Body:   ticket.Description,
+		Labels: labels,
+	})
+	if err != nil {
+		return Ticket{}, err
+	}
+	return newGitHubTicket(issue), nil
+}
+
+// priorityLabel will get the label representing a priority; e.g.
+// priority-high.
+func priorityLabel(priority string) string {
+	return "priority-" + strings.ToLower(strings.ReplaceAll(strings.TrimSpace(priority), " ", "-"))
+}
+
+// Update will update the title, the body, the labels, and the assignee of an
+// issue; the assignee is found by the public email address of their GitHub
+// account.
+func (g *GitHub) Update(key string, update Update) error {
+	number, err := g.issueNumber(key)
+	if err != nil {
+		return err
+	}
+	if len(update.Title) > 0 || len(update.Description) > 0 {
+		err := g.client.UpdateIssue(g.organization, g.repository, number, github.IssueUpdate{
+			Title: update.Title,
+			Body:  update.Description,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if err := g.client.AddIssueLabels(g.organization, g.repository, number, update.Labels...); err != nil {
+		return err
+	}
+	if len(update.Assignee) > 0 {
+		login, found, err := g.client.UserByEmail(update.Assignee)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("no github user found for %s", update.Assignee)
+		}
+		if err := g.client.AssignIssue(g.organization, g.repository, number, login); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Link will mention a related issue in a comment of an issue as GitHub issues
+// have no links; GitHub shows the mention on both issues.
+func (g *GitHub) Link(key string, relatedKey string) error {
+	return g.Comment(key, fmt.Sprintf("Related to %s", relatedKey))
+}
+
+// Comment will add a markdown comment to an issue.
+func (g *GitHub) Comment(key string, comment string) error {
+	number, err := g.issueNumber(key)
+	if err != nil {
+		return err
+	}
+	return g.client.CommentOnIssue(g.organization, g.repository, number, comment)
+}
+
+// FindByReference will find the oldest open issue with the label of the
+// reference or mentioning its URL in its body.
+func (g *GitHub) FindByReference(reference Reference) (Ticket, bool, error) {
+	scope := fmt.Sprintf("repo:%s/%s is:issue is:open", g.organization, g.repository)
+	var queries []string
+	if len(reference.Label) > 0 {
+		queries = append(queries, fmt.Sprintf("%s label:%q", scope, reference.Label))
+	}
+	if len(reference.URL) > 0 {
+		queries = append(queries, fmt.Sprintf("%s %q in:body", scope, reference.URL))
+	}
+	for _, query := range queries {
+		issues, err := g.client.SearchIssues(query)
+		if err != nil {
+			return Ticket{}, false, err
+		}
+		if len(issues) > 0 {
+			return newGitHubTicket(issues[0]), true, nil
+		}
+	}
+	return Ticket{}, false, nil
+}
+
+// Find will get the issues with the given keys; keys of other repositories or
+// of issues that do not exist are ignored.
+func (g *GitHub) Find(keys ...string) ([]Ticket, error) {
+	var tickets []Ticket
+	for _, key := range keys {
+		number, err := g.issueNumber(key)
+		if err != nil {
+			continue
+		}
+		issue, err := g.client.Issue(g.organization, g.repository, number)
+		if err != nil {
+			g.logger.Debug("referenced issue ignored", zap.String("key", key), zap.Error(err))
+			continue
+		}
+		tickets = append(tickets, newGitHubTicket(issue))
+	}
+	return tickets, nil
+}
+
+// Close will close an issue as completed, or as not planned when the work will
+// not be done.
+func (g *GitHub) Close(key string, resolution Resolution) error {
+	number, err := g.issueNumber(key)
+	if err != nil {
+		return err
+	}
+	stateReason := "completed"
+	if resolution == ResolutionWontDo {
+		stateReason = "not_planned"
+	}
+	return g.client.UpdateIssue(g.organization, g.repository, number, github.IssueUpdate{
+		State:       "closed",
+		StateReason: stateReason,
+	})
+}
+
+// Reopen will reopen a closed issue.
+func (g *GitHub) Reopen(key string) error {
+	number, err := g.issueNumber(key)
+	if err != nil {
+		return err
+	}
+	return g.client.UpdateIssue(g.organization, g.repository, number, github.IssueUpdate{
+		State:       "open",
+		StateReason: "reopened",
+	})
+}
+
+// issueNumber will get the number of an issue of the configured repository
+// from its key.
+func (g *GitHub) issueNumber(key string) (int, error) {
+	matches := gitHubKeyPattern.FindStringSubmatch(key)
+	if matches == nil || !strings.EqualFold(matches[1], g.organization) || !strings.EqualFold(matches[2], g.repository) {
+		return 0, fmt.Errorf("%s is not an issue of %s/%s", key, g.organization, g.repository)
+	}
+	return strconv.Atoi(matches[3])
+}
+
+// newGitHubTicket will create a ticket from a GitHub issue.
+func newGitHubTicket(issue github.Issue) Ticket {
+	return Ticket{
+		Key:    fmt.Sprintf("%s/%s#%d", issue.Organization, issue.Repository, issue.Number),
+		URL:    issue.URL,
+		Title:  issue.Title,
+		Status: issue.State,
+	}
+}
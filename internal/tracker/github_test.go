@@ -0,0 +1,169 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package tracker
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/kong/koko-slack-bot/internal/github"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("GitHub tracker", Label("tracker-github"), func() {
+	var logger *zap.Logger
+	var mux *http.ServeMux
+	var server *httptest.Server
+	var tracker *GitHub
+
+	BeforeEach(func() {
+		var err error
+		logger, err = zap.NewDevelopment()
+		Expect(err).NotTo(HaveOccurred())
+		mux = http.NewServeMux()
+		server = httptest.NewServer(mux)
+		client, err := github.NewClient(github.Options{
+			Token:   "token",
+			BaseURL: server.URL,
+			Logger:  logger,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		tracker, err = NewGitHub(GitHubOptions{
+			Client:       client,
+			Organization: "kong",
+			Repository:   "koko",
+			Logger:       logger,
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("a new GitHub tracker instance will not be instantiated without a repository", func() {
+		t, err := NewGitHub(GitHubOptions{
+			Client:       &github.Client{},
+			Organization: "kong",
+			Logger:       logger,
+		})
+		Expect(err).Should(MatchError("github repository is not set"))
+		Expect(t).To(BeNil())
+	})
+
+	It("a ticket will be created with its priority and reference as labels", func() {
+		mux.HandleFunc("/repos/kong/koko/issues", func(w http.ResponseWriter, r *http.Request) {
+			var request struct {
+				Labels []string `json:"labels"`
+			}
+			Expect(json.NewDecoder(r.Body).Decode(&request)).To(Succeed())
+			Expect(request.Labels).Should(Equal([]string{"gateway-new-plugin", "priority-high", "gateway-pr-kong-kong-11234"}))
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"number": 42, "state": "open", "html_url": "https://github.com/kong/koko/issues/42"}`))
+		})
+
+		ticket, err := tracker.Create(NewTicket{
+			Title:     "New bundled gateway plugin",
+			Labels:    []string{"gateway-new-plugin"},
+			Priority:  "High",
+			Reference: Reference{Label: "gateway-pr-kong-kong-11234"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ticket).Should(Equal(Ticket{
+			Key:    "kong/koko#42",
+			URL:    "https://github.com/kong/koko/issues/42",
+			Status: "open",
+		}))
+	})
+
+	It("a ticket will be found by the URL of its reference when no ticket has its label", func() {
+		var queries []string
+		mux.HandleFunc("/search/issues", func(w http.ResponseWriter, r *http.Request) {
+			queries = append(queries, r.URL.Query().Get("q"))
+			if len(queries) == 1 {
+				_, _ = w.Write([]byte(`{"total_count": 0, "items": []}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"total_count": 1, "items": [
+				{"number": 7, "state": "open", "repository_url": "https://api.github.com/repos/kong/koko"}
+			]}`))
+		})
+
+		ticket, found, err := tracker.FindByReference(Reference{
+			Label: "gateway-pr-kong-kong-11234",
+			URL:   "https://github.com/kong/kong/pull/11234",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(ticket.Key).Should(Equal("kong/koko#7"))
+		Expect(queries).Should(Equal([]string{
+			`repo:kong/koko is:issue is:open label:"gateway-pr-kong-kong-11234"`,
+			`repo:kong/koko is:issue is:open "https://github.com/kong/kong/pull/11234" in:body`,
+		}))
+	})
+
+	It("only issues of the configured repository will be found", func() {
+		mux.HandleFunc("/repos/kong/koko/issues/7", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"number": 7, "state": "closed", "title": "Sync rate"}`))
+		})
+
+		tickets, err := tracker.Find("KAG-1234", "kong/kong#11233", "Kong/Koko#7")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tickets).Should(Equal([]Ticket{{Key: "kong/koko#7", Title: "Sync rate", Status: "closed"}}))
+	})
+
+	It("a ticket will be linked by mentioning the related ticket", func() {
+		mux.HandleFunc("/repos/kong/koko/issues/42/comments", func(w http.ResponseWriter, r *http.Request) {
+			var request map[string]string
+			Expect(json.NewDecoder(r.Body).Decode(&request)).To(Succeed())
+			Expect(request["body"]).Should(Equal("Related to kong/koko#7"))
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id": 1}`))
+		})
+
+		Expect(tracker.Link("kong/koko#42", "kong/koko#7")).To(Succeed())
+	})
+
+	It("a ticket that will not be done will be closed as not planned", func() {
+		mux.HandleFunc("/repos/kong/koko/issues/42", func(w http.ResponseWriter, r *http.Request) {
+			var request map[string]string
+			Expect(json.NewDecoder(r.Body).Decode(&request)).To(Succeed())
+			Expect(request).Should(Equal(map[string]string{"state": "closed", "state_reason": "not_planned"}))
+			_, _ = w.Write([]byte(`{"number": 42}`))
+		})
+
+		Expect(tracker.Close("kong/koko#42", ResolutionWontDo)).To(Succeed())
+	})
+
+	It("a closed ticket will be reopened", func() {
+		mux.HandleFunc("/repos/kong/koko/issues/42", func(w http.ResponseWriter, r *http.Request) {
+			var request map[string]string
+			Expect(json.NewDecoder(r.Body).Decode(&request)).To(Succeed())
+			Expect(request).Should(Equal(map[string]string{"state": "open", "state_reason": "reopened"}))
+			_, _ = w.Write([]byte(`{"number": 42}`))
+		})
+
+		Expect(tracker.Reopen("kong/koko#42")).To(Succeed())
+	})
+
+	It("an error will occur for a ticket of another repository", func() {
+		err := tracker.Comment("kong/kong#1", "comment")
+		Expect(err).Should(MatchError("kong/kong#1 is not an issue of kong/koko"))
+	})
+})
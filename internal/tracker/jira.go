@@ -0,0 +1,171 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package tracker
+
+import (
+	"errors"
+	"regexp"
+
+	"github.com/kong/koko-slack-bot/internal/jira"
+	"go.uber.org/zap"
+)
+
+// jiraKeyPattern represents the key of a Jira issue.
+var jiraKeyPattern = regexp.MustCompile(`^[A-Z][A-Z0-9]+-\d+$`)
+
+// jiraWontDoStatuses represents the names of the Jira statuses, or
+// transitions, preferred when closing a ticket whose work will not be done.
+var jiraWontDoStatuses = []string{"Won't Do", "Won't Fix", "Declined"}
+
+// JiraOptions contain the parameters to create a new Jira tracker instance.
+type JiraOptions struct {
+	// Client represents the client for accessing Jira's API
+	Client *jira.Client
+	// Logger represents the base logger to use for the tracker package
+	Logger *zap.Logger
+}
+
+// Jira represents a tracker of tickets in Jira.
+type Jira struct {
+	// client represents the client for accessing Jira's API
+	client *jira.Client
+	// logger represents the logger to use for the tracker package
+	logger *zap.Logger
+}
+
+// NewJira will validate options and instantiate a new Jira tracker instance.
+func NewJira(opts JiraOptions) (*Jira, error) {
+	// Validate required options
+	if opts.Client == nil {
+		return nil, errors.New("jira client is not set")
+	}
+	if opts.Logger == nil {
+		return nil, errors.New("logger is not set")
+	}
+
+	return &Jira{
+		client: opts.Client,
+		logger: opts.Logger.With(zap.String("component", "tracker"), zap.String("backend", string(BackendJira))),
+	}, nil
+}
+
+// Backend will get the issue tracker backing the tracker.
+func (j *Jira) Backend() Backend {
+	return BackendJira
+}
+
+// Create will create an issue in the configured project.
+func (j *Jira) Create(ticket NewTicket) (Ticket, error) {
+	labels := append([]string{}, ticket.Labels...)
+	if len(ticket.Reference.Label) > 0 {
+		labels = append(labels, ticket.Reference.Label)
+	}
+	issue, err := j.client.CreateIssue(jira.NewIssue{
+		Summary:     ticket.Title,
+		Description: ticket.Description,
+		Labels:      labels,
+		Priority:    ticket.Priority,
+		Components:  ticket.Components,
+		Fields:      ticket.Fields,
+	})
+	if err != nil {
+		return Ticket{}, err
+	}
+	return newJiraTicket(issue), nil
+}
+
+// Update will update the summary, the description, the labels, and the
+// assignee of an issue.
+func (j *Jira) Update(key string, update Update) error {
+	if err := j.client.UpdateIssue(key, update.Title, update.Description); err != nil {
+		return err
+	}
+	if len(update.Labels) > 0 {
+		if err := j.client.AddLabels(key, update.Labels...); err != nil {
+			return err
+		}
+	}
+	if len(update.Assignee) > 0 {
+		if err := j.client.AssignIssue(key, update.Assignee); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Link will link an issue to a related issue.
+func (j *Jira) Link(key string, relatedKey string) error {
+	return j.client.LinkIssues(key, relatedKey)
+}
+
+// Comment will add a markdown comment to an issue.
+func (j *Jira) Comment(key string, comment string) error {
+	return j.client.AddComment(key, comment)
+}
+
+// FindByReference will find an unresolved issue with the label of the
+// reference or mentioning its URL.
+func (j *Jira) FindByReference(reference Reference) (Ticket, bool, error) {
+	issue, found, err := j.client.FindOpenIssue(reference.Label, reference.URL)
+	if err != nil || !found {
+		return Ticket{}, false, err
+	}
+	return newJiraTicket(issue), true, nil
+}
+
+// Find will get the issues with the given keys; keys that are not Jira issue
+// keys are ignored.
+func (j *Jira) Find(keys ...string) ([]Ticket, error) {
+	var issueKeys []string
+	for _, key := range keys {
+		if jiraKeyPattern.MatchString(key) {
+			issueKeys = append(issueKeys, key)
+		}
+	}
+	issues, err := j.client.FindIssues(issueKeys...)
+	if err != nil {
+		return nil, err
+	}
+	tickets := make([]Ticket, 0, len(issues))
+	for _, issue := range issues {
+		tickets = append(tickets, newJiraTicket(issue))
+	}
+	return tickets, nil
+}
+
+// Close will transition an issue to a done status; a won't do status is
+// preferred when the work will not be done.
+func (j *Jira) Close(key string, resolution Resolution) error {
+	if resolution == ResolutionWontDo {
+		return j.client.CloseIssue(key, jiraWontDoStatuses...)
+	}
+	return j.client.CloseIssue(key, "Done")
+}
+
+// Reopen will transition a resolved issue back to a to do status.
+func (j *Jira) Reopen(key string) error {
+	return j.client.ReopenIssue(key)
+}
+
+// newJiraTicket will create a ticket from a Jira issue.
+func newJiraTicket(issue jira.Issue) Ticket {
+	return Ticket{
+		Key:    issue.Key,
+		URL:    issue.URL,
+		Title:  issue.Summary,
+		Status: issue.Status,
+	}
+}
@@ -0,0 +1,134 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package tracker
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/kong/koko-slack-bot/internal/jira"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("Jira tracker", Label("tracker-jira"), func() {
+	var logger *zap.Logger
+	var mux *http.ServeMux
+	var server *httptest.Server
+	var tracker *Jira
+
+	BeforeEach(func() {
+		var err error
+		logger, err = zap.NewDevelopment()
+		Expect(err).NotTo(HaveOccurred())
+		mux = http.NewServeMux()
+		server = httptest.NewServer(mux)
+		client, err := jira.NewClient(jira.Options{
+			URL:      server.URL,
+			Username: "koko@konghq.com",
+			Token:    "token",
+			Project:  "KOKO",
+			Logger:   logger,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		tracker, err = NewJira(JiraOptions{Client: client, Logger: logger})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("a new Jira tracker instance will not be instantiated without a client", func() {
+		t, err := NewJira(JiraOptions{Logger: logger})
+		Expect(err).Should(MatchError("jira client is not set"))
+		Expect(t).To(BeNil())
+	})
+
+	It("a ticket will be created with the label of its reference", func() {
+		mux.HandleFunc("/rest/api/3/issue", func(w http.ResponseWriter, r *http.Request) {
+			var request struct {
+				Fields struct {
+					Summary string   `json:"summary"`
+					Labels  []string `json:"labels"`
+				} `json:"fields"`
+			}
+			Expect(json.NewDecoder(r.Body).Decode(&request)).To(Succeed())
+			Expect(request.Fields.Summary).Should(Equal("Gateway schema change"))
+			Expect(request.Fields.Labels).Should(Equal([]string{"gateway-schema-change", "gateway-pr-kong-kong-11234"}))
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id": "10000", "key": "KOKO-1"}`))
+		})
+
+		ticket, err := tracker.Create(NewTicket{
+			Title:     "Gateway schema change",
+			Labels:    []string{"gateway-schema-change"},
+			Reference: Reference{Label: "gateway-pr-kong-kong-11234"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ticket).Should(Equal(Ticket{Key: "KOKO-1", URL: server.URL + "/browse/KOKO-1"}))
+	})
+
+	It("only Jira issue keys will be searched", func() {
+		mux.HandleFunc("/rest/api/3/search", func(w http.ResponseWriter, r *http.Request) {
+			var request map[string]any
+			Expect(json.NewDecoder(r.Body).Decode(&request)).To(Succeed())
+			Expect(request["jql"]).Should(Equal(`key in ("KAG-1234")`))
+			_, _ = w.Write([]byte(`{"issues": [{"key": "KAG-1234", "fields": {"summary": "Sync rate"}}]}`))
+		})
+
+		tickets, err := tracker.Find("KAG-1234", "kong/kong#11233")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tickets).To(HaveLen(1))
+		Expect(tickets[0].Title).Should(Equal("Sync rate"))
+	})
+
+	It("a ticket that will not be done will be closed with a won't do transition", func() {
+		mux.HandleFunc("/rest/api/3/issue/KOKO-1/transitions", func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				_, _ = w.Write([]byte(`{"transitions": [
+					{"id": "21", "name": "Done", "to": {"name": "Done", "statusCategory": {"key": "done"}}},
+					{"id": "31", "name": "Decline", "to": {"name": "Declined", "statusCategory": {"key": "done"}}}
+				]}`))
+				return
+			}
+			var request struct {
+				Transition map[string]string `json:"transition"`
+			}
+			Expect(json.NewDecoder(r.Body).Decode(&request)).To(Succeed())
+			Expect(request.Transition["id"]).Should(Equal("31"))
+			w.WriteHeader(http.StatusNoContent)
+		})
+
+		Expect(tracker.Close("KOKO-1", ResolutionWontDo)).To(Succeed())
+	})
+
+	It("a resolved ticket will be reopened with a to do transition", func() {
+		mux.HandleFunc("/rest/api/3/issue/KOKO-1/transitions", func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				_, _ = w.Write([]byte(`{"transitions": [
+					{"id": "41", "name": "Reopen", "to": {"name": "To Do", "statusCategory": {"key": "new"}}}
+				]}`))
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+
+		Expect(tracker.Reopen("KOKO-1")).To(Succeed())
+	})
+})
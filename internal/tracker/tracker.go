@@ -0,0 +1,117 @@
+/*
+Copyright © 2023 Kong, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package tracker
+
+// Backend represents the issue tracker used for tickets.
+type Backend string
+
+const (
+	// BackendJira represents tickets tracked in Jira
+	BackendJira Backend = "jira"
+	// BackendGitHub represents tickets tracked in GitHub Issues
+	BackendGitHub Backend = "github"
+)
+
+// Resolution represents why a ticket is closed.
+type Resolution string
+
+const (
+	// ResolutionDone represents a ticket whose work is complete
+	ResolutionDone Resolution = "done"
+	// ResolutionWontDo represents a ticket whose work will not be done
+	ResolutionWontDo Resolution = "wont-do"
+)
+
+// Ticket represents a ticket of an issue tracker.
+type Ticket struct {
+	// Key represents the identifier of the ticket; e.g. KOKO-1234 or
+	// kong/koko#1234
+	Key string
+	// URL represents the browsable URL of the ticket
+	URL string
+	// Title represents the title of the ticket when retrieved by a search
+	Title string
+	// Status represents the name of the status of the ticket when retrieved by
+	// a search
+	Status string
+}
+
+// Reference represents the external object a ticket is tracking; e.g. a
+// gateway pull request.
+type Reference struct {
+	// Label represents the label identifying the tickets of the object
+	Label string
+	// URL represents the URL of the object mentioned by its tickets
+	URL string
+}
+
+// NewTicket represents the content of a ticket to create.
+type NewTicket struct {
+	// Title represents the title of the ticket
+	Title string
+	// Description represents the markdown body of the ticket
+	Description string
+	// Labels represents the labels to add to the ticket
+	Labels []string
+	// Priority represents the name of the priority of the ticket; the default
+	// priority of the tracker is used when empty
+	Priority string
+	// Reference represents the external object the ticket is tracking; its
+	// label is added to the ticket
+	Reference Reference
+	// Components represents the components of the ticket
+	Components []string
+	// Fields represents the custom fields of the ticket keyed by field ID;
+	// only supported by Jira
+	Fields map[string]any
+}
+
+// Update represents the changes to make to a ticket; empty values are left
+// unchanged.
+type Update struct {
+	// Title represents the new title of the ticket
+	Title string
+	// Description represents the new markdown body of the ticket
+	Description string
+	// Labels represents the labels to add to the ticket
+	Labels []string
+	// Assignee represents the email address of the user to assign the ticket
+	// to
+	Assignee string
+}
+
+// Tracker represents an issue tracker holding the tickets of schema changes.
+type Tracker interface {
+	// Backend will get the issue tracker backing the tracker
+	Backend() Backend
+	// Create will create a ticket
+	Create(ticket NewTicket) (Ticket, error)
+	// Update will update a ticket
+	Update(key string, update Update) error
+	// Link will link a ticket to a related ticket
+	Link(key string, relatedKey string) error
+	// Comment will add a markdown comment to a ticket
+	Comment(key string, comment string) error
+	// FindByReference will find an open ticket tracking an external object
+	FindByReference(reference Reference) (Ticket, bool, error)
+	// Find will get the tickets with the given keys; keys that do not belong
+	// to the tracker or whose tickets do not exist are ignored
+	Find(keys ...string) ([]Ticket, error)
+	// Close will close a ticket
+	Close(key string, resolution Resolution) error
+	// Reopen will reopen a closed ticket
+	Reopen(key string) error
+}
@@ -16,11 +16,20 @@ limitations under the License.
 package main
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/kong/koko-slack-bot/internal/github"
+	"github.com/kong/koko-slack-bot/internal/jira"
+	"github.com/kong/koko-slack-bot/internal/mirror"
 	"github.com/kong/koko-slack-bot/internal/slack"
+	"github.com/kong/koko-slack-bot/internal/store"
+	"github.com/kong/koko-slack-bot/internal/templates"
+	"github.com/kong/koko-slack-bot/internal/tracker"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -31,6 +40,49 @@ func main() {
 	appToken := os.Getenv("SLACK_APP_TOKEN")
 	botToken := os.Getenv("SLACK_BOT_TOKEN")
 	gitHubToken := os.Getenv("GITHUB_TOKEN")
+	jiraURL := os.Getenv("JIRA_URL")
+	trackerBackend := tracker.Backend(os.Getenv("TRACKER"))
+	if len(trackerBackend) == 0 && len(jiraURL) > 0 {
+		trackerBackend = tracker.BackendJira
+	}
+	webhookAddress := os.Getenv("WEBHOOK_ADDRESS")
+	storePath := os.Getenv("STORE_PATH")
+	if len(storePath) == 0 {
+		storePath = "koko-slack-bot.json"
+	}
+	pullRequestPollInterval := os.Getenv("PULL_REQUEST_POLL_INTERVAL")
+	if len(pullRequestPollInterval) == 0 {
+		pullRequestPollInterval = "30m"
+	}
+	closeAbandonedTickets := os.Getenv("CLOSE_ABANDONED_TICKETS") == "true"
+	releasePollInterval := os.Getenv("RELEASE_POLL_INTERVAL")
+	if len(releasePollInterval) == 0 {
+		releasePollInterval = "1h"
+	}
+	driftPollInterval := os.Getenv("DRIFT_POLL_INTERVAL")
+	if len(driftPollInterval) == 0 {
+		driftPollInterval = "24h"
+	}
+	driftRef := os.Getenv("DRIFT_REF")
+	if len(driftRef) == 0 {
+		driftRef = "master"
+	}
+	kokoRepository := os.Getenv("KOKO_REPOSITORY")
+	if len(kokoRepository) == 0 {
+		kokoRepository = "kong/koko"
+	}
+	kokoSchemasPath := os.Getenv("KOKO_SCHEMAS_PATH")
+	if len(kokoSchemasPath) == 0 {
+		kokoSchemasPath = "internal/plugin/schemas"
+	}
+	compatWorkflowRef := os.Getenv("COMPAT_WORKFLOW_REF")
+	if len(compatWorkflowRef) == 0 {
+		compatWorkflowRef = "main"
+	}
+	workflowPollInterval := os.Getenv("WORKFLOW_POLL_INTERVAL")
+	if len(workflowPollInterval) == 0 {
+		workflowPollInterval = "1m"
+	}
 
 	logConfig := zap.NewProductionConfig()
 	logConfig.Encoding = "console"
@@ -43,8 +95,40 @@ func main() {
 		os.Exit(1)
 	}
 
+	// The local git mirror is only enabled when a mirror path is configured;
+	// e.g. MIRROR_REPOSITORIES=kong/kong,kong/kong-ee
+	var gitMirror github.Mirror
+	if mirrorPath := os.Getenv("MIRROR_PATH"); len(mirrorPath) > 0 {
+		mirrorRepositories := os.Getenv("MIRROR_REPOSITORIES")
+		if len(mirrorRepositories) == 0 {
+			mirrorRepositories = "kong/kong"
+		}
+		mirrorSyncInterval := os.Getenv("MIRROR_SYNC_INTERVAL")
+		if len(mirrorSyncInterval) == 0 {
+			mirrorSyncInterval = "5m"
+		}
+		syncInterval, err := time.ParseDuration(mirrorSyncInterval)
+		if err != nil {
+			logger.Error("invalid mirror sync interval", zap.Error(err))
+			os.Exit(1)
+		}
+		localMirror, err := mirror.NewMirror(mirror.Options{
+			Path:         mirrorPath,
+			Repositories: strings.Split(mirrorRepositories, ","),
+			Token:        gitHubToken,
+			Logger:       logger,
+		})
+		if err != nil {
+			logger.Error("unable to create mirror", zap.Error(err))
+			os.Exit(1)
+		}
+		go localMirror.Watch(syncInterval)
+		gitMirror = localMirror
+	}
+
 	githubClient, err := github.NewClient(github.Options{
 		Token:  gitHubToken,
+		Mirror: gitMirror,
 		Logger: logger,
 	})
 	if err != nil {
@@ -52,17 +136,160 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Ticket tracking is only enabled when an issue tracker is configured
+	var ticketTracker tracker.Tracker
+	switch trackerBackend {
+	case tracker.BackendJira:
+		jiraClient, err := jira.NewClient(jira.Options{
+			URL:       jiraURL,
+			Username:  os.Getenv("JIRA_USERNAME"),
+			Token:     os.Getenv("JIRA_TOKEN"),
+			Project:   os.Getenv("JIRA_PROJECT"),
+			IssueType: os.Getenv("JIRA_ISSUE_TYPE"),
+			Logger:    logger,
+		})
+		if err != nil {
+			logger.Error("unable to create Jira client", zap.Error(err))
+			os.Exit(1)
+		}
+		ticketTracker, err = tracker.NewJira(tracker.JiraOptions{
+			Client: jiraClient,
+			Logger: logger,
+		})
+		if err != nil {
+			logger.Error("unable to create Jira tracker", zap.Error(err))
+			os.Exit(1)
+		}
+	case tracker.BackendGitHub:
+		// Tickets are created in a repository; e.g. kong/koko
+		organization, repository, _ := strings.Cut(os.Getenv("GITHUB_TRACKER_REPOSITORY"), "/")
+		ticketTracker, err = tracker.NewGitHub(tracker.GitHubOptions{
+			Client:       githubClient,
+			Organization: organization,
+			Repository:   repository,
+			Logger:       logger,
+		})
+		if err != nil {
+			logger.Error("unable to create GitHub tracker", zap.Error(err))
+			os.Exit(1)
+		}
+	case "":
+	default:
+		logger.Error("unknown tracker", zap.String("tracker", string(trackerBackend)))
+		os.Exit(1)
+	}
+
+	// Ticket templates are validated at startup even when ticket tracking is
+	// disabled so configuration errors are caught early
+	ticketTemplates, err := templates.New(templates.Options{
+		Path:   os.Getenv("TICKET_TEMPLATES"),
+		Logger: logger,
+	})
+	if err != nil {
+		logger.Error("invalid ticket templates", zap.Error(err))
+		os.Exit(1)
+	}
+
+	// Polling of the pull request state is disabled with an interval of 0
+	pollInterval, err := time.ParseDuration(pullRequestPollInterval)
+	if err != nil {
+		logger.Error("invalid pull request poll interval", zap.Error(err))
+		os.Exit(1)
+	}
+
+	// Releases of the gateway repositories are reported; e.g. kong/kong,kong/kong-ee
+	var releaseRepositories []string
+	for _, repository := range strings.Split(os.Getenv("RELEASE_REPOSITORIES"), ",") {
+		if repository = strings.TrimSpace(repository); len(repository) > 0 {
+			releaseRepositories = append(releaseRepositories, repository)
+		}
+	}
+	releaseInterval, err := time.ParseDuration(releasePollInterval)
+	if err != nil {
+		logger.Error("invalid release poll interval", zap.Error(err))
+		os.Exit(1)
+	}
+
+	// Drift detection only runs when its report channel or its tickets are
+	// enabled
+	driftInterval, err := time.ParseDuration(driftPollInterval)
+	if err != nil {
+		logger.Error("invalid drift poll interval", zap.Error(err))
+		os.Exit(1)
+	}
+
+	// Dispatched workflow runs are only followed when a compatibility
+	// workflow is configured
+	workflowInterval, err := time.ParseDuration(workflowPollInterval)
+	if err != nil {
+		logger.Error("invalid workflow poll interval", zap.Error(err))
+		os.Exit(1)
+	}
+
+	st, err := store.NewStore(store.Options{
+		Path:   storePath,
+		Logger: logger,
+	})
+	if err != nil {
+		logger.Error("unable to create store", zap.Error(err))
+		os.Exit(1)
+	}
+
 	s, err := slack.NewSlack(slack.Options{
-		AppToken:     appToken,
-		BotToken:     botToken,
-		Debug:        true,
-		GitHubClient: githubClient,
-		Logger:       logger,
+		AppToken:                appToken,
+		BotToken:                botToken,
+		Debug:                   true,
+		GitHubClient:            githubClient,
+		Templates:               ticketTemplates,
+		Tracker:                 ticketTracker,
+		PullRequestPollInterval: pollInterval,
+		CloseAbandonedTickets:   closeAbandonedTickets,
+		ReleasePollInterval:     releaseInterval,
+		ReleaseChannel:          os.Getenv("RELEASE_CHANNEL"),
+		ReleaseRepositories:     releaseRepositories,
+		DriftPollInterval:       driftInterval,
+		DriftRef:                driftRef,
+		DriftChannel:            os.Getenv("DRIFT_CHANNEL"),
+		DriftTickets:            os.Getenv("DRIFT_TICKETS") == "true",
+		ImpactAnalysis:          os.Getenv("IMPACT_ANALYSIS") == "true",
+		KokoRepository:          kokoRepository,
+		KokoSchemasPath:         kokoSchemasPath,
+		CompatWorkflow:          os.Getenv("COMPAT_WORKFLOW"),
+		CompatWorkflowRef:       compatWorkflowRef,
+		WorkflowPollInterval:    workflowInterval,
+		PullRequestComments:     os.Getenv("PULL_REQUEST_COMMENTS") == "true",
+		Logger:                  logger,
+		Store:                   st,
 	})
 	if err != nil {
 		logger.Error("unable to create Slack instance", zap.Error(err))
 		os.Exit(1)
 	}
+
+	// Ticket status synchronization is only enabled when the webhook receiver
+	// and Jira are configured
+	if len(webhookAddress) > 0 && trackerBackend == tracker.BackendJira {
+		webhookSecret := os.Getenv("JIRA_WEBHOOK_SECRET")
+		if len(webhookSecret) == 0 {
+			logger.Error("Jira webhook secret is not set; refusing to receive unsigned webhooks")
+			os.Exit(1)
+		}
+		mux := http.NewServeMux()
+		mux.Handle("/webhooks/jira", s.JiraWebhookHandler(webhookSecret))
+		server := &http.Server{
+			Addr:              webhookAddress,
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() {
+			logger.Info("receiving webhooks", zap.String("address", webhookAddress))
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Error("unable to receive webhooks", zap.Error(err))
+				os.Exit(1)
+			}
+		}()
+	}
+
 	err = s.Run()
 	if err != nil {
 		logger.Error("issue running Slack instance", zap.Error(err))